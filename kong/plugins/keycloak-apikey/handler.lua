@@ -7,6 +7,9 @@ local KeycloakAPIKeyHandler = {
 }
 
 function KeycloakAPIKeyHandler:access(conf)
+  -- Only a key validated here may reach downstream services as X-API-Key-ID
+  kong.service.request.clear_header("X-API-Key-ID")
+
   -- Get API key from headers
   local api_key = kong.request.get_header("X-API-Key") or 
                   kong.request.get_header("X-Api-Key") or
@@ -70,6 +73,9 @@ function KeycloakAPIKeyHandler:access(conf)
   kong.service.request.set_header("X-User-Email", user_info.email or "")
   kong.service.request.set_header("X-User-Username", user_info.username or "")
   kong.service.request.set_header("X-Auth-Method", "apikey")
+  if user_info.api_key_id and user_info.api_key_id ~= "" then
+    kong.service.request.set_header("X-API-Key-ID", user_info.api_key_id)
+  end
   
   -- Set authenticated credential for rate limiting
   kong.client.authenticate(user_info, {
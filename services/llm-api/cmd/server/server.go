@@ -10,6 +10,7 @@ import (
 	"jan-server/services/llm-api/internal/infrastructure/logger"
 	"jan-server/services/llm-api/internal/infrastructure/observability"
 	"jan-server/services/llm-api/internal/interfaces/httpserver"
+	"jan-server/services/llm-api/internal/utils/tokenizer"
 
 	"golang.org/x/sync/errgroup"
 
@@ -82,6 +83,7 @@ func main() {
 	if cfg == nil {
 		log.Fatal().Msg("config not loaded")
 	}
+	tokenizer.SetModelDir(cfg.TokenizerModelDir)
 
 	application, err := CreateApplication()
 	if err != nil {
@@ -33,6 +33,7 @@ import (
 	"jan-server/services/llm-api/internal/interfaces/httpserver/routes/auth"
 	v1 "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1"
 	"jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin"
+	"jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin/feedback"
 	model3 "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin/model"
 	provider2 "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin/provider"
 	"jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/chat"
@@ -70,25 +71,33 @@ func CreateApplication() (*Application, error) {
 	service := user.NewService(repository)
 	authHandler := authhandler.NewAuthHandler(service, zerologLogger)
 	modelRoute := model2.NewModelRoute(modelHandler, modelCatalogHandler, modelProviderRoute, authHandler)
-	inferenceProvider := inference.NewInferenceProvider()
-	providerHandler := modelhandler.NewProviderHandler(providerService, providerModelService, inferenceProvider)
+	providerHealthConfig := domain.ProvideProviderHealthConfig(config)
+	upstreamErrorClassifier := inference.NewUpstreamErrorClassifier()
+	providerHealthTracker := model.NewProviderHealthTracker(providerHealthConfig, upstreamErrorClassifier)
+	chatCompleterRegistry := inference.NewChatCompleterRegistry()
+	inferenceProvider := inference.NewInferenceProvider(providerHealthTracker, chatCompleterRegistry)
+	routingConfig := domain.ProvideProviderRoutingConfig(config)
+	providerRouter := model.NewProviderRouter(routingConfig)
+	providerHandler := modelhandler.NewProviderHandler(providerService, providerModelService, providerRouter, providerHealthTracker, inferenceProvider)
 	conversationRepository := conversationrepo.NewConversationGormRepository(database)
 	conversationService := conversation.NewConversationService(conversationRepository)
 	projectRepository := projectrepo.NewProjectGormRepository(db)
 	projectService := project.NewProjectService(projectRepository)
 	conversationHandler := conversationhandler.NewConversationHandler(conversationService, projectService)
+	conversationSummarizer := chathandler.ProvideConversationSummarizer(config, inferenceProvider, providerHandler, conversationService)
 	client := infrastructure.ProvideKeycloakClient(config, zerologLogger)
 	resolver := infrastructure.ProvideMediaResolver(config, zerologLogger, client)
-	chatHandler := chathandler.NewChatHandler(inferenceProvider, providerHandler, conversationHandler, conversationService, resolver)
+	chatHandler := chathandler.NewChatHandler(inferenceProvider, providerHandler, conversationHandler, conversationService, projectService, modelCatalogService, conversationSummarizer, resolver)
 	chatCompletionRoute := chat.NewChatCompletionRoute(chatHandler, authHandler)
 	chatRoute := chat.NewChatRoute(chatCompletionRoute)
-	conversationRoute := conversation2.NewConversationRoute(conversationHandler, authHandler)
+	conversationRoute := conversation2.NewConversationRoute(conversationHandler, chatHandler, authHandler)
 	projectHandler := projecthandler.NewProjectHandler(projectService)
 	projectRoute := projects.NewProjectRoute(projectHandler, authHandler)
 	providerModelHandler := modelhandler.NewProviderModelHandler(providerModelService, providerService, modelCatalogService)
 	adminModelRoute := model3.NewAdminModelRoute(modelHandler, modelCatalogHandler, providerModelHandler)
 	adminProviderRoute := provider2.NewAdminProviderRoute(providerHandler)
-	adminRoute := admin.NewAdminRoute(adminModelRoute, adminProviderRoute)
+	adminFeedbackRoute := feedback.NewAdminFeedbackRoute(conversationHandler, config)
+	adminRoute := admin.NewAdminRoute(adminModelRoute, adminProviderRoute, adminFeedbackRoute)
 	v1Route := v1.NewV1Route(modelRoute, chatRoute, conversationRoute, projectRoute, adminRoute)
 	guestHandler := guestauth.NewGuestHandler(client, zerologLogger)
 	upgradeHandler := guestauth.NewUpgradeHandler(client, zerologLogger)
@@ -130,7 +139,11 @@ func CreateDataInitializer() (*DataInitializer, error) {
 	providerModelService := model.NewProviderModelService(providerModelRepository, modelCatalogRepository)
 	modelCatalogService := model.NewModelCatalogService(modelCatalogRepository)
 	providerService := model.NewProviderService(providerRepository, providerModelService, modelCatalogService)
-	inferenceProvider := inference.NewInferenceProvider()
+	providerHealthConfig := domain.ProvideProviderHealthConfig(config)
+	upstreamErrorClassifier := inference.NewUpstreamErrorClassifier()
+	providerHealthTracker := model.NewProviderHealthTracker(providerHealthConfig, upstreamErrorClassifier)
+	chatCompleterRegistry := inference.NewChatCompleterRegistry()
+	inferenceProvider := inference.NewInferenceProvider(providerHealthTracker, chatCompleterRegistry)
 	dataInitializer := &DataInitializer{
 		provider:            providerService,
 		modelCatalogService: modelCatalogService,
@@ -32,6 +32,7 @@ type Config struct {
 	TargetClientID      string        `env:"TARGET_CLIENT_ID,notEmpty"`
 	OAuthRedirectURI    string        `env:"OAUTH_REDIRECT_URI,notEmpty"`
 	GuestRole           string        `env:"GUEST_ROLE" envDefault:"guest"`
+	AdminRole           string        `env:"ADMIN_ROLE" envDefault:"admin"`
 	KeycloakAdminUser   string        `env:"KEYCLOAK_ADMIN"`
 	KeycloakAdminPass   string        `env:"KEYCLOAK_ADMIN_PASSWORD"`
 	KeycloakAdminRealm  string        `env:"KEYCLOAK_ADMIN_REALM" envDefault:"master"`
@@ -66,6 +67,18 @@ type Config struct {
 	JanProviderConfigFile     string                   `env:"JAN_PROVIDER_CONFIGS_FILE"`
 	ProviderBootstrap         *ProviderBootstrapConfig `env:"-"`
 
+	// Provider Routing
+	ProviderRoutingStrategy        string            `env:"PROVIDER_ROUTING_STRATEGY" envDefault:"lowest_price"`      // lowest_price, weighted_round_robin, latency, least_in_flight, sticky
+	ProviderRoutingModelStrategies map[string]string `env:"PROVIDER_ROUTING_MODEL_STRATEGIES" envKeyValSeparator:"="` // e.g. "openai/gpt-4o=latency,jan/qwen3=sticky"
+	ProviderRoutingLatencyAlpha    float64           `env:"PROVIDER_ROUTING_LATENCY_ALPHA" envDefault:"0.3"`          // EWMA smoothing factor for latency routing
+
+	// Provider Circuit Breaker
+	ProviderBreakerEnabled     bool          `env:"PROVIDER_BREAKER_ENABLED" envDefault:"true"`
+	ProviderBreakerWindow      time.Duration `env:"PROVIDER_BREAKER_WINDOW" envDefault:"60s"`       // rolling window for error rate
+	ProviderBreakerMinFailures int           `env:"PROVIDER_BREAKER_MIN_FAILURES" envDefault:"5"`   // failures in window before the breaker may open
+	ProviderBreakerFailureRate float64       `env:"PROVIDER_BREAKER_FAILURE_RATE" envDefault:"0.5"` // failure ratio that opens the breaker
+	ProviderBreakerCooldown    time.Duration `env:"PROVIDER_BREAKER_COOLDOWN" envDefault:"30s"`     // time an open breaker stays open
+
 	// Model Sync
 	ModelSyncIntervalMinutes int  `env:"MODEL_SYNC_INTERVAL_MINUTES" envDefault:"60"`
 	ModelSyncEnabled         bool `env:"MODEL_SYNC_ENABLED" envDefault:"true"`
@@ -88,6 +101,16 @@ type Config struct {
 	MediaResolveURL     string        `env:"MEDIA_RESOLVE_URL" envDefault:"http://kong:8000/media/v1/media/resolve"`
 	MediaResolveTimeout time.Duration `env:"MEDIA_RESOLVE_TIMEOUT" envDefault:"5s"`
 
+	// Conversation summarization
+	ConversationSummaryModel      string        `env:"CONVERSATION_SUMMARY_MODEL"`                        // model public ID; empty disables summarization
+	ConversationSummaryThreshold  int           `env:"CONVERSATION_SUMMARY_THRESHOLD" envDefault:"40"`    // unsummarized items that trigger a summary
+	ConversationSummaryKeepRecent int           `env:"CONVERSATION_SUMMARY_KEEP_RECENT" envDefault:"10"`  // newest items left out of the summary
+	ConversationSummaryMaxTokens  int           `env:"CONVERSATION_SUMMARY_MAX_TOKENS" envDefault:"1024"` // completion cap for a summary
+	ConversationSummaryTimeout    time.Duration `env:"CONVERSATION_SUMMARY_TIMEOUT" envDefault:"60s"`
+
+	// Tokenizers
+	TokenizerModelDir string `env:"TOKENIZER_MODEL_DIR"` // SentencePiece models named <family>.model, e.g. llama2.model
+
 	// Internal
 	EnvReloadedAt time.Time
 }
@@ -250,6 +250,7 @@ func (s *Service) ValidateAPIKey(ctx context.Context, apiKey string) (*keycloak.
 			Msg("api key validated successfully")
 
 		return &keycloak.APIKeyUserInfo{
+			APIKeyID:  key.ID,
 			UserID:    fmt.Sprintf("%d", usr.ID),
 			Subject:   usr.Subject,
 			Username:  ptrToString(usr.Username),
@@ -267,6 +268,7 @@ func (s *Service) ValidateAPIKey(ctx context.Context, apiKey string) (*keycloak.
 		Msg("api key validated successfully (no keycloak verification)")
 
 	return &keycloak.APIKeyUserInfo{
+		APIKeyID: key.ID,
 		UserID:   fmt.Sprintf("%d", usr.ID),
 		Subject:  usr.Subject,
 		Username: ptrToString(usr.Username),
@@ -3,9 +3,11 @@ package conversation
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"jan-server/services/llm-api/internal/domain/query"
+	"jan-server/services/llm-api/internal/utils/idgen"
 )
 
 // ===============================================
@@ -29,6 +31,30 @@ const (
 // Branch names for edited conversations follow pattern: "EDIT_1", "EDIT_2", etc.
 // Or custom names for specific purposes
 
+// HistoryStrategy controls how stored conversation items are fitted into the model context window
+type HistoryStrategy string
+
+const (
+	HistoryStrategyFull      HistoryStrategy = "full"      // Send every item, even if the upstream may reject it
+	HistoryStrategyTruncate  HistoryStrategy = "truncate"  // Drop the oldest turns until the prompt fits
+	HistoryStrategySummarize HistoryStrategy = "summarize" // Drop the oldest turns and replace them with a summary item
+)
+
+// MetadataKeyHistoryStrategy is the conversation metadata key holding its default HistoryStrategy
+const MetadataKeyHistoryStrategy = "history_strategy"
+
+// ParseHistoryStrategy validates a history strategy name. An empty name yields HistoryStrategyTruncate.
+func ParseHistoryStrategy(value string) (HistoryStrategy, error) {
+	switch strategy := HistoryStrategy(strings.ToLower(strings.TrimSpace(value))); strategy {
+	case "":
+		return HistoryStrategyTruncate, nil
+	case HistoryStrategyFull, HistoryStrategyTruncate, HistoryStrategySummarize:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unsupported history strategy: %s", value)
+	}
+}
+
 // ===============================================
 // Conversation Structure
 // ===============================================
@@ -89,18 +115,25 @@ type ConversationRepository interface {
 	FindByID(ctx context.Context, id uint) (*Conversation, error)
 	FindByPublicID(ctx context.Context, publicID string) (*Conversation, error)
 	Update(ctx context.Context, conversation *Conversation) error
+	// UpdateInstructionSnapshot writes only the instruction version and snapshot columns, leaving
+	// fields changed concurrently (active branch, title) untouched
+	UpdateInstructionSnapshot(ctx context.Context, conversationID uint, version int, snapshot *string) error
 	Delete(ctx context.Context, id uint) error
+	Search(ctx context.Context, filter ConversationSearchFilter, pagination *query.Pagination) ([]*SearchHit, error)
+	// CreateWithBranches stores new conversations with their non-MAIN branches and the items of
+	// all their branches in one transaction
+	CreateWithBranches(ctx context.Context, conversations []*ConversationWithBranches) error
 
 	// Item operations (legacy - assumes MAIN branch)
 	AddItem(ctx context.Context, conversationID uint, item *Item) error
-	SearchItems(ctx context.Context, conversationID uint, query string) ([]*Item, error) // TODO: Implement search functionality
+	SearchItems(ctx context.Context, conversationID uint, query string) ([]*Item, error)
 	BulkAddItems(ctx context.Context, conversationID uint, items []*Item) error
 	GetItemByID(ctx context.Context, conversationID uint, itemID uint) (*Item, error)
 	GetItemByPublicID(ctx context.Context, conversationID uint, publicID string) (*Item, error)
 	DeleteItem(ctx context.Context, conversationID uint, itemID uint) error
 	CountItems(ctx context.Context, conversationID uint, branchName string) (int, error)
 
-	// Branch operations - TODO: Implement branching UI and endpoints
+	// Branch operations
 	CreateBranch(ctx context.Context, conversationID uint, branchName string, metadata *BranchMetadata) error
 	GetBranch(ctx context.Context, conversationID uint, branchName string) (*BranchMetadata, error)
 	ListBranches(ctx context.Context, conversationID uint) ([]*BranchMetadata, error)
@@ -113,13 +146,15 @@ type ConversationRepository interface {
 	BulkAddItemsToBranch(ctx context.Context, conversationID uint, branchName string, items []*Item) error
 
 	// Fork operation - creates a new branch from an existing branch at a specific item
-	// TODO: Implement forking functionality for conversation editing
 	ForkBranch(ctx context.Context, conversationID uint, sourceBranch, newBranch string, fromItemID string, description *string) error
 
-	// Item rating operations - TODO: Implement item rating/feedback system
+	// Item rating operations
 	RateItem(ctx context.Context, conversationID uint, itemID string, rating ItemRating, comment *string) error
 	GetItemRating(ctx context.Context, conversationID uint, itemID string) (*ItemRating, error)
 	RemoveItemRating(ctx context.Context, conversationID uint, itemID string) error
+
+	// FindItemsByFilter queries items across conversations, e.g. rated items for feedback export
+	FindItemsByFilter(ctx context.Context, filter ItemFilter, pagination *query.Pagination) ([]*Item, error)
 }
 
 // ===============================================
@@ -199,6 +234,25 @@ func (c *Conversation) GetBranchItems(branchName string) []Item {
 	return []Item{}
 }
 
+// HistoryStrategy returns the history strategy configured in the conversation metadata
+func (c *Conversation) HistoryStrategy() (HistoryStrategy, error) {
+	if c == nil {
+		return HistoryStrategyTruncate, nil
+	}
+	return ParseHistoryStrategy(c.Metadata[MetadataKeyHistoryStrategy])
+}
+
+// RecordInstructionSnapshot stores the merged instruction applied to the conversation together with
+// the project instruction version it was built from. It reports whether anything changed.
+func (c *Conversation) RecordInstructionSnapshot(version int, snapshot string) bool {
+	if c.InstructionVersion == version && c.EffectiveInstructionSnapshot != nil && *c.EffectiveInstructionSnapshot == snapshot {
+		return false
+	}
+	c.InstructionVersion = version
+	c.EffectiveInstructionSnapshot = &snapshot
+	return true
+}
+
 // AddItemToActiveBranch adds an item to the currently active branch
 // TODO: Currently unused - will be needed when implementing conversation branching UI
 func (c *Conversation) AddItemToActiveBranch(item Item) {
@@ -222,6 +276,14 @@ func (c *Conversation) AddItemToActiveBranch(item Item) {
 	}
 }
 
+// GetActiveBranch returns the active branch name, defaulting to MAIN
+func (c *Conversation) GetActiveBranch() string {
+	if c.ActiveBranch == "" {
+		return BranchMain
+	}
+	return c.ActiveBranch
+}
+
 // SwitchBranch changes the active branch
 // TODO: Currently unused - will be needed when implementing conversation branching UI
 func (c *Conversation) SwitchBranch(branchName string) error {
@@ -296,8 +358,8 @@ func (c *Conversation) CreateBranch(newBranchName, sourceBranch, fromItemID stri
 	return nil
 }
 
-// GenerateEditBranchName generates a unique branch name for conversation edits
-// TODO: Currently unused - will be needed when implementing conversation branching UI
-func GenerateEditBranchName(conversationID uint) string {
-	return fmt.Sprintf("EDIT_%d_%d", conversationID, time.Now().Unix())
+// GenerateEditBranchName generates a unique branch name for conversation edits. The random suffix
+// keeps edits of the same conversation within one second apart.
+func GenerateEditBranchName(conversationID uint) (string, error) {
+	return idgen.GenerateSecureID(fmt.Sprintf("EDIT_%d_%d", conversationID, time.Now().Unix()), 8)
 }
@@ -6,21 +6,24 @@ import (
 	"time"
 
 	"jan-server/services/llm-api/internal/domain/query"
+	"jan-server/services/llm-api/internal/infrastructure/logger"
 	"jan-server/services/llm-api/internal/utils/idgen"
 	"jan-server/services/llm-api/internal/utils/platformerrors"
 )
 
 // ConversationService handles business logic for conversations
 type ConversationService struct {
-	repo      ConversationRepository
-	validator *ConversationValidator
+	repo          ConversationRepository
+	validator     *ConversationValidator
+	itemValidator *ItemValidator
 }
 
 // NewConversationService creates a new conversation service
 func NewConversationService(repo ConversationRepository) *ConversationService {
 	return &ConversationService{
-		repo:      repo,
-		validator: NewConversationValidator(nil), // Use default config
+		repo:          repo,
+		validator:     NewConversationValidator(nil), // Use default config
+		itemValidator: NewItemValidator(nil),
 	}
 }
 
@@ -79,6 +82,15 @@ func (s *ConversationService) UpdateConversation(ctx context.Context, conv *Conv
 	return conv, nil
 }
 
+// UpdateInstructionSnapshot persists the instruction version and snapshot recorded on the conversation
+// without saving its other fields
+func (s *ConversationService) UpdateInstructionSnapshot(ctx context.Context, conv *Conversation) error {
+	if err := s.repo.UpdateInstructionSnapshot(ctx, conv.ID, conv.InstructionVersion, conv.EffectiveInstructionSnapshot); err != nil {
+		return platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to update instruction snapshot")
+	}
+	return nil
+}
+
 // DeleteConversation deletes a conversation (core function - marks as deleted)
 func (s *ConversationService) DeleteConversation(ctx context.Context, conv *Conversation) error {
 	if err := s.repo.Delete(ctx, conv.ID); err != nil {
@@ -191,9 +203,15 @@ func (s *ConversationService) AddItemsToConversation(ctx context.Context, conv *
 		return []Item{}, nil
 	}
 
-	// Validate branch exists (for now, only MAIN is supported)
+	if branchName == "" {
+		branchName = BranchMain
+	}
+
+	// Validate branch exists
 	if branchName != BranchMain {
-		return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeNotFound, fmt.Sprintf("branch not found: %s", branchName), nil, "")
+		if _, err := s.GetBranch(ctx, conv, branchName); err != nil {
+			return nil, err
+		}
 	}
 
 	// Get current item count to determine starting sequence number
@@ -221,7 +239,7 @@ func (s *ConversationService) AddItemsToConversation(ctx context.Context, conv *
 	}
 
 	// Add items to repository
-	if branchName == BranchMain || branchName == "" {
+	if branchName == BranchMain {
 		if err := s.repo.BulkAddItems(ctx, conv.ID, itemPtrs); err != nil {
 			return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to add items")
 		}
@@ -288,6 +306,262 @@ func (s *ConversationService) DeleteConversationItem(ctx context.Context, conv *
 	return nil
 }
 
+// RateItem stores like/unlike feedback, with an optional comment, on an assistant message
+func (s *ConversationService) RateItem(ctx context.Context, conv *Conversation, itemPublicID string, rating ItemRating, comment *string) (*Item, error) {
+	if err := s.validator.ValidateItemRating(rating, comment); err != nil {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "invalid rating", err, "")
+	}
+
+	item, err := s.repo.GetItemByPublicID(ctx, conv.ID, itemPublicID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "item not found")
+	}
+	if item.Type != ItemTypeMessage || item.Role == nil || *item.Role != ItemRoleAssistant {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "only assistant messages can be rated", nil, "")
+	}
+
+	if err := s.repo.RateItem(ctx, conv.ID, itemPublicID, rating, comment); err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to rate item")
+	}
+
+	now := time.Now()
+	item.Rating = &rating
+	item.RatedAt = &now
+	item.RatingComment = comment
+	return item, nil
+}
+
+// RemoveItemRating clears the feedback stored on an item
+func (s *ConversationService) RemoveItemRating(ctx context.Context, conv *Conversation, itemPublicID string) (*Item, error) {
+	item, err := s.repo.GetItemByPublicID(ctx, conv.ID, itemPublicID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "item not found")
+	}
+
+	if err := s.repo.RemoveItemRating(ctx, conv.ID, itemPublicID); err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to remove item rating")
+	}
+
+	item.Rating = nil
+	item.RatedAt = nil
+	item.RatingComment = nil
+	return item, nil
+}
+
+// ===============================================
+// Branch Management Methods
+// ===============================================
+
+// ForkBranchInput represents the input for forking a conversation branch
+type ForkBranchInput struct {
+	Name         string // Optional; an EDIT_ branch name is generated when empty
+	SourceBranch string // Defaults to the active branch
+	FromItemID   string // Last item copied into the new branch; empty copies the whole source branch
+	Description  *string
+	Activate     bool // Make the new branch the active branch
+}
+
+// ForkBranch creates a new branch from the items of an existing branch
+func (s *ConversationService) ForkBranch(ctx context.Context, conv *Conversation, input ForkBranchInput) (*BranchMetadata, error) {
+	sourceBranch := input.SourceBranch
+	if sourceBranch == "" {
+		sourceBranch = conv.GetActiveBranch()
+	}
+	if _, err := s.GetBranch(ctx, conv, sourceBranch); err != nil {
+		return nil, err
+	}
+
+	name := input.Name
+	if name == "" {
+		generated, err := GenerateEditBranchName(conv.ID)
+		if err != nil {
+			return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to generate branch name")
+		}
+		name = generated
+	} else if err := s.validator.ValidateBranchName(name); err != nil {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "invalid branch name", err, "")
+	}
+
+	if _, err := s.GetBranch(ctx, conv, name); err == nil {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeConflict, fmt.Sprintf("branch already exists: %s", name), nil, "")
+	} else if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeNotFound) {
+		return nil, err
+	}
+
+	if err := s.repo.ForkBranch(ctx, conv.ID, sourceBranch, name, input.FromItemID, input.Description); err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to fork branch")
+	}
+
+	if input.Activate {
+		if err := s.activateNewBranch(ctx, conv, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetBranch(ctx, conv, name)
+}
+
+// ForkBranchBeforeItem forks the active branch into a new EDIT_ branch holding the items before
+// itemPublicID and makes it active, so the item and the turns after it can be replaced
+func (s *ConversationService) ForkBranchBeforeItem(ctx context.Context, conv *Conversation, itemPublicID string) (*BranchMetadata, error) {
+	sourceBranch := conv.GetActiveBranch()
+	items, err := s.repo.GetBranchItems(ctx, conv.ID, sourceBranch, &query.Pagination{Order: "asc"})
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to get items")
+	}
+
+	index := -1
+	for i, item := range items {
+		if item.PublicID == itemPublicID {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeNotFound, fmt.Sprintf("item not found in active branch: %s", itemPublicID), nil, "")
+	}
+
+	description := fmt.Sprintf("Edit of %s", itemPublicID)
+	if index > 0 {
+		return s.ForkBranch(ctx, conv, ForkBranchInput{
+			SourceBranch: sourceBranch,
+			FromItemID:   items[index-1].PublicID,
+			Description:  &description,
+			Activate:     true,
+		})
+	}
+
+	// The item opens the branch, so the edit starts from an empty branch
+	name, err := GenerateEditBranchName(conv.ID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to generate branch name")
+	}
+	if _, err := s.GetBranch(ctx, conv, name); err == nil {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeConflict, fmt.Sprintf("branch already exists: %s", name), nil, "")
+	}
+	now := time.Now()
+	branch := &BranchMetadata{
+		Name:         name,
+		Description:  &description,
+		ParentBranch: &sourceBranch,
+		ForkedAt:     &now,
+	}
+	if err := s.repo.CreateBranch(ctx, conv.ID, name, branch); err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to create branch")
+	}
+	if err := s.activateNewBranch(ctx, conv, name); err != nil {
+		return nil, err
+	}
+
+	return branch, nil
+}
+
+// activateNewBranch makes a branch created by the caller active and deletes it again when that
+// fails, so a failed fork leaves no unused branch behind
+func (s *ConversationService) activateNewBranch(ctx context.Context, conv *Conversation, branchName string) error {
+	err := s.SwitchBranch(ctx, conv, branchName)
+	if err == nil {
+		return nil
+	}
+	// Use a fresh context so a cancelled request still cleans up
+	if deleteErr := s.repo.DeleteBranch(context.WithoutCancel(ctx), conv.ID, branchName); deleteErr != nil {
+		log := logger.GetLogger()
+		log.Warn().
+			Err(deleteErr).
+			Str("conversation_id", conv.PublicID).
+			Str("branch", branchName).
+			Msg("failed to delete unused branch")
+	}
+	return err
+}
+
+// GetBranch retrieves a branch with its current item count. MAIN always exists, even when it has
+// no stored metadata.
+func (s *ConversationService) GetBranch(ctx context.Context, conv *Conversation, branchName string) (*BranchMetadata, error) {
+	branch, err := s.repo.GetBranch(ctx, conv.ID, branchName)
+	if err != nil {
+		if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeNotFound) {
+			return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to get branch")
+		}
+		if branchName != BranchMain {
+			return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeNotFound, fmt.Sprintf("branch not found: %s", branchName), nil, "")
+		}
+		branch = mainBranchMetadata(conv)
+	}
+
+	count, err := s.repo.CountItems(ctx, conv.ID, branchName)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to get item count")
+	}
+	branch.ItemCount = count
+
+	return branch, nil
+}
+
+// ListBranches lists the branches of a conversation, MAIN first and the others by creation time
+func (s *ConversationService) ListBranches(ctx context.Context, conv *Conversation) ([]*BranchMetadata, error) {
+	stored, err := s.repo.ListBranches(ctx, conv.ID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to list branches")
+	}
+
+	branches := []*BranchMetadata{mainBranchMetadata(conv)}
+	for _, branch := range stored {
+		if branch.Name == BranchMain {
+			branches[0] = branch
+			continue
+		}
+		branches = append(branches, branch)
+	}
+
+	for _, branch := range branches {
+		count, err := s.repo.CountItems(ctx, conv.ID, branch.Name)
+		if err != nil {
+			return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to get item count")
+		}
+		branch.ItemCount = count
+	}
+
+	return branches, nil
+}
+
+// SwitchBranch makes an existing branch the active branch of the conversation
+func (s *ConversationService) SwitchBranch(ctx context.Context, conv *Conversation, branchName string) error {
+	if _, err := s.GetBranch(ctx, conv, branchName); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetActiveBranch(ctx, conv.ID, branchName); err != nil {
+		return platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to switch branch")
+	}
+	conv.ActiveBranch = branchName
+
+	return nil
+}
+
+// DeleteBranch deletes a branch and its items. MAIN cannot be deleted; deleting the active branch
+// switches the conversation back to MAIN.
+func (s *ConversationService) DeleteBranch(ctx context.Context, conv *Conversation, branchName string) error {
+	if branchName == BranchMain {
+		return platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "the MAIN branch cannot be deleted", nil, "")
+	}
+	if _, err := s.GetBranch(ctx, conv, branchName); err != nil {
+		return err
+	}
+
+	if conv.GetActiveBranch() == branchName {
+		if err := s.SwitchBranch(ctx, conv, BranchMain); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.DeleteBranch(ctx, conv.ID, branchName); err != nil {
+		return platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to delete branch")
+	}
+
+	return nil
+}
+
 // ===============================================
 // Helper Functions
 // ===============================================
@@ -302,3 +576,12 @@ func convertItemPtrsToItems(itemPtrs []*Item) []Item {
 	}
 	return items
 }
+
+// mainBranchMetadata describes the MAIN branch of a conversation that has no stored MAIN metadata
+func mainBranchMetadata(conv *Conversation) *BranchMetadata {
+	return &BranchMetadata{
+		Name:      BranchMain,
+		CreatedAt: conv.CreatedAt,
+		UpdatedAt: conv.UpdatedAt,
+	}
+}
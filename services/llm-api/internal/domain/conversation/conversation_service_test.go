@@ -0,0 +1,315 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"jan-server/services/llm-api/internal/domain/query"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// memoryBranches is a ConversationRepository that keeps the branches of one conversation in memory.
+// Methods the branch operations do not use are left to the embedded nil interface.
+type memoryBranches struct {
+	ConversationRepository
+	branches   map[string]*BranchMetadata
+	items      map[string]int
+	listed     map[string][]*Item // items returned by GetBranchItems
+	active     string
+	forks      []string
+	failSwitch error
+}
+
+func newMemoryBranches(names ...string) *memoryBranches {
+	repo := &memoryBranches{branches: map[string]*BranchMetadata{}, items: map[string]int{BranchMain: 3}}
+	for _, name := range names {
+		repo.branches[name] = &BranchMetadata{Name: name}
+		repo.items[name] = 1
+	}
+	return repo
+}
+
+func (m *memoryBranches) GetBranch(ctx context.Context, _ uint, branchName string) (*BranchMetadata, error) {
+	branch, ok := m.branches[branchName]
+	if !ok {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotFound, "branch not found", nil, "")
+	}
+	copied := *branch
+	return &copied, nil
+}
+
+func (m *memoryBranches) CountItems(_ context.Context, _ uint, branchName string) (int, error) {
+	return m.items[branchName], nil
+}
+
+func (m *memoryBranches) ForkBranch(_ context.Context, _ uint, sourceBranch, newBranch string, fromItemID string, description *string) error {
+	m.forks = append(m.forks, sourceBranch+">"+newBranch)
+	m.branches[newBranch] = &BranchMetadata{Name: newBranch, ParentBranch: &sourceBranch, ForkedFromItemID: &fromItemID, Description: description}
+	m.items[newBranch] = m.items[sourceBranch]
+	return nil
+}
+
+func (m *memoryBranches) CreateBranch(_ context.Context, _ uint, branchName string, metadata *BranchMetadata) error {
+	copied := *metadata
+	m.branches[branchName] = &copied
+	return nil
+}
+
+func (m *memoryBranches) GetBranchItems(_ context.Context, _ uint, branchName string, _ *query.Pagination) ([]*Item, error) {
+	return m.listed[branchName], nil
+}
+
+func (m *memoryBranches) SetActiveBranch(_ context.Context, _ uint, branchName string) error {
+	if m.failSwitch != nil {
+		return m.failSwitch
+	}
+	m.active = branchName
+	return nil
+}
+
+func (m *memoryBranches) DeleteBranch(_ context.Context, _ uint, branchName string) error {
+	delete(m.branches, branchName)
+	delete(m.items, branchName)
+	return nil
+}
+
+func TestForkBranchFromActiveBranch(t *testing.T) {
+	repo := newMemoryBranches("EDIT_1")
+	service := NewConversationService(repo)
+	conv := &Conversation{ID: 1, ActiveBranch: "EDIT_1"}
+
+	branch, err := service.ForkBranch(context.Background(), conv, ForkBranchInput{Name: "retry", Activate: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if branch.Name != "retry" || branch.ParentBranch == nil || *branch.ParentBranch != "EDIT_1" || branch.ItemCount != 1 {
+		t.Fatalf("unexpected branch %+v", branch)
+	}
+	if repo.active != "retry" || conv.ActiveBranch != "retry" {
+		t.Fatalf("expected the fork to become active, got %q", repo.active)
+	}
+}
+
+func TestForkBranchGeneratesName(t *testing.T) {
+	repo := newMemoryBranches()
+	service := NewConversationService(repo)
+
+	branch, err := service.ForkBranch(context.Background(), &Conversation{ID: 7}, ForkBranchInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(branch.Name, "EDIT_7_") || len(branch.Name) > 50 {
+		t.Fatalf("expected a generated EDIT_ name, got %s", branch.Name)
+	}
+	// Edits within the same second get their own branch
+	second, err := service.ForkBranch(context.Background(), &Conversation{ID: 7}, ForkBranchInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Name == branch.Name {
+		t.Fatalf("expected distinct generated names, got %s twice", branch.Name)
+	}
+	if repo.active != "" {
+		t.Fatal("expected the active branch to stay unchanged")
+	}
+}
+
+func TestForkBranchRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     ForkBranchInput
+		errorType platformerrors.ErrorType
+	}{
+		{"existing name", ForkBranchInput{Name: "EDIT_1"}, platformerrors.ErrorTypeConflict},
+		{"main as name", ForkBranchInput{Name: BranchMain}, platformerrors.ErrorTypeConflict},
+		{"invalid name", ForkBranchInput{Name: "has space"}, platformerrors.ErrorTypeValidation},
+		{"unknown source", ForkBranchInput{Name: "retry", SourceBranch: "missing"}, platformerrors.ErrorTypeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryBranches("EDIT_1")
+			service := NewConversationService(repo)
+
+			_, err := service.ForkBranch(context.Background(), &Conversation{ID: 1}, tt.input)
+			if !platformerrors.IsErrorType(err, tt.errorType) {
+				t.Fatalf("expected %s, got %v", tt.errorType, err)
+			}
+			if len(repo.forks) != 0 {
+				t.Fatalf("expected no fork, got %v", repo.forks)
+			}
+		})
+	}
+}
+
+// branchWithMessages returns a repository whose MAIN branch holds the items msg_1..msg_3.
+func branchWithMessages() *memoryBranches {
+	repo := newMemoryBranches()
+	repo.listed = map[string][]*Item{BranchMain: {{PublicID: "msg_1"}, {PublicID: "msg_2"}, {PublicID: "msg_3"}}}
+	return repo
+}
+
+func TestForkBranchBeforeItem(t *testing.T) {
+	t.Run("later item", func(t *testing.T) {
+		repo := branchWithMessages()
+		service := NewConversationService(repo)
+		conv := &Conversation{ID: 1}
+
+		branch, err := service.ForkBranchBeforeItem(context.Background(), conv, "msg_3")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if branch.ForkedFromItemID == nil || *branch.ForkedFromItemID != "msg_2" || len(repo.forks) != 1 {
+			t.Fatalf("expected a fork up to msg_2, got %+v", branch)
+		}
+		if repo.active != branch.Name || conv.ActiveBranch != branch.Name {
+			t.Fatalf("expected the fork to become active, got %q", repo.active)
+		}
+	})
+
+	t.Run("first item", func(t *testing.T) {
+		repo := branchWithMessages()
+		service := NewConversationService(repo)
+		conv := &Conversation{ID: 1}
+
+		branch, err := service.ForkBranchBeforeItem(context.Background(), conv, "msg_1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.forks) != 0 || repo.items[branch.Name] != 0 {
+			t.Fatalf("expected an empty branch instead of a fork, got forks %v", repo.forks)
+		}
+		if branch.ParentBranch == nil || *branch.ParentBranch != BranchMain || !strings.HasPrefix(branch.Name, "EDIT_1_") {
+			t.Fatalf("unexpected branch %+v", branch)
+		}
+		if repo.active != branch.Name || conv.ActiveBranch != branch.Name {
+			t.Fatalf("expected the new branch to become active, got %q", repo.active)
+		}
+	})
+}
+
+func TestForkBranchBeforeItemRejectsItemOutsideActiveBranch(t *testing.T) {
+	repo := branchWithMessages()
+	service := NewConversationService(repo)
+
+	_, err := service.ForkBranchBeforeItem(context.Background(), &Conversation{ID: 1}, "msg_other")
+	if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if len(repo.forks) != 0 || len(repo.branches) != 0 {
+		t.Fatalf("expected no branch, got forks %v and branches %v", repo.forks, repo.branches)
+	}
+}
+
+func TestForkBranchBeforeItemRemovesBranchWhenSwitchFails(t *testing.T) {
+	for _, itemID := range []string{"msg_1", "msg_3"} {
+		t.Run(itemID, func(t *testing.T) {
+			repo := branchWithMessages()
+			repo.failSwitch = errors.New("connection reset")
+			service := NewConversationService(repo)
+			conv := &Conversation{ID: 1}
+
+			if _, err := service.ForkBranchBeforeItem(context.Background(), conv, itemID); err == nil {
+				t.Fatal("expected the failed switch to be returned")
+			}
+			if len(repo.branches) != 0 {
+				t.Fatalf("expected the new branch to be deleted, got %v", repo.branches)
+			}
+			if conv.GetActiveBranch() != BranchMain {
+				t.Fatalf("expected MAIN to stay active, got %s", conv.GetActiveBranch())
+			}
+		})
+	}
+}
+
+func TestSwitchBranch(t *testing.T) {
+	repo := newMemoryBranches("EDIT_1")
+	service := NewConversationService(repo)
+	conv := &Conversation{ID: 1}
+
+	if err := service.SwitchBranch(context.Background(), conv, "EDIT_1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conv.ActiveBranch != "EDIT_1" || repo.active != "EDIT_1" {
+		t.Fatalf("expected EDIT_1 to be active, got %q", conv.ActiveBranch)
+	}
+
+	// MAIN exists without stored metadata
+	if err := service.SwitchBranch(context.Background(), conv, BranchMain); err != nil {
+		t.Fatalf("unexpected error switching to MAIN: %v", err)
+	}
+
+	err := service.SwitchBranch(context.Background(), conv, "missing")
+	if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if conv.ActiveBranch != BranchMain {
+		t.Fatalf("expected MAIN to stay active, got %s", conv.ActiveBranch)
+	}
+}
+
+func TestDeleteBranch(t *testing.T) {
+	repo := newMemoryBranches("EDIT_1", "EDIT_2")
+	service := NewConversationService(repo)
+	conv := &Conversation{ID: 1, ActiveBranch: "EDIT_1"}
+
+	if err := service.DeleteBranch(context.Background(), conv, "EDIT_2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := repo.branches["EDIT_2"]; ok {
+		t.Fatal("expected EDIT_2 to be deleted")
+	}
+	if conv.ActiveBranch != "EDIT_1" {
+		t.Fatalf("expected the active branch to stay EDIT_1, got %s", conv.ActiveBranch)
+	}
+
+	// Deleting the active branch switches back to MAIN
+	if err := service.DeleteBranch(context.Background(), conv, "EDIT_1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conv.ActiveBranch != BranchMain || repo.active != BranchMain {
+		t.Fatalf("expected MAIN to become active, got %s", conv.ActiveBranch)
+	}
+}
+
+func TestDeleteBranchRejects(t *testing.T) {
+	repo := newMemoryBranches()
+	service := NewConversationService(repo)
+	conv := &Conversation{ID: 1}
+
+	err := service.DeleteBranch(context.Background(), conv, BranchMain)
+	if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeValidation) {
+		t.Fatalf("expected MAIN to be protected, got %v", err)
+	}
+	err = service.DeleteBranch(context.Background(), conv, "missing")
+	if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+// snapshotRepo records targeted snapshot updates; a full Update would hit the embedded nil interface.
+type snapshotRepo struct {
+	ConversationRepository
+	version  int
+	snapshot *string
+}
+
+func (r *snapshotRepo) UpdateInstructionSnapshot(_ context.Context, _ uint, version int, snapshot *string) error {
+	r.version, r.snapshot = version, snapshot
+	return nil
+}
+
+func TestUpdateInstructionSnapshotWritesOnlySnapshot(t *testing.T) {
+	repo := &snapshotRepo{}
+	service := NewConversationService(repo)
+	conv := &Conversation{ID: 1, ActiveBranch: "EDIT_1"}
+	conv.RecordInstructionSnapshot(2, "be brief")
+
+	if err := service.UpdateInstructionSnapshot(context.Background(), conv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.version != 2 || repo.snapshot == nil || *repo.snapshot != "be brief" {
+		t.Fatalf("expected version 2 with the snapshot, got %d and %v", repo.version, repo.snapshot)
+	}
+}
@@ -21,6 +21,8 @@ type ConversationValidationConfig struct {
 	MaxMetadataValueLength  int
 	MaxItemsPerConversation int // TODO: Implement validation for maximum items in a conversation
 	MaxReferrerLength       int
+	MaxBranchNameLength     int
+	MaxRatingCommentLength  int
 }
 
 // DefaultConversationValidationConfig returns OpenAI-aligned conversation validation rules
@@ -32,6 +34,8 @@ func DefaultConversationValidationConfig() *ConversationValidationConfig {
 		MaxMetadataValueLength:  512,  // OpenAI default
 		MaxItemsPerConversation: 1000, // Reasonable conversation size limit
 		MaxReferrerLength:       64,
+		MaxBranchNameLength:     50, // Matches the branch column size
+		MaxRatingCommentLength:  2000,
 	}
 }
 
@@ -39,6 +43,7 @@ func DefaultConversationValidationConfig() *ConversationValidationConfig {
 type ConversationValidator struct {
 	config             *ConversationValidationConfig
 	metadataKeyPattern *regexp.Regexp
+	branchNamePattern  *regexp.Regexp
 }
 
 // NewConversationValidator creates a validator for conversations
@@ -50,6 +55,7 @@ func NewConversationValidator(config *ConversationValidationConfig) *Conversatio
 	return &ConversationValidator{
 		config:             config,
 		metadataKeyPattern: regexp.MustCompile(`^[a-zA-Z0-9_]+$`),
+		branchNamePattern:  regexp.MustCompile(`^[a-zA-Z0-9_-]+$`),
 	}
 }
 
@@ -133,6 +139,38 @@ func (v *ConversationValidator) ValidateConversationID(id string) error {
 	return nil
 }
 
+// ValidateBranchName validates a branch name chosen by the client
+func (v *ConversationValidator) ValidateBranchName(name string) error {
+	if name == "" {
+		return fmt.Errorf("branch name cannot be empty")
+	}
+
+	if len(name) > v.config.MaxBranchNameLength {
+		return fmt.Errorf("branch name cannot exceed %d characters (got %d)", v.config.MaxBranchNameLength, len(name))
+	}
+
+	if !v.branchNamePattern.MatchString(name) {
+		return fmt.Errorf("branch name can only contain letters, numbers, underscores and hyphens")
+	}
+
+	return nil
+}
+
+// ValidateItemRating validates feedback given on an item
+func (v *ConversationValidator) ValidateItemRating(rating ItemRating, comment *string) error {
+	if !rating.Validate() {
+		return fmt.Errorf("invalid rating: must be 'like' or 'unlike'")
+	}
+
+	if comment != nil {
+		if length := utf8.RuneCountInString(*comment); length > v.config.MaxRatingCommentLength {
+			return fmt.Errorf("rating comment cannot exceed %d characters (got %d)", v.config.MaxRatingCommentLength, length)
+		}
+	}
+
+	return nil
+}
+
 // validateTitle validates conversation title (internal use only)
 func (v *ConversationValidator) validateTitle(title string) error {
 	// Title can be empty (optional field)
@@ -0,0 +1,135 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"jan-server/services/llm-api/internal/domain/query"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+const (
+	// ConversationExportObject identifies our own export documents
+	ConversationExportObject = "conversation.export"
+	// ConversationExportVersion is the version of the export document layout
+	ConversationExportVersion = 1
+
+	// exportPageSize is the number of conversations loaded per query during a bulk export
+	exportPageSize = 50
+)
+
+// @Enum(json, jsonl, markdown)
+type ExportFormat string
+
+const (
+	ExportFormatJSON     ExportFormat = "json"     // One document, or a list of documents for bulk exports
+	ExportFormatJSONL    ExportFormat = "jsonl"    // One document per line
+	ExportFormatMarkdown ExportFormat = "markdown" // Readable transcript of the active branch
+)
+
+// ParseExportFormat validates an export format, defaulting to JSON
+func ParseExportFormat(value string) (ExportFormat, error) {
+	switch format := ExportFormat(value); format {
+	case "":
+		return ExportFormatJSON, nil
+	case ExportFormatJSON, ExportFormatJSONL, ExportFormatMarkdown:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid export format %q: must be json, jsonl or markdown", value)
+	}
+}
+
+// ConversationExport is a conversation with the items of all its branches. It is the document
+// written by exports and read back by imports.
+type ConversationExport struct {
+	Object       string            `json:"object"`
+	Version      int               `json:"version"`
+	ID           string            `json:"id"`
+	Title        *string           `json:"title,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	ProjectID    *string           `json:"project_id,omitempty"`
+	ActiveBranch string            `json:"active_branch"`
+	Branches     []BranchExport    `json:"branches"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// BranchExport is a branch of an exported conversation with its items in order
+type BranchExport struct {
+	BranchMetadata
+	Items []Item `json:"items"`
+}
+
+// Branch returns the exported branch with the given name, or nil
+func (e *ConversationExport) Branch(name string) *BranchExport {
+	for idx := range e.Branches {
+		if e.Branches[idx].Name == name {
+			return &e.Branches[idx]
+		}
+	}
+	return nil
+}
+
+// ExportConversation collects a conversation and the items of all its branches
+func (s *ConversationService) ExportConversation(ctx context.Context, conv *Conversation) (*ConversationExport, error) {
+	branches, err := s.ListBranches(ctx, conv)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &ConversationExport{
+		Object:       ConversationExportObject,
+		Version:      ConversationExportVersion,
+		ID:           conv.PublicID,
+		Title:        conv.Title,
+		Metadata:     conv.Metadata,
+		ProjectID:    conv.ProjectPublicID,
+		ActiveBranch: conv.GetActiveBranch(),
+		Branches:     make([]BranchExport, 0, len(branches)),
+		CreatedAt:    conv.CreatedAt,
+		UpdatedAt:    conv.UpdatedAt,
+	}
+	for _, branch := range branches {
+		items, err := s.repo.GetBranchItems(ctx, conv.ID, branch.Name, nil)
+		if err != nil {
+			return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, fmt.Sprintf("failed to get items of branch %s", branch.Name))
+		}
+		branch.ItemCount = len(items)
+		export.Branches = append(export.Branches, BranchExport{
+			BranchMetadata: *branch,
+			Items:          convertItemPtrsToItems(items),
+		})
+	}
+	return export, nil
+}
+
+// ExportUserConversations passes every conversation of the user to emit, oldest first. The
+// export stops at the first error returned by emit.
+func (s *ConversationService) ExportUserConversations(ctx context.Context, userID uint, emit func(*ConversationExport) error) error {
+	filter := ConversationFilter{UserID: &userID}
+	limit := exportPageSize
+	pagination := &query.Pagination{Limit: &limit, Order: "asc"}
+
+	for {
+		conversations, err := s.repo.FindByFilter(ctx, filter, pagination)
+		if err != nil {
+			return platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to list conversations")
+		}
+
+		for _, conv := range conversations {
+			export, err := s.ExportConversation(ctx, conv)
+			if err != nil {
+				return err
+			}
+			if err := emit(export); err != nil {
+				return err
+			}
+		}
+
+		if len(conversations) < limit {
+			return nil
+		}
+		pagination.After = &conversations[len(conversations)-1].ID
+	}
+}
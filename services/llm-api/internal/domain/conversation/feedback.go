@@ -0,0 +1,124 @@
+package conversation
+
+import (
+	"context"
+	"time"
+
+	"jan-server/services/llm-api/internal/domain/query"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// feedbackExportPageSize is the number of rated items loaded per query during an export
+const feedbackExportPageSize = 100
+
+// FeedbackFilter selects the rated items included in a feedback export
+type FeedbackFilter struct {
+	Model       *string     // Public model ID that generated the item
+	Rating      *ItemRating // Only likes or only unlikes
+	RatedAfter  *time.Time  // Inclusive
+	RatedBefore *time.Time  // Exclusive
+}
+
+// FeedbackMessage is a message of the prompt context that led to a rated response
+type FeedbackMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// FeedbackRecord is one rated response together with the context it was generated from
+type FeedbackRecord struct {
+	ConversationID string            `json:"conversation_id"`
+	ItemID         string            `json:"item_id"`
+	Branch         string            `json:"branch"`
+	Model          *string           `json:"model"`
+	Provider       *string           `json:"provider"`
+	Rating         ItemRating        `json:"rating"`
+	Comment        *string           `json:"comment,omitempty"`
+	Prompt         []FeedbackMessage `json:"prompt"`
+	Response       string            `json:"response"`
+	RatedAt        *time.Time        `json:"rated_at"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// ExportFeedback walks all rated items matching the filter, oldest first, and passes each as a
+// FeedbackRecord to emit. The export stops at the first error returned by emit.
+func (s *ConversationService) ExportFeedback(ctx context.Context, filter FeedbackFilter, emit func(FeedbackRecord) error) error {
+	rated := true
+	itemFilter := ItemFilter{
+		Model:       filter.Model,
+		Rating:      filter.Rating,
+		Rated:       &rated,
+		RatedAfter:  filter.RatedAfter,
+		RatedBefore: filter.RatedBefore,
+	}
+	limit := feedbackExportPageSize
+	pagination := &query.Pagination{Limit: &limit, Order: "asc"}
+	conversations := make(map[uint]string)
+
+	for {
+		items, err := s.repo.FindItemsByFilter(ctx, itemFilter, pagination)
+		if err != nil {
+			return platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to find rated items")
+		}
+
+		for _, item := range items {
+			conversationID, ok := conversations[item.ConversationID]
+			if !ok {
+				conv, err := s.repo.FindByID(ctx, item.ConversationID)
+				if err != nil {
+					return platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to find conversation of rated item")
+				}
+				conversationID = conv.PublicID
+				conversations[item.ConversationID] = conversationID
+			}
+
+			branchItems, err := s.repo.GetBranchItems(ctx, item.ConversationID, item.Branch, nil)
+			if err != nil {
+				return platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to get prompt context of rated item")
+			}
+
+			if err := emit(NewFeedbackRecord(conversationID, item, branchItems)); err != nil {
+				return err
+			}
+		}
+
+		if len(items) < limit {
+			return nil
+		}
+		pagination.After = &items[len(items)-1].ID
+	}
+}
+
+// NewFeedbackRecord builds the export record of a rated item. The prompt holds the text of the
+// messages that precede the item in its branch.
+func NewFeedbackRecord(conversationID string, item *Item, branchItems []*Item) FeedbackRecord {
+	prompt := make([]FeedbackMessage, 0, len(branchItems))
+	for _, candidate := range branchItems {
+		if candidate.ID >= item.ID {
+			break
+		}
+		if candidate.Type != ItemTypeMessage || candidate.Role == nil {
+			continue
+		}
+		if text := candidate.Text(); text != "" {
+			prompt = append(prompt, FeedbackMessage{Role: string(*candidate.Role), Content: text})
+		}
+	}
+
+	record := FeedbackRecord{
+		ConversationID: conversationID,
+		ItemID:         item.PublicID,
+		Branch:         item.Branch,
+		Model:          item.Model,
+		Provider:       item.ProviderID,
+		Comment:        item.RatingComment,
+		Prompt:         prompt,
+		Response:       item.Text(),
+		RatedAt:        item.RatedAt,
+		CreatedAt:      item.CreatedAt,
+	}
+	if item.Rating != nil {
+		record.Rating = *item.Rating
+	}
+	return record
+}
@@ -0,0 +1,254 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"jan-server/services/llm-api/internal/domain/query"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// memoryFeedback is a ConversationRepository holding the items of conversation 1 in memory.
+// Methods the rating and export operations do not use are left to the embedded nil interface.
+type memoryFeedback struct {
+	ConversationRepository
+	items   []*Item
+	filters []ItemFilter
+	queries int
+}
+
+func newMemoryFeedback(items ...*Item) *memoryFeedback {
+	for i, item := range items {
+		item.ID = uint(i + 1)
+		item.ConversationID = 1
+		if item.Branch == "" {
+			item.Branch = BranchMain
+		}
+	}
+	return &memoryFeedback{items: items}
+}
+
+func (m *memoryFeedback) item(publicID string) *Item {
+	for _, item := range m.items {
+		if item.PublicID == publicID {
+			return item
+		}
+	}
+	return nil
+}
+
+func (m *memoryFeedback) GetItemByPublicID(ctx context.Context, _ uint, publicID string) (*Item, error) {
+	item := m.item(publicID)
+	if item == nil {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotFound, "item not found", nil, "")
+	}
+	copied := *item
+	return &copied, nil
+}
+
+func (m *memoryFeedback) RateItem(_ context.Context, _ uint, itemID string, rating ItemRating, comment *string) error {
+	now := time.Now()
+	item := m.item(itemID)
+	item.Rating = &rating
+	item.RatedAt = &now
+	item.RatingComment = comment
+	return nil
+}
+
+func (m *memoryFeedback) RemoveItemRating(_ context.Context, _ uint, itemID string) error {
+	item := m.item(itemID)
+	item.Rating = nil
+	item.RatedAt = nil
+	item.RatingComment = nil
+	return nil
+}
+
+func (m *memoryFeedback) FindItemsByFilter(_ context.Context, filter ItemFilter, pagination *query.Pagination) ([]*Item, error) {
+	m.filters = append(m.filters, filter)
+	m.queries++
+	var found []*Item
+	for _, item := range m.items {
+		if item.Rating == nil || (filter.Rating != nil && *item.Rating != *filter.Rating) {
+			continue
+		}
+		if pagination.After != nil && item.ID <= *pagination.After {
+			continue
+		}
+		found = append(found, item)
+		if len(found) == *pagination.Limit {
+			break
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryFeedback) FindByID(_ context.Context, id uint) (*Conversation, error) {
+	return &Conversation{ID: id, PublicID: fmt.Sprintf("conv_%d", id)}, nil
+}
+
+func (m *memoryFeedback) GetBranchItems(_ context.Context, _ uint, branchName string, _ *query.Pagination) ([]*Item, error) {
+	var items []*Item
+	for _, item := range m.items {
+		if item.Branch == branchName {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func message(publicID string, role ItemRole, text string) *Item {
+	return &Item{PublicID: publicID, Type: ItemTypeMessage, Role: &role, Content: []Content{NewTextContent(text)}}
+}
+
+func rated(item *Item, rating ItemRating) *Item {
+	now := time.Now()
+	item.Rating = &rating
+	item.RatedAt = &now
+	return item
+}
+
+func TestRateItem(t *testing.T) {
+	repo := newMemoryFeedback(message("msg_user", ItemRoleUser, "hi"), message("msg_reply", ItemRoleAssistant, "hello"))
+	service := NewConversationService(repo)
+	conv := &Conversation{ID: 1}
+	comment := "friendly"
+
+	item, err := service.RateItem(context.Background(), conv, "msg_reply", ItemRatingLike, &comment)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if item.Rating == nil || *item.Rating != ItemRatingLike || item.RatedAt == nil || item.RatingComment != &comment {
+		t.Fatalf("expected the returned item to carry the rating, got %+v", item)
+	}
+	if stored := repo.item("msg_reply"); stored.Rating == nil || *stored.Rating != ItemRatingLike {
+		t.Fatal("expected the rating to be stored")
+	}
+}
+
+func TestRateItemRejects(t *testing.T) {
+	long := strings.Repeat("x", DefaultConversationValidationConfig().MaxRatingCommentLength+1)
+	tests := []struct {
+		name      string
+		itemID    string
+		rating    ItemRating
+		comment   *string
+		errorType platformerrors.ErrorType
+	}{
+		{"invalid rating", "msg_reply", ItemRating("love"), nil, platformerrors.ErrorTypeValidation},
+		{"long comment", "msg_reply", ItemRatingUnlike, &long, platformerrors.ErrorTypeValidation},
+		{"user message", "msg_user", ItemRatingLike, nil, platformerrors.ErrorTypeValidation},
+		{"missing item", "msg_missing", ItemRatingLike, nil, platformerrors.ErrorTypeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryFeedback(message("msg_user", ItemRoleUser, "hi"), message("msg_reply", ItemRoleAssistant, "hello"))
+			service := NewConversationService(repo)
+
+			_, err := service.RateItem(context.Background(), &Conversation{ID: 1}, tt.itemID, tt.rating, tt.comment)
+			if !platformerrors.IsErrorType(err, tt.errorType) {
+				t.Fatalf("expected %s, got %v", tt.errorType, err)
+			}
+			for _, item := range repo.items {
+				if item.Rating != nil {
+					t.Fatalf("expected nothing rated, got %s", item.PublicID)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveItemRating(t *testing.T) {
+	comment := "wrong"
+	reply := rated(message("msg_reply", ItemRoleAssistant, "hello"), ItemRatingUnlike)
+	reply.RatingComment = &comment
+	repo := newMemoryFeedback(reply)
+	service := NewConversationService(repo)
+
+	item, err := service.RemoveItemRating(context.Background(), &Conversation{ID: 1}, "msg_reply")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if item.Rating != nil || item.RatedAt != nil || item.RatingComment != nil {
+		t.Fatalf("expected the returned item without rating, got %+v", item)
+	}
+	if repo.item("msg_reply").Rating != nil {
+		t.Fatal("expected the stored rating to be removed")
+	}
+
+	_, err = service.RemoveItemRating(context.Background(), &Conversation{ID: 1}, "msg_missing")
+	if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestExportFeedbackPassesFilterAndPages(t *testing.T) {
+	items := []*Item{message("msg_q", ItemRoleUser, "question")}
+	for i := 0; i < feedbackExportPageSize+1; i++ {
+		items = append(items, rated(message(fmt.Sprintf("msg_%d", i), ItemRoleAssistant, "answer"), ItemRatingUnlike))
+	}
+	repo := newMemoryFeedback(items...)
+	service := NewConversationService(repo)
+
+	model := "jan-v1-4b"
+	rating := ItemRatingUnlike
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := after.AddDate(0, 1, 0)
+	var records []FeedbackRecord
+	err := service.ExportFeedback(context.Background(), FeedbackFilter{Model: &model, Rating: &rating, RatedAfter: &after, RatedBefore: &before}, func(record FeedbackRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != feedbackExportPageSize+1 || repo.queries != 2 {
+		t.Fatalf("expected all records over 2 pages, got %d records in %d queries", len(records), repo.queries)
+	}
+	filter := repo.filters[0]
+	if filter.Rated == nil || !*filter.Rated || filter.Model != &model || filter.Rating != &rating || filter.RatedAfter != &after || filter.RatedBefore != &before {
+		t.Fatalf("expected the filter to select rated items only with the given bounds, got %+v", filter)
+	}
+	if records[0].ConversationID != "conv_1" || records[0].Response != "answer" || records[0].Rating != ItemRatingUnlike {
+		t.Fatalf("unexpected record %+v", records[0])
+	}
+}
+
+func TestExportFeedbackStopsOnEmitError(t *testing.T) {
+	repo := newMemoryFeedback(
+		rated(message("msg_1", ItemRoleAssistant, "one"), ItemRatingLike),
+		rated(message("msg_2", ItemRoleAssistant, "two"), ItemRatingLike),
+	)
+	service := NewConversationService(repo)
+
+	emitted := 0
+	err := service.ExportFeedback(context.Background(), FeedbackFilter{}, func(FeedbackRecord) error {
+		emitted++
+		return fmt.Errorf("client went away")
+	})
+	if err == nil || emitted != 1 {
+		t.Fatalf("expected the export to stop after the first failed write, got %d writes and %v", emitted, err)
+	}
+}
+
+func TestNewFeedbackRecordBuildsPrompt(t *testing.T) {
+	repo := newMemoryFeedback(
+		message("msg_sys", ItemRoleSystem, "be brief"),
+		message("msg_q", ItemRoleUser, "question"),
+		&Item{PublicID: "msg_call", Type: ItemTypeFunctionCall},
+		rated(message("msg_a", ItemRoleAssistant, "answer"), ItemRatingLike),
+		message("msg_later", ItemRoleUser, "follow-up"),
+	)
+	reply := repo.item("msg_a")
+
+	record := NewFeedbackRecord("conv_1", reply, repo.items)
+	want := []FeedbackMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: "question"}}
+	if fmt.Sprint(record.Prompt) != fmt.Sprint(want) {
+		t.Fatalf("expected the preceding messages as prompt, got %+v", record.Prompt)
+	}
+	if record.ItemID != "msg_a" || record.Branch != BranchMain || record.Response != "answer" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+}
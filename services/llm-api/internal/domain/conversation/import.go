@@ -0,0 +1,556 @@
+package conversation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"jan-server/services/llm-api/internal/utils/idgen"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// importBranchPrefix names the extra branches created for alternative ChatGPT replies
+const importBranchPrefix = "IMPORT_"
+
+// ParseConversationImport reads conversations from our own JSON or JSON Lines exports, or from a
+// ChatGPT conversations.json export. The input may hold single documents, arrays of documents
+// and {"object": "list", "data": [...]} lists, in any combination.
+func ParseConversationImport(r io.Reader) ([]*ConversationExport, error) {
+	decoder := json.NewDecoder(r)
+	var exports []*ConversationExport
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		parsed, err := parseImportValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, parsed...)
+	}
+	if len(exports) == 0 {
+		return nil, fmt.Errorf("no conversations found")
+	}
+	return exports, nil
+}
+
+func parseImportValue(raw json.RawMessage) ([]*ConversationExport, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var values []json.RawMessage
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %w", err)
+		}
+		var exports []*ConversationExport
+		for _, value := range values {
+			parsed, err := parseImportValue(value)
+			if err != nil {
+				return nil, err
+			}
+			exports = append(exports, parsed...)
+		}
+		return exports, nil
+	}
+
+	var probe struct {
+		Object  string            `json:"object"`
+		Data    []json.RawMessage `json:"data"`
+		Mapping json.RawMessage   `json:"mapping"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, fmt.Errorf("conversation must be a JSON object: %w", err)
+	}
+
+	switch {
+	case probe.Object == "list":
+		var exports []*ConversationExport
+		for _, value := range probe.Data {
+			parsed, err := parseImportValue(value)
+			if err != nil {
+				return nil, err
+			}
+			exports = append(exports, parsed...)
+		}
+		return exports, nil
+	case probe.Object == ConversationExportObject:
+		var export ConversationExport
+		if err := json.Unmarshal(raw, &export); err != nil {
+			return nil, fmt.Errorf("invalid conversation export: %w", err)
+		}
+		return []*ConversationExport{&export}, nil
+	case len(probe.Mapping) > 0:
+		var source chatGPTConversation
+		if err := json.Unmarshal(raw, &source); err != nil {
+			return nil, fmt.Errorf("invalid ChatGPT conversation: %w", err)
+		}
+		export, err := source.toExport()
+		if err != nil {
+			return nil, err
+		}
+		return []*ConversationExport{export}, nil
+	default:
+		return nil, fmt.Errorf("unrecognized conversation format: expected a %s document or a ChatGPT conversation", ConversationExportObject)
+	}
+}
+
+// ImportConversations creates conversations for the user from parsed export documents. All
+// documents are validated first, then all conversations are stored in one transaction, so an
+// import creates every conversation or none. Items get new IDs and lose their ratings, and
+// imported conversations belong to no project.
+func (s *ConversationService) ImportConversations(ctx context.Context, userID uint, exports []*ConversationExport) ([]*Conversation, error) {
+	prepared := make([]*ConversationWithBranches, 0, len(exports))
+	for idx, export := range exports {
+		imported, err := s.prepareImport(userID, export)
+		if err != nil {
+			return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, fmt.Sprintf("conversation %d: %s", idx+1, err.Error()), err, "")
+		}
+		prepared = append(prepared, imported)
+	}
+
+	if err := s.repo.CreateWithBranches(ctx, prepared); err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to import conversations")
+	}
+
+	conversations := make([]*Conversation, 0, len(prepared))
+	for _, imported := range prepared {
+		conversations = append(conversations, imported.Conversation)
+	}
+	return conversations, nil
+}
+
+// ConversationWithBranches is a new conversation with its branches and items, stored together
+type ConversationWithBranches struct {
+	Conversation *Conversation
+	Branches     []*BranchMetadata // Branches other than MAIN
+	Items        []*Item           // Items of all branches
+}
+
+func (s *ConversationService) prepareImport(userID uint, export *ConversationExport) (*ConversationWithBranches, error) {
+	publicID, err := idgen.GenerateSecureID("conv", 16)
+	if err != nil {
+		return nil, err
+	}
+	conv := NewConversation(publicID, userID, export.Title, export.Metadata)
+	if !export.CreatedAt.IsZero() {
+		conv.CreatedAt = export.CreatedAt
+		conv.UpdatedAt = export.CreatedAt
+	}
+	if export.UpdatedAt.After(conv.UpdatedAt) {
+		conv.UpdatedAt = export.UpdatedAt
+	}
+	if err := s.validator.ValidateConversation(conv); err != nil {
+		return nil, err
+	}
+
+	// Items get new IDs; fork points and summaries are remapped to the new IDs of the items they
+	// refer to
+	newIDs := make(map[string]string)
+	seen := make(map[string]bool)
+	prepared := &ConversationWithBranches{}
+	for _, branch := range export.Branches {
+		name := branch.Name
+		if name == "" {
+			name = BranchMain
+		}
+		if name != BranchMain {
+			if err := s.validator.ValidateBranchName(name); err != nil {
+				return nil, err
+			}
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate branch: %s", name)
+		}
+		seen[name] = true
+
+		for idx := range branch.Items {
+			item := branch.Items[idx]
+			oldID := item.PublicID
+			item.PublicID = ""
+			if err := s.validateImportedItem(item); err != nil {
+				return nil, fmt.Errorf("branch %s, item %d: %w", name, idx+1, err)
+			}
+			if item.PublicID, err = idgen.GenerateSecureID("msg", 16); err != nil {
+				return nil, err
+			}
+			if oldID != "" {
+				newIDs[oldID] = item.PublicID
+			}
+			item.ID = 0
+			item.ConversationID = 0
+			item.ResponseID = nil
+			// Ratings were given elsewhere and must not reach the feedback export
+			item.Rating = nil
+			item.RatedAt = nil
+			item.RatingComment = nil
+			item.Object = "conversation.item"
+			item.Branch = name
+			item.SequenceNumber = idx + 1
+			if item.CreatedAt.IsZero() {
+				item.CreatedAt = conv.CreatedAt
+			}
+			prepared.Items = append(prepared.Items, &item)
+		}
+
+		if name == BranchMain {
+			continue
+		}
+		meta := branch.BranchMetadata
+		meta.Name = name
+		meta.ItemCount = len(branch.Items)
+		if meta.CreatedAt.IsZero() {
+			meta.CreatedAt = conv.CreatedAt
+		}
+		meta.UpdatedAt = meta.CreatedAt
+		prepared.Branches = append(prepared.Branches, &meta)
+	}
+
+	for _, item := range prepared.Items {
+		item.Content = append([]Content(nil), item.Content...)
+		for idx := range item.Content {
+			if through := item.Content[idx].SummarizedThrough; through != nil {
+				if newID, ok := newIDs[*through]; ok {
+					item.Content[idx].SummarizedThrough = &newID
+				}
+			}
+		}
+	}
+
+	for _, branch := range prepared.Branches {
+		if branch.ParentBranch != nil && !seen[*branch.ParentBranch] {
+			branch.ParentBranch = nil
+		}
+		if branch.ForkedFromItemID != nil {
+			if newID, ok := newIDs[*branch.ForkedFromItemID]; ok {
+				branch.ForkedFromItemID = &newID
+			} else {
+				branch.ForkedFromItemID = nil
+			}
+		}
+	}
+
+	if active := export.ActiveBranch; active != "" && seen[active] {
+		conv.ActiveBranch = active
+	}
+	prepared.Conversation = conv
+	return prepared, nil
+}
+
+// validateImportedItem checks the fields an item needs to be stored. Content blocks are not
+// validated one by one: exports hold the content types written by chat completions, such as
+// reasoning and tool results, which the items API does not accept from clients.
+func (s *ConversationService) validateImportedItem(item Item) error {
+	if err := s.itemValidator.ValidateItemType(item.Type); err != nil {
+		return err
+	}
+	if item.Role != nil {
+		if err := s.itemValidator.ValidateItemRole(*item.Role); err != nil {
+			return err
+		}
+	}
+	if item.Status != nil {
+		if err := s.itemValidator.ValidateItemStatus(*item.Status); err != nil {
+			return err
+		}
+	}
+	if len(item.Content) > s.itemValidator.config.MaxContentBlocks {
+		return fmt.Errorf("content array cannot exceed %d blocks (got %d)", s.itemValidator.config.MaxContentBlocks, len(item.Content))
+	}
+	return nil
+}
+
+// ===============================================
+// ChatGPT conversations.json
+// ===============================================
+
+// chatGPTConversation is a conversation of a ChatGPT data export. Messages form a tree: each
+// regenerated reply or edited prompt starts a new child, and current_node is the leaf shown.
+type chatGPTConversation struct {
+	ID          string                 `json:"id"`
+	Title       string                 `json:"title"`
+	CreateTime  float64                `json:"create_time"`
+	UpdateTime  float64                `json:"update_time"`
+	CurrentNode string                 `json:"current_node"`
+	Mapping     map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	ID       string          `json:"id"`
+	Message  *chatGPTMessage `json:"message"`
+	Parent   *string         `json:"parent"`
+	Children []string        `json:"children"`
+}
+
+type chatGPTMessage struct {
+	ID     string `json:"id"`
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime *float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+		Text        string            `json:"text"`
+	} `json:"content"`
+	Metadata struct {
+		ModelSlug        string `json:"model_slug"`
+		IsVisuallyHidden bool   `json:"is_visually_hidden_from_conversation"`
+	} `json:"metadata"`
+}
+
+// text returns the text of a message; image and other non-text parts are dropped
+func (m *chatGPTMessage) text() string {
+	parts := make([]string, 0, len(m.Content.Parts))
+	for _, raw := range m.Content.Parts {
+		var part string
+		if err := json.Unmarshal(raw, &part); err == nil && strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 && strings.TrimSpace(m.Content.Text) != "" {
+		return m.Content.Text
+	}
+	return strings.Join(parts, "\n")
+}
+
+// toItem maps a visible user, assistant or system message to an item; tool messages, hidden
+// messages and messages without text are skipped
+func (m *chatGPTMessage) toItem(fallbackTime time.Time) *Item {
+	if m.Metadata.IsVisuallyHidden {
+		return nil
+	}
+	text := m.text()
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	var content Content
+	role := ItemRole(m.Author.Role)
+	switch role {
+	case ItemRoleUser:
+		content = NewInputTextContent(text)
+	case ItemRoleAssistant, ItemRoleSystem:
+		content = NewTextContent(text)
+	default:
+		return nil
+	}
+
+	status := ItemStatusCompleted
+	item := &Item{
+		Type:      ItemTypeMessage,
+		Role:      &role,
+		Status:    &status,
+		Content:   []Content{content},
+		CreatedAt: fallbackTime,
+	}
+	if m.CreateTime != nil && *m.CreateTime > 0 {
+		item.CreatedAt = unixSeconds(*m.CreateTime)
+	}
+	if role == ItemRoleAssistant && m.Metadata.ModelSlug != "" {
+		model := m.Metadata.ModelSlug
+		item.Model = &model
+	}
+	return item
+}
+
+// toExport converts the message tree to branches. The path to current_node becomes MAIN; every
+// other leaf becomes an IMPORT_n branch forked from the branch it diverges from.
+func (c *chatGPTConversation) toExport() (*ConversationExport, error) {
+	createdAt := time.Now().UTC()
+	if c.CreateTime > 0 {
+		createdAt = unixSeconds(c.CreateTime)
+	}
+	updatedAt := createdAt
+	if c.UpdateTime > 0 {
+		updatedAt = unixSeconds(c.UpdateTime)
+	}
+
+	var title *string
+	if trimmed := strings.TrimSpace(c.Title); trimmed != "" {
+		if utf8.RuneCountInString(trimmed) > DefaultConversationValidationConfig().MaxTitleLength {
+			trimmed = string([]rune(trimmed)[:DefaultConversationValidationConfig().MaxTitleLength])
+		}
+		title = &trimmed
+	}
+	metadata := map[string]string{"import_source": "chatgpt"}
+	if c.ID != "" {
+		metadata["import_source_id"] = c.ID
+	}
+
+	leaves := c.leaves()
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("ChatGPT conversation %q has no messages", c.Title)
+	}
+	current := c.CurrentNode
+	if _, ok := c.Mapping[current]; !ok {
+		current = leaves[len(leaves)-1]
+	}
+	ordered := append([]string{current}, without(leaves, current)...)
+
+	export := &ConversationExport{
+		Object:       ConversationExportObject,
+		Version:      ConversationExportVersion,
+		ID:           c.ID,
+		Title:        title,
+		Metadata:     metadata,
+		ActiveBranch: BranchMain,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+	}
+
+	// owner is the first branch containing a node; itemIDs the export item ID of a node per branch
+	owner := make(map[string]string)
+	itemIDs := make(map[string]map[string]string)
+	for _, leaf := range ordered {
+		name := BranchMain
+		if len(export.Branches) > 0 {
+			name = fmt.Sprintf("%s%d", importBranchPrefix, len(export.Branches))
+		}
+		path := c.pathTo(leaf)
+
+		branch := BranchExport{BranchMetadata: BranchMetadata{Name: name, CreatedAt: createdAt}}
+		ids := make(map[string]string)
+		var forkNode string
+		newItems := 0
+		for _, nodeID := range path {
+			_, shared := owner[nodeID]
+			if shared {
+				forkNode = nodeID
+			}
+			node := c.Mapping[nodeID]
+			if node.Message == nil {
+				continue
+			}
+			item := node.Message.toItem(createdAt)
+			if item == nil {
+				continue
+			}
+			item.PublicID = name + "/" + nodeID
+			ids[nodeID] = item.PublicID
+			branch.Items = append(branch.Items, *item)
+			if !shared {
+				if newItems == 0 && name != BranchMain {
+					forkedAt := item.CreatedAt
+					branch.ForkedAt = &forkedAt
+				}
+				newItems++
+			}
+		}
+		// Alternatives that only differ in skipped messages add nothing
+		if name != BranchMain && newItems == 0 {
+			continue
+		}
+
+		if forkNode != "" {
+			parent := owner[forkNode]
+			branch.ParentBranch = &parent
+			// Fork after the last message the branches share
+			for _, nodeID := range path {
+				if id, ok := itemIDs[parent][nodeID]; ok {
+					forkedFrom := id
+					branch.ForkedFromItemID = &forkedFrom
+				}
+				if nodeID == forkNode {
+					break
+				}
+			}
+		}
+		for _, nodeID := range path {
+			if _, owned := owner[nodeID]; !owned {
+				owner[nodeID] = name
+			}
+		}
+		itemIDs[name] = ids
+		export.Branches = append(export.Branches, branch)
+	}
+	return export, nil
+}
+
+// leaves lists the nodes without children in depth-first order from the roots
+func (c *chatGPTConversation) leaves() []string {
+	var roots []string
+	for id, node := range c.Mapping {
+		if node.Parent == nil {
+			roots = append(roots, id)
+			continue
+		}
+		if _, ok := c.Mapping[*node.Parent]; !ok {
+			roots = append(roots, id)
+		}
+	}
+	// Map iteration order is random; keep the export deterministic
+	sort.Strings(roots)
+
+	var leaves []string
+	visited := make(map[string]bool)
+	var walk func(id string)
+	walk = func(id string) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		node := c.Mapping[id]
+		children := 0
+		for _, child := range node.Children {
+			if _, ok := c.Mapping[child]; ok {
+				children++
+				walk(child)
+			}
+		}
+		if children == 0 {
+			leaves = append(leaves, id)
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+	return leaves
+}
+
+// pathTo returns the node IDs from the root to the given node
+func (c *chatGPTConversation) pathTo(id string) []string {
+	var path []string
+	visited := make(map[string]bool)
+	for id != "" && !visited[id] {
+		node, ok := c.Mapping[id]
+		if !ok {
+			break
+		}
+		visited[id] = true
+		path = append(path, id)
+		if node.Parent == nil {
+			break
+		}
+		id = *node.Parent
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func unixSeconds(seconds float64) time.Time {
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*1e9)).UTC()
+}
+
+func without(values []string, excluded string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != excluded {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,220 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+const exportDocument = `{"object":"conversation.export","version":1,"id":"conv_old","title":"Trip","active_branch":"MAIN",
+"branches":[{"name":"MAIN","items":[{"id":"msg_q","type":"message","role":"user","content":[{"type":"input_text","input_text":"where to?"}]}]}],
+"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-02T00:00:00Z"}`
+
+// chatGPTDocument has a regenerated reply: the current reply becomes MAIN, the older one a branch
+const chatGPTDocument = `{"id":"gpt-1","title":"Pasta","create_time":1700000000,"update_time":1700000100,"current_node":"a2",
+"mapping":{
+ "root":{"id":"root","message":null,"parent":null,"children":["sys"]},
+ "sys":{"id":"sys","message":{"id":"sys","author":{"role":"system"},"content":{"content_type":"text","parts":[""]},"metadata":{"is_visually_hidden_from_conversation":true}},"parent":"root","children":["q"]},
+ "q":{"id":"q","message":{"id":"q","author":{"role":"user"},"create_time":1700000010,"content":{"content_type":"text","parts":["how long to boil pasta?"]},"metadata":{}},"parent":"sys","children":["a1","a2"]},
+ "a1":{"id":"a1","message":{"id":"a1","author":{"role":"assistant"},"create_time":1700000020,"content":{"content_type":"text","parts":["10 minutes"]},"metadata":{"model_slug":"gpt-4o"}},"parent":"q","children":[]},
+ "a2":{"id":"a2","message":{"id":"a2","author":{"role":"assistant"},"create_time":1700000030,"content":{"content_type":"text","parts":["8 to 12 minutes"]},"metadata":{"model_slug":"gpt-4o"}},"parent":"q","children":[]}
+}}`
+
+func TestParseConversationImportLayouts(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		count int
+	}{
+		{"document", exportDocument, 1},
+		{"array", "[" + exportDocument + "," + exportDocument + "]", 2},
+		{"list", `{"object":"list","data":[` + exportDocument + `]}`, 1},
+		{"json lines", exportDocument + "\n" + exportDocument + "\n", 2},
+		{"chatgpt array", "[" + chatGPTDocument + "]", 1},
+		{"mixed", exportDocument + "\n" + chatGPTDocument, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exports, err := ParseConversationImport(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(exports) != tt.count {
+				t.Fatalf("expected %d conversations, got %d", tt.count, len(exports))
+			}
+		})
+	}
+}
+
+func TestParseConversationImportRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		error string
+	}{
+		{"empty", "", "no conversations found"},
+		{"empty list", `{"object":"list","data":[]}`, "no conversations found"},
+		{"not json", "title: trip", "invalid JSON"},
+		{"scalar", `"trip"`, "must be a JSON object"},
+		{"unknown object", `{"object":"chat.completion"}`, "unrecognized conversation format"},
+		{"chatgpt without messages", `{"title":"empty","mapping":{}}`, "has no messages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConversationImport(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("expected an error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestParseChatGPTConversation(t *testing.T) {
+	exports, err := ParseConversationImport(strings.NewReader(chatGPTDocument))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	export := exports[0]
+	if export.Title == nil || *export.Title != "Pasta" || export.Metadata["import_source"] != "chatgpt" || export.Metadata["import_source_id"] != "gpt-1" {
+		t.Fatalf("unexpected conversation %+v", export)
+	}
+	if !export.CreatedAt.Equal(time.Unix(1700000000, 0)) || export.ActiveBranch != BranchMain {
+		t.Fatalf("unexpected creation time or active branch: %s %s", export.CreatedAt, export.ActiveBranch)
+	}
+	if len(export.Branches) != 2 {
+		t.Fatalf("expected MAIN and one alternative, got %d branches", len(export.Branches))
+	}
+
+	main, alternative := export.Branches[0], export.Branches[1]
+	if main.Name != BranchMain || len(main.Items) != 2 || main.Items[1].Text() != "8 to 12 minutes" {
+		t.Fatalf("expected the current thread as MAIN, got %+v", main)
+	}
+	if main.Items[0].Role == nil || *main.Items[0].Role != ItemRoleUser || main.Items[0].Text() != "how long to boil pasta?" {
+		t.Fatalf("expected the hidden system message skipped, got %+v", main.Items[0])
+	}
+	if main.Items[1].Model == nil || *main.Items[1].Model != "gpt-4o" {
+		t.Fatalf("expected the model of the reply, got %v", main.Items[1].Model)
+	}
+
+	if alternative.Name != importBranchPrefix+"1" || len(alternative.Items) != 2 || alternative.Items[1].Text() != "10 minutes" {
+		t.Fatalf("expected the older reply as a branch, got %+v", alternative)
+	}
+	if alternative.ParentBranch == nil || *alternative.ParentBranch != BranchMain {
+		t.Fatalf("expected the branch forked from MAIN, got %v", alternative.ParentBranch)
+	}
+	if alternative.ForkedFromItemID == nil || *alternative.ForkedFromItemID != main.Items[0].PublicID {
+		t.Fatalf("expected the fork after the shared prompt, got %v", alternative.ForkedFromItemID)
+	}
+}
+
+// memoryImports is a ConversationRepository recording the conversations stored by imports.
+// Methods imports do not use are left to the embedded nil interface.
+type memoryImports struct {
+	ConversationRepository
+	calls  [][]*ConversationWithBranches
+	failed error
+}
+
+func (m *memoryImports) CreateWithBranches(_ context.Context, conversations []*ConversationWithBranches) error {
+	m.calls = append(m.calls, conversations)
+	if m.failed != nil {
+		return m.failed
+	}
+	for i, created := range conversations {
+		created.Conversation.ID = uint(i + 1)
+	}
+	return nil
+}
+
+func ratedExport() *ConversationExport {
+	user, assistant, system := ItemRoleUser, ItemRoleAssistant, ItemRoleSystem
+	like := ItemRatingLike
+	comment := "great"
+	ratedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	forkedFrom := "msg_q"
+	parent := BranchMain
+	return &ConversationExport{
+		Object:       ConversationExportObject,
+		ActiveBranch: "EDIT_1",
+		Branches: []BranchExport{
+			{BranchMetadata: BranchMetadata{Name: BranchMain}, Items: []Item{
+				{PublicID: "msg_q", Type: ItemTypeMessage, Role: &user, Content: []Content{NewInputTextContent("question")}},
+				{PublicID: "msg_a", Type: ItemTypeMessage, Role: &assistant, Content: []Content{NewTextContent("answer")},
+					Rating: &like, RatedAt: &ratedAt, RatingComment: &comment},
+				{PublicID: "msg_sum", Type: ItemTypeMessage, Role: &system, Content: []Content{NewSummaryTextContent("asked and answered", "msg_a")}},
+			}},
+			{BranchMetadata: BranchMetadata{Name: "EDIT_1", ParentBranch: &parent, ForkedFromItemID: &forkedFrom}, Items: []Item{
+				{PublicID: "msg_edit", Type: ItemTypeMessage, Role: &user, Content: []Content{NewInputTextContent("other question")}},
+			}},
+		},
+	}
+}
+
+func TestImportConversations(t *testing.T) {
+	repo := &memoryImports{}
+	service := NewConversationService(repo)
+	exports := []*ConversationExport{ratedExport(), ratedExport()}
+
+	conversations, err := service.ImportConversations(context.Background(), 7, exports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.calls) != 1 || len(repo.calls[0]) != 2 || len(conversations) != 2 {
+		t.Fatalf("expected both conversations stored together, got %d calls", len(repo.calls))
+	}
+
+	imported := repo.calls[0][0]
+	if imported.Conversation.UserID != 7 || imported.Conversation.ActiveBranch != "EDIT_1" || imported.Conversation.PublicID == "" {
+		t.Fatalf("unexpected conversation %+v", imported.Conversation)
+	}
+	oldIDs := map[string]bool{"msg_q": true, "msg_a": true, "msg_sum": true, "msg_edit": true}
+	ids := make(map[string]*Item)
+	for _, item := range imported.Items {
+		if item.PublicID == "" || oldIDs[item.PublicID] {
+			t.Fatalf("expected a new item ID, got %s", item.PublicID)
+		}
+		if item.Rating != nil || item.RatedAt != nil || item.RatingComment != nil {
+			t.Fatalf("expected ratings to be dropped, got %+v", item)
+		}
+		ids[item.Text()] = item
+	}
+	summary := ids["asked and answered"]
+	if through := summary.Content[0].SummarizedThrough; through == nil || *through != ids["answer"].PublicID {
+		t.Fatalf("expected the summary to cover the new answer ID, got %v", through)
+	}
+	if len(imported.Branches) != 1 || *imported.Branches[0].ForkedFromItemID != ids["question"].PublicID {
+		t.Fatalf("expected the fork point remapped to the new question ID, got %+v", imported.Branches)
+	}
+	if *exports[0].Branches[0].Items[2].Content[0].SummarizedThrough != "msg_a" {
+		t.Fatal("expected the parsed export to stay unchanged")
+	}
+}
+
+func TestImportConversationsCreatesNoneOnInvalidConversation(t *testing.T) {
+	repo := &memoryImports{}
+	service := NewConversationService(repo)
+	invalid := ratedExport()
+	invalid.Branches[1].Name = "has space"
+
+	_, err := service.ImportConversations(context.Background(), 7, []*ConversationExport{ratedExport(), invalid})
+	if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeValidation) || !strings.Contains(err.Error(), "conversation 2") {
+		t.Fatalf("expected a validation error naming conversation 2, got %v", err)
+	}
+	if len(repo.calls) != 0 {
+		t.Fatal("expected nothing stored")
+	}
+}
+
+func TestImportConversationsReportsStoreFailure(t *testing.T) {
+	repo := &memoryImports{failed: errors.New("connection reset")}
+	service := NewConversationService(repo)
+
+	conversations, err := service.ImportConversations(context.Background(), 7, []*ConversationExport{ratedExport()})
+	if err == nil || conversations != nil {
+		t.Fatalf("expected the import to fail without conversations, got %v, %v", conversations, err)
+	}
+}
@@ -3,6 +3,7 @@ package conversation
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"jan-server/services/llm-api/internal/domain/query"
@@ -256,6 +257,8 @@ type Item struct {
 	IncompleteDetails *IncompleteDetails `json:"incomplete_details,omitempty"`
 	CompletedAt       *time.Time         `json:"completed_at,omitempty"`
 	ResponseID        *uint              `json:"-"`
+	Model             *string            `json:"model,omitempty"` // Model that generated the item
+	ProviderID        *string            `json:"-"`               // Public ID of the provider that served the model
 
 	// User feedback/rating
 	Rating        *ItemRating `json:"rating,omitempty"`         // Like/unlike rating
@@ -265,6 +268,107 @@ type Item struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// IsSummary reports whether the item is a rolling conversation summary
+func (i Item) IsSummary() bool {
+	return i.summaryContent() != nil
+}
+
+func (i Item) summaryContent() *Content {
+	if i.Type != ItemTypeMessage || i.Role == nil || *i.Role != ItemRoleSystem {
+		return nil
+	}
+	for idx := range i.Content {
+		if i.Content[idx].SummaryText != nil && i.Content[idx].SummarizedThrough != nil {
+			return &i.Content[idx]
+		}
+	}
+	return nil
+}
+
+// Text returns the plain text of the item, joining all text-bearing content parts
+func (i Item) Text() string {
+	parts := make([]string, 0, len(i.Content))
+	for _, content := range i.Content {
+		switch {
+		case content.Text != nil && content.Text.Text != "":
+			parts = append(parts, content.Text.Text)
+		case content.InputText != nil && *content.InputText != "":
+			parts = append(parts, *content.InputText)
+		case content.OutputText != nil && content.OutputText.Text != "":
+			parts = append(parts, content.OutputText.Text)
+		case content.SummaryText != nil && *content.SummaryText != "":
+			parts = append(parts, *content.SummaryText)
+		case content.Refusal != nil && *content.Refusal != "":
+			parts = append(parts, *content.Refusal)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// FunctionCall returns the call of a function_call item, or nil for other items
+func (i Item) FunctionCall() *FunctionCall {
+	if i.Type != ItemTypeFunctionCall {
+		return nil
+	}
+	for idx := range i.Content {
+		if i.Content[idx].FunctionCall != nil {
+			return i.Content[idx].FunctionCall
+		}
+	}
+	return nil
+}
+
+// FunctionCallOutput returns the output of a function_call_output item, or nil for other items
+func (i Item) FunctionCallOutput() *FunctionCallOut {
+	if i.Type != ItemTypeFunctionCallOut {
+		return nil
+	}
+	for idx := range i.Content {
+		if i.Content[idx].FunctionCallOut != nil {
+			return i.Content[idx].FunctionCallOut
+		}
+	}
+	return nil
+}
+
+// SplitAtLatestSummary returns the most recent summary item and the items it does not cover, in
+// order and without summary items. Without a summary, all non-summary items are returned.
+func SplitAtLatestSummary(items []Item) (*Item, []Item) {
+	var summary *Item
+	start := 0
+	for idx := len(items) - 1; idx >= 0; idx-- {
+		content := items[idx].summaryContent()
+		if content == nil {
+			continue
+		}
+		summary = &items[idx]
+		start = idx
+		for j := idx - 1; j >= 0; j-- {
+			if items[j].PublicID == *content.SummarizedThrough {
+				start = j + 1
+				break
+			}
+		}
+		break
+	}
+
+	recent := make([]Item, 0, len(items)-start)
+	for _, item := range items[start:] {
+		if !item.IsSummary() {
+			recent = append(recent, item)
+		}
+	}
+	return summary, recent
+}
+
+// SummaryText returns the text of a summary item, or "" if the item is not a summary
+func (i Item) SummaryText() string {
+	if content := i.summaryContent(); content != nil {
+		return *content.SummaryText
+	}
+	return ""
+}
+
 // ===============================================
 // Rating Support
 // ===============================================
@@ -314,6 +418,7 @@ type Content struct {
 	ReasoningContent   *string            `json:"reasoning_content,omitempty"`    // AI reasoning content
 	Refusal            *string            `json:"refusal,omitempty"`              // Model refusal message
 	SummaryText        *string            `json:"summary_text,omitempty"`         // Summary content
+	SummarizedThrough  *string            `json:"summarized_through,omitempty"`   // Last item covered by a conversation summary
 	Thinking           *string            `json:"thinking,omitempty"`             // Internal reasoning (o1 models)
 	Image              *ImageContent      `json:"image,omitempty"`                // Image content
 	File               *FileContent       `json:"file,omitempty"`                 // File content
@@ -484,8 +589,14 @@ type ItemFilter struct {
 	ID             *uint
 	PublicID       *string
 	ConversationID *uint
+	Branch         *string
 	Role           *ItemRole
 	ResponseID     *uint
+	Model          *string
+	Rating         *ItemRating
+	Rated          *bool      // true: rated items only, false: unrated items only
+	RatedAfter     *time.Time // Inclusive lower bound on rated_at
+	RatedBefore    *time.Time // Exclusive upper bound on rated_at
 }
 
 type ItemRepository interface {
@@ -707,6 +818,15 @@ func NewTextContent(text string) Content {
 	}
 }
 
+// NewSummaryTextContent creates conversation summary content covering items up to summarizedThrough
+func NewSummaryTextContent(summary string, summarizedThrough string) Content {
+	return Content{
+		Type:              "summary_text",
+		SummaryText:       &summary,
+		SummarizedThrough: &summarizedThrough,
+	}
+}
+
 // NewInputTextContent creates a new input text content (for user messages)
 func NewInputTextContent(text string) Content {
 	return Content{
@@ -726,6 +846,29 @@ func NewOutputTextContent(text string, annotations []Annotation) Content {
 	}
 }
 
+// NewFunctionCallContent creates the content of a function_call item
+func NewFunctionCallContent(callID, name, arguments string) Content {
+	return Content{
+		Type: "function_call",
+		FunctionCall: &FunctionCall{
+			ID:        callID,
+			Name:      name,
+			Arguments: arguments,
+		},
+	}
+}
+
+// NewFunctionCallOutputContent creates the content of a function_call_output item
+func NewFunctionCallOutputContent(callID, output string) Content {
+	return Content{
+		Type: "function_call_output",
+		FunctionCallOut: &FunctionCallOut{
+			CallID: callID,
+			Output: output,
+		},
+	}
+}
+
 // NewImageContent creates a new image content
 func NewImageContent(url, fileID, detail string) Content {
 	return Content{
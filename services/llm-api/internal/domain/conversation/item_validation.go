@@ -298,6 +298,18 @@ func (v *ItemValidator) ValidateContent(content Content) error {
 		}
 		return fmt.Errorf("computer_action content type requires computer_action field")
 
+	case "function_call":
+		if content.FunctionCall != nil {
+			return v.validateFunctionCall(content.FunctionCall)
+		}
+		return fmt.Errorf("function_call content type requires function_call field")
+
+	case "function_call_output":
+		if content.FunctionCallOut != nil {
+			return v.validateFunctionCallOutput(content.FunctionCallOut)
+		}
+		return fmt.Errorf("function_call_output content type requires function_call_output field")
+
 	default:
 		return fmt.Errorf("unsupported content type: %s", content.Type)
 	}
@@ -384,6 +396,32 @@ func (v *ItemValidator) validateSimpleText(text, fieldName string) error {
 	return nil
 }
 
+func (v *ItemValidator) validateFunctionCall(call *FunctionCall) error {
+	if strings.TrimSpace(call.ID) == "" {
+		return fmt.Errorf("function_call id cannot be empty")
+	}
+	if strings.TrimSpace(call.Name) == "" {
+		return fmt.Errorf("function_call name cannot be empty")
+	}
+	if length := utf8.RuneCountInString(call.Arguments); length > v.config.MaxTextContentLength {
+		return fmt.Errorf("function_call arguments cannot exceed %d characters (got %d)", v.config.MaxTextContentLength, length)
+	}
+	return nil
+}
+
+func (v *ItemValidator) validateFunctionCallOutput(output *FunctionCallOut) error {
+	if strings.TrimSpace(output.CallID) == "" {
+		return fmt.Errorf("function_call_output call_id cannot be empty")
+	}
+	if length := utf8.RuneCountInString(output.Output); length > v.config.MaxTextContentLength {
+		return fmt.Errorf("function_call_output output cannot exceed %d characters (got %d)", v.config.MaxTextContentLength, length)
+	}
+	if strings.Contains(output.Output, "\x00") {
+		return fmt.Errorf("function_call_output output cannot contain null bytes")
+	}
+	return nil
+}
+
 func (v *ItemValidator) validateThinkingContent(thinking string) error {
 	if thinking == "" {
 		return fmt.Errorf("thinking content cannot be empty")
@@ -0,0 +1,54 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"jan-server/services/llm-api/internal/domain/query"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// maxSearchQueryLength bounds the search text accepted from clients
+const maxSearchQueryLength = 256
+
+// ConversationSearchFilter selects the conversations and items searched for a user
+type ConversationSearchFilter struct {
+	UserID          uint
+	Query           string     // Web search syntax: quoted phrases, OR, -excluded words
+	ProjectPublicID *string    // Only conversations of this project
+	From            *time.Time // Inclusive lower bound on when the title or item was last written
+	To              *time.Time // Exclusive upper bound
+}
+
+// SearchHit is a conversation title or message matching a search
+type SearchHit struct {
+	ConversationID    string    // Public ID of the conversation
+	ConversationTitle *string   // Title of the conversation
+	ProjectID         *string   // Public ID of the conversation's project
+	ItemID            *string   // Public ID of the matching item; nil when the title matched
+	Branch            *string   // Branch of the matching item
+	Role              *ItemRole // Role of the matching item
+	Snippet           string    // Matching text with search terms wrapped in <mark></mark>
+	Rank              float64   // Relevance; higher is better
+	MatchedAt         time.Time // Creation time of the item, or last update of the conversation
+}
+
+// SearchConversations ranks the titles and messages of a user's conversations against a query
+func (s *ConversationService) SearchConversations(ctx context.Context, filter ConversationSearchFilter, pagination *query.Pagination) ([]*SearchHit, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Query == "" {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "search query cannot be empty", nil, "")
+	}
+	if length := utf8.RuneCountInString(filter.Query); length > maxSearchQueryLength {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, fmt.Sprintf("search query cannot exceed %d characters (got %d)", maxSearchQueryLength, length), nil, "")
+	}
+
+	hits, err := s.repo.Search(ctx, filter, pagination)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to search conversations")
+	}
+	return hits, nil
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"time"
 
 	"jan-server/services/llm-api/internal/domain/query"
@@ -54,6 +55,13 @@ const (
 	MetadataKeyDescription      = "description"            // Human-readable description
 	MetadataKeyEnvironment      = "environment"            // e.g., "production", "staging", "local"
 	MetadataKeyAutoEnableModels = "auto_enable_new_models" // "true" to auto-enable new models
+	MetadataKeyAPIFormat        = "api_format"             // "native" or "openai"; overrides the kind's default wire format
+)
+
+// API wire formats a provider can be reached with
+const (
+	APIFormatOpenAI = "openai" // OpenAI-compatible /chat/completions
+	APIFormatNative = "native" // the vendor's own API (e.g. Anthropic Messages)
 )
 
 // ImageInputCapability describes how a provider supports image input
@@ -151,6 +159,29 @@ func (p *Provider) GetDescription() string {
 	return p.Metadata[MetadataKeyDescription]
 }
 
+// APIFormat returns the wire format used to call the provider. Vendors with a native
+// adapter default to it; everything else is treated as OpenAI-compatible. Google providers
+// configured with the OpenAI compatibility endpoint (".../openai") keep using it.
+func (p *Provider) APIFormat() string {
+	if p.Metadata != nil {
+		switch format := strings.ToLower(strings.TrimSpace(p.Metadata[MetadataKeyAPIFormat])); format {
+		case APIFormatOpenAI, APIFormatNative:
+			return format
+		}
+	}
+	switch p.Kind {
+	case ProviderAnthropic:
+		return APIFormatNative
+	case ProviderGoogle:
+		if strings.HasSuffix(strings.TrimRight(p.BaseURL, "/"), "/openai") {
+			return APIFormatOpenAI
+		}
+		return APIFormatNative
+	default:
+		return APIFormatOpenAI
+	}
+}
+
 // GetEnvironment returns the environment from metadata (e.g., "production", "staging")
 func (p *Provider) GetEnvironment() string {
 	if p.Metadata == nil {
@@ -0,0 +1,292 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the circuit breaker state of a provider.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // healthy, receives traffic
+	BreakerOpen     BreakerState = "open"      // unhealthy, removed from selection until the cooldown elapses
+	BreakerHalfOpen BreakerState = "half_open" // cooldown elapsed, a single probe call decides whether to close or reopen
+)
+
+// UpstreamErrorClassifier decides how a failed upstream call counts against a provider.
+// It is implemented by the inference infrastructure, which knows the upstream error types.
+type UpstreamErrorClassifier interface {
+	// IsProviderFailure reports whether the error is the provider's fault (e.g. 5xx, 429,
+	// connection errors) rather than a client cancellation or an invalid request.
+	IsProviderFailure(err error) bool
+	IsTimeout(err error) bool
+}
+
+// ProviderHealthConfig tunes the per-provider circuit breaker.
+type ProviderHealthConfig struct {
+	Enabled          bool
+	Window           time.Duration // rolling window used to compute the error rate
+	MinFailures      int           // minimum failures in the window before the breaker may open
+	FailureRate      float64       // failure ratio (0-1] within the window that opens the breaker
+	Cooldown         time.Duration // how long an open breaker keeps the provider out of selection
+	MaxWindowSamples int
+}
+
+// ProviderHealth is a point-in-time view of a provider's breaker and recent outcomes.
+type ProviderHealth struct {
+	ProviderID          uint
+	State               BreakerState
+	WindowCalls         int
+	WindowErrors        int
+	WindowTimeouts      int
+	ConsecutiveFailures int
+	TotalCalls          int64
+	TotalErrors         int64
+	LastError           string
+	LastErrorAt         *time.Time
+	LastSuccessAt       *time.Time
+	OpenedAt            *time.Time
+	RetryAt             *time.Time
+}
+
+type callSample struct {
+	at      time.Time
+	failed  bool
+	timeout bool
+}
+
+type providerBreaker struct {
+	state               BreakerState
+	samples             []callSample
+	consecutiveFailures int
+	totalCalls          int64
+	totalErrors         int64
+	lastError           string
+	lastErrorAt         *time.Time
+	lastSuccessAt       *time.Time
+	openedAt            *time.Time
+	probeStartedAt      *time.Time // set while the half-open probe call is in flight
+}
+
+// ProviderHealthTracker keeps a passive circuit breaker per provider, fed by upstream call outcomes.
+// State is held in memory and is local to each llm-api instance.
+type ProviderHealthTracker struct {
+	mu         sync.Mutex
+	config     ProviderHealthConfig
+	classifier UpstreamErrorClassifier
+	breakers   map[uint]*providerBreaker
+	now        func() time.Time
+}
+
+func NewProviderHealthTracker(config ProviderHealthConfig, classifier UpstreamErrorClassifier) *ProviderHealthTracker {
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.MinFailures <= 0 {
+		config.MinFailures = 5
+	}
+	if config.FailureRate <= 0 || config.FailureRate > 1 {
+		config.FailureRate = 0.5
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	if config.MaxWindowSamples <= 0 {
+		config.MaxWindowSamples = 200
+	}
+	return &ProviderHealthTracker{
+		config:     config,
+		classifier: classifier,
+		breakers:   make(map[uint]*providerBreaker),
+		now:        time.Now,
+	}
+}
+
+// RecordCall feeds the outcome of an upstream call into the provider's breaker.
+// Client cancellations and non-retryable errors (e.g. 4xx validation failures) are not
+// held against the provider, but they do end a half-open probe.
+func (t *ProviderHealthTracker) RecordCall(providerID uint, duration time.Duration, err error) {
+	if t == nil || !t.config.Enabled {
+		return
+	}
+	failed := err != nil && t.classifier.IsProviderFailure(err)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	breaker := t.breakerLocked(providerID)
+	breaker.probeStartedAt = nil
+	if err != nil && !failed {
+		return
+	}
+
+	breaker.totalCalls++
+	breaker.samples = append(breaker.samples, callSample{at: now, failed: failed, timeout: failed && t.classifier.IsTimeout(err)})
+	t.pruneLocked(breaker, now)
+
+	if !failed {
+		breaker.consecutiveFailures = 0
+		breaker.lastSuccessAt = &now
+		if breaker.state == BreakerHalfOpen {
+			breaker.state = BreakerClosed
+			breaker.openedAt = nil
+			breaker.samples = nil
+		}
+		return
+	}
+
+	breaker.consecutiveFailures++
+	breaker.totalErrors++
+	breaker.lastError = err.Error()
+	breaker.lastErrorAt = &now
+
+	if breaker.state == BreakerHalfOpen {
+		t.openLocked(breaker, now)
+		return
+	}
+
+	calls, failures, _ := countSamples(breaker.samples)
+	if failures >= t.config.MinFailures && float64(failures)/float64(calls) >= t.config.FailureRate {
+		t.openLocked(breaker, now)
+	}
+}
+
+// Available reports whether the provider may receive traffic. An open breaker whose
+// cooldown has elapsed moves to half-open and admits a single probe call; other callers
+// are turned away until the probe's outcome is recorded. A probe that never reports back
+// (e.g. the request went to another provider) expires after the cooldown.
+func (t *ProviderHealthTracker) Available(providerID uint) bool {
+	if t == nil || !t.config.Enabled {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	breaker, ok := t.breakers[providerID]
+	if !ok {
+		return true
+	}
+	now := t.now()
+	t.refreshStateLocked(breaker, now)
+	switch breaker.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if breaker.probeStartedAt != nil && now.Before(breaker.probeStartedAt.Add(t.config.Cooldown)) {
+			return false
+		}
+		breaker.probeStartedAt = &now
+	}
+	return true
+}
+
+// FilterAvailable drops candidates whose provider breaker is open. If every provider is
+// unhealthy, all candidates are kept rather than failing the request outright.
+func (t *ProviderHealthTracker) FilterAvailable(candidates []ProviderSelection) []ProviderSelection {
+	healthy := make([]ProviderSelection, 0, len(candidates))
+	for _, candidate := range candidates {
+		if t.Available(candidate.Provider.ID) {
+			healthy = append(healthy, candidate)
+		}
+	}
+	if len(healthy) == 0 {
+		return candidates
+	}
+	return healthy
+}
+
+// Health returns the current breaker state and recent error counts for the provider.
+func (t *ProviderHealthTracker) Health(providerID uint) ProviderHealth {
+	health := ProviderHealth{ProviderID: providerID, State: BreakerClosed}
+	if t == nil {
+		return health
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	breaker, ok := t.breakers[providerID]
+	if !ok {
+		return health
+	}
+	now := t.now()
+	t.refreshStateLocked(breaker, now)
+	t.pruneLocked(breaker, now)
+
+	health.State = breaker.state
+	health.WindowCalls, health.WindowErrors, health.WindowTimeouts = countSamples(breaker.samples)
+	health.ConsecutiveFailures = breaker.consecutiveFailures
+	health.TotalCalls = breaker.totalCalls
+	health.TotalErrors = breaker.totalErrors
+	health.LastError = breaker.lastError
+	health.LastErrorAt = breaker.lastErrorAt
+	health.LastSuccessAt = breaker.lastSuccessAt
+	health.OpenedAt = breaker.openedAt
+	if breaker.state == BreakerOpen && breaker.openedAt != nil {
+		retryAt := breaker.openedAt.Add(t.config.Cooldown)
+		health.RetryAt = &retryAt
+	}
+	return health
+}
+
+// Reset closes the provider's breaker and clears its recent outcomes.
+func (t *ProviderHealthTracker) Reset(providerID uint) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.breakers, providerID)
+}
+
+func (t *ProviderHealthTracker) breakerLocked(providerID uint) *providerBreaker {
+	breaker, ok := t.breakers[providerID]
+	if !ok {
+		breaker = &providerBreaker{state: BreakerClosed}
+		t.breakers[providerID] = breaker
+	}
+	t.refreshStateLocked(breaker, t.now())
+	return breaker
+}
+
+func (t *ProviderHealthTracker) refreshStateLocked(breaker *providerBreaker, now time.Time) {
+	if breaker.state == BreakerOpen && breaker.openedAt != nil && !now.Before(breaker.openedAt.Add(t.config.Cooldown)) {
+		breaker.state = BreakerHalfOpen
+		breaker.probeStartedAt = nil
+	}
+}
+
+func (t *ProviderHealthTracker) openLocked(breaker *providerBreaker, now time.Time) {
+	breaker.state = BreakerOpen
+	breaker.openedAt = &now
+}
+
+func (t *ProviderHealthTracker) pruneLocked(breaker *providerBreaker, now time.Time) {
+	cutoff := now.Add(-t.config.Window)
+	start := 0
+	for start < len(breaker.samples) && breaker.samples[start].at.Before(cutoff) {
+		start++
+	}
+	if overflow := len(breaker.samples) - start - t.config.MaxWindowSamples; overflow > 0 {
+		start += overflow
+	}
+	if start > 0 {
+		breaker.samples = append([]callSample(nil), breaker.samples[start:]...)
+	}
+}
+
+func countSamples(samples []callSample) (calls int, failures int, timeouts int) {
+	for _, sample := range samples {
+		calls++
+		if sample.failed {
+			failures++
+		}
+		if sample.timeout {
+			timeouts++
+		}
+	}
+	return calls, failures, timeouts
+}
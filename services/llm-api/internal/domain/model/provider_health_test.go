@@ -0,0 +1,126 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type statusError int
+
+func (e statusError) Error() string { return fmt.Sprintf("upstream status %d", int(e)) }
+
+func upstreamError(status int) error { return statusError(status) }
+
+// fakeClassifier counts 5xx and 429 responses and deadlines as provider failures.
+type fakeClassifier struct{}
+
+func (fakeClassifier) IsProviderFailure(err error) bool {
+	var status statusError
+	if errors.As(err, &status) {
+		return status >= 500 || status == 429
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func (fakeClassifier) IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func TestProviderHealthTrackerOpensAndRecovers(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tracker := NewProviderHealthTracker(ProviderHealthConfig{Enabled: true, MinFailures: 3, FailureRate: 0.5, Window: time.Minute, Cooldown: 30 * time.Second}, fakeClassifier{})
+	tracker.now = func() time.Time { return now }
+
+	tracker.RecordCall(1, time.Second, nil)
+	tracker.RecordCall(1, time.Second, upstreamError(400))
+	tracker.RecordCall(1, time.Second, upstreamError(502))
+	tracker.RecordCall(1, time.Second, upstreamError(429))
+	if !tracker.Available(1) {
+		t.Fatal("expected provider to stay available below the failure threshold")
+	}
+
+	tracker.RecordCall(1, time.Second, context.DeadlineExceeded)
+	if tracker.Available(1) {
+		t.Fatal("expected breaker to open after repeated upstream failures")
+	}
+	health := tracker.Health(1)
+	if health.State != BreakerOpen || health.WindowErrors != 3 || health.WindowTimeouts != 1 || health.RetryAt == nil {
+		t.Fatalf("unexpected health snapshot: %+v", health)
+	}
+
+	now = now.Add(31 * time.Second)
+	if !tracker.Available(1) || tracker.Health(1).State != BreakerHalfOpen {
+		t.Fatal("expected breaker to be half-open after cooldown")
+	}
+	tracker.RecordCall(1, time.Second, upstreamError(503))
+	if tracker.Available(1) {
+		t.Fatal("expected failed probe to reopen the breaker")
+	}
+
+	now = now.Add(31 * time.Second)
+	tracker.RecordCall(1, time.Second, nil)
+	if health := tracker.Health(1); health.State != BreakerClosed || health.ConsecutiveFailures != 0 {
+		t.Fatalf("expected successful probe to close the breaker, got %+v", health)
+	}
+}
+
+func TestProviderHealthTrackerIgnoresClientCancellation(t *testing.T) {
+	tracker := NewProviderHealthTracker(ProviderHealthConfig{Enabled: true, MinFailures: 1}, fakeClassifier{})
+	tracker.RecordCall(1, time.Second, context.Canceled)
+	if health := tracker.Health(1); health.TotalCalls != 0 || health.State != BreakerClosed {
+		t.Fatalf("expected cancellation to be ignored, got %+v", health)
+	}
+}
+
+func TestProviderHealthTrackerAdmitsSingleHalfOpenProbe(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tracker := NewProviderHealthTracker(ProviderHealthConfig{Enabled: true, MinFailures: 1, FailureRate: 0.5, Cooldown: 30 * time.Second}, fakeClassifier{})
+	tracker.now = func() time.Time { return now }
+
+	tracker.RecordCall(1, time.Second, upstreamError(502))
+	now = now.Add(31 * time.Second)
+
+	if !tracker.Available(1) {
+		t.Fatal("expected the first caller after the cooldown to probe the provider")
+	}
+	if tracker.Available(1) {
+		t.Fatal("expected concurrent callers to wait for the probe")
+	}
+
+	// A request error ends the probe without deciding the breaker state
+	tracker.RecordCall(1, time.Second, upstreamError(400))
+	if !tracker.Available(1) {
+		t.Fatal("expected a new probe once the previous one reported back")
+	}
+
+	// A probe that never reports back expires after the cooldown
+	now = now.Add(31 * time.Second)
+	if !tracker.Available(1) {
+		t.Fatal("expected an abandoned probe to expire")
+	}
+	tracker.RecordCall(1, time.Second, nil)
+	if !tracker.Available(1) || !tracker.Available(1) || tracker.Health(1).State != BreakerClosed {
+		t.Fatal("expected a successful probe to close the breaker")
+	}
+}
+
+func TestProviderHealthTrackerFilterAvailable(t *testing.T) {
+	tracker := NewProviderHealthTracker(ProviderHealthConfig{Enabled: true, MinFailures: 1, FailureRate: 0.5}, fakeClassifier{})
+	candidates := []ProviderSelection{
+		routingCandidate(1, ProviderJan, 0, nil),
+		routingCandidate(2, ProviderJan, 0, nil),
+	}
+
+	tracker.RecordCall(1, time.Second, upstreamError(503))
+	if got := rankedIDs(tracker.FilterAvailable(candidates)); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("expected only provider 2, got %v", got)
+	}
+
+	tracker.RecordCall(2, time.Second, upstreamError(503))
+	if got := tracker.FilterAvailable(candidates); len(got) != 2 {
+		t.Fatalf("expected all candidates when every provider is unhealthy, got %d", len(got))
+	}
+}
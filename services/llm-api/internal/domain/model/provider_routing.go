@@ -0,0 +1,436 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RoutingStrategyName identifies how requests are spread across providers serving the same model.
+type RoutingStrategyName string
+
+const (
+	RoutingLowestPrice        RoutingStrategyName = "lowest_price"         // cheapest price line, then Jan providers
+	RoutingWeightedRoundRobin RoutingStrategyName = "weighted_round_robin" // smooth weighted round-robin using routing_weight metadata
+	RoutingLatency            RoutingStrategyName = "latency"              // lowest EWMA of recent upstream durations
+	RoutingLeastInFlight      RoutingStrategyName = "least_in_flight"      // fewest requests currently in progress
+	RoutingSticky             RoutingStrategyName = "sticky"               // pin each conversation to one provider
+)
+
+// Metadata keys for provider routing
+const (
+	MetadataKeyRoutingStrategy = "routing_strategy" // RoutingStrategyName applied to models served by this provider
+	MetadataKeyRoutingWeight   = "routing_weight"   // positive integer weight for weighted_round_robin (default 1)
+)
+
+const (
+	defaultLatencyEWMAAlpha = 0.3
+	// latencyFailurePenalty is the latency sample recorded for a failed upstream call, so a
+	// provider that keeps failing drifts to the end of the latency ranking.
+	latencyFailurePenalty = 30 * time.Second
+)
+
+// ProviderSelection pairs a provider model with the provider serving it.
+type ProviderSelection struct {
+	ProviderModel *ProviderModel
+	Provider      *Provider
+}
+
+// NewProviderSelections pairs each provider model with its provider. Entries whose provider
+// is missing (deleted or inaccessible) are skipped.
+func NewProviderSelections(providerModels []*ProviderModel, providers map[uint]*Provider) []ProviderSelection {
+	selections := make([]ProviderSelection, 0, len(providerModels))
+	for _, providerModel := range providerModels {
+		if providerModel == nil {
+			continue
+		}
+		provider, ok := providers[providerModel.ProviderID]
+		if !ok || provider == nil {
+			continue
+		}
+		selections = append(selections, ProviderSelection{
+			ProviderModel: providerModel,
+			Provider:      provider,
+		})
+	}
+	return selections
+}
+
+// RoutingHint carries request context that strategies may use.
+type RoutingHint struct {
+	ModelPublicID  string
+	ConversationID string
+}
+
+// RoutingStrategy orders candidate providers for a request, best first.
+// Entries after the first are used as failover targets.
+type RoutingStrategy interface {
+	Name() RoutingStrategyName
+	Rank(candidates []ProviderSelection, hint RoutingHint) []ProviderSelection
+}
+
+// RoutingConfig configures the default and per-model routing strategies.
+type RoutingConfig struct {
+	DefaultStrategy  RoutingStrategyName
+	ModelStrategies  map[string]RoutingStrategyName // keyed by model public ID
+	LatencyEWMAAlpha float64
+}
+
+// ProviderRouter selects providers using the configured strategies and tracks the
+// in-memory load and latency statistics the strategies depend on.
+type ProviderRouter struct {
+	config     RoutingConfig
+	stats      *providerStats
+	strategies map[RoutingStrategyName]RoutingStrategy
+}
+
+func NewProviderRouter(config RoutingConfig) *ProviderRouter {
+	if config.LatencyEWMAAlpha <= 0 || config.LatencyEWMAAlpha > 1 {
+		config.LatencyEWMAAlpha = defaultLatencyEWMAAlpha
+	}
+	if _, ok := ParseRoutingStrategy(string(config.DefaultStrategy)); !ok {
+		config.DefaultStrategy = RoutingLowestPrice
+	}
+
+	stats := &providerStats{
+		alpha:    config.LatencyEWMAAlpha,
+		latency:  make(map[uint]float64),
+		inFlight: make(map[uint]int),
+	}
+	router := &ProviderRouter{
+		config: config,
+		stats:  stats,
+		strategies: map[RoutingStrategyName]RoutingStrategy{
+			RoutingLowestPrice:        lowestPriceStrategy{},
+			RoutingWeightedRoundRobin: &weightedRoundRobinStrategy{current: make(map[string]map[uint]int)},
+			RoutingLatency:            latencyStrategy{stats: stats},
+			RoutingLeastInFlight:      leastInFlightStrategy{stats: stats},
+			RoutingSticky:             stickyStrategy{},
+		},
+	}
+	return router
+}
+
+// ParseRoutingStrategy converts a configured value into a known strategy name.
+func ParseRoutingStrategy(value string) (RoutingStrategyName, bool) {
+	name := RoutingStrategyName(strings.ToLower(strings.TrimSpace(value)))
+	switch name {
+	case RoutingLowestPrice, RoutingWeightedRoundRobin, RoutingLatency, RoutingLeastInFlight, RoutingSticky:
+		return name, true
+	}
+	return "", false
+}
+
+// RegisterStrategy adds or replaces a routing strategy.
+func (r *ProviderRouter) RegisterStrategy(strategy RoutingStrategy) {
+	if strategy == nil {
+		return
+	}
+	r.strategies[strategy.Name()] = strategy
+}
+
+// Rank orders the candidates using the strategy resolved for the model.
+func (r *ProviderRouter) Rank(candidates []ProviderSelection, hint RoutingHint) []ProviderSelection {
+	if len(candidates) <= 1 {
+		return candidates
+	}
+	// Every strategy starts from the price ranking so failover order stays deterministic
+	ranked := lowestPriceStrategy{}.Rank(candidates, hint)
+	strategy := r.ResolveStrategy(ranked, hint.ModelPublicID)
+	if strategy.Name() == RoutingLowestPrice {
+		return ranked
+	}
+	return strategy.Rank(ranked, hint)
+}
+
+// ResolveStrategy picks the strategy for a model: per-model configuration first, then
+// provider metadata (in candidate order), then the configured default.
+func (r *ProviderRouter) ResolveStrategy(candidates []ProviderSelection, modelPublicID string) RoutingStrategy {
+	if name, ok := r.config.ModelStrategies[modelPublicID]; ok {
+		if strategy, ok := r.strategies[name]; ok {
+			return strategy
+		}
+	}
+	for _, candidate := range candidates {
+		if candidate.Provider == nil || candidate.Provider.Metadata == nil {
+			continue
+		}
+		if name, ok := ParseRoutingStrategy(candidate.Provider.Metadata[MetadataKeyRoutingStrategy]); ok {
+			if strategy, ok := r.strategies[name]; ok {
+				return strategy
+			}
+		}
+	}
+	if strategy, ok := r.strategies[r.config.DefaultStrategy]; ok {
+		return strategy
+	}
+	return lowestPriceStrategy{}
+}
+
+// Begin marks a request to the provider as in flight. The returned function must be called
+// once the upstream call finishes; its duration feeds the latency average, and failures count
+// as at least latencyFailurePenalty. Client cancellations are not held against the provider.
+func (r *ProviderRouter) Begin(providerID uint) func(duration time.Duration, err error) {
+	r.stats.begin(providerID)
+	var once sync.Once
+	return func(duration time.Duration, err error) {
+		once.Do(func() {
+			r.stats.end(providerID, duration, err)
+		})
+	}
+}
+
+type providerStats struct {
+	mu       sync.Mutex
+	alpha    float64
+	latency  map[uint]float64 // EWMA in milliseconds
+	inFlight map[uint]int
+}
+
+func (s *providerStats) begin(providerID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight[providerID]++
+}
+
+func (s *providerStats) end(providerID uint, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inFlight[providerID] > 0 {
+		s.inFlight[providerID]--
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		if duration < latencyFailurePenalty {
+			duration = latencyFailurePenalty
+		}
+	}
+	if duration <= 0 {
+		return
+	}
+	sample := float64(duration.Milliseconds())
+	if previous, ok := s.latency[providerID]; ok {
+		s.latency[providerID] = s.alpha*sample + (1-s.alpha)*previous
+		return
+	}
+	s.latency[providerID] = sample
+}
+
+func (s *providerStats) snapshotLatency() map[uint]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[uint]float64, len(s.latency))
+	for id, value := range s.latency {
+		result[id] = value
+	}
+	return result
+}
+
+func (s *providerStats) snapshotInFlight() map[uint]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[uint]int, len(s.inFlight))
+	for id, value := range s.inFlight {
+		result[id] = value
+	}
+	return result
+}
+
+// lowestPriceStrategy prefers:
+// 1. LOWEST PRICING (if pricing data exists)
+// 2. MENLO PROVIDER (if prices are equal or no pricing)
+// 3. ORIGINAL ORDER (if all criteria equal)
+type lowestPriceStrategy struct{}
+
+func (lowestPriceStrategy) Name() RoutingStrategyName { return RoutingLowestPrice }
+
+func (lowestPriceStrategy) Rank(candidates []ProviderSelection, _ RoutingHint) []ProviderSelection {
+	type priceCandidate struct {
+		selection   ProviderSelection
+		hasPricing  bool
+		lowestPrice MicroUSD
+		isJan       bool
+	}
+
+	priced := make([]priceCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.ProviderModel == nil {
+			continue
+		}
+		lowestPrice, hasPricing := calculateLowestPrice(candidate.ProviderModel.Pricing)
+		priced = append(priced, priceCandidate{
+			selection:   candidate,
+			hasPricing:  hasPricing,
+			lowestPrice: lowestPrice,
+			isJan:       candidate.ProviderModel.Kind == ProviderJan,
+		})
+	}
+
+	sort.SliceStable(priced, func(i, j int) bool {
+		left, right := priced[i], priced[j]
+
+		// Prefer candidate with pricing over one without, then the lower price
+		if left.hasPricing != right.hasPricing {
+			return left.hasPricing
+		}
+		if left.hasPricing && left.lowestPrice != right.lowestPrice {
+			return left.lowestPrice < right.lowestPrice
+		}
+
+		// Prefer Jan provider
+		return left.isJan && !right.isJan
+	})
+
+	ranked := make([]ProviderSelection, 0, len(priced))
+	for _, candidate := range priced {
+		ranked = append(ranked, candidate.selection)
+	}
+	return ranked
+}
+
+// weightedRoundRobinStrategy implements smooth weighted round-robin per model.
+type weightedRoundRobinStrategy struct {
+	mu      sync.Mutex
+	current map[string]map[uint]int // model public ID -> provider model ID -> current weight
+}
+
+func (s *weightedRoundRobinStrategy) Name() RoutingStrategyName { return RoutingWeightedRoundRobin }
+
+func (s *weightedRoundRobinStrategy) Rank(candidates []ProviderSelection, hint RoutingHint) []ProviderSelection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weights, ok := s.current[hint.ModelPublicID]
+	if !ok {
+		weights = make(map[uint]int)
+		s.current[hint.ModelPublicID] = weights
+	}
+
+	total := 0
+	best := -1
+	for i, candidate := range candidates {
+		weight := routingWeight(candidate.Provider)
+		total += weight
+		weights[candidate.ProviderModel.ID] += weight
+		if best < 0 || weights[candidate.ProviderModel.ID] > weights[candidates[best].ProviderModel.ID] {
+			best = i
+		}
+	}
+	weights[candidates[best].ProviderModel.ID] -= total
+
+	return moveToFront(candidates, best)
+}
+
+func routingWeight(provider *Provider) int {
+	if provider == nil || provider.Metadata == nil {
+		return 1
+	}
+	weight, err := strconv.Atoi(strings.TrimSpace(provider.Metadata[MetadataKeyRoutingWeight]))
+	if err != nil || weight <= 0 {
+		return 1
+	}
+	return weight
+}
+
+// latencyStrategy prefers the provider with the lowest average latency. Providers without
+// samples rank after measured ones, in price order, and get measured when failover reaches them.
+type latencyStrategy struct {
+	stats *providerStats
+}
+
+func (latencyStrategy) Name() RoutingStrategyName { return RoutingLatency }
+
+func (s latencyStrategy) Rank(candidates []ProviderSelection, _ RoutingHint) []ProviderSelection {
+	latency := s.stats.snapshotLatency()
+	ranked := append([]ProviderSelection(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		left, leftSampled := latency[ranked[i].ProviderModel.ProviderID]
+		right, rightSampled := latency[ranked[j].ProviderModel.ProviderID]
+		if leftSampled != rightSampled {
+			return leftSampled
+		}
+		return left < right
+	})
+	return ranked
+}
+
+// leastInFlightStrategy prefers the provider with the fewest requests in progress.
+type leastInFlightStrategy struct {
+	stats *providerStats
+}
+
+func (leastInFlightStrategy) Name() RoutingStrategyName { return RoutingLeastInFlight }
+
+func (s leastInFlightStrategy) Rank(candidates []ProviderSelection, _ RoutingHint) []ProviderSelection {
+	inFlight := s.stats.snapshotInFlight()
+	ranked := append([]ProviderSelection(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return inFlight[ranked[i].ProviderModel.ProviderID] < inFlight[ranked[j].ProviderModel.ProviderID]
+	})
+	return ranked
+}
+
+// stickyStrategy pins a conversation to one provider using rendezvous hashing, so the
+// choice is stable across replicas and only moves when that provider disappears.
+// Requests without a conversation keep the price ranking.
+type stickyStrategy struct{}
+
+func (stickyStrategy) Name() RoutingStrategyName { return RoutingSticky }
+
+func (stickyStrategy) Rank(candidates []ProviderSelection, hint RoutingHint) []ProviderSelection {
+	if hint.ConversationID == "" {
+		return candidates
+	}
+	best := 0
+	var bestScore uint64
+	for i, candidate := range candidates {
+		hasher := fnv.New64a()
+		_, _ = hasher.Write([]byte(hint.ConversationID))
+		_, _ = hasher.Write([]byte{0})
+		_, _ = hasher.Write([]byte(candidate.ProviderModel.PublicID))
+		score := hasher.Sum64()
+		if i == 0 || score > bestScore {
+			best = i
+			bestScore = score
+		}
+	}
+	return moveToFront(candidates, best)
+}
+
+func moveToFront(candidates []ProviderSelection, index int) []ProviderSelection {
+	if index <= 0 {
+		return candidates
+	}
+	ranked := make([]ProviderSelection, 0, len(candidates))
+	ranked = append(ranked, candidates[index])
+	ranked = append(ranked, candidates[:index]...)
+	ranked = append(ranked, candidates[index+1:]...)
+	return ranked
+}
+
+// TODO(pricing): Remove pricing calculation from provider routing
+// This function calculates the lowest price for a provider model, but pricing logic
+// should be handled by a dedicated billing domain, not in the model management layer.
+// Consider removing this once pricing is moved to the billing domain.
+// Related: See TODO in internal/domain/model/provider_model.go
+func calculateLowestPrice(pricing Pricing) (MicroUSD, bool) {
+	if len(pricing.Lines) == 0 {
+		return 0, false
+	}
+
+	lowest := pricing.Lines[0].Amount
+	for _, line := range pricing.Lines[1:] {
+		if line.Amount < lowest {
+			lowest = line.Amount
+		}
+	}
+
+	return lowest, true
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func routingCandidate(id uint, kind ProviderKind, price MicroUSD, metadata map[string]string) ProviderSelection {
+	pricing := Pricing{}
+	if price > 0 {
+		pricing.Lines = []PriceLine{{Unit: Per1KPromptTokens, Amount: price, Currency: "USD"}}
+	}
+	return ProviderSelection{
+		ProviderModel: &ProviderModel{ID: id, PublicID: "pmdl_" + string(rune('a'+id)), ProviderID: id, Kind: kind, Pricing: pricing},
+		Provider:      &Provider{ID: id, Kind: kind, Metadata: metadata},
+	}
+}
+
+func rankedIDs(selections []ProviderSelection) []uint {
+	ids := make([]uint, 0, len(selections))
+	for _, selection := range selections {
+		ids = append(ids, selection.ProviderModel.ID)
+	}
+	return ids
+}
+
+func TestProviderRouterLowestPrice(t *testing.T) {
+	router := NewProviderRouter(RoutingConfig{})
+	candidates := []ProviderSelection{
+		routingCandidate(1, ProviderOpenRouter, 0, nil),
+		routingCandidate(2, ProviderOpenRouter, 300, nil),
+		routingCandidate(3, ProviderJan, 0, nil),
+		routingCandidate(4, ProviderOpenAI, 100, nil),
+	}
+
+	got := rankedIDs(router.Rank(candidates, RoutingHint{ModelPublicID: "m"}))
+	want := []uint{4, 2, 3, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, got)
+		}
+	}
+}
+
+func TestProviderRouterWeightedRoundRobin(t *testing.T) {
+	router := NewProviderRouter(RoutingConfig{DefaultStrategy: RoutingWeightedRoundRobin})
+	candidates := []ProviderSelection{
+		routingCandidate(1, ProviderJan, 0, map[string]string{MetadataKeyRoutingWeight: "3"}),
+		routingCandidate(2, ProviderJan, 0, nil),
+	}
+
+	counts := map[uint]int{}
+	for i := 0; i < 8; i++ {
+		ranked := router.Rank(candidates, RoutingHint{ModelPublicID: "m"})
+		if len(ranked) != 2 {
+			t.Fatalf("expected all candidates to be kept for failover, got %d", len(ranked))
+		}
+		counts[ranked[0].ProviderModel.ID]++
+	}
+	if counts[1] != 6 || counts[2] != 2 {
+		t.Fatalf("expected 6/2 split, got %v", counts)
+	}
+}
+
+func TestProviderRouterLatencyAndInFlight(t *testing.T) {
+	candidates := []ProviderSelection{
+		routingCandidate(1, ProviderJan, 0, nil),
+		routingCandidate(2, ProviderJan, 0, nil),
+	}
+
+	latencyRouter := NewProviderRouter(RoutingConfig{DefaultStrategy: RoutingLatency})
+	latencyRouter.Begin(1)(900*time.Millisecond, nil)
+	latencyRouter.Begin(2)(100*time.Millisecond, nil)
+	if got := latencyRouter.Rank(candidates, RoutingHint{})[0].ProviderModel.ID; got != 2 {
+		t.Fatalf("expected fastest provider 2 first, got %d", got)
+	}
+
+	inFlightRouter := NewProviderRouter(RoutingConfig{ModelStrategies: map[string]RoutingStrategyName{"m": RoutingLeastInFlight}})
+	done := inFlightRouter.Begin(1)
+	if got := inFlightRouter.Rank(candidates, RoutingHint{ModelPublicID: "m"})[0].ProviderModel.ID; got != 2 {
+		t.Fatalf("expected idle provider 2 first, got %d", got)
+	}
+	done(time.Second, nil)
+	if got := inFlightRouter.Rank(candidates, RoutingHint{ModelPublicID: "m"})[0].ProviderModel.ID; got != 1 {
+		t.Fatalf("expected provider 1 first once idle, got %d", got)
+	}
+}
+
+func TestProviderRouterStickyFromProviderMetadata(t *testing.T) {
+	router := NewProviderRouter(RoutingConfig{})
+	sticky := map[string]string{MetadataKeyRoutingStrategy: "sticky"}
+	candidates := []ProviderSelection{
+		routingCandidate(1, ProviderJan, 0, sticky),
+		routingCandidate(2, ProviderJan, 0, sticky),
+		routingCandidate(3, ProviderJan, 0, sticky),
+	}
+
+	first := router.Rank(candidates, RoutingHint{ConversationID: "conv_123"})[0].ProviderModel.ID
+	for i := 0; i < 5; i++ {
+		if got := router.Rank(candidates, RoutingHint{ConversationID: "conv_123"})[0].ProviderModel.ID; got != first {
+			t.Fatalf("expected conversation to stay on provider %d, got %d", first, got)
+		}
+	}
+}
+
+func TestNewProviderSelectionsSkipsMissingProviders(t *testing.T) {
+	providerModels := []*ProviderModel{
+		{ID: 1, ProviderID: 10},
+		nil,
+		{ID: 2, ProviderID: 20},
+	}
+	providers := map[uint]*Provider{10: {ID: 10}}
+
+	selections := NewProviderSelections(providerModels, providers)
+	if len(selections) != 1 || selections[0].ProviderModel.ID != 1 || selections[0].Provider.ID != 10 {
+		t.Fatalf("expected only provider model 1 to be selected, got %+v", selections)
+	}
+}
+
+func TestProviderRouterLatencyRanksUnsampledAndFailingLast(t *testing.T) {
+	candidates := []ProviderSelection{
+		routingCandidate(1, ProviderJan, 0, nil),
+		routingCandidate(2, ProviderJan, 0, nil),
+		routingCandidate(3, ProviderJan, 0, nil),
+	}
+	router := NewProviderRouter(RoutingConfig{DefaultStrategy: RoutingLatency})
+
+	router.Begin(2)(2*time.Second, nil)
+	router.Begin(3)(100*time.Millisecond, nil)
+	if got := rankedIDs(router.Rank(candidates, RoutingHint{})); got[0] != 3 || got[1] != 2 || got[2] != 1 {
+		t.Fatalf("expected measured providers before unsampled provider 1, got %v", got)
+	}
+
+	// Fast failures must not make a provider look fast
+	for i := 0; i < 5; i++ {
+		router.Begin(3)(10*time.Millisecond, errors.New("upstream failed"))
+	}
+	if got := rankedIDs(router.Rank(candidates, RoutingHint{})); got[0] != 2 || got[1] != 3 {
+		t.Fatalf("expected failing provider 3 behind provider 2, got %v", got)
+	}
+
+	// Client cancellations are not held against the provider
+	router.Begin(2)(10*time.Millisecond, context.Canceled)
+	if got := rankedIDs(router.Rank(candidates, RoutingHint{})); got[0] != 2 {
+		t.Fatalf("expected cancellation to leave provider 2 first, got %v", got)
+	}
+}
@@ -20,6 +20,7 @@ type Principal struct {
 	Email           string
 	Name            string
 	Scopes          []string
+	Roles           []string // Realm roles of a JWT principal
 	Credentials     map[string]string
 }
 
@@ -32,3 +33,13 @@ func (p Principal) HasScope(scope string) bool {
 	}
 	return false
 }
+
+// HasRole checks if the principal possesses a realm role.
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -25,6 +25,26 @@ type Project struct {
 	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+
+	InstructionVersion int `json:"instruction_version"` // Incremented whenever Instruction changes
+}
+
+// SetInstruction replaces the project instruction and bumps InstructionVersion when the text
+// actually changes. It reports whether the instruction changed.
+func (p *Project) SetInstruction(instruction *string) bool {
+	current, next := "", ""
+	if p.Instruction != nil {
+		current = *p.Instruction
+	}
+	if instruction != nil {
+		next = *instruction
+	}
+	p.Instruction = instruction
+	if current == next {
+		return false
+	}
+	p.InstructionVersion++
+	return true
 }
 
 // ===============================================
@@ -68,5 +88,7 @@ func NewProject(publicID string, userID uint, name string, instruction *string)
 		LastUsedAt:  nil,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+
+		InstructionVersion: 1,
 	}
 }
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"strings"
+
 	"github.com/google/wire"
 
 	"jan-server/services/llm-api/internal/config"
@@ -23,6 +25,10 @@ var ServiceProvider = wire.NewSet(
 	model.NewProviderModelService,
 	model.NewModelCatalogService,
 	model.NewProviderService,
+	ProvideProviderRoutingConfig,
+	model.NewProviderRouter,
+	ProvideProviderHealthConfig,
+	model.NewProviderHealthTracker,
 
 	// User domain
 	user.NewService,
@@ -40,3 +46,31 @@ func ProvideAPIKeyConfig(cfg *config.Config) apikey.Config {
 		KeyPrefix:  cfg.APIKeyPrefix,
 	}
 }
+
+func ProvideProviderRoutingConfig(cfg *config.Config) model.RoutingConfig {
+	defaultStrategy, ok := model.ParseRoutingStrategy(cfg.ProviderRoutingStrategy)
+	if !ok {
+		defaultStrategy = model.RoutingLowestPrice
+	}
+	modelStrategies := make(map[string]model.RoutingStrategyName, len(cfg.ProviderRoutingModelStrategies))
+	for modelPublicID, value := range cfg.ProviderRoutingModelStrategies {
+		if strategy, ok := model.ParseRoutingStrategy(value); ok {
+			modelStrategies[strings.TrimSpace(modelPublicID)] = strategy
+		}
+	}
+	return model.RoutingConfig{
+		DefaultStrategy:  defaultStrategy,
+		ModelStrategies:  modelStrategies,
+		LatencyEWMAAlpha: cfg.ProviderRoutingLatencyAlpha,
+	}
+}
+
+func ProvideProviderHealthConfig(cfg *config.Config) model.ProviderHealthConfig {
+	return model.ProviderHealthConfig{
+		Enabled:     cfg.ProviderBreakerEnabled,
+		Window:      cfg.ProviderBreakerWindow,
+		MinFailures: cfg.ProviderBreakerMinFailures,
+		FailureRate: cfg.ProviderBreakerFailureRate,
+		Cooldown:    cfg.ProviderBreakerCooldown,
+	}
+}
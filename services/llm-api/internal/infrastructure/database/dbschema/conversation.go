@@ -65,15 +65,18 @@ type ConversationItem struct {
 	Type              conversation.ItemType `gorm:"type:varchar(50);not null"`
 	Role              *string               `gorm:"type:varchar(20)"` // Stored as string, converted to/from ItemRole
 	Content           JSONContent           `gorm:"type:jsonb"`       // Stores []Content as JSON
+	TextContent       *string               `gorm:"type:text"`        // Plain text of Content, indexed for full-text search
 	Status            *string               `gorm:"type:varchar(20)"` // Stored as string, converted to/from ItemStatus
 	IncompleteAt      *time.Time            `gorm:"type:timestamp"`
 	IncompleteDetails JSONIncompleteDetails `gorm:"type:jsonb"`
 	CompletedAt       *time.Time            `gorm:"type:timestamp"`
 	ResponseID        *uint                 `gorm:"index"`
+	Model             *string               `gorm:"type:varchar(128);index"` // Model that generated the item
+	ProviderID        *string               `gorm:"type:varchar(64)"`        // Public ID of the provider that served the model
 
 	// User feedback/rating
 	Rating        *string    `gorm:"type:varchar(10)"` // 'like' or 'unlike'
-	RatedAt       *time.Time `gorm:"type:timestamp"`
+	RatedAt       *time.Time `gorm:"type:timestamp;index"`
 	RatingComment *string    `gorm:"type:text"`
 }
 
@@ -273,6 +276,12 @@ func NewSchemaConversationItem(item *conversation.Item) *ConversationItem {
 		IncompleteAt:   item.IncompleteAt,
 		CompletedAt:    item.CompletedAt,
 		ResponseID:     item.ResponseID,
+		Model:          item.Model,
+		ProviderID:     item.ProviderID,
+	}
+
+	if text := item.Text(); text != "" {
+		schemaItem.TextContent = &text
 	}
 
 	// Convert Role pointer to string pointer
@@ -318,6 +327,8 @@ func (i *ConversationItem) EtoD() *conversation.Item {
 		IncompleteAt:   i.IncompleteAt,
 		CompletedAt:    i.CompletedAt,
 		ResponseID:     i.ResponseID,
+		Model:          i.Model,
+		ProviderID:     i.ProviderID,
 		CreatedAt:      i.CreatedAt,
 	}
 
@@ -0,0 +1,35 @@
+package dbschema
+
+import (
+	"testing"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+)
+
+func TestNewSchemaConversationItemStoresTextContent(t *testing.T) {
+	refusal := "I can't help with that"
+	input := "question"
+	role := conversation.ItemRoleAssistant
+	item := &conversation.Item{
+		Type: conversation.ItemTypeMessage,
+		Role: &role,
+		Content: []conversation.Content{
+			conversation.NewTextContent("first"),
+			{Type: "input_text", InputText: &input},
+			{Type: "output_text", OutputText: &conversation.OutputText{Text: "answer"}},
+			{Type: "refusal", Refusal: &refusal},
+			{Type: "image"},
+		},
+	}
+
+	schemaItem := NewSchemaConversationItem(item)
+	want := "first\nquestion\nanswer\nI can't help with that"
+	if schemaItem.TextContent == nil || *schemaItem.TextContent != want {
+		t.Fatalf("expected text content %q, got %v", want, schemaItem.TextContent)
+	}
+
+	empty := NewSchemaConversationItem(&conversation.Item{Type: conversation.ItemTypeFunctionCall})
+	if empty.TextContent != nil {
+		t.Fatalf("expected no text content without text, got %q", *empty.TextContent)
+	}
+}
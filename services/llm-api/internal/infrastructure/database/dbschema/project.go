@@ -26,6 +26,8 @@ type Project struct {
 	ArchivedAt  *time.Time `gorm:"index"`
 	DeletedAt   *time.Time `gorm:"index"`
 	LastUsedAt  *time.Time
+
+	InstructionVersion int `gorm:"not null;default:1"` // Incremented whenever Instruction changes
 }
 
 // TableName specifies the table name for Project
@@ -52,6 +54,8 @@ func (p *Project) EtoD() *project.Project {
 		LastUsedAt:  p.LastUsedAt,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
+
+		InstructionVersion: p.InstructionVersion,
 	}
 }
 
@@ -71,6 +75,8 @@ func ProjectDtoE(p *project.Project) *Project {
 		ArchivedAt:  p.ArchivedAt,
 		DeletedAt:   p.DeletedAt,
 		LastUsedAt:  p.LastUsedAt,
+
+		InstructionVersion: p.InstructionVersion,
 	}
 }
 
@@ -90,5 +96,7 @@ func NewSchemaProject(p *project.Project) *Project {
 		ArchivedAt:  p.ArchivedAt,
 		DeletedAt:   p.DeletedAt,
 		LastUsedAt:  p.LastUsedAt,
+
+		InstructionVersion: p.InstructionVersion,
 	}
 }
@@ -40,11 +40,14 @@ func newConversationItem(db *gorm.DB, opts ...gen.DOOption) conversationItem {
 	_conversationItem.Type = field.NewString(tableName, "type")
 	_conversationItem.Role = field.NewString(tableName, "role")
 	_conversationItem.Content = field.NewField(tableName, "content")
+	_conversationItem.TextContent = field.NewString(tableName, "text_content")
 	_conversationItem.Status = field.NewString(tableName, "status")
 	_conversationItem.IncompleteAt = field.NewTime(tableName, "incomplete_at")
 	_conversationItem.IncompleteDetails = field.NewField(tableName, "incomplete_details")
 	_conversationItem.CompletedAt = field.NewTime(tableName, "completed_at")
 	_conversationItem.ResponseID = field.NewUint(tableName, "response_id")
+	_conversationItem.Model = field.NewString(tableName, "model")
+	_conversationItem.ProviderID = field.NewString(tableName, "provider_id")
 	_conversationItem.Rating = field.NewString(tableName, "rating")
 	_conversationItem.RatedAt = field.NewTime(tableName, "rated_at")
 	_conversationItem.RatingComment = field.NewString(tableName, "rating_comment")
@@ -106,11 +109,14 @@ type conversationItem struct {
 	Type              field.String
 	Role              field.String
 	Content           field.Field
+	TextContent       field.String
 	Status            field.String
 	IncompleteAt      field.Time
 	IncompleteDetails field.Field
 	CompletedAt       field.Time
 	ResponseID        field.Uint
+	Model             field.String
+	ProviderID        field.String
 	Rating            field.String
 	RatedAt           field.Time
 	RatingComment     field.String
@@ -143,11 +149,14 @@ func (c *conversationItem) updateTableName(table string) *conversationItem {
 	c.Type = field.NewString(table, "type")
 	c.Role = field.NewString(table, "role")
 	c.Content = field.NewField(table, "content")
+	c.TextContent = field.NewString(table, "text_content")
 	c.Status = field.NewString(table, "status")
 	c.IncompleteAt = field.NewTime(table, "incomplete_at")
 	c.IncompleteDetails = field.NewField(table, "incomplete_details")
 	c.CompletedAt = field.NewTime(table, "completed_at")
 	c.ResponseID = field.NewUint(table, "response_id")
+	c.Model = field.NewString(table, "model")
+	c.ProviderID = field.NewString(table, "provider_id")
 	c.Rating = field.NewString(table, "rating")
 	c.RatedAt = field.NewTime(table, "rated_at")
 	c.RatingComment = field.NewString(table, "rating_comment")
@@ -167,7 +176,7 @@ func (c *conversationItem) GetFieldByName(fieldName string) (field.OrderExpr, bo
 }
 
 func (c *conversationItem) fillFieldMap() {
-	c.fieldMap = make(map[string]field.Expr, 21)
+	c.fieldMap = make(map[string]field.Expr, 24)
 	c.fieldMap["id"] = c.ID
 	c.fieldMap["created_at"] = c.CreatedAt
 	c.fieldMap["updated_at"] = c.UpdatedAt
@@ -180,11 +189,14 @@ func (c *conversationItem) fillFieldMap() {
 	c.fieldMap["type"] = c.Type
 	c.fieldMap["role"] = c.Role
 	c.fieldMap["content"] = c.Content
+	c.fieldMap["text_content"] = c.TextContent
 	c.fieldMap["status"] = c.Status
 	c.fieldMap["incomplete_at"] = c.IncompleteAt
 	c.fieldMap["incomplete_details"] = c.IncompleteDetails
 	c.fieldMap["completed_at"] = c.CompletedAt
 	c.fieldMap["response_id"] = c.ResponseID
+	c.fieldMap["model"] = c.Model
+	c.fieldMap["provider_id"] = c.ProviderID
 	c.fieldMap["rating"] = c.Rating
 	c.fieldMap["rated_at"] = c.RatedAt
 	c.fieldMap["rating_comment"] = c.RatingComment
@@ -2,6 +2,9 @@ package conversationrepo
 
 import (
 	"context"
+	"time"
+
+	"gorm.io/gorm"
 
 	"jan-server/services/llm-api/internal/domain/conversation"
 	"jan-server/services/llm-api/internal/domain/query"
@@ -9,6 +12,7 @@ import (
 	"jan-server/services/llm-api/internal/infrastructure/database/gormgen"
 	"jan-server/services/llm-api/internal/infrastructure/database/transaction"
 	"jan-server/services/llm-api/internal/utils/functional"
+	"jan-server/services/llm-api/internal/utils/idgen"
 	"jan-server/services/llm-api/internal/utils/platformerrors"
 )
 
@@ -35,6 +39,48 @@ func (repo *ConversationGormRepository) Create(ctx context.Context, conv *conver
 	return nil
 }
 
+// CreateWithBranches implements conversation.ConversationRepository.
+func (repo *ConversationGormRepository) CreateWithBranches(ctx context.Context, conversations []*conversation.ConversationWithBranches) error {
+	return repo.db.GetTx(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := transaction.WithTx(ctx, tx)
+		q := repo.db.GetQuery(txCtx)
+
+		for _, created := range conversations {
+			conv := created.Conversation
+			model := dbschema.NewSchemaConversation(conv)
+			if err := q.Conversation.WithContext(txCtx).Create(model); err != nil {
+				return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "failed to create conversation")
+			}
+			conv.ID = model.ID
+			conv.CreatedAt = model.CreatedAt
+			conv.UpdatedAt = model.UpdatedAt
+
+			for _, branch := range created.Branches {
+				branchModel := dbschema.NewSchemaConversationBranch(conv.ID, *branch)
+				if err := q.ConversationBranch.WithContext(txCtx).Create(branchModel); err != nil {
+					return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "failed to create branch")
+				}
+			}
+
+			if len(created.Items) == 0 {
+				continue
+			}
+			models := functional.Map(created.Items, func(item *conversation.Item) *dbschema.ConversationItem {
+				item.ConversationID = conv.ID
+				return dbschema.NewSchemaConversationItem(item)
+			})
+			if err := q.ConversationItem.WithContext(txCtx).CreateInBatches(models, 100); err != nil {
+				return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "failed to create items")
+			}
+			for i, model := range models {
+				created.Items[i].ID = model.ID
+				created.Items[i].CreatedAt = model.CreatedAt
+			}
+		}
+		return nil
+	})
+}
+
 // FindByFilter implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) FindByFilter(ctx context.Context, filter conversation.ConversationFilter, pagination *query.Pagination) ([]*conversation.Conversation, error) {
 	q := repo.db.GetQuery(ctx)
@@ -100,6 +146,21 @@ func (repo *ConversationGormRepository) Update(ctx context.Context, conv *conver
 	return nil
 }
 
+// UpdateInstructionSnapshot implements conversation.ConversationRepository.
+func (repo *ConversationGormRepository) UpdateInstructionSnapshot(ctx context.Context, conversationID uint, version int, snapshot *string) error {
+	q := repo.db.GetQuery(ctx)
+	_, err := q.Conversation.WithContext(ctx).
+		Where(q.Conversation.ID.Eq(conversationID)).
+		Updates(map[string]interface{}{
+			"instruction_version":            version,
+			"effective_instruction_snapshot": snapshot,
+		})
+	if err != nil {
+		return platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to update instruction snapshot")
+	}
+	return nil
+}
+
 // Delete implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) Delete(ctx context.Context, id uint) error {
 	q := repo.db.GetQuery(ctx)
@@ -138,19 +199,12 @@ func (repo *ConversationGormRepository) AddItem(ctx context.Context, conversatio
 
 // SearchItems implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) SearchItems(ctx context.Context, conversationID uint, searchQuery string) ([]*conversation.Item, error) {
-	// For now, this is a simple implementation
-	// In production, you'd want to use full-text search or a search engine like Elasticsearch
 	q := repo.db.GetQuery(ctx)
 	sql := q.ConversationItem.WithContext(ctx)
 	sql = repo.applyItemFilter(q, sql, conversation.ItemFilter{
 		ConversationID: &conversationID,
 	})
-
-	// Search in content JSON field (PostgreSQL JSONB search)
-	// This is a basic implementation - enhance based on your database capabilities
-	// Note: For proper JSON search in PostgreSQL, you might need raw SQL or custom query
-	rows, err := sql.Find()
-
+	rows, err := sql.Where(itemSearchCondition(searchQuery)).Order(q.ConversationItem.ID.Asc()).Find()
 	if err != nil {
 		return nil, platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to search items")
 	}
@@ -158,9 +212,6 @@ func (repo *ConversationGormRepository) SearchItems(ctx context.Context, convers
 	result := functional.Map(rows, func(item *dbschema.ConversationItem) *conversation.Item {
 		return item.EtoD()
 	})
-
-	// TODO: Implement proper full-text search filtering
-	// For now, returning all items in the conversation
 	return result, nil
 }
 
@@ -261,11 +312,12 @@ func (repo *ConversationGormRepository) DeleteItem(ctx context.Context, conversa
 func (repo *ConversationGormRepository) CountItems(ctx context.Context, conversationID uint, branchName string) (int, error) {
 	q := repo.db.GetQuery(ctx)
 	sql := q.ConversationItem.WithContext(ctx)
+	branch := branchOrMain(branchName)
 	sql = repo.applyItemFilter(q, sql, conversation.ItemFilter{
 		ConversationID: &conversationID,
+		Branch:         &branch,
 	})
 
-	// For now, we count all items since branch filtering isn't fully implemented in gormgen
 	count, err := sql.Count()
 
 	if err != nil {
@@ -284,63 +336,118 @@ func (repo *ConversationGormRepository) CreateBranch(ctx context.Context, conver
 		return platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "conversation not found")
 	}
 
-	// TODO: Implement branch storage in database
-	// For now, return not implemented error
-	return platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "branch operations not yet implemented in database layer", nil, "")
+	meta := conversation.BranchMetadata{Name: branchName}
+	if metadata != nil {
+		meta = *metadata
+		meta.Name = branchName
+	}
+
+	model := dbschema.NewSchemaConversationBranch(conversationID, meta)
+	if err := repo.db.GetQuery(ctx).ConversationBranch.WithContext(ctx).Create(model); err != nil {
+		return platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to create branch")
+	}
+	if metadata != nil {
+		metadata.CreatedAt = model.CreatedAt
+		metadata.UpdatedAt = model.UpdatedAt
+	}
+	return nil
 }
 
 // GetBranch implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) GetBranch(ctx context.Context, conversationID uint, branchName string) (*conversation.BranchMetadata, error) {
-	return nil, platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "branch operations not yet implemented in database layer", nil, "")
+	q := repo.db.GetQuery(ctx)
+	result, err := q.ConversationBranch.WithContext(ctx).
+		Where(q.ConversationBranch.ConversationID.Eq(conversationID), q.ConversationBranch.Name.Eq(branchName)).
+		First()
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to find branch")
+	}
+	meta := result.EtoD()
+	return &meta, nil
 }
 
 // ListBranches implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) ListBranches(ctx context.Context, conversationID uint) ([]*conversation.BranchMetadata, error) {
-	return nil, platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "branch operations not yet implemented in database layer", nil, "")
+	q := repo.db.GetQuery(ctx)
+	rows, err := q.ConversationBranch.WithContext(ctx).
+		Where(q.ConversationBranch.ConversationID.Eq(conversationID)).
+		Order(q.ConversationBranch.CreatedAt.Asc()).
+		Find()
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to list branches")
+	}
+
+	return functional.Map(rows, func(row *dbschema.ConversationBranch) *conversation.BranchMetadata {
+		meta := row.EtoD()
+		return &meta
+	}), nil
 }
 
 // DeleteBranch implements conversation.ConversationRepository.
+// The branch items and its metadata are removed together; the metadata row is deleted
+// permanently so the branch name can be reused.
 func (repo *ConversationGormRepository) DeleteBranch(ctx context.Context, conversationID uint, branchName string) error {
-	return platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "branch operations not yet implemented in database layer", nil, "")
+	return repo.db.GetTx(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := transaction.WithTx(ctx, tx)
+		q := repo.db.GetQuery(txCtx)
+
+		items := q.ConversationItem.WithContext(txCtx)
+		items = repo.applyItemFilter(q, items, conversation.ItemFilter{
+			ConversationID: &conversationID,
+			Branch:         &branchName,
+		})
+		if _, err := items.Delete(); err != nil {
+			return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "failed to delete branch items")
+		}
+
+		_, err := q.ConversationBranch.WithContext(txCtx).Unscoped().
+			Where(q.ConversationBranch.ConversationID.Eq(conversationID), q.ConversationBranch.Name.Eq(branchName)).
+			Delete()
+		if err != nil {
+			return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "failed to delete branch")
+		}
+		return nil
+	})
 }
 
 // SetActiveBranch implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) SetActiveBranch(ctx context.Context, conversationID uint, branchName string) error {
-	return platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "branch operations not yet implemented in database layer", nil, "")
+	q := repo.db.GetQuery(ctx)
+	_, err := q.Conversation.WithContext(ctx).
+		Where(q.Conversation.ID.Eq(conversationID)).
+		Update(q.Conversation.ActiveBranch, branchName)
+	if err != nil {
+		return platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to set active branch")
+	}
+	return nil
 }
 
 // Branch item operations
 // AddItemToBranch implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) AddItemToBranch(ctx context.Context, conversationID uint, branchName string, item *conversation.Item) error {
-	// For now, branch operations are not implemented
-	// Default to MAIN branch behavior
-	if branchName == "MAIN" || branchName == "" {
-		return repo.AddItem(ctx, conversationID, item)
-	}
-	return platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "branch operations not yet implemented in database layer", nil, "")
+	item.Branch = branchOrMain(branchName)
+	return repo.AddItem(ctx, conversationID, item)
 }
 
 // GetBranchItems implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) GetBranchItems(ctx context.Context, conversationID uint, branchName string, pagination *query.Pagination) ([]*conversation.Item, error) {
-	// For now, return items for MAIN branch with pagination support
-	if branchName == "MAIN" || branchName == "" {
-		q := repo.db.GetQuery(ctx)
-		sql := q.ConversationItem.WithContext(ctx)
-		sql = repo.applyItemFilter(q, sql, conversation.ItemFilter{
-			ConversationID: &conversationID,
-		})
-		sql = repo.applyItemPagination(q, sql, pagination)
-
-		rows, err := sql.Find()
-		if err != nil {
-			return nil, platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to get branch items")
-		}
+	branch := branchOrMain(branchName)
+	q := repo.db.GetQuery(ctx)
+	sql := q.ConversationItem.WithContext(ctx)
+	sql = repo.applyItemFilter(q, sql, conversation.ItemFilter{
+		ConversationID: &conversationID,
+		Branch:         &branch,
+	})
+	sql = repo.applyItemPagination(q, sql, pagination)
 
-		return functional.Map(rows, func(item *dbschema.ConversationItem) *conversation.Item {
-			return item.EtoD()
-		}), nil
+	rows, err := sql.Find()
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to get branch items")
 	}
-	return nil, platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "branch operations not yet implemented in database layer", nil, "")
+
+	return functional.Map(rows, func(item *dbschema.ConversationItem) *conversation.Item {
+		return item.EtoD()
+	}), nil
 }
 
 // applyItemPagination applies pagination to item queries
@@ -375,35 +482,174 @@ func (repo *ConversationGormRepository) applyItemPagination(q *gormgen.Query, sq
 
 // BulkAddItemsToBranch implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) BulkAddItemsToBranch(ctx context.Context, conversationID uint, branchName string, items []*conversation.Item) error {
-	// For now, branch operations are not implemented
-	// Default to MAIN branch behavior
-	if branchName == "MAIN" || branchName == "" {
-		return repo.BulkAddItems(ctx, conversationID, items)
+	branch := branchOrMain(branchName)
+	for _, item := range items {
+		item.Branch = branch
 	}
-	return platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "branch operations not yet implemented in database layer", nil, "")
+	return repo.BulkAddItems(ctx, conversationID, items)
 }
 
 // ForkBranch implements conversation.ConversationRepository.
+// The items of the source branch up to and including fromItemID are copied into the new branch
+// with new public IDs; an empty fromItemID copies the whole source branch.
 func (repo *ConversationGormRepository) ForkBranch(ctx context.Context, conversationID uint, sourceBranch, newBranch string, fromItemID string, description *string) error {
-	return platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "branch operations not yet implemented in database layer", nil, "")
+	return repo.db.GetTx(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := transaction.WithTx(ctx, tx)
+		q := repo.db.GetQuery(txCtx)
+
+		sql := q.ConversationItem.WithContext(txCtx)
+		sql = repo.applyItemFilter(q, sql, conversation.ItemFilter{
+			ConversationID: &conversationID,
+			Branch:         &sourceBranch,
+		})
+		if fromItemID != "" {
+			forkItem, err := repo.GetItemByPublicID(txCtx, conversationID, fromItemID)
+			if err != nil {
+				return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "fork item not found")
+			}
+			if forkItem.Branch != sourceBranch {
+				return platformerrors.NewError(txCtx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotFound, "fork item not found in source branch", nil, "")
+			}
+			sql = sql.Where(q.ConversationItem.ID.Lte(forkItem.ID))
+		}
+
+		rows, err := sql.Order(q.ConversationItem.ID.Asc()).Find()
+		if err != nil {
+			return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "failed to get source branch items")
+		}
+
+		copies, err := copyBranchItems(rows, newBranch, func() (string, error) {
+			return idgen.GenerateSecureID("msg", 16)
+		})
+		if err != nil {
+			return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "failed to generate item ID")
+		}
+		if len(copies) > 0 {
+			if err := q.ConversationItem.WithContext(txCtx).CreateInBatches(copies, 100); err != nil {
+				return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "failed to copy branch items")
+			}
+		}
+
+		now := time.Now()
+		meta := conversation.BranchMetadata{
+			Name:         newBranch,
+			Description:  description,
+			ParentBranch: &sourceBranch,
+			ForkedAt:     &now,
+			ItemCount:    len(copies),
+		}
+		if fromItemID != "" {
+			meta.ForkedFromItemID = &fromItemID
+		}
+		model := dbschema.NewSchemaConversationBranch(conversationID, meta)
+		if err := q.ConversationBranch.WithContext(txCtx).Create(model); err != nil {
+			return platformerrors.AsError(txCtx, platformerrors.LayerRepository, err, "failed to create branch")
+		}
+		return nil
+	})
+}
+
+// copyBranchItems copies items into newBranch with public IDs from newID, numbered from 1.
+// Summaries of the copies point at the copied items they cover.
+func copyBranchItems(rows []*dbschema.ConversationItem, newBranch string, newID func() (string, error)) ([]*dbschema.ConversationItem, error) {
+	copiedIDs := make(map[string]string, len(rows))
+	copies := make([]*dbschema.ConversationItem, 0, len(rows))
+	for i, row := range rows {
+		publicID, err := newID()
+		if err != nil {
+			return nil, err
+		}
+		copiedIDs[row.PublicID] = publicID
+
+		copied := *row
+		copied.BaseModel = dbschema.BaseModel{CreatedAt: row.CreatedAt}
+		copied.PublicID = publicID
+		copied.Branch = newBranch
+		copied.SequenceNumber = i + 1
+		// Ratings belong to the original item, not to its copy
+		copied.Rating = nil
+		copied.RatedAt = nil
+		copied.RatingComment = nil
+
+		if row.Content != nil {
+			copied.Content = make(dbschema.JSONContent, len(row.Content))
+			copy(copied.Content, row.Content)
+			for idx := range copied.Content {
+				through := copied.Content[idx].SummarizedThrough
+				if through == nil {
+					continue
+				}
+				if copiedID, ok := copiedIDs[*through]; ok {
+					copied.Content[idx].SummarizedThrough = &copiedID
+				}
+			}
+		}
+		copies = append(copies, &copied)
+	}
+	return copies, nil
 }
 
 // Item rating operations
 // RateItem implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) RateItem(ctx context.Context, conversationID uint, itemID string, rating conversation.ItemRating, comment *string) error {
-	// TODO: Implement rating storage in database
-	// For now, return not implemented error
-	return platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "rating operations not yet implemented in database layer", nil, "")
+	return repo.updateItemRating(ctx, conversationID, itemID, map[string]interface{}{
+		"rating":         string(rating),
+		"rated_at":       time.Now(),
+		"rating_comment": comment,
+	})
 }
 
 // GetItemRating implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) GetItemRating(ctx context.Context, conversationID uint, itemID string) (*conversation.ItemRating, error) {
-	return nil, platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "rating operations not yet implemented in database layer", nil, "")
+	item, err := repo.GetItemByPublicID(ctx, conversationID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return item.Rating, nil
 }
 
 // RemoveItemRating implements conversation.ConversationRepository.
 func (repo *ConversationGormRepository) RemoveItemRating(ctx context.Context, conversationID uint, itemID string) error {
-	return platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotImplemented, "rating operations not yet implemented in database layer", nil, "")
+	return repo.updateItemRating(ctx, conversationID, itemID, map[string]interface{}{
+		"rating":         nil,
+		"rated_at":       nil,
+		"rating_comment": nil,
+	})
+}
+
+// updateItemRating writes the rating columns of an item, returning NotFound when the item does not exist
+func (repo *ConversationGormRepository) updateItemRating(ctx context.Context, conversationID uint, itemID string, columns map[string]interface{}) error {
+	q := repo.db.GetQuery(ctx)
+	sql := q.ConversationItem.WithContext(ctx)
+	sql = repo.applyItemFilter(q, sql, conversation.ItemFilter{
+		PublicID:       &itemID,
+		ConversationID: &conversationID,
+	})
+	result, err := sql.Updates(columns)
+	if err != nil {
+		return platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to update item rating")
+	}
+	if result.RowsAffected == 0 {
+		return platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotFound, "item not found", nil, "")
+	}
+	return nil
+}
+
+// FindItemsByFilter implements conversation.ConversationRepository.
+func (repo *ConversationGormRepository) FindItemsByFilter(ctx context.Context, filter conversation.ItemFilter, pagination *query.Pagination) ([]*conversation.Item, error) {
+	q := repo.db.GetQuery(ctx)
+	sql := q.ConversationItem.WithContext(ctx)
+	sql = repo.applyItemFilter(q, sql, filter)
+	sql = repo.applyItemPagination(q, sql, pagination)
+
+	rows, err := sql.Find()
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to find items by filter")
+	}
+
+	return functional.Map(rows, func(item *dbschema.ConversationItem) *conversation.Item {
+		return item.EtoD()
+	}), nil
 }
 
 // applyFilter applies filter conditions to the query
@@ -434,6 +680,9 @@ func (repo *ConversationGormRepository) applyItemFilter(q *gormgen.Query, sql go
 	if filter.ConversationID != nil {
 		sql = sql.Where(q.ConversationItem.ConversationID.Eq(*filter.ConversationID))
 	}
+	if filter.Branch != nil {
+		sql = sql.Where(q.ConversationItem.Branch.Eq(*filter.Branch))
+	}
 	if filter.Role != nil {
 		roleStr := string(*filter.Role)
 		sql = sql.Where(q.ConversationItem.Role.Eq(roleStr))
@@ -441,9 +690,36 @@ func (repo *ConversationGormRepository) applyItemFilter(q *gormgen.Query, sql go
 	if filter.ResponseID != nil {
 		sql = sql.Where(q.ConversationItem.ResponseID.Eq(*filter.ResponseID))
 	}
+	if filter.Model != nil {
+		sql = sql.Where(q.ConversationItem.Model.Eq(*filter.Model))
+	}
+	if filter.Rating != nil {
+		sql = sql.Where(q.ConversationItem.Rating.Eq(string(*filter.Rating)))
+	}
+	if filter.Rated != nil {
+		if *filter.Rated {
+			sql = sql.Where(q.ConversationItem.Rating.IsNotNull())
+		} else {
+			sql = sql.Where(q.ConversationItem.Rating.IsNull())
+		}
+	}
+	if filter.RatedAfter != nil {
+		sql = sql.Where(q.ConversationItem.RatedAt.Gte(*filter.RatedAfter))
+	}
+	if filter.RatedBefore != nil {
+		sql = sql.Where(q.ConversationItem.RatedAt.Lt(*filter.RatedBefore))
+	}
 	return sql
 }
 
+// branchOrMain returns the branch name, defaulting to MAIN when empty
+func branchOrMain(branchName string) string {
+	if branchName == "" {
+		return conversation.BranchMain
+	}
+	return branchName
+}
+
 // applyPagination applies pagination to the query
 func (repo *ConversationGormRepository) applyPagination(q *gormgen.Query, sql gormgen.IConversationDo, p *query.Pagination) gormgen.IConversationDo {
 	if p != nil {
@@ -0,0 +1,73 @@
+package conversationrepo
+
+import (
+	"fmt"
+	"testing"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+	"jan-server/services/llm-api/internal/infrastructure/database/dbschema"
+)
+
+func sequentialIDs() func() (string, error) {
+	next := 0
+	return func() (string, error) {
+		next++
+		return fmt.Sprintf("msg_copy_%d", next), nil
+	}
+}
+
+func TestCopyBranchItemsRemapsSummaries(t *testing.T) {
+	rating := "like"
+	rows := []*dbschema.ConversationItem{
+		{PublicID: "msg_a", Branch: conversation.BranchMain, SequenceNumber: 4, Rating: &rating},
+		{PublicID: "msg_b", Branch: conversation.BranchMain, SequenceNumber: 5},
+		{PublicID: "msg_sum", Branch: conversation.BranchMain, SequenceNumber: 6, Content: dbschema.JSONContent{
+			conversation.NewSummaryTextContent("earlier turns", "msg_b"),
+		}},
+	}
+
+	copies, err := copyBranchItems(rows, "EDIT_1", sequentialIDs())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(copies) != 3 {
+		t.Fatalf("expected 3 copies, got %d", len(copies))
+	}
+	for i, copied := range copies {
+		if copied.PublicID != fmt.Sprintf("msg_copy_%d", i+1) || copied.Branch != "EDIT_1" || copied.SequenceNumber != i+1 {
+			t.Fatalf("unexpected copy %d: %+v", i, copied)
+		}
+	}
+	if copies[0].Rating != nil {
+		t.Fatal("expected the rating to stay with the original item")
+	}
+	if got := *copies[2].Content[0].SummarizedThrough; got != "msg_copy_2" {
+		t.Fatalf("expected the summary to cover the copied item, got %s", got)
+	}
+	if got := *rows[2].Content[0].SummarizedThrough; got != "msg_b" {
+		t.Fatalf("expected the source summary to be unchanged, got %s", got)
+	}
+}
+
+func TestCopyBranchItemsKeepsUncopiedSummaryTarget(t *testing.T) {
+	rows := []*dbschema.ConversationItem{
+		{PublicID: "msg_sum", Content: dbschema.JSONContent{conversation.NewSummaryTextContent("older turns", "msg_gone")}},
+	}
+
+	copies, err := copyBranchItems(rows, "EDIT_1", sequentialIDs())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := *copies[0].Content[0].SummarizedThrough; got != "msg_gone" {
+		t.Fatalf("expected the summary target to be kept, got %s", got)
+	}
+}
+
+func TestBranchOrMain(t *testing.T) {
+	if got := branchOrMain(""); got != conversation.BranchMain {
+		t.Fatalf("expected MAIN for an empty branch, got %s", got)
+	}
+	if got := branchOrMain("EDIT_1"); got != "EDIT_1" {
+		t.Fatalf("expected the named branch, got %s", got)
+	}
+}
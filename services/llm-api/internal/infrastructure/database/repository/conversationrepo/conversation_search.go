@@ -0,0 +1,150 @@
+package conversationrepo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gen/field"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+	"jan-server/services/llm-api/internal/domain/query"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// searchConfig is the text search configuration of the search_vector columns. It must match
+// the configuration used by the migration that creates them.
+const searchConfig = "simple"
+
+// searchHeadlineOptions wraps matched terms in <mark> and keeps snippets short
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// searchHitRow is a row of the conversation search query
+type searchHitRow struct {
+	ConversationID    string
+	ConversationTitle *string
+	ProjectPublicID   *string
+	ItemID            *string
+	Branch            *string
+	Role              *string
+	Snippet           string
+	Rank              float64
+	MatchedAt         time.Time
+}
+
+// Search implements conversation.ConversationRepository. Conversation titles and the text of
+// user and assistant messages in every branch are matched against the query and ranked
+// together; snippets are only highlighted for the returned page.
+func (repo *ConversationGormRepository) Search(ctx context.Context, filter conversation.ConversationSearchFilter, pagination *query.Pagination) ([]*conversation.SearchHit, error) {
+	q := repo.db.GetQuery(ctx)
+	sql, args := searchQuery(q.Conversation.TableName(), q.ConversationItem.TableName(), filter, pagination)
+
+	var rows []searchHitRow
+	if err := repo.db.GetTx(ctx).WithContext(ctx).Raw(sql, args).Scan(&rows).Error; err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to search conversations")
+	}
+
+	hits := make([]*conversation.SearchHit, 0, len(rows))
+	for _, row := range rows {
+		hit := &conversation.SearchHit{
+			ConversationID:    row.ConversationID,
+			ConversationTitle: row.ConversationTitle,
+			ProjectID:         row.ProjectPublicID,
+			ItemID:            row.ItemID,
+			Branch:            row.Branch,
+			Snippet:           row.Snippet,
+			Rank:              row.Rank,
+			MatchedAt:         row.MatchedAt,
+		}
+		if row.Role != nil {
+			role := conversation.ItemRole(*row.Role)
+			hit.Role = &role
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+// searchQuery builds the search SQL and its named arguments. Both the title and the item part
+// are limited to conversations of filter.UserID.
+func searchQuery(conversations, items string, filter conversation.ConversationSearchFilter, pagination *query.Pagination) (string, map[string]interface{}) {
+	args := map[string]interface{}{
+		"config":  searchConfig,
+		"query":   filter.Query,
+		"user_id": filter.UserID,
+		"options": searchHeadlineOptions,
+	}
+	var titleConds, itemConds []string
+	if filter.ProjectPublicID != nil {
+		args["project"] = *filter.ProjectPublicID
+		titleConds = append(titleConds, "c.project_public_id = @project")
+		itemConds = append(itemConds, "c.project_public_id = @project")
+	}
+	if filter.From != nil {
+		args["from"] = *filter.From
+		titleConds = append(titleConds, "c.updated_at >= @from")
+		itemConds = append(itemConds, "i.created_at >= @from")
+	}
+	if filter.To != nil {
+		args["to"] = *filter.To
+		titleConds = append(titleConds, "c.updated_at < @to")
+		itemConds = append(itemConds, "i.created_at < @to")
+	}
+
+	page := ""
+	if pagination != nil {
+		if pagination.Limit != nil && *pagination.Limit > 0 {
+			args["limit"] = *pagination.Limit
+			page += " LIMIT @limit"
+		}
+		if pagination.Offset != nil && *pagination.Offset > 0 {
+			args["offset"] = *pagination.Offset
+			page += " OFFSET @offset"
+		}
+	}
+
+	sql := fmt.Sprintf(`
+WITH terms AS (
+	SELECT websearch_to_tsquery(CAST(@config AS regconfig), @query) AS query
+),
+hits AS (
+	SELECT c.public_id AS conversation_id, c.title AS conversation_title, c.project_public_id,
+		CAST(NULL AS varchar) AS item_id, CAST(NULL AS varchar) AS branch, CAST(NULL AS varchar) AS role,
+		COALESCE(c.title, '') AS document, ts_rank(c.search_vector, terms.query) AS rank, c.updated_at AS matched_at
+	FROM %[1]s AS c, terms
+	WHERE c.user_id = @user_id AND c.deleted_at IS NULL AND c.search_vector @@ terms.query%[3]s
+	UNION ALL
+	SELECT c.public_id, c.title, c.project_public_id,
+		i.public_id, i.branch, i.role,
+		i.text_content, ts_rank(i.search_vector, terms.query), i.created_at
+	FROM %[2]s AS i
+	JOIN %[1]s AS c ON c.id = i.conversation_id, terms
+	WHERE c.user_id = @user_id AND c.deleted_at IS NULL AND i.deleted_at IS NULL
+		AND i.type = 'message' AND i.role IN ('user', 'assistant')
+		AND i.search_vector @@ terms.query%[4]s
+),
+page AS (
+	SELECT * FROM hits ORDER BY rank DESC, matched_at DESC%[5]s
+)
+SELECT page.conversation_id, page.conversation_title, page.project_public_id,
+	page.item_id, page.branch, page.role, page.rank, page.matched_at,
+	ts_headline(CAST(@config AS regconfig), page.document, terms.query, @options) AS snippet
+FROM page, terms
+ORDER BY page.rank DESC, page.matched_at DESC`,
+		conversations, items, andConditions(titleConds), andConditions(itemConds), page)
+	return sql, args
+}
+
+// andConditions joins extra WHERE conditions, each prefixed with AND
+func andConditions(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " AND " + strings.Join(conds, " AND ")
+}
+
+// itemSearchCondition matches items whose text contains the query, in web search syntax
+func itemSearchCondition(searchQuery string) field.Expr {
+	return field.NewUnsafeFieldRaw("search_vector @@ websearch_to_tsquery(CAST(? AS regconfig), ?)", searchConfig, searchQuery)
+}
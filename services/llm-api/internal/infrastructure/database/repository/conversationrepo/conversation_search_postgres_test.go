@@ -0,0 +1,222 @@
+package conversationrepo
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepostgres "github.com/golang-migrate/migrate/v4/database/postgres"
+	iofs "github.com/golang-migrate/migrate/v4/source/iofs"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+	"jan-server/services/llm-api/internal/infrastructure/database/transaction"
+	"jan-server/services/llm-api/internal/utils/ptr"
+	"jan-server/services/llm-api/migrations"
+)
+
+// testDatabaseEnv names a throwaway Postgres database for the tests that need one. Its llm_api
+// schema is dropped and migrated again by every test.
+const testDatabaseEnv = "LLM_API_TEST_DATABASE_URL"
+
+// openTestDatabase connects to the test database with an empty llm_api schema and returns a
+// migrator for it. The test is skipped with -short or when no test database is configured.
+func openTestDatabase(t *testing.T) (*gorm.DB, *migrate.Migrate) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("integration test")
+	}
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{TablePrefix: "llm_api."},
+		Logger:         gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	for _, statement := range []string{"DROP SCHEMA IF EXISTS llm_api CASCADE", "CREATE SCHEMA llm_api"} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("reset schema: %v", err)
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("migration connection: %v", err)
+	}
+	driver, err := migratepostgres.WithConnection(context.Background(), conn, &migratepostgres.Config{
+		MigrationsTable: "schema_migrations",
+		SchemaName:      "llm_api",
+	})
+	if err != nil {
+		t.Fatalf("migration driver: %v", err)
+	}
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		t.Fatalf("migration source: %v", err)
+	}
+	migrator, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = migrator.Close()
+		_ = sqlDB.Close()
+	})
+	return db, migrator
+}
+
+// insertUser stores a user and returns its ID
+func insertUser(t *testing.T, db *gorm.DB, subject string) uint {
+	t.Helper()
+	var id uint
+	if err := db.Raw("INSERT INTO llm_api.users (issuer, subject) VALUES ('test', ?) RETURNING id", subject).Scan(&id).Error; err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return id
+}
+
+// insertConversation stores a conversation of the user and returns its ID
+func insertConversation(t *testing.T, db *gorm.DB, userID uint, publicID, title string) uint {
+	t.Helper()
+	var id uint
+	if err := db.Raw("INSERT INTO llm_api.conversations (public_id, title, user_id) VALUES (?, ?, ?) RETURNING id", publicID, title, userID).Scan(&id).Error; err != nil {
+		t.Fatalf("insert conversation: %v", err)
+	}
+	return id
+}
+
+// insertMessage stores a message item with its plain text
+func insertMessage(t *testing.T, db *gorm.DB, conversationID uint, sequence int, publicID, role, text string) {
+	t.Helper()
+	err := db.Exec("INSERT INTO llm_api.conversation_items (conversation_id, public_id, sequence_number, type, role, text_content) VALUES (?, ?, ?, 'message', ?, ?)",
+		conversationID, publicID, sequence, role, text).Error
+	if err != nil {
+		t.Fatalf("insert item: %v", err)
+	}
+}
+
+func TestSearchPostgres(t *testing.T) {
+	db, migrator := openTestDatabase(t)
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	alice := insertUser(t, db, "alice")
+	bob := insertUser(t, db, "bob")
+	aliceConv := insertConversation(t, db, alice, "conv_alice", "Deploy checklist")
+	insertMessage(t, db, aliceConv, 1, "msg_question", "user", "how do I deploy the api")
+	insertMessage(t, db, aliceConv, 2, "msg_answer", "assistant", "deploy staging, deploy production, then deploy the workers")
+	insertMessage(t, db, aliceConv, 3, "msg_tool", "tool", "deploy output")
+	bobConv := insertConversation(t, db, bob, "conv_bob", "Deploy secrets")
+	insertMessage(t, db, bobConv, 1, "msg_bob", "assistant", "deploy with the vault token")
+
+	repo := NewConversationGormRepository(transaction.NewDatabase(db))
+	ctx := context.Background()
+
+	hits, err := repo.Search(ctx, conversation.ConversationSearchFilter{UserID: alice, Query: "deploy"}, nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 3 {
+		t.Fatalf("expected the title and both messages of alice, got %d hits", len(hits))
+	}
+	for _, hit := range hits {
+		if hit.ConversationID != "conv_alice" {
+			t.Fatalf("expected only alice's conversations, got %s", hit.ConversationID)
+		}
+	}
+
+	t.Run("ranking", func(t *testing.T) {
+		for i := 1; i < len(hits); i++ {
+			if hits[i].Rank > hits[i-1].Rank {
+				t.Fatalf("expected hits by descending rank, got %v after %v", hits[i].Rank, hits[i-1].Rank)
+			}
+		}
+		if hits[0].ItemID == nil || *hits[0].ItemID != "msg_answer" {
+			t.Fatalf("expected the message repeating the term first, got %+v", hits[0])
+		}
+	})
+
+	t.Run("highlighting", func(t *testing.T) {
+		for _, hit := range hits {
+			if !strings.Contains(strings.ToLower(hit.Snippet), "<mark>deploy</mark>") {
+				t.Fatalf("expected the term highlighted, got %q", hit.Snippet)
+			}
+		}
+	})
+
+	t.Run("isolation", func(t *testing.T) {
+		for _, term := range []string{"vault", "secrets"} {
+			hits, err := repo.Search(ctx, conversation.ConversationSearchFilter{UserID: alice, Query: term}, nil)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if len(hits) != 0 {
+				t.Fatalf("expected no hits on bob's conversation for %q, got %+v", term, hits[0])
+			}
+		}
+	})
+}
+
+func TestTextContentBackfillPostgres(t *testing.T) {
+	db, migrator := openTestDatabase(t)
+	// Items stored before text_content existed
+	if err := migrator.Migrate(6); err != nil {
+		t.Fatalf("migrate to 6: %v", err)
+	}
+
+	user := insertUser(t, db, "carol")
+	conv := insertConversation(t, db, user, "conv_carol", "Old chat")
+	items := map[string]string{
+		"msg_text":  `[{"type":"text","text":{"text":"kubernetes ingress"}}]`,
+		"msg_parts": `[{"type":"input_text","input_text":"first part"},{"type":"image"},{"type":"output_text","output_text":{"text":"second part","annotations":[]}}]`,
+		"msg_image": `[{"type":"image"}]`,
+	}
+	sequence := 0
+	for publicID, content := range items {
+		sequence++
+		err := db.Exec("INSERT INTO llm_api.conversation_items (conversation_id, public_id, sequence_number, type, role, content) VALUES (?, ?, ?, 'message', 'user', CAST(? AS jsonb))",
+			conv, publicID, sequence, content).Error
+		if err != nil {
+			t.Fatalf("insert item: %v", err)
+		}
+	}
+
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	want := map[string]*string{"msg_text": ptr.ToString("kubernetes ingress"), "msg_parts": ptr.ToString("first part\nsecond part"), "msg_image": nil}
+	for publicID, text := range want {
+		var got *string
+		if err := db.Raw("SELECT text_content FROM llm_api.conversation_items WHERE public_id = ?", publicID).Scan(&got).Error; err != nil {
+			t.Fatalf("read %s: %v", publicID, err)
+		}
+		if (got == nil) != (text == nil) || (got != nil && *got != *text) {
+			t.Fatalf("unexpected text content of %s: %v", publicID, got)
+		}
+	}
+
+	repo := NewConversationGormRepository(transaction.NewDatabase(db))
+	hits, err := repo.Search(context.Background(), conversation.ConversationSearchFilter{UserID: user, Query: "ingress"}, nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 1 || hits[0].ItemID == nil || *hits[0].ItemID != "msg_text" {
+		t.Fatalf("expected the backfilled item to be found, got %d hits", len(hits))
+	}
+}
@@ -0,0 +1,80 @@
+package conversationrepo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+	"jan-server/services/llm-api/internal/domain/query"
+)
+
+func TestSearchQueryIsLimitedToUser(t *testing.T) {
+	sql, args := searchQuery("llm_api.conversations", "llm_api.conversation_items", conversation.ConversationSearchFilter{UserID: 42, Query: "deploy"}, nil)
+
+	if args["user_id"] != uint(42) {
+		t.Fatalf("expected the user bound to the query, got %v", args["user_id"])
+	}
+	parts := strings.Split(sql, "UNION ALL")
+	if len(parts) != 2 {
+		t.Fatalf("expected a title and an item part, got %d parts", len(parts))
+	}
+	for i, part := range parts {
+		if !strings.Contains(part, "c.user_id = @user_id") || !strings.Contains(part, "c.deleted_at IS NULL") {
+			t.Fatalf("expected part %d to be limited to the user's conversations:\n%s", i, part)
+		}
+	}
+	if strings.Contains(sql, "LIMIT") || strings.Contains(sql, "OFFSET") {
+		t.Fatal("expected no pagination without one")
+	}
+}
+
+func TestSearchQueryAppliesFiltersToBothParts(t *testing.T) {
+	project := "proj_1"
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	limit, offset := 20, 40
+	sql, args := searchQuery("llm_api.conversations", "llm_api.conversation_items", conversation.ConversationSearchFilter{
+		UserID:          1,
+		Query:           `"release notes" -draft`,
+		ProjectPublicID: &project,
+		From:            &from,
+		To:              &to,
+	}, &query.Pagination{Limit: &limit, Offset: &offset})
+
+	if args["project"] != project || args["from"] != from || args["to"] != to || args["limit"] != limit || args["offset"] != offset {
+		t.Fatalf("unexpected arguments %v", args)
+	}
+	if args["query"] != `"release notes" -draft` {
+		t.Fatalf("expected the query passed as an argument, got %v", args["query"])
+	}
+	titles, items, _ := strings.Cut(sql, "UNION ALL")
+	for _, cond := range []string{"c.project_public_id = @project", "c.updated_at >= @from", "c.updated_at < @to"} {
+		if !strings.Contains(titles, cond) {
+			t.Fatalf("expected the title part to filter on %q", cond)
+		}
+	}
+	for _, cond := range []string{"c.project_public_id = @project", "i.created_at >= @from", "i.created_at < @to"} {
+		if !strings.Contains(items, cond) {
+			t.Fatalf("expected the item part to filter on %q", cond)
+		}
+	}
+	if !strings.Contains(sql, "ORDER BY rank DESC, matched_at DESC LIMIT @limit OFFSET @offset") {
+		t.Fatal("expected the page to be cut after ranking")
+	}
+}
+
+func TestSearchQueryHighlightsOnlyThePage(t *testing.T) {
+	sql, args := searchQuery("c", "i", conversation.ConversationSearchFilter{UserID: 1, Query: "go"}, nil)
+
+	if args["options"] != searchHeadlineOptions || !strings.Contains(searchHeadlineOptions, "StartSel=<mark>, StopSel=</mark>") {
+		t.Fatalf("expected matches wrapped in <mark>, got %v", args["options"])
+	}
+	ranked, page, ok := strings.Cut(sql, "page AS (")
+	if !ok || strings.Contains(ranked, "ts_headline(") || !strings.Contains(page, "ts_headline(") {
+		t.Fatal("expected snippets to be highlighted only for the page")
+	}
+	if args["config"] != searchConfig {
+		t.Fatalf("expected the %s text search configuration, got %v", searchConfig, args["config"])
+	}
+}
@@ -76,15 +76,10 @@ func (repo *ItemGormRepository) FindByConversationID(ctx context.Context, conver
 
 // Search implements conversation.ItemRepository.
 func (repo *ItemGormRepository) Search(ctx context.Context, conversationID uint, searchQuery string) ([]*conversation.Item, error) {
-	// For now, this is a simple implementation
-	// In production, you'd want to use full-text search or a search engine
 	q := repo.db.GetQuery(ctx)
 	sql := q.ConversationItem.WithContext(ctx)
 	sql = repo.applyFilter(q, sql, conversation.ItemFilter{ConversationID: &conversationID})
-
-	// Basic search - in production, enhance with proper full-text search
-	rows, err := sql.Order(q.ConversationItem.CreatedAt.Asc()).Find()
-
+	rows, err := sql.Where(itemSearchCondition(searchQuery)).Order(q.ConversationItem.CreatedAt.Asc()).Find()
 	if err != nil {
 		return nil, platformerrors.AsError(ctx, platformerrors.LayerRepository, err, "failed to search items")
 	}
@@ -92,8 +87,6 @@ func (repo *ItemGormRepository) Search(ctx context.Context, conversationID uint,
 	result := functional.Map(rows, func(item *dbschema.ConversationItem) *conversation.Item {
 		return item.EtoD()
 	})
-
-	// TODO: Implement proper full-text search filtering based on searchQuery
 	return result, nil
 }
 
@@ -200,6 +193,9 @@ func (repo *ItemGormRepository) applyFilter(q *gormgen.Query, sql gormgen.IConve
 	if filter.ConversationID != nil {
 		sql = sql.Where(q.ConversationItem.ConversationID.Eq(*filter.ConversationID))
 	}
+	if filter.Branch != nil {
+		sql = sql.Where(q.ConversationItem.Branch.Eq(*filter.Branch))
+	}
 	if filter.Role != nil {
 		roleStr := string(*filter.Role)
 		sql = sql.Where(q.ConversationItem.Role.Eq(roleStr))
@@ -207,6 +203,25 @@ func (repo *ItemGormRepository) applyFilter(q *gormgen.Query, sql gormgen.IConve
 	if filter.ResponseID != nil {
 		sql = sql.Where(q.ConversationItem.ResponseID.Eq(*filter.ResponseID))
 	}
+	if filter.Model != nil {
+		sql = sql.Where(q.ConversationItem.Model.Eq(*filter.Model))
+	}
+	if filter.Rating != nil {
+		sql = sql.Where(q.ConversationItem.Rating.Eq(string(*filter.Rating)))
+	}
+	if filter.Rated != nil {
+		if *filter.Rated {
+			sql = sql.Where(q.ConversationItem.Rating.IsNotNull())
+		} else {
+			sql = sql.Where(q.ConversationItem.Rating.IsNull())
+		}
+	}
+	if filter.RatedAfter != nil {
+		sql = sql.Where(q.ConversationItem.RatedAt.Gte(*filter.RatedAfter))
+	}
+	if filter.RatedBefore != nil {
+		sql = sql.Where(q.ConversationItem.RatedAt.Lt(*filter.RatedBefore))
+	}
 	return sql
 }
 
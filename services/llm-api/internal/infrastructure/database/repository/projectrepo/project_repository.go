@@ -123,12 +123,13 @@ func (repo *ProjectGormRepository) Update(ctx context.Context, proj *project.Pro
 	err := repo.db.WithContext(ctx).Model(&dbschema.Project{}).
 		Where("public_id = ?", proj.PublicID).
 		Updates(map[string]interface{}{
-			"name":         dbProject.Name,
-			"instruction":  dbProject.Instruction,
-			"favorite":     dbProject.Favorite,
-			"archived_at":  dbProject.ArchivedAt,
-			"last_used_at": dbProject.LastUsedAt,
-			"updated_at":   dbProject.UpdatedAt,
+			"name":                dbProject.Name,
+			"instruction":         dbProject.Instruction,
+			"instruction_version": dbProject.InstructionVersion,
+			"favorite":            dbProject.Favorite,
+			"archived_at":         dbProject.ArchivedAt,
+			"last_used_at":        dbProject.LastUsedAt,
+			"updated_at":          dbProject.UpdatedAt,
 		}).Error
 
 	if err != nil {
@@ -0,0 +1,69 @@
+package inference
+
+import (
+	"sync"
+
+	domainmodel "jan-server/services/llm-api/internal/domain/model"
+	chatclient "jan-server/services/llm-api/internal/utils/httpclients/chat"
+
+	"resty.dev/v3"
+)
+
+// ChatCompleterFactory builds the ChatCompleter for a provider. client is preconfigured with the
+// provider's base URL and authentication headers.
+type ChatCompleterFactory func(provider *domainmodel.Provider, client *resty.Client) chatclient.ChatCompleter
+
+// ChatCompleterRegistry selects the ChatCompleter implementation for each provider kind.
+// Kinds without a registration use the OpenAI-compatible client.
+type ChatCompleterRegistry struct {
+	mu        sync.RWMutex
+	factories map[domainmodel.ProviderKind]ChatCompleterFactory
+	fallback  ChatCompleterFactory
+}
+
+func NewChatCompleterRegistry() *ChatCompleterRegistry {
+	registry := &ChatCompleterRegistry{
+		factories: make(map[domainmodel.ProviderKind]ChatCompleterFactory),
+		fallback:  openAICompatibleCompleter,
+	}
+	registry.Register(domainmodel.ProviderAnthropic, nativeCompleter(func() chatclient.Adapter {
+		return chatclient.NewAnthropicAdapter()
+	}))
+	registry.Register(domainmodel.ProviderGoogle, nativeCompleter(func() chatclient.Adapter {
+		return chatclient.NewGeminiAdapter()
+	}))
+	return registry
+}
+
+// Register sets the factory used for providers of the given kind, replacing any existing one.
+func (r *ChatCompleterRegistry) Register(kind domainmodel.ProviderKind, factory ChatCompleterFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[kind] = factory
+}
+
+// Factory returns the factory registered for the kind, or the OpenAI-compatible fallback.
+func (r *ChatCompleterRegistry) Factory(kind domainmodel.ProviderKind) ChatCompleterFactory {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if factory, ok := r.factories[kind]; ok {
+		return factory
+	}
+	return r.fallback
+}
+
+func openAICompatibleCompleter(provider *domainmodel.Provider, client *resty.Client) chatclient.ChatCompleter {
+	return chatclient.NewChatCompletionClient(client, provider.DisplayName, provider.BaseURL)
+}
+
+// nativeCompleter uses the vendor adapter unless the provider is configured for its
+// OpenAI-compatible endpoint.
+func nativeCompleter(newAdapter func() chatclient.Adapter) ChatCompleterFactory {
+	return func(provider *domainmodel.Provider, client *resty.Client) chatclient.ChatCompleter {
+		completer := chatclient.NewChatCompletionClient(client, provider.DisplayName, provider.BaseURL)
+		if provider.APIFormat() == domainmodel.APIFormatNative {
+			completer.WithAdapter(newAdapter())
+		}
+		return completer
+	}
+}
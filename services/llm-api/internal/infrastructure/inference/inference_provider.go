@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"jan-server/services/llm-api/internal/config"
 	domainmodel "jan-server/services/llm-api/internal/domain/model"
@@ -15,39 +16,42 @@ import (
 	"resty.dev/v3"
 )
 
-type InferenceProvider struct{}
-
-func NewInferenceProvider() *InferenceProvider {
-	return &InferenceProvider{}
+type InferenceProvider struct {
+	healthTracker *domainmodel.ProviderHealthTracker
+	completers    *ChatCompleterRegistry
 }
 
-func (ip *InferenceProvider) GetChatCompletionClient(ctx context.Context, provider *domainmodel.Provider) (*chatclient.ChatCompletionClient, error) {
-	client, err := ip.createRestyClient(ctx, provider)
-	if err != nil {
-		return nil, err
+func NewInferenceProvider(healthTracker *domainmodel.ProviderHealthTracker, completers *ChatCompleterRegistry) *InferenceProvider {
+	return &InferenceProvider{
+		healthTracker: healthTracker,
+		completers:    completers,
 	}
-
-	clientName := provider.DisplayName
-	return chatclient.NewChatCompletionClient(client, clientName, provider.BaseURL), nil
 }
 
-func (ip *InferenceProvider) GetChatModelClient(ctx context.Context, provider *domainmodel.Provider) (*chatclient.ChatModelClient, error) {
+// GetChatCompleter returns the upstream client registered for the provider's kind, reporting
+// call outcomes to the provider health tracker.
+func (ip *InferenceProvider) GetChatCompleter(ctx context.Context, provider *domainmodel.Provider) (chatclient.ChatCompleter, error) {
 	client, err := ip.createRestyClient(ctx, provider)
 	if err != nil {
 		return nil, err
 	}
 
-	clientName := provider.DisplayName
-	return chatclient.NewChatModelClient(client, clientName, provider.BaseURL), nil
+	providerID := provider.ID
+	return &observedCompleter{
+		ChatCompleter: ip.completers.Factory(provider.Kind)(provider, client),
+		observe: func(duration time.Duration, err error) {
+			ip.healthTracker.RecordCall(providerID, duration, err)
+		},
+	}, nil
 }
 
 func (ip *InferenceProvider) ListModels(ctx context.Context, provider *domainmodel.Provider) ([]chatclient.Model, error) {
-	modelClient, err := ip.GetChatModelClient(ctx, provider)
+	completer, err := ip.GetChatCompleter(ctx, provider)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := modelClient.ListModels(ctx)
+	resp, err := completer.ListModels(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -73,6 +77,12 @@ func (ip *InferenceProvider) createRestyClient(ctx context.Context, provider *do
 			case domainmodel.ProviderAnthropic:
 				client.SetHeader("X-API-Key", apiKey)
 				client.SetHeader("Anthropic-Version", "2023-06-01")
+			case domainmodel.ProviderGoogle:
+				if provider.APIFormat() == domainmodel.APIFormatNative {
+					client.SetHeader("X-Goog-Api-Key", apiKey)
+				} else {
+					client.SetHeader("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+				}
 			case domainmodel.ProviderCohere:
 				client.SetHeader("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 			default:
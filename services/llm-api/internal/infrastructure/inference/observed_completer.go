@@ -0,0 +1,54 @@
+package inference
+
+import (
+	"context"
+	"time"
+
+	chatclient "jan-server/services/llm-api/internal/utils/httpclients/chat"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// observedCompleter reports the outcome of every completion call made through the wrapped
+// ChatCompleter, e.g. to feed the provider circuit breaker.
+type observedCompleter struct {
+	chatclient.ChatCompleter
+	observe func(duration time.Duration, err error)
+}
+
+func (c *observedCompleter) CreateChatCompletion(ctx context.Context, apiKey string, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	start := time.Now()
+	resp, err := c.ChatCompleter.CreateChatCompletion(ctx, apiKey, request)
+	c.observe(time.Since(start), err)
+	return resp, err
+}
+
+func (c *observedCompleter) CreateChatCompletionStream(ctx context.Context, apiKey string, request openai.ChatCompletionRequest, opts ...chatclient.StreamOption) (<-chan chatclient.StreamChunk, error) {
+	start := time.Now()
+	chunks, err := c.ChatCompleter.CreateChatCompletionStream(ctx, apiKey, request, opts...)
+	if err != nil {
+		c.observe(time.Since(start), err)
+		return nil, err
+	}
+
+	observed := make(chan chatclient.StreamChunk, cap(chunks))
+	go func() {
+		defer close(observed)
+
+		var streamErr error
+		for chunk := range chunks {
+			if chunk.Err != nil {
+				streamErr = chunk.Err
+			}
+			select {
+			case observed <- chunk:
+			case <-ctx.Done():
+			}
+		}
+		if streamErr == nil {
+			streamErr = ctx.Err()
+		}
+		c.observe(time.Since(start), streamErr)
+	}()
+	return observed, nil
+}
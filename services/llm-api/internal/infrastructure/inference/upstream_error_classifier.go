@@ -0,0 +1,22 @@
+package inference
+
+import (
+	domainmodel "jan-server/services/llm-api/internal/domain/model"
+	chatclient "jan-server/services/llm-api/internal/utils/httpclients/chat"
+)
+
+// upstreamErrorClassifier classifies chat client errors for the provider circuit breaker.
+type upstreamErrorClassifier struct{}
+
+// NewUpstreamErrorClassifier returns the classifier for errors of the chat clients.
+func NewUpstreamErrorClassifier() domainmodel.UpstreamErrorClassifier {
+	return upstreamErrorClassifier{}
+}
+
+func (upstreamErrorClassifier) IsProviderFailure(err error) bool {
+	return chatclient.IsRetryableError(err)
+}
+
+func (upstreamErrorClassifier) IsTimeout(err error) bool {
+	return chatclient.IsTimeoutError(err)
+}
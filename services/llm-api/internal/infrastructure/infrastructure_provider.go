@@ -130,7 +130,9 @@ var InfrastructureProvider = wire.NewSet(
 	repository.RepositoryProvider,
 
 	// Provider registry
+	inference.NewChatCompleterRegistry,
 	inference.NewInferenceProvider,
+	inference.NewUpstreamErrorClassifier,
 
 	// Media resolver
 	ProvideMediaResolver,
@@ -753,6 +753,7 @@ func (c *Client) RemoveAPIKeyHash(ctx context.Context, userID, keyID string) err
 
 // APIKeyUserInfo represents validated user information from API key
 type APIKeyUserInfo struct {
+	APIKeyID  string   `json:"api_key_id,omitempty"` // the validated key; the gateway forwards it as X-API-Key-ID
 	UserID    string   `json:"user_id"`
 	Subject   string   `json:"subject"`
 	Username  string   `json:"username"`
@@ -3,7 +3,6 @@ package chathandler
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"strings"
 	"time"
 
@@ -13,6 +12,9 @@ import (
 	"go.opentelemetry.io/otel/codes"
 
 	"jan-server/services/llm-api/internal/domain/conversation"
+	domainmodel "jan-server/services/llm-api/internal/domain/model"
+	"jan-server/services/llm-api/internal/domain/project"
+	"jan-server/services/llm-api/internal/domain/query"
 	"jan-server/services/llm-api/internal/infrastructure/inference"
 	"jan-server/services/llm-api/internal/infrastructure/logger"
 	"jan-server/services/llm-api/internal/infrastructure/mediaresolver"
@@ -24,15 +26,18 @@ import (
 	"jan-server/services/llm-api/internal/utils/httpclients/chat"
 	"jan-server/services/llm-api/internal/utils/idgen"
 	"jan-server/services/llm-api/internal/utils/platformerrors"
+	"jan-server/services/llm-api/internal/utils/tokenizer"
 )
 
 const ConversationReferrerContextKey = "conversation_referrer"
 
 // ChatCompletionResult wraps the response with conversation context
 type ChatCompletionResult struct {
-	Response          *openai.ChatCompletionResponse
-	ConversationID    string
-	ConversationTitle *string
+	Response           *openai.ChatCompletionResponse
+	ConversationID     string
+	ConversationTitle  *string
+	ConversationBranch string // Active branch the completion was stored in
+	UsageEstimated     bool   // Usage was counted locally with an approximate tokenizer
 }
 
 // ChatHandler handles chat completion requests
@@ -41,6 +46,9 @@ type ChatHandler struct {
 	providerHandler     *modelHandler.ProviderHandler
 	conversationHandler *conversationHandler.ConversationHandler
 	conversationService *conversation.ConversationService
+	projectService      *project.ProjectService
+	modelCatalogService *domainmodel.ModelCatalogService
+	summarizer          *ConversationSummarizer
 	mediaResolver       mediaresolver.Resolver
 }
 
@@ -50,6 +58,9 @@ func NewChatHandler(
 	providerHandler *modelHandler.ProviderHandler,
 	conversationHandler *conversationHandler.ConversationHandler,
 	conversationService *conversation.ConversationService,
+	projectService *project.ProjectService,
+	modelCatalogService *domainmodel.ModelCatalogService,
+	summarizer *ConversationSummarizer,
 	mediaResolver mediaresolver.Resolver,
 ) *ChatHandler {
 	return &ChatHandler{
@@ -57,6 +68,9 @@ func NewChatHandler(
 		providerHandler:     providerHandler,
 		conversationHandler: conversationHandler,
 		conversationService: conversationService,
+		projectService:      projectService,
+		modelCatalogService: modelCatalogService,
+		summarizer:          summarizer,
 		mediaResolver:       mediaResolver,
 	}
 }
@@ -85,6 +99,8 @@ func (h *ChatHandler) CreateChatCompletion(
 
 	var conv *conversation.Conversation
 	var conversationID string
+	var window *promptWindow
+	var historyStrategy conversation.HistoryStrategy
 	var err error
 	newMessages := append([]openai.ChatCompletionMessage(nil), request.Messages...)
 
@@ -111,12 +127,24 @@ func (h *ChatHandler) CreateChatCompletion(
 		// Auto-generate title from first message if conversation was just created
 		conv = h.updateConversationTitleFromMessages(ctx, userID, conv, request.Messages)
 
-		// Prepend conversation items to messages
 		conversationID = conv.PublicID
 		observability.AddSpanAttributes(ctx,
 			attribute.String("conversation.id", conversationID),
 		)
-		request.Messages = h.prependConversationItems(conv, request.Messages)
+
+		historyStrategy, err = h.resolveHistoryStrategy(ctx, conv, request.History)
+		if err != nil {
+			observability.RecordError(ctx, err)
+			return nil, err
+		}
+
+		// Prepend conversation items to messages, pinning the system message with the project instruction
+		summary, history := h.conversationHistory(ctx, conv)
+		conversationWindow := newPromptWindow(history, request.Messages)
+		conversationWindow.summary = summary
+		h.applyProjectInstruction(ctx, userID, conv, &conversationWindow)
+		window = &conversationWindow
+		request.Messages = window.messages()
 	}
 	// If no conversation.id exists, bypass as non-conversation completion
 
@@ -127,58 +155,85 @@ func (h *ChatHandler) CreateChatCompletion(
 		return nil, err
 	}
 
-	// Get provider based on the requested model
+	// Get providers based on the requested model, best first
 	observability.AddSpanEvent(ctx, "selecting_provider")
-	selectedProviderModel, selectedProvider, err := h.providerHandler.SelectProviderModelForModelPublicID(ctx, request.Model)
+	selections, err := h.providerHandler.SelectProviderModelsForModelPublicID(ctx, request.Model, conversationID)
 	if err != nil {
 		observability.RecordError(ctx, err)
 		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to select provider model")
 	}
 
-	if selectedProviderModel == nil {
-		err := platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeNotFound, fmt.Sprintf("model not found: %s", request.Model), nil, "")
-		observability.RecordError(ctx, err)
-		return nil, err
-	}
+	var response *openai.ChatCompletionResponse
+	var usageEstimated bool
+	var llmDuration time.Duration
+	var servingProviderID string
+	attemptedProviders := make([]string, 0, len(selections))
 
-	if selectedProvider == nil {
-		err := platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeNotFound, "provider not found", nil, "")
-		observability.RecordError(ctx, err)
-		return nil, err
-	}
+	// Call the selected provider, failing over to the next-ranked one on retryable upstream errors
+	observability.AddSpanEvent(ctx, "calling_llm")
+	for i, selection := range selections {
+		selectedProvider := selection.Provider
+		selectedProviderModel := selection.ProviderModel
+		attemptedProviders = append(attemptedProviders, selectedProvider.PublicID)
+		servingProviderID = selectedProvider.PublicID
 
-	// Add provider information to span
-	observability.AddSpanAttributes(ctx,
-		attribute.String("provider.display_name", selectedProvider.DisplayName),
-		attribute.String("provider.id", selectedProvider.PublicID),
-		attribute.String("provider.kind", string(selectedProvider.Kind)),
-		attribute.String("model.original_id", selectedProviderModel.ProviderOriginalModelID),
-	)
+		// Add provider information to span
+		observability.AddSpanAttributes(ctx,
+			attribute.String("provider.display_name", selectedProvider.DisplayName),
+			attribute.String("provider.id", selectedProvider.PublicID),
+			attribute.String("provider.kind", string(selectedProvider.Kind)),
+			attribute.String("model.original_id", selectedProviderModel.ProviderOriginalModelID),
+			attribute.StringSlice("provider.attempted", attemptedProviders),
+			attribute.Int("provider.attempt_count", len(attemptedProviders)),
+		)
 
-	// Override the request model with the provider's original model ID
-	request.Model = selectedProviderModel.ProviderOriginalModelID
+		// Override the request model with the provider's original model ID
+		providerRequest := request.ChatCompletionRequest
+		providerRequest.Model = selectedProviderModel.ProviderOriginalModelID
 
-	// Resolve jan_* media placeholders (best-effort)
-	request.Messages = h.resolveMediaPlaceholders(ctx, reqCtx, request.Messages)
+		// Get the upstream chat completer for the provider
+		chatClient, clientErr := h.inferenceProvider.GetChatCompleter(ctx, selectedProvider)
+		if clientErr != nil {
+			observability.RecordError(ctx, clientErr)
+			return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, clientErr, "failed to create chat client")
+		}
 
-	// Get chat completion client
-	chatClient, err := h.inferenceProvider.GetChatCompletionClient(ctx, selectedProvider)
-	if err != nil {
-		observability.RecordError(ctx, err)
-		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to create chat client")
-	}
+		// Fit conversation history into this model's context window
+		tok := h.modelTokenizer(ctx, selectedProviderModel)
+		if window != nil {
+			summarize := h.historySummarizer(ctx, chatClient, providerRequest.Model)
+			providerRequest.Messages = h.fitContextWindow(ctx, tok, *window, historyStrategy, selectedProviderModel, providerRequest, summarize)
+		}
 
-	var response *openai.ChatCompletionResponse
+		// Resolve jan_* media placeholders (best-effort)
+		providerRequest.Messages = h.resolveMediaPlaceholders(ctx, reqCtx, providerRequest.Messages)
 
-	// Handle streaming vs non-streaming
-	observability.AddSpanEvent(ctx, "calling_llm")
-	llmStartTime := time.Now()
-	if request.Stream {
-		response, err = h.streamCompletion(ctx, reqCtx, chatClient, conv, request.ChatCompletionRequest)
-	} else {
-		response, err = h.callCompletion(ctx, chatClient, request.ChatCompletionRequest)
+		endProviderCall := h.providerHandler.BeginProviderCall(selectedProvider.ID)
+		llmStartTime := time.Now()
+		if request.Stream {
+			response, usageEstimated, err = h.streamCompletion(ctx, reqCtx, chatClient, tok, conv, providerRequest)
+		} else {
+			response, usageEstimated, err = h.callCompletion(ctx, chatClient, tok, providerRequest)
+		}
+		llmDuration = time.Since(llmStartTime)
+		endProviderCall(llmDuration, err)
+
+		if err == nil || i == len(selections)-1 || !h.canFailover(ctx, reqCtx, request.Stream, err) {
+			break
+		}
+
+		log := logger.GetLogger()
+		log.Warn().
+			Err(err).
+			Str("provider_id", selectedProvider.PublicID).
+			Str("next_provider_id", selections[i+1].Provider.PublicID).
+			Str("model", selectedProviderModel.ModelPublicID).
+			Msg("upstream provider failed, failing over to next provider")
+		observability.AddSpanEvent(ctx, "provider_failover",
+			attribute.String("provider.id", selectedProvider.PublicID),
+			attribute.String("error", err.Error()),
+		)
 	}
-	llmDuration := time.Since(llmStartTime)
 
 	if err != nil {
 		observability.RecordError(ctx, err)
@@ -194,6 +249,7 @@ func (h *ChatHandler) CreateChatCompletion(
 			attribute.Int("completion.prompt_tokens", response.Usage.PromptTokens),
 			attribute.Int("completion.completion_tokens", response.Usage.CompletionTokens),
 			attribute.Int("completion.total_tokens", response.Usage.TotalTokens),
+			attribute.Bool("completion.usage_estimated", usageEstimated),
 			attribute.Float64("completion.llm_duration_ms", float64(llmDuration.Milliseconds())),
 			attribute.String("completion.status", "success"),
 		)
@@ -236,7 +292,8 @@ func (h *ChatHandler) CreateChatCompletion(
 			storeReasoning = *request.StoreReasoning
 		}
 
-		if err := h.addCompletionToConversation(ctx, conv, newMessages, response, askItemID, completionItemID, storeReasoning); err != nil {
+		servedBy := completionSource{model: request.Model, providerID: servingProviderID}
+		if err := h.addCompletionToConversation(ctx, conv, newMessages, response, servedBy, askItemID, completionItemID, storeReasoning); err != nil {
 			// Log error but don't fail the request
 			log := logger.GetLogger()
 			log.Warn().
@@ -250,6 +307,9 @@ func (h *ChatHandler) CreateChatCompletion(
 			observability.AddSpanAttributes(ctx,
 				attribute.Bool("completion.stored", true),
 			)
+
+			// Fold older turns into the rolling summary once the conversation grows long
+			h.summarizer.Schedule(conv)
 		}
 	}
 
@@ -262,48 +322,75 @@ func (h *ChatHandler) CreateChatCompletion(
 	// Set span status to OK
 	observability.SetSpanStatus(ctx, codes.Ok, "chat completion successful")
 
-	// Prepare conversation title for response
+	// Prepare conversation title and branch for response
 	var conversationTitle *string
-	if conv != nil && conv.Title != nil {
+	var conversationBranch string
+	if conv != nil {
 		conversationTitle = conv.Title
+		conversationBranch = conv.GetActiveBranch()
 	}
 
 	return &ChatCompletionResult{
-		Response:          response,
-		ConversationID:    conversationID,
-		ConversationTitle: conversationTitle,
+		Response:           response,
+		ConversationID:     conversationID,
+		ConversationTitle:  conversationTitle,
+		ConversationBranch: conversationBranch,
+		UsageEstimated:     usageEstimated,
 	}, nil
 }
 
-// callCompletion handles non-streaming chat completion
+// canFailover reports whether a failed upstream call may be retried on another provider.
+// Streaming requests can only fail over while nothing has been written to the client yet.
+func (h *ChatHandler) canFailover(ctx context.Context, reqCtx *gin.Context, stream bool, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if reqCtx != nil && reqCtx.Request != nil && reqCtx.Request.Context().Err() != nil {
+		return false
+	}
+	if stream && reqCtx != nil && reqCtx.Writer.Written() {
+		return false
+	}
+	return chat.IsRetryableError(err)
+}
+
+// callCompletion handles non-streaming chat completion. Usage missing from the upstream response
+// is counted with tok; the returned flag reports whether that count is an estimate.
 func (h *ChatHandler) callCompletion(
 	ctx context.Context,
-	chatClient *chat.ChatCompletionClient,
+	chatClient chat.ChatCompleter,
+	tok tokenizer.Tokenizer,
 	request openai.ChatCompletionRequest,
-) (*openai.ChatCompletionResponse, error) {
+) (*openai.ChatCompletionResponse, bool, error) {
 	chatCompletion, err := chatClient.CreateChatCompletion(ctx, "", request)
 	if err != nil {
-		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "chat completion failed")
+		return nil, false, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "chat completion failed")
 	}
 
-	return chatCompletion, nil
+	usageEstimated := false
+	if chatCompletion.Usage.TotalTokens == 0 && len(chatCompletion.Choices) > 0 {
+		chatCompletion.Usage, usageEstimated = chat.CountUsage(tok, request, chatCompletion.Choices[0].Message)
+	}
+	return chatCompletion, usageEstimated, nil
 }
 
 // streamCompletion handles streaming chat completion
 func (h *ChatHandler) streamCompletion(
 	ctx context.Context,
 	reqCtx *gin.Context,
-	chatClient *chat.ChatCompletionClient,
+	chatClient chat.ChatCompleter,
+	tok tokenizer.Tokenizer,
 	conv *conversation.Conversation,
 	request openai.ChatCompletionRequest,
-) (*openai.ChatCompletionResponse, error) {
+) (*openai.ChatCompletionResponse, bool, error) {
 	// Create callback to send conversation data before [DONE]
 	var beforeDoneCallback chat.BeforeDoneCallback
 	if conv != nil && conv.PublicID != "" {
 		beforeDoneCallback = func(reqCtx *gin.Context) error {
-			// Build conversation data with ID and title
+			// Build conversation data with ID, branch and title
 			conversationData := map[string]interface{}{
-				"id": conv.PublicID,
+				"id":     conv.PublicID,
+				"branch": conv.GetActiveBranch(),
 			}
 
 			// Include title if available
@@ -330,12 +417,12 @@ func (h *ChatHandler) streamCompletion(
 	}
 
 	// Stream completion response to context with callback
-	resp, err := chatClient.StreamChatCompletionToContextWithCallback(reqCtx, "", request, beforeDoneCallback)
+	resp, usageEstimated, err := chat.StreamToContext(reqCtx, chatClient, "", request, tok, beforeDoneCallback)
 	if err != nil {
-		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "streaming completion failed")
+		return nil, false, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "streaming completion failed")
 	}
 
-	return resp, nil
+	return resp, usageEstimated, nil
 }
 
 func (h *ChatHandler) resolveMediaPlaceholders(ctx context.Context, reqCtx *gin.Context, messages []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
@@ -472,38 +559,209 @@ func (h *ChatHandler) getOrCreateConversation(
 	return conv, nil
 }
 
-// prependConversationItems prepends conversation items to the request messages
-func (h *ChatHandler) prependConversationItems(
-	conv *conversation.Conversation,
-	messages []openai.ChatCompletionMessage,
-) []openai.ChatCompletionMessage {
+// conversationHistory converts the items of the conversation's active branch to chat messages. When
+// the branch has a rolling summary, only the summary and the turns after it are returned.
+func (h *ChatHandler) conversationHistory(ctx context.Context, conv *conversation.Conversation) (*openai.ChatCompletionMessage, []openai.ChatCompletionMessage) {
 	if conv == nil {
-		return messages
+		return nil, nil
 	}
 
-	// Get items from the active branch or main branch
-	var items []conversation.Item
-	if conv.Branches != nil && conv.ActiveBranch != "" {
-		items = conv.Branches[conv.ActiveBranch]
-	} else {
-		items = conv.Items
+	branch := conv.GetActiveBranch()
+	items := conv.GetBranchItems(branch)
+	if len(items) == 0 && conv.ID != 0 && h.conversationService != nil {
+		loaded, err := h.conversationService.GetConversationItems(ctx, conv, branch, &query.Pagination{Order: "asc"})
+		if err != nil {
+			log := logger.GetLogger()
+			log.Warn().
+				Err(err).
+				Str("conversation_id", conv.PublicID).
+				Str("branch", branch).
+				Msg("failed to load conversation history")
+			return nil, nil
+		}
+		items = loaded
 	}
 
-	if len(items) == 0 {
-		return messages
+	summaryItem, items := conversation.SplitAtLatestSummary(items)
+
+	var summary *openai.ChatCompletionMessage
+	if summaryItem != nil {
+		observability.AddSpanAttributes(ctx,
+			attribute.String("conversation.summary_item_id", summaryItem.PublicID),
+		)
+		summary = &openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: "Summary of the earlier conversation:\n" + summaryItem.SummaryText(),
+		}
 	}
 
 	// Convert conversation items to chat messages
 	conversationMessages := make([]openai.ChatCompletionMessage, 0, len(items))
 	for _, item := range items {
 		msg := h.itemToMessage(item)
-		if msg != nil {
-			conversationMessages = append(conversationMessages, *msg)
+		if msg == nil {
+			continue
+		}
+		// function_call items continue the assistant message that requested them
+		if item.Type == conversation.ItemTypeFunctionCall && len(conversationMessages) > 0 {
+			last := &conversationMessages[len(conversationMessages)-1]
+			if last.Role == openai.ChatMessageRoleAssistant {
+				last.ToolCalls = append(last.ToolCalls, msg.ToolCalls...)
+				continue
+			}
 		}
+		conversationMessages = append(conversationMessages, *msg)
+	}
+	return summary, conversationMessages
+}
+
+// applyProjectInstruction merges the instruction of the conversation's project into the pinned
+// system message and snapshots the merged text on the conversation. Failures are logged and the
+// window is left unchanged so a project lookup never blocks the completion.
+func (h *ChatHandler) applyProjectInstruction(
+	ctx context.Context,
+	userID uint,
+	conv *conversation.Conversation,
+	window *promptWindow,
+) {
+	if h.projectService == nil || conv == nil || conv.ProjectPublicID == nil || *conv.ProjectPublicID == "" {
+		return
 	}
 
-	// Prepend conversation messages to request messages
-	return append(conversationMessages, messages...)
+	proj, err := h.projectService.GetProjectByPublicIDAndUserID(ctx, *conv.ProjectPublicID, userID)
+	if err != nil {
+		log := logger.GetLogger()
+		log.Warn().
+			Err(err).
+			Str("conversation_id", conv.PublicID).
+			Str("project_id", *conv.ProjectPublicID).
+			Msg("failed to load project instruction")
+		return
+	}
+	if proj.Instruction == nil || strings.TrimSpace(*proj.Instruction) == "" {
+		return
+	}
+
+	window.mergeInstruction(strings.TrimSpace(*proj.Instruction))
+	observability.AddSpanAttributes(ctx,
+		attribute.String("project.id", proj.PublicID),
+		attribute.Int("project.instruction_version", proj.InstructionVersion),
+	)
+
+	if conv.RecordInstructionSnapshot(proj.InstructionVersion, window.system.Content) {
+		if err := h.conversationService.UpdateInstructionSnapshot(ctx, conv); err != nil {
+			log := logger.GetLogger()
+			log.Warn().
+				Err(err).
+				Str("conversation_id", conv.PublicID).
+				Int("instruction_version", proj.InstructionVersion).
+				Msg("failed to store instruction snapshot")
+		} else {
+			observability.AddSpanEvent(ctx, "instruction_snapshot_updated")
+		}
+	}
+}
+
+// modelTokenizer returns the tokenizer for a provider model, keyed by the tokenizer family of its
+// catalog architecture when the model is cataloged.
+func (h *ChatHandler) modelTokenizer(ctx context.Context, providerModel *domainmodel.ProviderModel) tokenizer.Tokenizer {
+	family := ""
+	if providerModel.ModelCatalogID != nil && h.modelCatalogService != nil {
+		catalog, err := h.modelCatalogService.FindByID(ctx, *providerModel.ModelCatalogID)
+		if err != nil {
+			log := logger.GetLogger()
+			log.Warn().
+				Err(err).
+				Str("model", providerModel.ModelPublicID).
+				Msg("failed to load model catalog for tokenizer")
+		} else if catalog != nil {
+			family = catalog.Architecture.Tokenizer
+		}
+	}
+	return tokenizer.ForArchitecture(family, providerModel.ProviderOriginalModelID)
+}
+
+// fitContextWindow fits the prompt window into the context length of the provider model using the
+// model's tokenizer.
+func (h *ChatHandler) fitContextWindow(
+	ctx context.Context,
+	tok tokenizer.Tokenizer,
+	window promptWindow,
+	strategy conversation.HistoryStrategy,
+	providerModel *domainmodel.ProviderModel,
+	request openai.ChatCompletionRequest,
+	summarize historySummarizer,
+) []openai.ChatCompletionMessage {
+	budget := promptTokenBudget(tok, providerModel.TokenLimits, request)
+	fit := fitPromptWindow(tok, window, strategy, budget, summarize)
+
+	observability.AddSpanAttributes(ctx,
+		attribute.String("context.strategy", string(strategy)),
+		attribute.String("context.tokenizer", tok.Name()),
+		attribute.Int("context.budget_tokens", budget),
+		attribute.Int("context.prompt_tokens", fit.promptTokens),
+		attribute.Int("context.history_dropped", fit.dropped),
+		attribute.Bool("context.history_summarized", fit.summarized),
+	)
+	if fit.summaryErr != nil {
+		log := logger.GetLogger()
+		log.Warn().
+			Err(fit.summaryErr).
+			Str("model", providerModel.ProviderOriginalModelID).
+			Int("history_dropped", fit.dropped).
+			Msg("failed to summarize dropped conversation history, sending excerpts")
+	}
+	if budget > 0 && fit.promptTokens > budget && strategy != conversation.HistoryStrategyFull {
+		log := logger.GetLogger()
+		log.Warn().
+			Str("model", providerModel.ProviderOriginalModelID).
+			Int("prompt_tokens", fit.promptTokens).
+			Int("budget_tokens", budget).
+			Msg("prompt exceeds context window after dropping conversation history")
+	}
+	return fit.messages
+}
+
+// historySummarizer summarizes dropped history with the configured summary model, or with the model
+// serving the request when none is configured.
+func (h *ChatHandler) historySummarizer(ctx context.Context, completer chat.ChatCompleter, model string) historySummarizer {
+	return func(dropped []openai.ChatCompletionMessage, maxTokens int) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, historySummaryTimeout)
+		defer cancel()
+
+		prompt := historySummaryPrompt(dropped)
+		if h.summarizer.Enabled() {
+			return h.summarizer.complete(ctx, prompt, maxTokens)
+		}
+		return requestSummary(ctx, completer, model, prompt, maxTokens)
+	}
+}
+
+// resolveHistoryStrategy returns the history strategy from the request, falling back to the
+// conversation metadata.
+func (h *ChatHandler) resolveHistoryStrategy(
+	ctx context.Context,
+	conv *conversation.Conversation,
+	options *chatrequests.HistoryOptions,
+) (conversation.HistoryStrategy, error) {
+	if options != nil && strings.TrimSpace(options.Strategy) != "" {
+		strategy, err := conversation.ParseHistoryStrategy(options.Strategy)
+		if err != nil {
+			return "", platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, err.Error(), err, "")
+		}
+		return strategy, nil
+	}
+
+	strategy, err := conv.HistoryStrategy()
+	if err != nil {
+		log := logger.GetLogger()
+		log.Warn().
+			Err(err).
+			Str("conversation_id", conv.PublicID).
+			Msg("ignoring invalid conversation history strategy")
+		return conversation.HistoryStrategyTruncate, nil
+	}
+	return strategy, nil
 }
 
 // itemToMessage converts a conversation item to a chat completion message
@@ -513,6 +771,32 @@ func (h *ChatHandler) itemToMessage(item conversation.Item) *openai.ChatCompleti
 		return nil
 	}
 
+	switch item.Type {
+	case conversation.ItemTypeFunctionCall:
+		call := item.FunctionCall()
+		if call == nil {
+			return nil
+		}
+		return &openai.ChatCompletionMessage{
+			Role: openai.ChatMessageRoleAssistant,
+			ToolCalls: []openai.ToolCall{{
+				ID:       call.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: call.Name, Arguments: call.Arguments},
+			}},
+		}
+	case conversation.ItemTypeFunctionCallOut:
+		output := item.FunctionCallOutput()
+		if output == nil {
+			return nil
+		}
+		return &openai.ChatCompletionMessage{
+			Role:       openai.ChatMessageRoleTool,
+			Content:    output.Output,
+			ToolCallID: output.CallID,
+		}
+	}
+
 	role := conversation.ItemRoleUser
 	if item.Role != nil {
 		role = *item.Role
@@ -550,6 +834,22 @@ func (h *ChatHandler) itemToMessage(item conversation.Item) *openai.ChatCompleti
 				})
 			}
 
+			// Tool calls and tool results stored by chat completions
+			for _, call := range content.ToolCalls {
+				toolType := openai.ToolType(call.Type)
+				if toolType == "" {
+					toolType = openai.ToolTypeFunction
+				}
+				msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+					ID:       call.ID,
+					Type:     toolType,
+					Function: openai.FunctionCall{Name: call.Function.Name, Arguments: call.Function.Arguments},
+				})
+			}
+			if content.ToolCallID != nil && msg.ToolCallID == "" {
+				msg.ToolCallID = *content.ToolCallID
+			}
+
 			// Handle image content
 			if content.Image != nil && content.Image.URL != "" {
 				hasMultiModal = true
@@ -593,12 +893,19 @@ func (h *ChatHandler) itemRoleToOpenAI(role conversation.ItemRole) string {
 	}
 }
 
+// completionSource identifies the model and provider that generated a completion
+type completionSource struct {
+	model      string // Public model ID requested by the client
+	providerID string // Public ID of the provider that served the request
+}
+
 // addCompletionToConversation persists the latest input and assistant response to the conversation
 func (h *ChatHandler) addCompletionToConversation(
 	ctx context.Context,
 	conv *conversation.Conversation,
 	newMessages []openai.ChatCompletionMessage,
 	response *openai.ChatCompletionResponse,
+	servedBy completionSource,
 	askItemID string,
 	completionItemID string,
 	storeReasoning bool,
@@ -614,6 +921,12 @@ func (h *ChatHandler) addCompletionToConversation(
 	}
 
 	if item := h.buildAssistantConversationItem(response, storeReasoning, completionItemID); item != nil {
+		if servedBy.model != "" {
+			item.Model = &servedBy.model
+		}
+		if servedBy.providerID != "" {
+			item.ProviderID = &servedBy.providerID
+		}
 		items = append(items, *item)
 	}
 
@@ -621,7 +934,7 @@ func (h *ChatHandler) addCompletionToConversation(
 		return nil
 	}
 
-	if _, err := h.conversationService.AddItemsToConversation(ctx, conv, conversation.BranchMain, items); err != nil {
+	if _, err := h.conversationService.AddItemsToConversation(ctx, conv, conv.GetActiveBranch(), items); err != nil {
 		return platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to add items to conversation")
 	}
 
@@ -0,0 +1,310 @@
+package chathandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	openai "github.com/sashabaranov/go-openai"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+	chatrequests "jan-server/services/llm-api/internal/interfaces/httpserver/requests/chat"
+	"jan-server/services/llm-api/internal/utils/httpclients/chat"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+	"jan-server/services/llm-api/internal/utils/tokenizer"
+)
+
+// fakeCompleter is an in-memory upstream replaying canned stream chunks.
+type fakeCompleter struct {
+	chunks    []chat.StreamChunk
+	streamErr error
+}
+
+func (f *fakeCompleter) CreateChatCompletion(ctx context.Context, apiKey string, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeCompleter) CreateChatCompletionStream(ctx context.Context, apiKey string, request openai.ChatCompletionRequest, opts ...chat.StreamOption) (<-chan chat.StreamChunk, error) {
+	if f.streamErr != nil {
+		return nil, f.streamErr
+	}
+	chunks := make(chan chat.StreamChunk, len(f.chunks))
+	for _, chunk := range f.chunks {
+		chunks <- chunk
+	}
+	close(chunks)
+	return chunks, nil
+}
+
+func (f *fakeCompleter) ListModels(ctx context.Context) (*chat.ModelsResponse, error) {
+	return &chat.ModelsResponse{}, nil
+}
+
+func newStreamTestContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	reqCtx, _ := gin.CreateTestContext(recorder)
+	reqCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	return reqCtx, recorder
+}
+
+func TestStreamCompletionWithFakeUpstream(t *testing.T) {
+	reqCtx, recorder := newStreamTestContext()
+	upstream := &fakeCompleter{chunks: []chat.StreamChunk{
+		{Data: `{"choices":[{"delta":{"role":"assistant","content":"Hel"}}]}`},
+		{Data: `{"choices":[{"delta":{"content":"lo"}}]}`},
+	}}
+	conv := &conversation.Conversation{PublicID: "conv_test"}
+
+	h := &ChatHandler{}
+	request := openai.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Say hello"}},
+	}
+	resp, usageEstimated, err := h.streamCompletion(reqCtx.Request.Context(), reqCtx, upstream, nil, conv, request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "Hello" {
+		t.Fatalf("expected accumulated content, got %q", resp.Choices[0].Message.Content)
+	}
+	if !usageEstimated || resp.Usage.PromptTokens == 0 || resp.Usage.CompletionTokens == 0 {
+		t.Fatalf("expected estimated usage counted for an unknown model, got %+v (estimated=%v)", resp.Usage, usageEstimated)
+	}
+
+	body := recorder.Body.String()
+	if got := recorder.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("expected SSE content type, got %q", got)
+	}
+	usageAt := strings.Index(body, `"usage_estimated":true`)
+	conversationAt := strings.Index(body, `"conversation":{"branch":"MAIN","id":"conv_test"}`)
+	if usageAt < 0 || conversationAt < usageAt {
+		t.Fatalf("expected usage chunk before the conversation chunk, got body:\n%s", body)
+	}
+	doneAt := strings.Index(body, "data: [DONE]")
+	if conversationAt < 0 || doneAt < conversationAt {
+		t.Fatalf("expected conversation chunk before [DONE], got body:\n%s", body)
+	}
+}
+
+func TestStreamCompletionKeepsUpstreamUsage(t *testing.T) {
+	reqCtx, recorder := newStreamTestContext()
+	upstream := &fakeCompleter{chunks: []chat.StreamChunk{
+		{Data: `{"choices":[{"delta":{"role":"assistant","content":"Hi"}}]}`},
+		{Data: `{"choices":[],"usage":{"prompt_tokens":11,"completion_tokens":1,"total_tokens":12}}`},
+	}}
+
+	h := &ChatHandler{}
+	resp, usageEstimated, err := h.streamCompletion(reqCtx.Request.Context(), reqCtx, upstream, tokenizer.ForModel("gpt-4o"), nil, openai.ChatCompletionRequest{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usageEstimated || resp.Usage.PromptTokens != 11 || resp.Usage.TotalTokens != 12 {
+		t.Fatalf("expected upstream usage, got %+v (estimated=%v)", resp.Usage, usageEstimated)
+	}
+	if strings.Contains(recorder.Body.String(), "usage_estimated") {
+		t.Fatal("expected no counted usage chunk when upstream reports usage")
+	}
+}
+
+func TestStreamCompletionUpstreamErrorLeavesResponseUnwritten(t *testing.T) {
+	reqCtx, _ := newStreamTestContext()
+	upstream := &fakeCompleter{streamErr: errors.New("connection refused")}
+
+	h := &ChatHandler{}
+	if _, _, err := h.streamCompletion(reqCtx.Request.Context(), reqCtx, upstream, nil, nil, openai.ChatCompletionRequest{Model: "test-model"}); err == nil {
+		t.Fatal("expected upstream error")
+	}
+	if reqCtx.Writer.Written() {
+		t.Fatal("expected nothing written so the request can fail over")
+	}
+}
+
+func TestPromptWindowMergesProjectInstruction(t *testing.T) {
+	history := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleUser, Content: "earlier question"},
+	}
+	input := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "Answer briefly."},
+		{Role: openai.ChatMessageRoleUser, Content: "Hi"},
+	}
+
+	window := newPromptWindow(history, input)
+	window.mergeInstruction("You are a tax assistant.")
+	merged := window.messages()
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(merged))
+	}
+	if merged[0].Role != openai.ChatMessageRoleSystem || merged[0].Content != "You are a tax assistant.\n\nAnswer briefly." {
+		t.Fatalf("unexpected system message: %+v", merged[0])
+	}
+	if merged[1].Content != "earlier question" || merged[2].Content != "Hi" {
+		t.Fatalf("expected remaining messages in order, got %+v", merged[1:])
+	}
+
+	withoutSystem := newPromptWindow(nil, input[1:])
+	withoutSystem.mergeInstruction("You are a tax assistant.")
+	if got := withoutSystem.messages(); len(got) != 2 || got[0].Content != "You are a tax assistant." {
+		t.Fatalf("expected instruction-only system message, got %+v", got)
+	}
+}
+
+func TestConversationHistoryRebuildsToolCalls(t *testing.T) {
+	completed := conversation.ItemStatusCompleted
+	user, assistant := conversation.ItemRoleUser, conversation.ItemRoleAssistant
+	conv := &conversation.Conversation{Items: []conversation.Item{
+		{Type: conversation.ItemTypeMessage, Role: &user, Status: &completed, Content: []conversation.Content{conversation.NewInputTextContent("Weather in Paris and Rome?")}},
+		{Type: conversation.ItemTypeMessage, Role: &assistant, Status: &completed, Content: []conversation.Content{conversation.NewOutputTextContent("Checking.", []conversation.Annotation{})}},
+		{Type: conversation.ItemTypeFunctionCall, Status: &completed, Content: []conversation.Content{conversation.NewFunctionCallContent("call_1", "weather", `{"city":"Paris"}`)}},
+		{Type: conversation.ItemTypeFunctionCall, Status: &completed, Content: []conversation.Content{conversation.NewFunctionCallContent("call_2", "weather", `{"city":"Rome"}`)}},
+		{Type: conversation.ItemTypeFunctionCallOut, Status: &completed, Content: []conversation.Content{conversation.NewFunctionCallOutputContent("call_1", "sunny")}},
+		{Type: conversation.ItemTypeFunctionCallOut, Status: &completed, Content: []conversation.Content{conversation.NewFunctionCallOutputContent("call_2", "rainy")}},
+		{Type: conversation.ItemTypeMessage, Role: &assistant, Status: &completed, Content: []conversation.Content{conversation.NewOutputTextContent("Sunny in Paris, rainy in Rome.", []conversation.Annotation{})}},
+	}}
+
+	_, messages := (&ChatHandler{}).conversationHistory(context.Background(), conv)
+	if len(messages) != 5 {
+		t.Fatalf("expected user, assistant with calls, two tool results and answer, got %+v", messages)
+	}
+	if calls := messages[1].ToolCalls; messages[1].Content != "Checking." || len(calls) != 2 || calls[1].ID != "call_2" || calls[1].Function.Arguments != `{"city":"Rome"}` {
+		t.Fatalf("expected function calls merged into the assistant message, got %+v", messages[1])
+	}
+	if messages[2].Role != openai.ChatMessageRoleTool || messages[2].ToolCallID != "call_1" || messages[2].Content != "sunny" {
+		t.Fatalf("unexpected tool result: %+v", messages[2])
+	}
+	if messages[4].Role != openai.ChatMessageRoleAssistant || messages[4].Content != "Sunny in Paris, rainy in Rome." {
+		t.Fatalf("unexpected final answer: %+v", messages[4])
+	}
+}
+
+func TestFitPromptWindowDropsOldestTurns(t *testing.T) {
+	tok := tokenizer.ForModel("gpt-4o")
+	turn := strings.Repeat("lorem ipsum dolor sit amet ", 20)
+	history := make([]openai.ChatCompletionMessage, 0, 10)
+	for i := 0; i < 5; i++ {
+		history = append(history,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: turn},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: turn},
+		)
+	}
+	window := newPromptWindow(history, []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "latest"}})
+	window.mergeInstruction("Pinned instruction.")
+
+	full := tokenizer.CountMessages(tok, window.messages())
+	budget := full / 2
+
+	fit := fitPromptWindow(tok, window, conversation.HistoryStrategyTruncate, budget, nil)
+	if fit.dropped == 0 || fit.promptTokens > budget {
+		t.Fatalf("expected history to be dropped to fit %d tokens, got %d tokens with %d dropped", budget, fit.promptTokens, fit.dropped)
+	}
+	if fit.messages[0].Content != "Pinned instruction." || fit.messages[len(fit.messages)-1].Content != "latest" {
+		t.Fatalf("expected instruction and input to be kept, got %+v", fit.messages)
+	}
+
+	summarized := fitPromptWindow(tok, window, conversation.HistoryStrategySummarize, budget, nil)
+	if !summarized.summarized || !strings.HasPrefix(summarized.messages[0].Content, "Pinned instruction.\n\nSummary of") || summarized.promptTokens > budget {
+		t.Fatalf("expected a summary within budget in the system message, got %d tokens: %+v", summarized.promptTokens, summarized.messages[0])
+	}
+	if summarized.messages[1].Role == openai.ChatMessageRoleSystem {
+		t.Fatalf("expected a single system message, got %+v", summarized.messages[1])
+	}
+
+	untouched := fitPromptWindow(tok, window, conversation.HistoryStrategyFull, budget, nil)
+	if untouched.dropped != 0 || len(untouched.messages) != len(history)+2 {
+		t.Fatalf("expected full strategy to keep every message, got %d", len(untouched.messages))
+	}
+}
+
+func TestFitPromptWindowSummarizesDroppedTurns(t *testing.T) {
+	tok := tokenizer.ForModel("gpt-4o")
+	turn := strings.Repeat("lorem ipsum dolor sit amet ", 20)
+	history := make([]openai.ChatCompletionMessage, 0, 10)
+	for i := 0; i < 5; i++ {
+		history = append(history,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: turn},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: turn},
+		)
+	}
+	window := newPromptWindow(history, []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "latest"}})
+	budget := tokenizer.CountMessages(tok, window.messages()) / 2
+
+	var summarizedTurns, maxTokens int
+	fit := fitPromptWindow(tok, window, conversation.HistoryStrategySummarize, budget, func(dropped []openai.ChatCompletionMessage, limit int) (string, error) {
+		summarizedTurns, maxTokens = len(dropped), limit
+		return "The user asked about lorem ipsum.", nil
+	})
+	if summarizedTurns != fit.dropped || maxTokens <= 0 || maxTokens > maxHistorySummaryTokens {
+		t.Fatalf("expected the dropped turns to be summarized within the summary budget, got %d turns and %d tokens", summarizedTurns, maxTokens)
+	}
+	if !fit.summarized || fit.summaryErr != nil || !strings.HasSuffix(fit.messages[0].Content, "\nThe user asked about lorem ipsum.") {
+		t.Fatalf("expected the model summary in the system message, got %+v", fit.messages[0])
+	}
+
+	failed := fitPromptWindow(tok, window, conversation.HistoryStrategySummarize, budget, func([]openai.ChatCompletionMessage, int) (string, error) {
+		return "", errors.New("upstream unavailable")
+	})
+	if failed.summaryErr == nil || !failed.summarized || !strings.Contains(failed.messages[0].Content, "most recent excerpts") {
+		t.Fatalf("expected excerpts when the summary call fails, got %+v", failed.messages[0])
+	}
+}
+
+func TestPromptWindowMergesSystemMessages(t *testing.T) {
+	history := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "Stored instruction."},
+		{Role: openai.ChatMessageRoleUser, Content: "earlier question"},
+	}
+	input := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "Answer briefly."},
+		{Role: openai.ChatMessageRoleUser, Content: "Hi"},
+	}
+
+	window := newPromptWindow(history, input)
+	window.summary = &openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: "Earlier summary."}
+	messages := window.messages()
+	if len(messages) != 3 {
+		t.Fatalf("expected one system message and two turns, got %+v", messages)
+	}
+	if messages[0].Content != "Stored instruction.\n\nAnswer briefly.\n\nEarlier summary." {
+		t.Fatalf("unexpected system message: %q", messages[0].Content)
+	}
+}
+
+func TestRecordInstructionSnapshotTracksVersion(t *testing.T) {
+	conv := &conversation.Conversation{InstructionVersion: 1}
+	if !conv.RecordInstructionSnapshot(1, "instruction") {
+		t.Fatal("expected first snapshot to be recorded")
+	}
+	if conv.RecordInstructionSnapshot(1, "instruction") {
+		t.Fatal("expected unchanged snapshot to be skipped")
+	}
+	if !conv.RecordInstructionSnapshot(2, "instruction") || conv.InstructionVersion != 2 {
+		t.Fatalf("expected version bump to be recorded, got version %d", conv.InstructionVersion)
+	}
+}
+
+func TestRegenerateRejectsClientHistory(t *testing.T) {
+	reqCtx, _ := newStreamTestContext()
+	conversationID := "conv_other"
+
+	h := &ChatHandler{}
+	requests := map[string]chatrequests.ChatCompletionRequest{
+		"messages": {ChatCompletionRequest: openai.ChatCompletionRequest{
+			Model:    "test-model",
+			Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hi"}},
+		}},
+		"conversation": {
+			ChatCompletionRequest: openai.ChatCompletionRequest{Model: "test-model"},
+			Conversation:          &chatrequests.ConversationReference{ID: &conversationID},
+		},
+	}
+	for name, request := range requests {
+		_, err := h.RegenerateItem(reqCtx.Request.Context(), reqCtx, 1, "conv_test", "msg_test", request)
+		if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeValidation) {
+			t.Fatalf("%s: expected validation error, got %v", name, err)
+		}
+	}
+}
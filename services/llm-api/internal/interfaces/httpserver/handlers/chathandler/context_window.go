@@ -0,0 +1,271 @@
+package chathandler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+	domainmodel "jan-server/services/llm-api/internal/domain/model"
+	"jan-server/services/llm-api/internal/utils/tokenizer"
+)
+
+const (
+	// defaultCompletionReserve is kept free for the completion when the request sets no max tokens
+	defaultCompletionReserve = 4096
+	// maxHistorySummaryTokens caps the synthetic summary of dropped turns
+	maxHistorySummaryTokens = 1024
+	// historySummaryExcerptRunes caps each dropped message excerpt in the fallback summary
+	historySummaryExcerptRunes = 240
+	// historySummaryTimeout bounds the summary call made while fitting a prompt
+	historySummaryTimeout = 30 * time.Second
+)
+
+// historySummarizer condenses history turns dropped from the prompt into summary text of at most
+// maxTokens tokens.
+type historySummarizer func(dropped []openai.ChatCompletionMessage, maxTokens int) (string, error)
+
+// promptWindow splits a conversation prompt into the parts that are fitted into the context window.
+type promptWindow struct {
+	system  *openai.ChatCompletionMessage  // Pinned system message (with the project instruction), never dropped
+	summary *openai.ChatCompletionMessage  // Pinned rolling summary of turns before history, never dropped
+	history []openai.ChatCompletionMessage // Stored conversation turns, oldest first; dropped oldest first
+	input   []openai.ChatCompletionMessage // Messages sent with this request, never dropped
+}
+
+// newPromptWindow builds a window from the conversation history and the request messages. The
+// system messages found in either are merged, in order, into one message pinned at the front of
+// the prompt.
+func newPromptWindow(history, input []openai.ChatCompletionMessage) promptWindow {
+	var window promptWindow
+	var instructions []string
+	split := func(messages []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+		rest := make([]openai.ChatCompletionMessage, 0, len(messages))
+		for _, msg := range messages {
+			if msg.Role != openai.ChatMessageRoleSystem {
+				rest = append(rest, msg)
+				continue
+			}
+			if text := strings.TrimSpace(messageText(msg)); text != "" {
+				instructions = append(instructions, text)
+			}
+		}
+		return rest
+	}
+	window.history = split(history)
+	window.input = split(input)
+	if len(instructions) > 0 {
+		window.system = &openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: strings.Join(instructions, "\n\n"),
+		}
+	}
+	return window
+}
+
+// mergeInstruction places instruction ahead of the pinned system message text.
+func (w *promptWindow) mergeInstruction(instruction string) {
+	content := instruction
+	if w.system != nil {
+		if existing := strings.TrimSpace(messageText(*w.system)); existing != "" {
+			content = instruction + "\n\n" + existing
+		}
+	}
+	w.system = &openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: content,
+	}
+}
+
+// messages returns the full prompt without any truncation.
+func (w promptWindow) messages() []openai.ChatCompletionMessage {
+	return w.assemble(nil, w.history)
+}
+
+// assemble builds the prompt with a single leading system message holding the pinned system
+// message and the summaries, since some providers reject more than one.
+func (w promptWindow) assemble(summary *openai.ChatCompletionMessage, history []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, 0, len(history)+len(w.input)+1)
+
+	var sections []string
+	for _, msg := range []*openai.ChatCompletionMessage{w.system, w.summary, summary} {
+		if msg == nil {
+			continue
+		}
+		if text := strings.TrimSpace(messageText(*msg)); text != "" {
+			sections = append(sections, text)
+		}
+	}
+	if len(sections) > 0 {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: strings.Join(sections, "\n\n"),
+		})
+	}
+
+	messages = append(messages, history...)
+	return append(messages, w.input...)
+}
+
+// contextFit describes how a prompt window was fitted into a model context window.
+type contextFit struct {
+	messages     []openai.ChatCompletionMessage
+	promptTokens int
+	dropped      int
+	summarized   bool
+	summaryErr   error // Why the summary model could not be used, when excerpts were sent instead
+}
+
+// promptTokenBudget returns the prompt tokens available for messages on a provider model, or 0
+// when the model has no known context length.
+func promptTokenBudget(tok tokenizer.Tokenizer, limits *domainmodel.TokenLimits, request openai.ChatCompletionRequest) int {
+	if limits == nil || limits.ContextLength <= 0 {
+		return 0
+	}
+
+	reserve := request.MaxCompletionTokens
+	if reserve <= 0 {
+		reserve = request.MaxTokens
+	}
+	if reserve <= 0 {
+		reserve = min(defaultCompletionReserve, limits.ContextLength/4)
+	}
+
+	budget := limits.ContextLength - reserve - tokenizer.CountTools(tok, request.Tools, request.Functions)
+	return max(budget, 1)
+}
+
+// fitPromptWindow drops the oldest history turns until the prompt fits budget tokens. With
+// HistoryStrategySummarize the dropped turns are replaced by a summary written by summarize, or by
+// excerpts of them when summarize is nil or fails; with HistoryStrategyFull, or when budget is 0,
+// the prompt is returned unchanged.
+func fitPromptWindow(tok tokenizer.Tokenizer, window promptWindow, strategy conversation.HistoryStrategy, budget int, summarize historySummarizer) contextFit {
+	full := window.messages()
+	total := tokenizer.CountMessages(tok, full)
+	if strategy == conversation.HistoryStrategyFull || budget <= 0 || total <= budget || len(window.history) == 0 {
+		return contextFit{messages: full, promptTokens: total}
+	}
+
+	pinned := window.assemble(nil, nil)
+	available := budget - tokenizer.CountMessages(tok, pinned)
+
+	summaryBudget := 0
+	if strategy == conversation.HistoryStrategySummarize {
+		summaryBudget = min(maxHistorySummaryTokens, max(available, 0)/4)
+	}
+
+	// Keep the newest turns that fit
+	keepFrom := len(window.history)
+	used := 0
+	for i := len(window.history) - 1; i >= 0; i-- {
+		cost := tokenizer.CountMessage(tok, window.history[i])
+		if used+cost > available-summaryBudget {
+			break
+		}
+		used += cost
+		keepFrom = i
+	}
+	// Never start with tool results whose assistant tool call was dropped
+	for keepFrom < len(window.history) && window.history[keepFrom].Role == openai.ChatMessageRoleTool {
+		keepFrom++
+	}
+
+	dropped := window.history[:keepFrom]
+	kept := window.history[keepFrom:]
+
+	var summary *openai.ChatCompletionMessage
+	var summaryErr error
+	if len(dropped) > 0 && summaryBudget > 0 {
+		summary, summaryErr = summarizeHistory(tok, dropped, summaryBudget, summarize)
+	}
+
+	messages := window.assemble(summary, kept)
+	return contextFit{
+		messages:     messages,
+		promptTokens: tokenizer.CountMessages(tok, messages),
+		dropped:      len(dropped),
+		summarized:   summary != nil,
+		summaryErr:   summaryErr,
+	}
+}
+
+// summarizeHistory asks summarize for a summary of the dropped turns within budget tokens. It falls
+// back to excerpts of the turns, returning the reason alongside them, when there is no summarizer,
+// the call fails or the summary does not fit.
+func summarizeHistory(tok tokenizer.Tokenizer, dropped []openai.ChatCompletionMessage, budget int, summarize historySummarizer) (*openai.ChatCompletionMessage, error) {
+	if summarize == nil {
+		return summarizeDroppedHistory(tok, dropped, budget), nil
+	}
+
+	header := fmt.Sprintf("Summary of %d earlier conversation messages omitted to fit the context window:", len(dropped))
+	headerTokens := tokenizer.CountMessage(tok, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: header})
+	if budget <= headerTokens {
+		return summarizeDroppedHistory(tok, dropped, budget), nil
+	}
+
+	text, err := summarize(dropped, budget-headerTokens)
+	if err != nil {
+		return summarizeDroppedHistory(tok, dropped, budget), err
+	}
+	summary := &openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: header + "\n" + text,
+	}
+	if tokenizer.CountMessage(tok, *summary) > budget {
+		return summarizeDroppedHistory(tok, dropped, budget), fmt.Errorf("summary exceeds %d tokens", budget)
+	}
+	return summary, nil
+}
+
+// summarizeDroppedHistory builds a synthetic system message listing excerpts of the most recent
+// dropped turns that fit within budget tokens. It returns nil if not even one excerpt fits.
+func summarizeDroppedHistory(tok tokenizer.Tokenizer, dropped []openai.ChatCompletionMessage, budget int) *openai.ChatCompletionMessage {
+	header := fmt.Sprintf("Summary of %d earlier conversation messages omitted to fit the context window (most recent excerpts):", len(dropped))
+	used := tokenizer.CountMessage(tok, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: header})
+
+	lines := make([]string, 0, len(dropped))
+	for i := len(dropped) - 1; i >= 0; i-- {
+		text := strings.Join(strings.Fields(messageText(dropped[i])), " ")
+		if text == "" {
+			continue
+		}
+		if runes := []rune(text); len(runes) > historySummaryExcerptRunes {
+			text = string(runes[:historySummaryExcerptRunes]) + "..."
+		}
+		line := fmt.Sprintf("- %s: %s", dropped[i].Role, text)
+		cost := tok.Count("\n" + line)
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	// Restore chronological order
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return &openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: header + "\n" + strings.Join(lines, "\n"),
+	}
+}
+
+// messageText returns the text of a message, joining multimodal text parts.
+func messageText(msg openai.ChatCompletionMessage) string {
+	if msg.Content != "" {
+		return msg.Content
+	}
+	parts := make([]string, 0, len(msg.MultiContent))
+	for _, part := range msg.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeText && part.Text != "" {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,125 @@
+package chathandler
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	openai "github.com/sashabaranov/go-openai"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+	"jan-server/services/llm-api/internal/infrastructure/logger"
+	chatrequests "jan-server/services/llm-api/internal/interfaces/httpserver/requests/chat"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// EditItem replaces a user message of the conversation's active branch and regenerates the reply
+// in a new branch, keeping the original turns in the old branch
+func (h *ChatHandler) EditItem(
+	ctx context.Context,
+	reqCtx *gin.Context,
+	userID uint,
+	conversationID string,
+	itemID string,
+	request chatrequests.EditItemRequest,
+) (*ChatCompletionResult, error) {
+	message := &openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: request.Content,
+	}
+	return h.regenerateFromItem(ctx, reqCtx, userID, conversationID, itemID, conversation.ItemRoleUser, message, request.ChatCompletionRequest, h.CreateChatCompletion)
+}
+
+// RegenerateItem regenerates an assistant reply of the conversation's active branch in a new
+// branch, keeping the original reply in the old branch
+func (h *ChatHandler) RegenerateItem(
+	ctx context.Context,
+	reqCtx *gin.Context,
+	userID uint,
+	conversationID string,
+	itemID string,
+	request chatrequests.ChatCompletionRequest,
+) (*ChatCompletionResult, error) {
+	return h.regenerateFromItem(ctx, reqCtx, userID, conversationID, itemID, conversation.ItemRoleAssistant, nil, request, h.CreateChatCompletion)
+}
+
+// completionFunc runs a chat completion the way CreateChatCompletion does.
+type completionFunc func(ctx context.Context, reqCtx *gin.Context, userID uint, request chatrequests.ChatCompletionRequest) (*ChatCompletionResult, error)
+
+// regenerateFromItem forks the active branch before itemID, makes the fork active and runs the
+// completion on it through complete, appending message when set. When the completion fails the
+// fork is removed and the previous branch becomes active again.
+func (h *ChatHandler) regenerateFromItem(
+	ctx context.Context,
+	reqCtx *gin.Context,
+	userID uint,
+	conversationID string,
+	itemID string,
+	role conversation.ItemRole,
+	message *openai.ChatCompletionMessage,
+	request chatrequests.ChatCompletionRequest,
+	complete completionFunc,
+) (*ChatCompletionResult, error) {
+	if len(request.Messages) > 0 || !request.Conversation.IsEmpty() {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation,
+			"messages and conversation cannot be set; the history comes from the conversation", nil, "")
+	}
+
+	conv, err := h.conversationService.GetConversationByPublicIDAndUserID(ctx, conversationID, userID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get conversation")
+	}
+
+	item, err := h.conversationService.GetConversationItem(ctx, conv, itemID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get item")
+	}
+	if item.Type != conversation.ItemTypeMessage || item.Role == nil || *item.Role != role {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation,
+			"item must be a "+string(role)+" message", nil, "")
+	}
+
+	previousBranch := conv.GetActiveBranch()
+	branch, err := h.conversationService.ForkBranchBeforeItem(ctx, conv, itemID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to fork conversation")
+	}
+
+	request.Conversation = &chatrequests.ConversationReference{ID: &conv.PublicID}
+	request.Messages = nil
+	if message != nil {
+		request.Messages = []openai.ChatCompletionMessage{*message}
+	}
+	// The regenerated turn is what the new branch is for, so it is always stored
+	request.Store = nil
+
+	result, err := complete(ctx, reqCtx, userID, request)
+	if err != nil {
+		h.discardBranch(ctx, conv, branch.Name, previousBranch)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// discardBranch switches the conversation back to previousBranch and deletes branchName
+func (h *ChatHandler) discardBranch(ctx context.Context, conv *conversation.Conversation, branchName, previousBranch string) {
+	// Use a fresh context so a cancelled request still cleans up
+	ctx = context.WithoutCancel(ctx)
+	if err := h.conversationService.SwitchBranch(ctx, conv, previousBranch); err != nil {
+		log := logger.GetLogger()
+		log.Warn().
+			Err(err).
+			Str("conversation_id", conv.PublicID).
+			Str("branch", previousBranch).
+			Msg("failed to restore active branch")
+		return
+	}
+	if err := h.conversationService.DeleteBranch(ctx, conv, branchName); err != nil {
+		log := logger.GetLogger()
+		log.Warn().
+			Err(err).
+			Str("conversation_id", conv.PublicID).
+			Str("branch", branchName).
+			Msg("failed to delete unused branch")
+	}
+}
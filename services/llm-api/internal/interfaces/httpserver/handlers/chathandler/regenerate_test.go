@@ -0,0 +1,234 @@
+package chathandler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	openai "github.com/sashabaranov/go-openai"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+	"jan-server/services/llm-api/internal/domain/query"
+	chatrequests "jan-server/services/llm-api/internal/interfaces/httpserver/requests/chat"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// branchRepo is a ConversationRepository holding one conversation whose MAIN branch has a user
+// message, the reply, a second user message and its reply. Methods regenerating does not use are
+// left to the embedded nil interface.
+type branchRepo struct {
+	conversation.ConversationRepository
+	conv     *conversation.Conversation
+	items    []*conversation.Item
+	branches map[string]*conversation.BranchMetadata
+	active   string
+}
+
+func newBranchRepo() *branchRepo {
+	user, assistant := conversation.ItemRoleUser, conversation.ItemRoleAssistant
+	message := func(id string, role *conversation.ItemRole) *conversation.Item {
+		return &conversation.Item{PublicID: id, Type: conversation.ItemTypeMessage, Role: role}
+	}
+	return &branchRepo{
+		conv: &conversation.Conversation{ID: 1, PublicID: "conv_regen", UserID: 7},
+		items: []*conversation.Item{
+			message("msg_question", &user),
+			message("msg_answer", &assistant),
+			message("msg_followup", &user),
+			message("msg_followup_answer", &assistant),
+		},
+		branches: map[string]*conversation.BranchMetadata{},
+		active:   conversation.BranchMain,
+	}
+}
+
+func (r *branchRepo) FindByPublicID(ctx context.Context, publicID string) (*conversation.Conversation, error) {
+	if publicID != r.conv.PublicID {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotFound, "conversation not found", nil, "")
+	}
+	copied := *r.conv
+	copied.ActiveBranch = r.active
+	return &copied, nil
+}
+
+func (r *branchRepo) GetItemByPublicID(ctx context.Context, _ uint, publicID string) (*conversation.Item, error) {
+	for _, item := range r.items {
+		if item.PublicID == publicID {
+			return item, nil
+		}
+	}
+	return nil, platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotFound, "item not found", nil, "")
+}
+
+func (r *branchRepo) GetBranchItems(_ context.Context, _ uint, branchName string, _ *query.Pagination) ([]*conversation.Item, error) {
+	if branchName != conversation.BranchMain {
+		return nil, nil
+	}
+	return r.items, nil
+}
+
+func (r *branchRepo) GetBranch(ctx context.Context, _ uint, branchName string) (*conversation.BranchMetadata, error) {
+	branch, ok := r.branches[branchName]
+	if !ok {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerRepository, platformerrors.ErrorTypeNotFound, "branch not found", nil, "")
+	}
+	copied := *branch
+	return &copied, nil
+}
+
+func (r *branchRepo) CountItems(context.Context, uint, string) (int, error) { return 0, nil }
+
+func (r *branchRepo) ForkBranch(_ context.Context, _ uint, sourceBranch, newBranch string, fromItemID string, description *string) error {
+	r.branches[newBranch] = &conversation.BranchMetadata{Name: newBranch, ParentBranch: &sourceBranch, ForkedFromItemID: &fromItemID, Description: description}
+	return nil
+}
+
+func (r *branchRepo) CreateBranch(_ context.Context, _ uint, branchName string, metadata *conversation.BranchMetadata) error {
+	copied := *metadata
+	r.branches[branchName] = &copied
+	return nil
+}
+
+func (r *branchRepo) SetActiveBranch(_ context.Context, _ uint, branchName string) error {
+	r.active = branchName
+	return nil
+}
+
+func (r *branchRepo) DeleteBranch(_ context.Context, _ uint, branchName string) error {
+	delete(r.branches, branchName)
+	return nil
+}
+
+// completionRecorder stands in for CreateChatCompletion, recording the requests it gets.
+type completionRecorder struct {
+	requests []chatrequests.ChatCompletionRequest
+	err      error
+}
+
+func (c *completionRecorder) complete(_ context.Context, _ *gin.Context, _ uint, request chatrequests.ChatCompletionRequest) (*ChatCompletionResult, error) {
+	c.requests = append(c.requests, request)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ChatCompletionResult{}, nil
+}
+
+func regenerate(h *ChatHandler, itemID string, role conversation.ItemRole, message *openai.ChatCompletionMessage, completion *completionRecorder) error {
+	reqCtx, _ := newStreamTestContext()
+	request := chatrequests.ChatCompletionRequest{ChatCompletionRequest: openai.ChatCompletionRequest{Model: "test-model"}}
+	_, err := h.regenerateFromItem(reqCtx.Request.Context(), reqCtx, 7, "conv_regen", itemID, role, message, request, completion.complete)
+	return err
+}
+
+func TestRegenerateItemReusesStoredQuestion(t *testing.T) {
+	repo := newBranchRepo()
+	h := &ChatHandler{conversationService: conversation.NewConversationService(repo)}
+	completion := &completionRecorder{}
+
+	if err := regenerate(h, "msg_answer", conversation.ItemRoleAssistant, nil, completion); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(completion.requests) != 1 {
+		t.Fatalf("expected one completion, got %d", len(completion.requests))
+	}
+	// The question is already in the fork, so it is not sent and stored again
+	request := completion.requests[0]
+	if len(request.Messages) != 0 {
+		t.Fatalf("expected no new messages, got %+v", request.Messages)
+	}
+	if request.Conversation == nil || request.Conversation.ID == nil || *request.Conversation.ID != "conv_regen" {
+		t.Fatalf("expected the completion to run in the conversation, got %+v", request.Conversation)
+	}
+	branch, ok := repo.branches[repo.active]
+	if !ok || branch.ForkedFromItemID == nil || *branch.ForkedFromItemID != "msg_question" {
+		t.Fatalf("expected an active fork up to the question, got %q and %+v", repo.active, branch)
+	}
+}
+
+func TestEditItemSendsEditedMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		itemID     string
+		forkedFrom string // empty when the edit starts from an empty branch
+	}{
+		{name: "later message", itemID: "msg_followup", forkedFrom: "msg_answer"},
+		{name: "first message", itemID: "msg_question"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newBranchRepo()
+			h := &ChatHandler{conversationService: conversation.NewConversationService(repo)}
+			completion := &completionRecorder{}
+			edited := &openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "edited question"}
+
+			if err := regenerate(h, tt.itemID, conversation.ItemRoleUser, edited, completion); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			messages := completion.requests[0].Messages
+			if len(messages) != 1 || messages[0].Content != "edited question" {
+				t.Fatalf("expected only the edited message, got %+v", messages)
+			}
+			branch, ok := repo.branches[repo.active]
+			if !ok {
+				t.Fatalf("expected a new active branch, got %q", repo.active)
+			}
+			if tt.forkedFrom == "" && branch.ForkedFromItemID != nil {
+				t.Fatalf("expected an empty branch, got a fork from %s", *branch.ForkedFromItemID)
+			}
+			if tt.forkedFrom != "" && (branch.ForkedFromItemID == nil || *branch.ForkedFromItemID != tt.forkedFrom) {
+				t.Fatalf("expected a fork up to %s, got %+v", tt.forkedFrom, branch)
+			}
+		})
+	}
+}
+
+func TestRegenerateItemDiscardsBranchWhenCompletionFails(t *testing.T) {
+	for _, itemID := range []string{"msg_answer", "msg_question"} {
+		t.Run(itemID, func(t *testing.T) {
+			repo := newBranchRepo()
+			h := &ChatHandler{conversationService: conversation.NewConversationService(repo)}
+			completion := &completionRecorder{err: errors.New("upstream unavailable")}
+			role, message := conversation.ItemRoleAssistant, (*openai.ChatCompletionMessage)(nil)
+			if itemID == "msg_question" {
+				role, message = conversation.ItemRoleUser, &openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "edited"}
+			}
+
+			if err := regenerate(h, itemID, role, message, completion); err == nil {
+				t.Fatal("expected the completion error")
+			}
+			if repo.active != conversation.BranchMain {
+				t.Fatalf("expected MAIN to be active again, got %q", repo.active)
+			}
+			if len(repo.branches) != 0 {
+				t.Fatalf("expected the new branch to be deleted, got %v", repo.branches)
+			}
+		})
+	}
+}
+
+func TestRegenerateItemRejectsItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		itemID    string
+		errorType platformerrors.ErrorType
+	}{
+		{name: "user message", itemID: "msg_question", errorType: platformerrors.ErrorTypeValidation},
+		{name: "unknown item", itemID: "msg_missing", errorType: platformerrors.ErrorTypeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newBranchRepo()
+			h := &ChatHandler{conversationService: conversation.NewConversationService(repo)}
+			completion := &completionRecorder{}
+
+			err := regenerate(h, tt.itemID, conversation.ItemRoleAssistant, nil, completion)
+			if !platformerrors.IsErrorType(err, tt.errorType) {
+				t.Fatalf("expected %s, got %v", tt.errorType, err)
+			}
+			if len(repo.branches) != 0 || len(completion.requests) != 0 {
+				t.Fatalf("expected no branch and no completion, got %v and %d", repo.branches, len(completion.requests))
+			}
+		})
+	}
+}
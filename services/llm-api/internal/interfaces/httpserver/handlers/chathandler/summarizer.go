@@ -0,0 +1,292 @@
+package chathandler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"jan-server/services/llm-api/internal/config"
+	"jan-server/services/llm-api/internal/domain/conversation"
+	"jan-server/services/llm-api/internal/domain/query"
+	"jan-server/services/llm-api/internal/infrastructure/inference"
+	"jan-server/services/llm-api/internal/infrastructure/logger"
+	modelHandler "jan-server/services/llm-api/internal/interfaces/httpserver/handlers/modelhandler"
+	"jan-server/services/llm-api/internal/utils/httpclients/chat"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+const summarizerInstruction = `You maintain a running summary of a conversation between a user and an assistant.
+Write a concise summary that preserves facts, decisions, open questions, user preferences and any
+details needed to continue the conversation. If a previous summary is given, fold it into the new
+summary. Reply with the summary only.`
+
+// historySummaryMessageRunes caps each message sent to the summary model when summarizing dropped history
+const historySummaryMessageRunes = 4000
+
+// SummarizerConfig configures rolling conversation summarization.
+type SummarizerConfig struct {
+	Model      string        // Model public ID used for summaries; empty disables summarization
+	Threshold  int           // Unsummarized items that trigger a new summary
+	KeepRecent int           // Newest items left out of the summary
+	MaxTokens  int           // Completion cap for a summary
+	Timeout    time.Duration // Deadline for one background summarization
+}
+
+// ConversationSummarizer condenses older turns of long conversations into a summary item using a
+// cheap model, so completions send the summary plus recent turns instead of the full history.
+type ConversationSummarizer struct {
+	config              SummarizerConfig
+	inferenceProvider   *inference.InferenceProvider
+	providerHandler     *modelHandler.ProviderHandler
+	conversationService *conversation.ConversationService
+	inflight            sync.Map // conversation ID -> struct{} while a summary is being built
+}
+
+// NewConversationSummarizer creates a conversation summarizer
+func NewConversationSummarizer(
+	cfg SummarizerConfig,
+	inferenceProvider *inference.InferenceProvider,
+	providerHandler *modelHandler.ProviderHandler,
+	conversationService *conversation.ConversationService,
+) *ConversationSummarizer {
+	return &ConversationSummarizer{
+		config:              cfg,
+		inferenceProvider:   inferenceProvider,
+		providerHandler:     providerHandler,
+		conversationService: conversationService,
+	}
+}
+
+// ProvideConversationSummarizer creates the summarizer from the service configuration
+func ProvideConversationSummarizer(
+	cfg *config.Config,
+	inferenceProvider *inference.InferenceProvider,
+	providerHandler *modelHandler.ProviderHandler,
+	conversationService *conversation.ConversationService,
+) *ConversationSummarizer {
+	return NewConversationSummarizer(SummarizerConfig{
+		Model:      strings.TrimSpace(cfg.ConversationSummaryModel),
+		Threshold:  cfg.ConversationSummaryThreshold,
+		KeepRecent: cfg.ConversationSummaryKeepRecent,
+		MaxTokens:  cfg.ConversationSummaryMaxTokens,
+		Timeout:    cfg.ConversationSummaryTimeout,
+	}, inferenceProvider, providerHandler, conversationService)
+}
+
+// Enabled reports whether a summary model is configured
+func (s *ConversationSummarizer) Enabled() bool {
+	return s != nil && s.config.Model != "" && s.config.Threshold > 0
+}
+
+// Schedule summarizes the conversation in the background once it has grown past the threshold.
+// At most one summarization runs per conversation at a time.
+func (s *ConversationSummarizer) Schedule(conv *conversation.Conversation) {
+	if !s.Enabled() || conv == nil || conv.ID == 0 {
+		return
+	}
+	if _, busy := s.inflight.LoadOrStore(conv.ID, struct{}{}); busy {
+		return
+	}
+
+	// Work on a copy so the request can keep using its conversation
+	snapshot := *conv
+	conv = &snapshot
+	go func() {
+		defer s.inflight.Delete(conv.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		defer cancel()
+
+		if _, err := s.Summarize(ctx, conv); err != nil {
+			log := logger.GetLogger()
+			log.Warn().
+				Err(err).
+				Str("conversation_id", conv.PublicID).
+				Str("model", s.config.Model).
+				Msg("failed to summarize conversation")
+		}
+	}()
+}
+
+// Summarize stores a new summary item in the active branch when it has more unsummarized items than the
+// threshold. It returns nil when no summary was needed.
+func (s *ConversationSummarizer) Summarize(ctx context.Context, conv *conversation.Conversation) (*conversation.Item, error) {
+	branch := conv.GetActiveBranch()
+	items, err := s.conversationService.GetConversationItems(ctx, conv, branch, &query.Pagination{Order: "asc"})
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to load conversation items")
+	}
+
+	previous, recent := conversation.SplitAtLatestSummary(items)
+	older := summarizableItems(recent, s.config.Threshold, s.config.KeepRecent)
+	if len(older) == 0 {
+		return nil, nil
+	}
+
+	summary, err := s.complete(ctx, summaryPrompt(previous, older), s.config.MaxTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	status := conversation.ItemStatusCompleted
+	role := conversation.ItemRoleSystem
+	item := conversation.Item{
+		Type:      conversation.ItemTypeMessage,
+		Role:      &role,
+		Status:    &status,
+		Content:   []conversation.Content{conversation.NewSummaryTextContent(summary, older[len(older)-1].PublicID)},
+		CreatedAt: time.Now().UTC(),
+	}
+	added, err := s.conversationService.AddItemsToConversation(ctx, conv, branch, []conversation.Item{item})
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to store conversation summary")
+	}
+	return &added[0], nil
+}
+
+// complete runs the summary prompt on the summary model, failing over between its providers.
+func (s *ConversationSummarizer) complete(ctx context.Context, messages []openai.ChatCompletionMessage, maxTokens int) (string, error) {
+	selections, err := s.providerHandler.SelectProviderModelsForModelPublicID(ctx, s.config.Model, "")
+	if err != nil {
+		return "", platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to select summary model")
+	}
+
+	var lastErr error
+	for _, selection := range selections {
+		completer, err := s.inferenceProvider.GetChatCompleter(ctx, selection.Provider)
+		if err != nil {
+			return "", platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to create chat client")
+		}
+
+		endProviderCall := s.providerHandler.BeginProviderCall(selection.Provider.ID)
+		start := time.Now()
+		summary, err := requestSummary(ctx, completer, selection.ProviderModel.ProviderOriginalModelID, messages, maxTokens)
+		endProviderCall(time.Since(start), err)
+		if err != nil {
+			lastErr = err
+			if !chat.IsRetryableError(err) || ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		return summary, nil
+	}
+	if lastErr == nil {
+		return "", platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeNotFound, "no provider available for summary model", nil, "")
+	}
+	return "", platformerrors.AsError(ctx, platformerrors.LayerHandler, lastErr, "summary completion failed")
+}
+
+// requestSummary runs a summary prompt on one provider model.
+func requestSummary(ctx context.Context, completer chat.ChatCompleter, model string, messages []openai.ChatCompletionMessage, maxTokens int) (string, error) {
+	resp, err := completer.CreateChatCompletion(ctx, "", openai.ChatCompletionRequest{
+		Model:       model,
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeExternal, "summary model returned an empty summary", nil, "")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// summarizableItems returns the oldest items to fold into the next summary, or nil while the
+// unsummarized items stay within threshold. The newest keepRecent items are left out, and the cut
+// never separates tool calls or their results from the assistant turn that requested them.
+func summarizableItems(items []conversation.Item, threshold, keepRecent int) []conversation.Item {
+	if threshold <= 0 || len(items) <= threshold {
+		return nil
+	}
+	cut := len(items) - max(keepRecent, 0)
+	for cut > 0 && cut < len(items) && (continuesToolTurn(items[cut]) || requestsTools(items[cut-1])) {
+		cut--
+	}
+	if cut <= 0 {
+		return nil
+	}
+	return items[:cut]
+}
+
+// continuesToolTurn reports whether the item belongs to the tool turn before it: a function_call
+// item continues the assistant message that requested it, and tool results answer a call. Results
+// stored by response-api are function_call_output items without a role.
+func continuesToolTurn(item conversation.Item) bool {
+	if item.Type == conversation.ItemTypeFunctionCall || item.Type == conversation.ItemTypeFunctionCallOut {
+		return true
+	}
+	return item.Role != nil && *item.Role == conversation.ItemRoleTool
+}
+
+// requestsTools reports whether the item calls tools whose results follow it.
+func requestsTools(item conversation.Item) bool {
+	if item.Type == conversation.ItemTypeFunctionCall {
+		return true
+	}
+	if item.Role == nil || *item.Role != conversation.ItemRoleAssistant {
+		return false
+	}
+	for _, content := range item.Content {
+		if len(content.ToolCalls) > 0 || content.FunctionCall != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// summaryPrompt builds the messages asking the summary model to fold items into the previous summary.
+func summaryPrompt(previous *conversation.Item, items []conversation.Item) []openai.ChatCompletionMessage {
+	var transcript strings.Builder
+	if previous != nil {
+		fmt.Fprintf(&transcript, "Previous summary:\n%s\n\n", previous.SummaryText())
+	}
+	transcript.WriteString("Conversation:\n")
+	for _, item := range items {
+		text := strings.TrimSpace(item.Text())
+		if text == "" {
+			continue
+		}
+		role := conversation.ItemRoleUser
+		if item.Role != nil {
+			role = *item.Role
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n", role, text)
+	}
+
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: summarizerInstruction},
+		{Role: openai.ChatMessageRoleUser, Content: transcript.String()},
+	}
+}
+
+// historySummaryPrompt builds the messages asking for a summary of prompt messages dropped from the
+// context window. Long messages are shortened to keep the summary prompt small.
+func historySummaryPrompt(messages []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	var transcript strings.Builder
+	transcript.WriteString("Conversation:\n")
+	for _, msg := range messages {
+		text := strings.TrimSpace(messageText(msg))
+		for _, call := range msg.ToolCalls {
+			text = strings.TrimSpace(fmt.Sprintf("%s\ncalled %s(%s)", text, call.Function.Name, call.Function.Arguments))
+		}
+		if text == "" {
+			continue
+		}
+		if runes := []rune(text); len(runes) > historySummaryMessageRunes {
+			text = string(runes[:historySummaryMessageRunes]) + "..."
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n", msg.Role, text)
+	}
+
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: summarizerInstruction},
+		{Role: openai.ChatMessageRoleUser, Content: transcript.String()},
+	}
+}
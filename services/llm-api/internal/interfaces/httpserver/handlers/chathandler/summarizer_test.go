@@ -0,0 +1,120 @@
+package chathandler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+)
+
+func testItems(n int) []conversation.Item {
+	items := make([]conversation.Item, 0, n)
+	for i := 0; i < n; i++ {
+		role := conversation.ItemRoleUser
+		if i%2 == 1 {
+			role = conversation.ItemRoleAssistant
+		}
+		items = append(items, conversation.Item{
+			PublicID: fmt.Sprintf("msg_%d", i),
+			Type:     conversation.ItemTypeMessage,
+			Role:     &role,
+			Content:  []conversation.Content{conversation.NewTextContent(fmt.Sprintf("turn %d", i))},
+		})
+	}
+	return items
+}
+
+func TestSummarizableItemsRespectsThreshold(t *testing.T) {
+	items := testItems(12)
+	if got := summarizableItems(items, 12, 4); got != nil {
+		t.Fatalf("expected no summary at threshold, got %d items", len(got))
+	}
+
+	got := summarizableItems(items, 10, 4)
+	if len(got) != 8 || got[len(got)-1].PublicID != "msg_7" {
+		t.Fatalf("expected the 8 oldest items, got %d", len(got))
+	}
+
+	toolRole := conversation.ItemRoleTool
+	items[8].Role = &toolRole
+	if got := summarizableItems(items, 10, 4); len(got) != 7 {
+		t.Fatalf("expected cut to keep tool result with its call, got %d items", len(got))
+	}
+}
+
+func TestSummarizableItemsKeepsToolTurnsTogether(t *testing.T) {
+	assistantRole := conversation.ItemRoleAssistant
+	tests := []struct {
+		name  string
+		items func() []conversation.Item
+		want  int
+	}{
+		{
+			// response-api stores a call as a function_call item after the assistant message and
+			// its result as a function_call_output item without a role
+			name: "function call output",
+			items: func() []conversation.Item {
+				items := testItems(12)
+				items[7] = conversation.Item{PublicID: "call_7", Type: conversation.ItemTypeFunctionCall}
+				items[8] = conversation.Item{PublicID: "out_8", Type: conversation.ItemTypeFunctionCallOut}
+				return items
+			},
+			want: 6,
+		},
+		{
+			name: "assistant turn with pending tool calls",
+			items: func() []conversation.Item {
+				items := testItems(12)
+				items[7] = conversation.Item{
+					PublicID: "msg_7",
+					Type:     conversation.ItemTypeMessage,
+					Role:     &assistantRole,
+					Content:  []conversation.Content{{Type: "tool_calls", ToolCalls: []conversation.ToolCall{{ID: "call_1"}}}},
+				}
+				return items
+			},
+			want: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizableItems(tt.items(), 10, 4)
+			if len(got) != tt.want {
+				t.Fatalf("expected %d items, got %d", tt.want, len(got))
+			}
+			if last := got[len(got)-1]; requestsTools(last) || continuesToolTurn(last) {
+				t.Fatalf("expected the summary to end outside a tool turn, got %s", last.PublicID)
+			}
+		})
+	}
+}
+
+func TestSplitAtLatestSummary(t *testing.T) {
+	items := testItems(6)
+	role := conversation.ItemRoleSystem
+	summary := conversation.Item{
+		PublicID: "msg_summary",
+		Type:     conversation.ItemTypeMessage,
+		Role:     &role,
+		Content:  []conversation.Content{conversation.NewSummaryTextContent("user asked about turns", "msg_3")},
+	}
+	items = append(items, summary, testItems(7)[6])
+
+	found, recent := conversation.SplitAtLatestSummary(items)
+	if found == nil || found.SummaryText() != "user asked about turns" {
+		t.Fatalf("expected summary item, got %+v", found)
+	}
+	ids := make([]string, 0, len(recent))
+	for _, item := range recent {
+		ids = append(ids, item.PublicID)
+	}
+	if strings.Join(ids, ",") != "msg_4,msg_5,msg_6" {
+		t.Fatalf("expected items after the summarized range, got %v", ids)
+	}
+
+	prompt := summaryPrompt(found, recent)
+	if !strings.Contains(prompt[1].Content, "Previous summary:\nuser asked about turns") || !strings.Contains(prompt[1].Content, "assistant: turn 5") {
+		t.Fatalf("unexpected summary prompt: %s", prompt[1].Content)
+	}
+}
@@ -3,6 +3,9 @@ package conversationhandler
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -197,6 +200,68 @@ func (h *ConversationHandler) ListConversations(
 	return conversationresponses.NewConversationListResponse(conversations, hasMore, total), nil
 }
 
+// SearchConversations ranks the titles and messages of the user's conversations against a query
+func (h *ConversationHandler) SearchConversations(
+	ctx context.Context,
+	userID uint,
+	params conversationrequests.SearchConversationsQueryParams,
+) (*conversationresponses.SearchResultListResponse, error) {
+	filter := conversation.ConversationSearchFilter{
+		UserID: userID,
+		Query:  params.Query,
+	}
+	if params.ProjectID != nil && *params.ProjectID != "" {
+		filter.ProjectPublicID = params.ProjectID
+	}
+	if params.From != nil && *params.From != "" {
+		from, _, err := parseTimeBound(*params.From)
+		if err != nil {
+			return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, "invalid from: expected RFC 3339 timestamp or YYYY-MM-DD", err, "")
+		}
+		filter.From = &from
+	}
+	if params.To != nil && *params.To != "" {
+		to, dateOnly, err := parseTimeBound(*params.To)
+		if err != nil {
+			return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, "invalid to: expected RFC 3339 timestamp or YYYY-MM-DD", err, "")
+		}
+		if dateOnly {
+			// A date includes the whole day
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	limit := 20
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > 100 {
+			return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, "limit must be between 1 and 100", nil, "")
+		}
+		limit = *params.Limit
+	}
+	offset := 0
+	if params.Offset != nil {
+		if *params.Offset < 0 {
+			return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, "offset cannot be negative", nil, "")
+		}
+		offset = *params.Offset
+	}
+
+	// Fetch limit+1 hits to determine if there are more pages
+	extraLimit := limit + 1
+	hits, err := h.conversationService.SearchConversations(ctx, filter, &query.Pagination{Limit: &extraLimit, Offset: &offset})
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to search conversations")
+	}
+
+	hasMore := len(hits) > limit
+	if hasMore {
+		hits = hits[:limit]
+	}
+
+	return conversationresponses.NewSearchResultListResponse(hits, hasMore), nil
+}
+
 // DeleteConversation deletes a conversation
 func (h *ConversationHandler) DeleteConversation(
 	ctx context.Context,
@@ -210,12 +275,23 @@ func (h *ConversationHandler) DeleteConversation(
 	return conversationresponses.NewConversationDeletedResponse(conversationID), nil
 }
 
-// ListItems lists items in a conversation
+// ListItems lists items in the active branch of a conversation
 func (h *ConversationHandler) ListItems(
 	ctx context.Context,
 	userID uint,
 	conversationID string,
 	pagination *query.Pagination,
+) ([]conversation.Item, error) {
+	return h.ListBranchItems(ctx, userID, conversationID, "", pagination)
+}
+
+// ListBranchItems lists items in a branch of a conversation; an empty branch name lists the active branch
+func (h *ConversationHandler) ListBranchItems(
+	ctx context.Context,
+	userID uint,
+	conversationID string,
+	branchName string,
+	pagination *query.Pagination,
 ) ([]conversation.Item, error) {
 	// Verify conversation ownership
 	conv, err := h.conversationService.GetConversationByPublicIDAndUserID(ctx, conversationID, userID)
@@ -223,8 +299,13 @@ func (h *ConversationHandler) ListItems(
 		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get conversation")
 	}
 
-	// Get items from repository for the active branch
-	items, err := h.conversationService.GetConversationItems(ctx, conv, conv.ActiveBranch, pagination)
+	if branchName == "" {
+		branchName = conv.GetActiveBranch()
+	} else if _, err := h.conversationService.GetBranch(ctx, conv, branchName); err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get branch")
+	}
+
+	items, err := h.conversationService.GetConversationItems(ctx, conv, branchName, pagination)
 	if err != nil {
 		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to list items")
 	}
@@ -280,7 +361,7 @@ func (h *ConversationHandler) CreateItems(
 	}
 
 	// Add items to conversation
-	addedItems, err := h.conversationService.AddItemsToConversation(ctx, conv, conv.ActiveBranch, req.Items)
+	addedItems, err := h.conversationService.AddItemsToConversation(ctx, conv, conv.GetActiveBranch(), req.Items)
 	if err != nil {
 		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to add items")
 	}
@@ -332,6 +413,247 @@ func (h *ConversationHandler) DeleteItem(
 	return conversationresponses.NewConversationResponse(conv), nil
 }
 
+// RateItem stores like/unlike feedback on an item of a conversation
+func (h *ConversationHandler) RateItem(
+	ctx context.Context,
+	userID uint,
+	conversationID string,
+	itemID string,
+	req conversationrequests.RateItemRequest,
+) (*conversationresponses.ItemResponse, error) {
+	// Verify conversation ownership
+	conv, err := h.conversationService.GetConversationByPublicIDAndUserID(ctx, conversationID, userID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get conversation")
+	}
+
+	item, err := h.conversationService.RateItem(ctx, conv, itemID, conversation.ItemRating(req.Rating), req.Comment)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to rate item")
+	}
+
+	return item, nil
+}
+
+// RemoveItemRating clears the feedback on an item of a conversation
+func (h *ConversationHandler) RemoveItemRating(
+	ctx context.Context,
+	userID uint,
+	conversationID string,
+	itemID string,
+) (*conversationresponses.ItemResponse, error) {
+	// Verify conversation ownership
+	conv, err := h.conversationService.GetConversationByPublicIDAndUserID(ctx, conversationID, userID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get conversation")
+	}
+
+	item, err := h.conversationService.RemoveItemRating(ctx, conv, itemID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to remove item rating")
+	}
+
+	return item, nil
+}
+
+// ExportFeedback passes every rated item matching the query to emit, oldest first
+func (h *ConversationHandler) ExportFeedback(
+	ctx context.Context,
+	params conversationrequests.FeedbackExportQueryParams,
+	emit func(conversation.FeedbackRecord) error,
+) error {
+	filter := conversation.FeedbackFilter{Model: params.Model}
+	if params.Rating != nil && *params.Rating != "" {
+		rating, err := conversation.ParseItemRating(*params.Rating)
+		if err != nil {
+			return platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, err.Error(), nil, "")
+		}
+		filter.Rating = rating
+	}
+	if params.From != nil && *params.From != "" {
+		from, _, err := parseTimeBound(*params.From)
+		if err != nil {
+			return platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, "invalid from: expected RFC 3339 timestamp or YYYY-MM-DD", err, "")
+		}
+		filter.RatedAfter = &from
+	}
+	if params.To != nil && *params.To != "" {
+		to, dateOnly, err := parseTimeBound(*params.To)
+		if err != nil {
+			return platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, "invalid to: expected RFC 3339 timestamp or YYYY-MM-DD", err, "")
+		}
+		if dateOnly {
+			// A date includes the whole day
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.RatedBefore = &to
+	}
+
+	if err := h.conversationService.ExportFeedback(ctx, filter, emit); err != nil {
+		return platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to export feedback")
+	}
+	return nil
+}
+
+// ParseExportFormat validates the requested export format
+func (h *ConversationHandler) ParseExportFormat(
+	ctx context.Context,
+	params conversationrequests.ExportQueryParams,
+) (conversation.ExportFormat, error) {
+	var value string
+	if params.Format != nil {
+		value = strings.ToLower(strings.TrimSpace(*params.Format))
+	}
+	format, err := conversation.ParseExportFormat(value)
+	if err != nil {
+		return "", platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, err.Error(), nil, "")
+	}
+	return format, nil
+}
+
+// ExportConversation exports a conversation with the items of all its branches
+func (h *ConversationHandler) ExportConversation(
+	ctx context.Context,
+	userID uint,
+	conversationID string,
+) (*conversation.ConversationExport, error) {
+	conv, err := h.conversationService.GetConversationByPublicIDAndUserID(ctx, conversationID, userID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get conversation")
+	}
+
+	export, err := h.conversationService.ExportConversation(ctx, conv)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to export conversation")
+	}
+	return export, nil
+}
+
+// ExportUserConversations streams every conversation of the user to the export writer
+func (h *ConversationHandler) ExportUserConversations(
+	ctx context.Context,
+	userID uint,
+	writer *conversationresponses.ExportWriter,
+) error {
+	if err := h.conversationService.ExportUserConversations(ctx, userID, writer.Write); err != nil {
+		return platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to export conversations")
+	}
+	return nil
+}
+
+// ImportConversations parses an export document and creates its conversations for the user
+func (h *ConversationHandler) ImportConversations(
+	ctx context.Context,
+	userID uint,
+	r io.Reader,
+) (*conversationresponses.ConversationImportResponse, error) {
+	exports, err := conversation.ParseConversationImport(r)
+	if err != nil {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, err.Error(), err, "")
+	}
+
+	conversations, err := h.conversationService.ImportConversations(ctx, userID, exports)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to import conversations")
+	}
+	return conversationresponses.NewConversationImportResponse(conversations), nil
+}
+
+// ListBranches lists the branches of a conversation
+func (h *ConversationHandler) ListBranches(
+	ctx context.Context,
+	userID uint,
+	conversationID string,
+) (*conversationresponses.BranchListResponse, error) {
+	// Verify conversation ownership
+	conv, err := h.conversationService.GetConversationByPublicIDAndUserID(ctx, conversationID, userID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get conversation")
+	}
+
+	branches, err := h.conversationService.ListBranches(ctx, conv)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to list branches")
+	}
+
+	return conversationresponses.NewBranchListResponse(branches, conv.GetActiveBranch()), nil
+}
+
+// CreateBranch forks a new branch from a branch of a conversation
+func (h *ConversationHandler) CreateBranch(
+	ctx context.Context,
+	userID uint,
+	conversationID string,
+	req conversationrequests.CreateBranchRequest,
+) (*conversationresponses.BranchResponse, error) {
+	// Verify conversation ownership
+	conv, err := h.conversationService.GetConversationByPublicIDAndUserID(ctx, conversationID, userID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get conversation")
+	}
+
+	input := conversation.ForkBranchInput{
+		Description: req.Description,
+		Activate:    req.Activate,
+	}
+	if req.Name != nil {
+		input.Name = *req.Name
+	}
+	if req.SourceBranch != nil {
+		input.SourceBranch = *req.SourceBranch
+	}
+	if req.FromItemID != nil {
+		input.FromItemID = *req.FromItemID
+	}
+
+	branch, err := h.conversationService.ForkBranch(ctx, conv, input)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to create branch")
+	}
+
+	return conversationresponses.NewBranchResponse(branch, conv.GetActiveBranch()), nil
+}
+
+// ActivateBranch makes a branch the active branch of a conversation
+func (h *ConversationHandler) ActivateBranch(
+	ctx context.Context,
+	userID uint,
+	conversationID string,
+	branchName string,
+) (*conversationresponses.ConversationResponse, error) {
+	// Verify conversation ownership
+	conv, err := h.conversationService.GetConversationByPublicIDAndUserID(ctx, conversationID, userID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get conversation")
+	}
+
+	if err := h.conversationService.SwitchBranch(ctx, conv, branchName); err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to activate branch")
+	}
+
+	return conversationresponses.NewConversationResponse(conv), nil
+}
+
+// DeleteBranch deletes a non-MAIN branch of a conversation
+func (h *ConversationHandler) DeleteBranch(
+	ctx context.Context,
+	userID uint,
+	conversationID string,
+	branchName string,
+) (*conversationresponses.BranchDeletedResponse, error) {
+	// Verify conversation ownership
+	conv, err := h.conversationService.GetConversationByPublicIDAndUserID(ctx, conversationID, userID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get conversation")
+	}
+
+	if err := h.conversationService.DeleteBranch(ctx, conv, branchName); err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to delete branch")
+	}
+
+	return conversationresponses.NewBranchDeletedResponse(branchName), nil
+}
+
 // Helper functions
 
 // addItemsToConversation adds items to a conversation
@@ -410,3 +732,12 @@ func GetConversationFromContext(reqCtx *gin.Context) (*conversation.Conversation
 	}
 	return v, true
 }
+
+// parseTimeBound parses an RFC 3339 timestamp or a YYYY-MM-DD date (UTC), reporting which one was given
+func parseTimeBound(value string) (time.Time, bool, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, true, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	return parsed, false, err
+}
@@ -0,0 +1,96 @@
+package conversationhandler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+	"jan-server/services/llm-api/internal/domain/query"
+	conversationrequests "jan-server/services/llm-api/internal/interfaces/httpserver/requests/conversation"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// filterRecorder is a ConversationRepository that records the item filter of an export and finds
+// no items. Other methods are left to the embedded nil interface.
+type filterRecorder struct {
+	conversation.ConversationRepository
+	filter *conversation.ItemFilter
+}
+
+func (r *filterRecorder) FindItemsByFilter(_ context.Context, filter conversation.ItemFilter, _ *query.Pagination) ([]*conversation.Item, error) {
+	r.filter = &filter
+	return nil, nil
+}
+
+func exportFilter(t *testing.T, params conversationrequests.FeedbackExportQueryParams) (*conversation.ItemFilter, error) {
+	t.Helper()
+	repo := &filterRecorder{}
+	handler := NewConversationHandler(conversation.NewConversationService(repo), nil)
+	err := handler.ExportFeedback(context.Background(), params, func(conversation.FeedbackRecord) error { return nil })
+	return repo.filter, err
+}
+
+func TestExportFeedbackFilters(t *testing.T) {
+	model := "jan-v1-4b"
+	rating := "unlike"
+	from := "2025-01-01"
+	to := "2025-01-31"
+
+	filter, err := exportFilter(t, conversationrequests.FeedbackExportQueryParams{Model: &model, Rating: &rating, From: &from, To: &to})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Model == nil || *filter.Model != model {
+		t.Fatalf("expected the model filter, got %v", filter.Model)
+	}
+	if filter.Rating == nil || *filter.Rating != conversation.ItemRatingUnlike {
+		t.Fatalf("expected the rating filter, got %v", filter.Rating)
+	}
+	if want := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC); filter.RatedAfter == nil || !filter.RatedAfter.Equal(want) {
+		t.Fatalf("expected rated after %s, got %v", want, filter.RatedAfter)
+	}
+	// A date as upper bound includes the whole day
+	if want := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC); filter.RatedBefore == nil || !filter.RatedBefore.Equal(want) {
+		t.Fatalf("expected rated before %s, got %v", want, filter.RatedBefore)
+	}
+}
+
+func TestExportFeedbackTimestampBound(t *testing.T) {
+	to := "2025-01-31T12:00:00Z"
+
+	filter, err := exportFilter(t, conversationrequests.FeedbackExportQueryParams{To: &to})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC); filter.RatedBefore == nil || !filter.RatedBefore.Equal(want) {
+		t.Fatalf("expected rated before %s, got %v", want, filter.RatedBefore)
+	}
+	if filter.RatedAfter != nil || filter.Rating != nil {
+		t.Fatalf("expected no other bounds, got %+v", filter)
+	}
+}
+
+func TestExportFeedbackRejectsInvalidFilters(t *testing.T) {
+	invalidRating := "love"
+	invalidTime := "last week"
+	tests := []struct {
+		name   string
+		params conversationrequests.FeedbackExportQueryParams
+	}{
+		{"rating", conversationrequests.FeedbackExportQueryParams{Rating: &invalidRating}},
+		{"from", conversationrequests.FeedbackExportQueryParams{From: &invalidTime}},
+		{"to", conversationrequests.FeedbackExportQueryParams{To: &invalidTime}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := exportFilter(t, tt.params)
+			if !platformerrors.IsErrorType(err, platformerrors.ErrorTypeValidation) {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+			if filter != nil {
+				t.Fatal("expected no export to run")
+			}
+		})
+	}
+}
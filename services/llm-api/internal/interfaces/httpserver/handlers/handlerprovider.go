@@ -17,6 +17,7 @@ var HandlerProvider = wire.NewSet(
 	apikeyhandler.NewHandler,
 	guestauth.NewGuestHandler,
 	guestauth.NewUpgradeHandler,
+	chathandler.ProvideConversationSummarizer,
 	chathandler.NewChatHandler,
 	conversationhandler.NewConversationHandler,
 	modelhandler.NewModelHandler,
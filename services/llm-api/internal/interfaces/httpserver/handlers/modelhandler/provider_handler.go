@@ -3,6 +3,7 @@ package modelhandler
 import (
 	"context"
 	"strings"
+	"time"
 
 	domainmodel "jan-server/services/llm-api/internal/domain/model"
 	"jan-server/services/llm-api/internal/infrastructure/inference"
@@ -14,21 +15,33 @@ import (
 type ProviderHandler struct {
 	providerService      *domainmodel.ProviderService
 	providerModelService *domainmodel.ProviderModelService
+	providerRouter       *domainmodel.ProviderRouter
+	healthTracker        *domainmodel.ProviderHealthTracker
 	inferenceProvider    *inference.InferenceProvider
 }
 
 func NewProviderHandler(
 	providerService *domainmodel.ProviderService,
 	providerModelService *domainmodel.ProviderModelService,
+	providerRouter *domainmodel.ProviderRouter,
+	healthTracker *domainmodel.ProviderHealthTracker,
 	inferenceProvider *inference.InferenceProvider,
 ) *ProviderHandler {
 	return &ProviderHandler{
 		providerService:      providerService,
 		providerModelService: providerModelService,
+		providerRouter:       providerRouter,
+		healthTracker:        healthTracker,
 		inferenceProvider:    inferenceProvider,
 	}
 }
 
+// BeginProviderCall records an upstream call for routing statistics; call the returned
+// function with the call duration and error once it finishes.
+func (providerHandler *ProviderHandler) BeginProviderCall(providerID uint) func(duration time.Duration, err error) {
+	return providerHandler.providerRouter.Begin(providerID)
+}
+
 func (providerHandler *ProviderHandler) RegisterProvider(addProviderRequest requestmodels.AddProviderRequest, ctx context.Context) (*modelresponses.ProviderWithModelsResponse, error) {
 
 	// Check if provider with the same vendor already exists if vendor != "custom"
@@ -101,107 +114,71 @@ func (providerHandler *ProviderHandler) GetAllProviders(ctx context.Context) ([]
 }
 
 func (providerHandler *ProviderHandler) SelectProviderModelForModelPublicID(ctx context.Context, modelPublicID string) (*domainmodel.ProviderModel, *domainmodel.Provider, error) {
+	selections, err := providerHandler.SelectProviderModelsForModelPublicID(ctx, modelPublicID, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return selections[0].ProviderModel, selections[0].Provider, nil
+}
+
+// SelectProviderModelsForModelPublicID returns every active provider model serving the model,
+// ordered by the configured routing strategy, so callers can fail over to the next entry when
+// an upstream is unavailable. conversationID is used by sticky routing and may be empty.
+func (providerHandler *ProviderHandler) SelectProviderModelsForModelPublicID(ctx context.Context, modelPublicID string, conversationID string) ([]domainmodel.ProviderSelection, error) {
 	if strings.TrimSpace(modelPublicID) == "" {
-		return nil, nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, "model key is required", nil, "abeb247f-ef80-44bf-921b-6e2c92ffca73")
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, "model key is required", nil, "abeb247f-ef80-44bf-921b-6e2c92ffca73")
 	}
 	var providerModels []*domainmodel.ProviderModel
 
 	providerModels, err := providerHandler.providerModelService.FindActiveByModelKey(ctx, modelPublicID)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if len(providerModels) == 0 {
-		return nil, nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeNotFound, "model not found in accessible providers", nil, "caa8476d-1b95-42a7-a96b-18b0c11b2f64")
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeNotFound, "model not found in accessible providers", nil, "caa8476d-1b95-42a7-a96b-18b0c11b2f64")
 	}
 
-	selectedProviderModel := providerHandler.selectBestProvider(providerModels)
-	if selectedProviderModel == nil {
-		return nil, nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeNotFound, "no valid provider found for model", nil, "265747b1-0aee-4a99-863e-99a7af8ada5e")
+	providerIDs := make([]uint, 0, len(providerModels))
+	for _, providerModel := range providerModels {
+		if providerModel != nil {
+			providerIDs = append(providerIDs, providerModel.ProviderID)
+		}
 	}
-	selectedProvider, err := providerHandler.providerService.GetByID(ctx, selectedProviderModel.ProviderID)
+	providers, err := providerHandler.providerService.GetByIDs(ctx, providerIDs)
 	if err != nil {
-		return nil, nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get provider details")
-	}
-	return selectedProviderModel, selectedProvider, nil
-}
-
-// selectBestProvider selects the best provider for a model based on:
-// 1. LOWEST PRICING (if pricing data exists)
-// 2. MENLO PROVIDER (if prices are equal or no pricing)
-// 3. FIRST PROVIDER (if all criteria equal)
-func (providerHandler *ProviderHandler) selectBestProvider(
-	providerModels []*domainmodel.ProviderModel,
-) *domainmodel.ProviderModel {
-	if len(providerModels) == 0 {
-		return nil
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to get provider details")
 	}
 
-	type providerCandidate struct {
-		providerModel *domainmodel.ProviderModel
-		hasPricing    bool
-		lowestPrice   domainmodel.MicroUSD
-		isJan         bool
+	candidates := domainmodel.NewProviderSelections(providerModels, providers)
+	if len(candidates) == 0 {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeNotFound, "no valid provider found for model", nil, "265747b1-0aee-4a99-863e-99a7af8ada5e")
 	}
 
-	candidates := make([]providerCandidate, 0, len(providerModels))
+	// Skip providers whose circuit breaker is open
+	candidates = providerHandler.healthTracker.FilterAvailable(candidates)
 
-	for _, providerModel := range providerModels {
-		if providerModel == nil {
-			continue
-		}
-
-		lowestPrice, hasPricing := calculateLowestPrice(providerModel.Pricing)
-		isJan := providerModel.Kind == domainmodel.ProviderJan
+	return providerHandler.providerRouter.Rank(candidates, domainmodel.RoutingHint{
+		ModelPublicID:  strings.TrimSpace(modelPublicID),
+		ConversationID: conversationID,
+	}), nil
+}
 
-		candidates = append(candidates, providerCandidate{
-			providerModel: providerModel,
-			hasPricing:    hasPricing,
-			lowestPrice:   lowestPrice,
-			isJan:         isJan,
-		})
+// GetProviderHealth returns the circuit breaker state and recent error counts for a provider.
+func (h *ProviderHandler) GetProviderHealth(ctx context.Context, publicID string) (*modelresponses.ProviderHealthResponse, error) {
+	if publicID == "" {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeValidation, "provider public ID is required", nil, "5f0b7d2e-3c41-4a8e-b6f9-2d7e1c9a4b30")
 	}
 
-	if len(candidates) == 0 {
-		return nil
+	provider, err := h.providerService.FindByPublicID(ctx, publicID)
+	if err != nil {
+		return nil, platformerrors.AsError(ctx, platformerrors.LayerHandler, err, "failed to find provider")
 	}
-
-	// Find the best candidate
-	best := candidates[0]
-	for i := 1; i < len(candidates); i++ {
-		candidate := candidates[i]
-
-		// Compare pricing first (if both have pricing)
-		if candidate.hasPricing && best.hasPricing {
-			if candidate.lowestPrice < best.lowestPrice {
-				best = candidate
-				continue
-			} else if candidate.lowestPrice > best.lowestPrice {
-				continue
-			}
-			// Prices are equal, continue to next criteria
-		}
-
-		// Prefer candidate with pricing over one without
-		if candidate.hasPricing && !best.hasPricing {
-			best = candidate
-			continue
-		}
-		if !candidate.hasPricing && best.hasPricing {
-			continue
-		}
-
-		// Prefer Jan provider
-		if candidate.isJan && !best.isJan {
-			best = candidate
-			continue
-		}
-		if !candidate.isJan && best.isJan {
-			continue
-		}
-
+	if provider == nil {
+		return nil, platformerrors.NewError(ctx, platformerrors.LayerHandler, platformerrors.ErrorTypeNotFound, "provider not found", nil, "a3c9e6b1-7d24-4f58-9e0a-6b1d8c2f5e47")
 	}
 
-	return best.providerModel
+	response := modelresponses.BuildProviderHealthResponse(provider, h.healthTracker.Health(provider.ID))
+	return &response, nil
 }
 
 func (h *ProviderHandler) UpdateProvider(
@@ -237,23 +214,3 @@ func (h *ProviderHandler) UpdateProvider(
 	response := modelresponses.BuildProviderResponse(updatedProvider)
 	return &response, nil
 }
-
-// TODO(pricing): Remove pricing calculation from model handler
-// This function calculates the lowest price for a provider model, but pricing logic
-// should be handled by a dedicated billing domain, not in the model management layer.
-// Consider removing this once pricing is moved to the billing domain.
-// Related: See TODO in internal/domain/model/provider_model.go
-func calculateLowestPrice(pricing domainmodel.Pricing) (domainmodel.MicroUSD, bool) {
-	if len(pricing.Lines) == 0 {
-		return 0, false
-	}
-
-	lowest := pricing.Lines[0].Amount
-	for _, line := range pricing.Lines[1:] {
-		if line.Amount < lowest {
-			lowest = line.Amount
-		}
-	}
-
-	return lowest, true
-}
@@ -121,7 +121,7 @@ func (h *ProjectHandler) UpdateProject(
 	}
 	if req.Instruction != nil {
 		trimmed := strings.TrimSpace(*req.Instruction)
-		proj.Instruction = &trimmed
+		proj.SetInstruction(&trimmed)
 	}
 	if req.Favorite != nil {
 		proj.Favorite = *req.Favorite
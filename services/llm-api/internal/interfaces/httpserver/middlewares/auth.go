@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -53,6 +54,23 @@ func AuthMiddleware(validator *authvalidator.KeycloakValidator, logger zerolog.L
 	}
 }
 
+// RequireRole rejects requests whose principal neither has the realm role nor, for API keys, belongs
+// to the consumer group of that name.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := PrincipalFromContext(c)
+		if !ok {
+			responses.HandleErrorWithStatus(c, http.StatusUnauthorized, errors.New("authentication required"), "unauthorized")
+			return
+		}
+		if !principal.HasRole(role) && !principal.HasScope(role) {
+			responses.HandleErrorWithStatus(c, http.StatusForbidden, fmt.Errorf("role %s required", role), "forbidden")
+			return
+		}
+		c.Next()
+	}
+}
+
 // PrincipalFromContext returns the authenticated principal, if any.
 func PrincipalFromContext(c *gin.Context) (domain.Principal, bool) {
 	val, ok := c.Get(principalContextKey)
@@ -188,6 +206,7 @@ func principalFromJWT(c *gin.Context, validator *authvalidator.KeycloakValidator
 		Email:           claims.Email,
 		Name:            claims.Name,
 		Scopes:          claims.Scopes,
+		Roles:           claims.Roles,
 		Credentials:     credentials,
 	}, true, nil
 }
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"jan-server/services/llm-api/internal/domain"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name      string
+		principal *domain.Principal
+		status    int
+	}{
+		{"realm role", &domain.Principal{ID: "u1", Roles: []string{"admin"}}, http.StatusOK},
+		{"consumer group", &domain.Principal{ID: "k1", Scopes: []string{"admin"}}, http.StatusOK},
+		{"other role", &domain.Principal{ID: "u2", Roles: []string{"guest"}, Scopes: []string{"openid"}}, http.StatusForbidden},
+		{"no principal", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/export", func(c *gin.Context) {
+				if tt.principal != nil {
+					setPrincipal(c, *tt.principal)
+				}
+				c.Next()
+			}, RequireRole("admin"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/export", nil))
+			if recorder.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, recorder.Code)
+			}
+		})
+	}
+}
@@ -20,6 +20,23 @@ type ChatCompletionRequest struct {
 	Store *bool `json:"store,omitempty"`
 	// StoreReasoning controls whether reasoning content (if present) should also be persisted
 	StoreReasoning *bool `json:"store_reasoning,omitempty"`
+	// History controls how conversation items are fitted into the model context window
+	History *HistoryOptions `json:"history,omitempty"`
+}
+
+// EditItemRequest edits a user message of a conversation and regenerates the reply. The completion
+// options apply to the regenerated reply; Messages and Conversation are not accepted.
+type EditItemRequest struct {
+	ChatCompletionRequest
+	// Content replaces the text of the edited user message
+	Content string `json:"content" binding:"required"`
+}
+
+// HistoryOptions configures how prepended conversation history is fitted into the context window
+type HistoryOptions struct {
+	// Strategy is one of "full", "truncate" or "summarize". Overrides the conversation's
+	// "history_strategy" metadata; defaults to "truncate".
+	Strategy string `json:"strategy,omitempty"`
 }
 
 // ConversationReference can unmarshal from either a string (ID) or an object
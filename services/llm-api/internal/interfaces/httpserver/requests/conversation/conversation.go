@@ -44,3 +44,41 @@ type ListItemsQueryParams struct {
 type GetItemQueryParams struct {
 	Include []string `form:"include"`
 }
+
+// SearchConversationsQueryParams represents query parameters for searching conversations
+type SearchConversationsQueryParams struct {
+	Query     string  `form:"q" binding:"required"`
+	ProjectID *string `form:"project_id"`
+	From      *string `form:"from"` // RFC 3339 timestamp or YYYY-MM-DD, inclusive
+	To        *string `form:"to"`   // RFC 3339 timestamp (exclusive) or YYYY-MM-DD (inclusive)
+	Limit     *int    `form:"limit"`
+	Offset    *int    `form:"offset"`
+}
+
+// CreateBranchRequest represents the request to fork a conversation branch
+type CreateBranchRequest struct {
+	Name         *string `json:"name,omitempty"`          // Defaults to a generated EDIT_ name
+	SourceBranch *string `json:"source_branch,omitempty"` // Defaults to the active branch
+	FromItemID   *string `json:"from_item_id,omitempty"`  // Last item copied; defaults to the whole source branch
+	Description  *string `json:"description,omitempty"`
+	Activate     bool    `json:"activate,omitempty"` // Make the new branch the active branch
+}
+
+// RateItemRequest represents the feedback given on a conversation item
+type RateItemRequest struct {
+	Rating  string  `json:"rating" binding:"required"` // "like" or "unlike"
+	Comment *string `json:"comment,omitempty"`
+}
+
+// FeedbackExportQueryParams represents query parameters for exporting rated items
+type FeedbackExportQueryParams struct {
+	Model  *string `form:"model"`
+	Rating *string `form:"rating"` // "like" or "unlike"
+	From   *string `form:"from"`   // RFC 3339 timestamp or YYYY-MM-DD, inclusive
+	To     *string `form:"to"`     // RFC 3339 timestamp (exclusive) or YYYY-MM-DD (inclusive)
+}
+
+// ExportQueryParams represents query parameters for exporting conversations
+type ExportQueryParams struct {
+	Format *string `form:"format"` // "json" (default), "jsonl" or "markdown"
+}
@@ -7,25 +7,29 @@ import (
 // ChatCompletionResponse extends OpenAI's ChatCompletionResponse with conversation context
 type ChatCompletionResponse struct {
 	openai.ChatCompletionResponse
-	Conversation *ConversationContext `json:"conversation,omitempty"`
+	Conversation   *ConversationContext `json:"conversation,omitempty"`
+	UsageEstimated bool                 `json:"usage_estimated"` // Usage approximates the model's tokenizer instead of measuring it
 }
 
 // ConversationContext represents the conversation associated with this response
 type ConversationContext struct {
-	ID    string  `json:"id"`              // The unique ID of the conversation
-	Title *string `json:"title,omitempty"` // The title of the conversation (optional)
+	ID     string  `json:"id"`               // The unique ID of the conversation
+	Title  *string `json:"title,omitempty"`  // The title of the conversation (optional)
+	Branch string  `json:"branch,omitempty"` // The active branch the completion was stored in
 }
 
 // NewChatCompletionResponse creates a response with optional conversation context
-func NewChatCompletionResponse(openaiResp *openai.ChatCompletionResponse, conversationID string, conversationTitle *string) *ChatCompletionResponse {
+func NewChatCompletionResponse(openaiResp *openai.ChatCompletionResponse, conversationID string, conversationTitle *string, conversationBranch string, usageEstimated bool) *ChatCompletionResponse {
 	resp := &ChatCompletionResponse{
 		ChatCompletionResponse: *openaiResp,
+		UsageEstimated:         usageEstimated,
 	}
 
 	if conversationID != "" {
 		resp.Conversation = &ConversationContext{
-			ID:    conversationID,
-			Title: conversationTitle,
+			ID:     conversationID,
+			Title:  conversationTitle,
+			Branch: conversationBranch,
 		}
 	}
 
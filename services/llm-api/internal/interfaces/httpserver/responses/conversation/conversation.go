@@ -6,13 +6,14 @@ import (
 
 // ConversationResponse represents the OpenAI-compatible conversation response
 type ConversationResponse struct {
-	ID        string            `json:"id"`
-	Object    string            `json:"object"`
-	Title     *string           `json:"title,omitempty"`
-	CreatedAt int64             `json:"created_at"`
-	Metadata  map[string]string `json:"metadata,omitempty"`
-	Referrer  *string           `json:"referrer,omitempty"`
-	ProjectID *string           `json:"project_id,omitempty"`
+	ID           string            `json:"id"`
+	Object       string            `json:"object"`
+	Title        *string           `json:"title,omitempty"`
+	CreatedAt    int64             `json:"created_at"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Referrer     *string           `json:"referrer,omitempty"`
+	ProjectID    *string           `json:"project_id,omitempty"`
+	ActiveBranch string            `json:"active_branch"`
 }
 
 // ConversationListResponse represents a paginated list of conversations
@@ -41,16 +42,66 @@ type ItemListResponse struct {
 	HasMore bool                `json:"has_more"`
 }
 
+// BranchResponse represents a conversation branch
+type BranchResponse struct {
+	Name             string  `json:"name"`
+	Object           string  `json:"object"`
+	Description      *string `json:"description,omitempty"`
+	ParentBranch     *string `json:"parent_branch,omitempty"`
+	ForkedAt         *int64  `json:"forked_at,omitempty"`
+	ForkedFromItemID *string `json:"forked_from_item_id,omitempty"`
+	ItemCount        int     `json:"item_count"`
+	IsActive         bool    `json:"is_active"`
+	CreatedAt        int64   `json:"created_at"`
+	UpdatedAt        int64   `json:"updated_at"`
+}
+
+// BranchListResponse represents the branches of a conversation
+type BranchListResponse struct {
+	Object       string           `json:"object"`
+	Data         []BranchResponse `json:"data"`
+	ActiveBranch string           `json:"active_branch"`
+}
+
+// BranchDeletedResponse represents the branch delete confirmation response
+type BranchDeletedResponse struct {
+	Name    string `json:"name"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
+
+// SearchHitResponse represents a conversation title or message matching a search
+type SearchHitResponse struct {
+	Object            string  `json:"object"`
+	ConversationID    string  `json:"conversation_id"`
+	ConversationTitle *string `json:"conversation_title,omitempty"`
+	ProjectID         *string `json:"project_id,omitempty"`
+	ItemID            *string `json:"item_id,omitempty"`
+	Branch            *string `json:"branch,omitempty"`
+	Role              *string `json:"role,omitempty"`
+	Snippet           string  `json:"snippet"`
+	Rank              float64 `json:"rank"`
+	CreatedAt         int64   `json:"created_at"`
+}
+
+// SearchResultListResponse represents a page of ranked search hits
+type SearchResultListResponse struct {
+	Object  string              `json:"object"`
+	Data    []SearchHitResponse `json:"data"`
+	HasMore bool                `json:"has_more"`
+}
+
 // NewConversationResponse creates a response from a domain conversation
 func NewConversationResponse(conv *conversation.Conversation) *ConversationResponse {
 	response := &ConversationResponse{
-		ID:        conv.PublicID,
-		Object:    "conversation",
-		Title:     conv.Title,
-		CreatedAt: conv.CreatedAt.Unix(),
-		Metadata:  conv.Metadata,
-		Referrer:  conv.Referrer,
-		ProjectID: conv.ProjectPublicID,
+		ID:           conv.PublicID,
+		Object:       "conversation",
+		Title:        conv.Title,
+		CreatedAt:    conv.CreatedAt.Unix(),
+		Metadata:     conv.Metadata,
+		Referrer:     conv.Referrer,
+		ProjectID:    conv.ProjectPublicID,
+		ActiveBranch: conv.GetActiveBranch(),
 	}
 	return response
 }
@@ -147,3 +198,78 @@ func NewConversationItemCreatedResponse(items []conversation.Item) *Conversation
 		HasMore: false,
 	}
 }
+
+// NewBranchResponse creates a response from domain branch metadata
+func NewBranchResponse(branch *conversation.BranchMetadata, activeBranch string) *BranchResponse {
+	response := &BranchResponse{
+		Name:             branch.Name,
+		Object:           "conversation.branch",
+		Description:      branch.Description,
+		ParentBranch:     branch.ParentBranch,
+		ForkedFromItemID: branch.ForkedFromItemID,
+		ItemCount:        branch.ItemCount,
+		IsActive:         branch.Name == activeBranch,
+		CreatedAt:        branch.CreatedAt.Unix(),
+		UpdatedAt:        branch.UpdatedAt.Unix(),
+	}
+	if branch.ForkedAt != nil {
+		forkedAt := branch.ForkedAt.Unix()
+		response.ForkedAt = &forkedAt
+	}
+	return response
+}
+
+// NewBranchListResponse creates a branch list response
+func NewBranchListResponse(branches []*conversation.BranchMetadata, activeBranch string) *BranchListResponse {
+	data := make([]BranchResponse, 0, len(branches))
+	for _, branch := range branches {
+		if branch == nil {
+			continue
+		}
+		data = append(data, *NewBranchResponse(branch, activeBranch))
+	}
+
+	return &BranchListResponse{
+		Object:       "list",
+		Data:         data,
+		ActiveBranch: activeBranch,
+	}
+}
+
+// NewBranchDeletedResponse creates a branch delete response
+func NewBranchDeletedResponse(name string) *BranchDeletedResponse {
+	return &BranchDeletedResponse{
+		Name:    name,
+		Object:  "conversation.branch.deleted",
+		Deleted: true,
+	}
+}
+
+// NewSearchResultListResponse creates a search response from domain search hits
+func NewSearchResultListResponse(hits []*conversation.SearchHit, hasMore bool) *SearchResultListResponse {
+	data := make([]SearchHitResponse, 0, len(hits))
+	for _, hit := range hits {
+		response := SearchHitResponse{
+			Object:            "conversation.search_hit",
+			ConversationID:    hit.ConversationID,
+			ConversationTitle: hit.ConversationTitle,
+			ProjectID:         hit.ProjectID,
+			ItemID:            hit.ItemID,
+			Branch:            hit.Branch,
+			Snippet:           hit.Snippet,
+			Rank:              hit.Rank,
+			CreatedAt:         hit.MatchedAt.Unix(),
+		}
+		if hit.Role != nil {
+			role := string(*hit.Role)
+			response.Role = &role
+		}
+		data = append(data, response)
+	}
+
+	return &SearchResultListResponse{
+		Object:  "list",
+		Data:    data,
+		HasMore: hasMore,
+	}
+}
@@ -0,0 +1,185 @@
+package conversationresponses
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"jan-server/services/llm-api/internal/domain/conversation"
+)
+
+// markdownTimeLayout formats timestamps in Markdown transcripts
+const markdownTimeLayout = "2006-01-02 15:04 UTC"
+
+// ConversationImportResponse represents the conversations created by an import
+type ConversationImportResponse struct {
+	Object string                 `json:"object"`
+	Data   []ConversationResponse `json:"data"`
+}
+
+// NewConversationImportResponse creates an import response from the created conversations
+func NewConversationImportResponse(conversations []*conversation.Conversation) *ConversationImportResponse {
+	data := make([]ConversationResponse, 0, len(conversations))
+	for _, conv := range conversations {
+		data = append(data, *NewConversationResponse(conv))
+	}
+	return &ConversationImportResponse{
+		Object: "list",
+		Data:   data,
+	}
+}
+
+// NewMarkdownTranscript renders the active branch of an exported conversation as a readable
+// Markdown transcript. Summaries and reasoning are left out.
+func NewMarkdownTranscript(export *conversation.ConversationExport) string {
+	var b strings.Builder
+
+	title := "Untitled conversation"
+	if export.Title != nil && strings.TrimSpace(*export.Title) != "" {
+		title = *export.Title
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "- Conversation: `%s`\n", export.ID)
+	fmt.Fprintf(&b, "- Created: %s\n", export.CreatedAt.UTC().Format(markdownTimeLayout))
+	fmt.Fprintf(&b, "- Branch: `%s`\n", export.ActiveBranch)
+
+	branch := export.Branch(export.ActiveBranch)
+	if branch == nil {
+		return b.String()
+	}
+	for _, item := range branch.Items {
+		heading, body := markdownEntry(item)
+		if heading == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n---\n\n### %s · %s\n\n%s\n", heading, item.CreatedAt.UTC().Format(markdownTimeLayout), body)
+	}
+	return b.String()
+}
+
+// markdownEntry returns the heading and body of an item, or an empty heading to skip it
+func markdownEntry(item conversation.Item) (string, string) {
+	if call := item.FunctionCall(); call != nil {
+		return fmt.Sprintf("Tool call: %s", call.Name), fence(call.Arguments)
+	}
+	if output := item.FunctionCallOutput(); output != nil {
+		return "Tool result", fence(output.Output)
+	}
+	if item.Type != conversation.ItemTypeMessage || item.Role == nil || item.IsSummary() {
+		return "", ""
+	}
+	text := item.Text()
+	if strings.TrimSpace(text) == "" {
+		return "", ""
+	}
+
+	role := string(*item.Role)
+	heading := strings.ToUpper(role[:1]) + role[1:]
+	if item.Model != nil && *item.Model != "" {
+		heading = fmt.Sprintf("%s (%s)", heading, *item.Model)
+	}
+	return heading, text
+}
+
+// fence wraps text in a code block that its own backticks cannot close
+func fence(text string) string {
+	marker := "```"
+	for strings.Contains(text, marker) {
+		marker += "`"
+	}
+	return fmt.Sprintf("%s\n%s\n%s", marker, text, marker)
+}
+
+// ExportWriter writes conversation exports in the requested format. Bulk exports in JSON
+// are wrapped in a list object, so Close must be called after the last conversation.
+type ExportWriter struct {
+	w       io.Writer
+	format  conversation.ExportFormat
+	bulk    bool
+	written int
+}
+
+// NewExportWriter creates a writer for one conversation, or for a bulk export of many
+func NewExportWriter(w io.Writer, format conversation.ExportFormat, bulk bool) *ExportWriter {
+	return &ExportWriter{w: w, format: format, bulk: bulk}
+}
+
+// ContentType returns the MIME type of the export format
+func (w *ExportWriter) ContentType() string {
+	switch w.format {
+	case conversation.ExportFormatJSONL:
+		return "application/x-ndjson"
+	case conversation.ExportFormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// Written returns the number of conversations written so far
+func (w *ExportWriter) Written() int {
+	return w.written
+}
+
+// Write appends a conversation to the export
+func (w *ExportWriter) Write(export *conversation.ConversationExport) error {
+	var prefix string
+	switch {
+	case w.format == conversation.ExportFormatJSON && w.bulk && w.written == 0:
+		prefix = `{"object":"list","data":[`
+	case w.format == conversation.ExportFormatJSON && w.bulk:
+		prefix = ","
+	case w.format == conversation.ExportFormatMarkdown && w.written > 0:
+		prefix = "\n"
+	}
+	if prefix != "" {
+		if _, err := io.WriteString(w.w, prefix); err != nil {
+			return err
+		}
+	}
+
+	if w.format == conversation.ExportFormatMarkdown {
+		if _, err := io.WriteString(w.w, NewMarkdownTranscript(export)); err != nil {
+			return err
+		}
+	} else if err := json.NewEncoder(w.w).Encode(export); err != nil {
+		return err
+	}
+	w.written++
+
+	// Stream each conversation to the client as soon as it is written
+	if flusher, ok := w.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// Close completes the export document
+func (w *ExportWriter) Close() error {
+	if w.format != conversation.ExportFormatJSON || !w.bulk {
+		return nil
+	}
+	suffix := "]}\n"
+	if w.written == 0 {
+		suffix = `{"object":"list","data":[]}` + "\n"
+	}
+	_, err := io.WriteString(w.w, suffix)
+	return err
+}
+
+// ExportFileName returns the download file name of a conversation export
+func ExportFileName(base string, format conversation.ExportFormat) string {
+	extension := string(format)
+	if format == conversation.ExportFormatMarkdown {
+		extension = "md"
+	}
+	return fmt.Sprintf("%s.%s", base, extension)
+}
+
+// BulkExportBaseName names bulk export downloads after the export date
+func BulkExportBaseName(now time.Time) string {
+	return "conversations-" + now.UTC().Format("2006-01-02")
+}
@@ -2,6 +2,7 @@ package modelresponses
 
 import (
 	"strings"
+	"time"
 
 	domainmodel "jan-server/services/llm-api/internal/domain/model"
 )
@@ -63,6 +64,26 @@ type ProviderWithModelsResponse struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+type ProviderHealthResponse struct {
+	ID                  string     `json:"id"`
+	Name                string     `json:"name"`
+	Active              bool       `json:"active"`
+	State               string     `json:"state"`     // closed, open or half_open
+	Available           bool       `json:"available"` // whether the provider is currently eligible for selection
+	WindowCalls         int        `json:"window_calls"`
+	WindowErrors        int        `json:"window_errors"`
+	WindowTimeouts      int        `json:"window_timeouts"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	TotalCalls          int64      `json:"total_calls"`
+	TotalErrors         int64      `json:"total_errors"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+	LastSyncedAt        *time.Time `json:"last_synced_at,omitempty"`
+}
+
 type ProviderResponseList struct {
 	Object string             `json:"object"`
 	Data   []ProviderResponse `json:"data"`
@@ -298,3 +319,25 @@ type BulkOperationResponse struct {
 	TotalChecked int      `json:"total_checked,omitempty"`
 	FailedModels []string `json:"failed_models,omitempty"`
 }
+
+func BuildProviderHealthResponse(provider *domainmodel.Provider, health domainmodel.ProviderHealth) ProviderHealthResponse {
+	return ProviderHealthResponse{
+		ID:                  provider.PublicID,
+		Name:                provider.DisplayName,
+		Active:              provider.Active,
+		State:               string(health.State),
+		Available:           provider.Active && health.State != domainmodel.BreakerOpen,
+		WindowCalls:         health.WindowCalls,
+		WindowErrors:        health.WindowErrors,
+		WindowTimeouts:      health.WindowTimeouts,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		TotalCalls:          health.TotalCalls,
+		TotalErrors:         health.TotalErrors,
+		LastError:           health.LastError,
+		LastErrorAt:         health.LastErrorAt,
+		LastSuccessAt:       health.LastSuccessAt,
+		OpenedAt:            health.OpenedAt,
+		RetryAt:             health.RetryAt,
+		LastSyncedAt:        provider.LastSyncedAt,
+	}
+}
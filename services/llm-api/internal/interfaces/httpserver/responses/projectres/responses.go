@@ -6,15 +6,16 @@ import (
 
 // ProjectResponse represents a single project response
 type ProjectResponse struct {
-	ID          string  `json:"id"`
-	Object      string  `json:"object"`
-	Name        string  `json:"name"`
-	Instruction *string `json:"instruction,omitempty"`
-	Favorite    bool    `json:"is_favorite"`
-	IsArchived  bool    `json:"is_archived"`
-	ArchivedAt  *int64  `json:"archived_at,omitempty"`
-	CreatedAt   int64   `json:"created_at"`
-	UpdatedAt   int64   `json:"updated_at"`
+	ID                 string  `json:"id"`
+	Object             string  `json:"object"`
+	Name               string  `json:"name"`
+	Instruction        *string `json:"instruction,omitempty"`
+	InstructionVersion int     `json:"instruction_version"`
+	Favorite           bool    `json:"is_favorite"`
+	IsArchived         bool    `json:"is_archived"`
+	ArchivedAt         *int64  `json:"archived_at,omitempty"`
+	CreatedAt          int64   `json:"created_at"`
+	UpdatedAt          int64   `json:"updated_at"`
 }
 
 // ProjectListResponse represents a paginated list of projects
@@ -38,14 +39,15 @@ type ProjectDeletedResponse struct {
 // NewProjectResponse creates a response from a domain project
 func NewProjectResponse(proj *project.Project) *ProjectResponse {
 	resp := &ProjectResponse{
-		ID:          proj.PublicID,
-		Object:      "project",
-		Name:        proj.Name,
-		Instruction: proj.Instruction,
-		Favorite:    proj.Favorite,
-		IsArchived:  proj.ArchivedAt != nil,
-		CreatedAt:   proj.CreatedAt.Unix(),
-		UpdatedAt:   proj.UpdatedAt.Unix(),
+		ID:                 proj.PublicID,
+		Object:             "project",
+		Name:               proj.Name,
+		Instruction:        proj.Instruction,
+		InstructionVersion: proj.InstructionVersion,
+		Favorite:           proj.Favorite,
+		IsArchived:         proj.ArchivedAt != nil,
+		CreatedAt:          proj.CreatedAt.Unix(),
+		UpdatedAt:          proj.UpdatedAt.Unix(),
 	}
 
 	if proj.ArchivedAt != nil {
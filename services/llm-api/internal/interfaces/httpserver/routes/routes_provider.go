@@ -13,6 +13,7 @@ import (
 	"jan-server/services/llm-api/internal/interfaces/httpserver/routes/auth"
 	v1 "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1"
 	"jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin"
+	adminFeedback "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin/feedback"
 	adminModel "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin/model"
 	adminProvider "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin/provider"
 	"jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/chat"
@@ -28,6 +29,7 @@ var RouteProvider = wire.NewSet(
 	authhandler.NewTokenHandler,
 	authhandler.ProvideKeycloakOAuthHandler,
 	apikeyhandler.NewHandler,
+	chathandler.ProvideConversationSummarizer,
 	chathandler.NewChatHandler,
 	conversationhandler.NewConversationHandler,
 	guestauth.NewGuestHandler,
@@ -44,6 +46,7 @@ var RouteProvider = wire.NewSet(
 	admin.NewAdminRoute,
 	adminModel.NewAdminModelRoute,
 	adminProvider.NewAdminProviderRoute,
+	adminFeedback.NewAdminFeedbackRoute,
 	chat.NewChatRoute,
 	chat.NewChatCompletionRoute,
 	conversation.NewConversationRoute,
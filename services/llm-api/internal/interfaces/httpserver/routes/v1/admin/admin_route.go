@@ -1,6 +1,7 @@
 package admin
 
 import (
+	adminfeedback "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin/feedback"
 	adminmodel "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin/model"
 	adminprovider "jan-server/services/llm-api/internal/interfaces/httpserver/routes/v1/admin/provider"
 
@@ -11,16 +12,19 @@ import (
 type AdminRoute struct {
 	adminModelRoute    *adminmodel.AdminModelRoute
 	adminProviderRoute *adminprovider.AdminProviderRoute
+	adminFeedbackRoute *adminfeedback.AdminFeedbackRoute
 }
 
 // NewAdminRoute creates a new AdminRoute
 func NewAdminRoute(
 	adminModelRoute *adminmodel.AdminModelRoute,
 	adminProviderRoute *adminprovider.AdminProviderRoute,
+	adminFeedbackRoute *adminfeedback.AdminFeedbackRoute,
 ) *AdminRoute {
 	return &AdminRoute{
 		adminModelRoute:    adminModelRoute,
 		adminProviderRoute: adminProviderRoute,
+		adminFeedbackRoute: adminFeedbackRoute,
 	}
 }
 
@@ -30,5 +34,6 @@ func (r *AdminRoute) RegisterRouter(router gin.IRouter) {
 	{
 		r.adminModelRoute.RegisterRouter(adminGroup)
 		r.adminProviderRoute.RegisterRouter(adminGroup)
+		r.adminFeedbackRoute.RegisterRouter(adminGroup)
 	}
 }
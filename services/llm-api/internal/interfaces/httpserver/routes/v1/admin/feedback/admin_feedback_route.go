@@ -0,0 +1,100 @@
+package feedback
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"jan-server/services/llm-api/internal/config"
+	"jan-server/services/llm-api/internal/domain/conversation"
+	"jan-server/services/llm-api/internal/interfaces/httpserver/handlers/conversationhandler"
+	"jan-server/services/llm-api/internal/interfaces/httpserver/middlewares"
+	conversationrequests "jan-server/services/llm-api/internal/interfaces/httpserver/requests/conversation"
+	"jan-server/services/llm-api/internal/interfaces/httpserver/responses"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// flushEvery is the number of exported records written between flushes
+const flushEvery = 50
+
+type AdminFeedbackRoute struct {
+	conversationHandler *conversationhandler.ConversationHandler
+	adminRole           string
+}
+
+func NewAdminFeedbackRoute(
+	conversationHandler *conversationhandler.ConversationHandler,
+	cfg *config.Config,
+) *AdminFeedbackRoute {
+	return &AdminFeedbackRoute{
+		conversationHandler: conversationHandler,
+		adminRole:           cfg.AdminRole,
+	}
+}
+
+func (route *AdminFeedbackRoute) RegisterRouter(router *gin.RouterGroup) {
+	// Feedback holds the conversations of all users
+	feedbackRoute := router.Group("feedback", middlewares.RequireRole(route.adminRole))
+
+	feedbackRoute.GET("/export", route.ExportFeedback)
+}
+
+// ExportFeedback
+// @Summary Export rated responses
+// @Description Streams rated assistant messages as JSON Lines, oldest rating first. Each line holds the
+// @Description conversation and item IDs, branch, model, provider, rating, comment, the prompt context
+// @Description (preceding messages of the branch) and the rated response.
+// @Tags Admin Feedback API
+// @Security BearerAuth
+// @Produce application/x-ndjson
+// @Param model query string false "Only items generated by this model ID"
+// @Param rating query string false "Only likes or only unlikes" Enums(like, unlike)
+// @Param from query string false "Rated at or after (RFC 3339 timestamp or YYYY-MM-DD)"
+// @Param to query string false "Rated before (RFC 3339 timestamp) or on (YYYY-MM-DD)"
+// @Success 200 {object} conversation.FeedbackRecord "One record per line"
+// @Failure 400 {object} responses.ErrorResponse "Invalid filter"
+// @Failure 403 {object} responses.ErrorResponse "Caller lacks the admin role"
+// @Failure 500 {object} responses.ErrorResponse "Failed to export feedback"
+// @Router /v1/admin/feedback/export [get]
+func (route *AdminFeedbackRoute) ExportFeedback(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	var params conversationrequests.FeedbackExportQueryParams
+	if err := reqCtx.ShouldBindQuery(&params); err != nil {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "invalid query parameters", "2c8e4a61-7f3b-4d9e-b5a2-6e1c0f8d3b47")
+		return
+	}
+
+	written := 0
+	encoder := json.NewEncoder(reqCtx.Writer)
+	err := route.conversationHandler.ExportFeedback(ctx, params, func(record conversation.FeedbackRecord) error {
+		if written == 0 {
+			reqCtx.Header("Content-Type", "application/x-ndjson")
+			reqCtx.Header("Content-Disposition", `attachment; filename="feedback.jsonl"`)
+			reqCtx.Status(http.StatusOK)
+		}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+		written++
+		if written%flushEvery == 0 {
+			reqCtx.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if written > 0 {
+			// Headers are sent; the truncated body is all the client gets
+			_ = reqCtx.Error(err)
+			return
+		}
+		responses.HandleError(reqCtx, err, "Failed to export feedback")
+		return
+	}
+	if written == 0 {
+		reqCtx.Header("Content-Type", "application/x-ndjson")
+		reqCtx.Status(http.StatusOK)
+	}
+	reqCtx.Writer.Flush()
+}
@@ -28,6 +28,7 @@ func (AdminProviderRoute *AdminProviderRoute) RegisterRouter(router *gin.RouterG
 	providerRoute.GET("", AdminProviderRoute.GetAllProviders)
 	providerRoute.POST("", AdminProviderRoute.RegisterProvider)
 	providerRoute.PATCH("/:provider_public_id", AdminProviderRoute.UpdateProvider)
+	providerRoute.GET("/:provider_public_id/health", AdminProviderRoute.GetProviderHealth)
 
 }
 
@@ -114,3 +115,27 @@ func (route *AdminProviderRoute) UpdateProvider(reqCtx *gin.Context) {
 
 	reqCtx.JSON(http.StatusOK, providerResponse)
 }
+
+// GetProviderHealth
+// @Summary Get provider health
+// @Description Returns the provider's circuit breaker state and recent upstream error counts as observed by this llm-api instance
+// @Tags Admin Provider API
+// @Security BearerAuth
+// @Produce json
+// @Param provider_public_id path string true "Provider public ID"
+// @Success 200 {object} modelresponses.ProviderHealthResponse "Provider health"
+// @Failure 404 {object} responses.ErrorResponse "Provider not found"
+// @Failure 500 {object} responses.ErrorResponse "Failed to retrieve provider health"
+// @Router /v1/admin/providers/{provider_public_id}/health [get]
+func (route *AdminProviderRoute) GetProviderHealth(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	publicID := reqCtx.Param("provider_public_id")
+
+	health, err := route.providerHandler.GetProviderHealth(ctx, publicID)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to retrieve provider health")
+		return
+	}
+
+	reqCtx.JSON(http.StatusOK, health)
+}
@@ -96,7 +96,7 @@ func (chatCompletionRoute *ChatCompletionRoute) PostCompletion(reqCtx *gin.Conte
 	// For non-streaming requests, return the response with conversation context
 	if !request.Stream {
 		// Wrap the OpenAI response with conversation context (including title)
-		chatResponse := chatresponses.NewChatCompletionResponse(result.Response, result.ConversationID, result.ConversationTitle)
+		chatResponse := chatresponses.NewChatCompletionResponse(result.Response, result.ConversationID, result.ConversationTitle, result.ConversationBranch, result.UsageEstimated)
 		reqCtx.JSON(http.StatusOK, chatResponse)
 	}
 
@@ -0,0 +1,212 @@
+package conversation
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"jan-server/services/llm-api/internal/interfaces/httpserver/handlers/authhandler"
+	"jan-server/services/llm-api/internal/interfaces/httpserver/handlers/conversationhandler"
+	conversationrequests "jan-server/services/llm-api/internal/interfaces/httpserver/requests/conversation"
+	"jan-server/services/llm-api/internal/interfaces/httpserver/responses"
+	conversationresponses "jan-server/services/llm-api/internal/interfaces/httpserver/responses/conversation"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize limits the size of an uploaded import document
+const maxImportSize = 64 << 20
+
+// exportConversation godoc
+// @Summary Export a conversation
+// @Description Download a conversation with the items of all its branches.
+// @Description
+// @Description **Formats:**
+// @Description - `json` (default): one `conversation.export` document, which can be imported again
+// @Description - `jsonl`: the same document on a single line
+// @Description - `markdown`: a readable transcript of the active branch
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Produce application/x-ndjson
+// @Produce text/markdown
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Param format query string false "Export format" Enums(json, jsonl, markdown) default(json)
+// @Success 200 {object} conversation.ConversationExport "Conversation export"
+// @Failure 400 {object} responses.ErrorResponse "Invalid export format"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation not found or access denied"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/{conv_public_id}/export [get]
+func (route *ConversationRoute) exportConversation(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	// Get conversation from context (set by middleware)
+	conv, ok := conversationhandler.GetConversationFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeInternal, "conversation not found in context", "7d2a9c4e-1b6f-4e38-a5d0-3c8f7b2e9a61")
+		return
+	}
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "e5b1f7a3-9c2d-4a86-b0e4-6d3a8f1c5b27")
+		return
+	}
+
+	var params conversationrequests.ExportQueryParams
+	if err := reqCtx.ShouldBindQuery(&params); err != nil {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "invalid query parameters", "4f8c2e6a-3d1b-4b97-8e5c-a2f0d7b9c143")
+		return
+	}
+	format, err := route.handler.ParseExportFormat(ctx, params)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Invalid export format")
+		return
+	}
+
+	export, err := route.handler.ExportConversation(ctx, user.ID, conv.PublicID)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to export conversation")
+		return
+	}
+
+	writer := conversationresponses.NewExportWriter(reqCtx.Writer, format, false)
+	setAttachmentHeaders(reqCtx, writer.ContentType(), conversationresponses.ExportFileName(conv.PublicID, format))
+	reqCtx.Status(http.StatusOK)
+	if err := writer.Write(export); err != nil {
+		_ = reqCtx.Error(err)
+	}
+}
+
+// exportConversations godoc
+// @Summary Export all conversations
+// @Description Download every conversation of the authenticated user, oldest first, with the items of all branches.
+// @Description
+// @Description **Formats:**
+// @Description - `json` (default): a list object whose `data` holds one `conversation.export` document per conversation
+// @Description - `jsonl`: one `conversation.export` document per line
+// @Description - `markdown`: readable transcripts of the active branches, one after another
+// @Description
+// @Description The response is streamed; a failure after the first conversation truncates the download.
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Produce application/x-ndjson
+// @Produce text/markdown
+// @Param format query string false "Export format" Enums(json, jsonl, markdown) default(json)
+// @Success 200 {object} conversation.ConversationExport "Conversation exports"
+// @Failure 400 {object} responses.ErrorResponse "Invalid export format"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/export [get]
+func (route *ConversationRoute) exportConversations(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "b3e7a1d9-6f4c-4c25-9a8e-1d5b0f3c7e82")
+		return
+	}
+
+	var params conversationrequests.ExportQueryParams
+	if err := reqCtx.ShouldBindQuery(&params); err != nil {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "invalid query parameters", "9a6d3f1b-8e2c-4d74-b1a5-c7e0f4d2b938")
+		return
+	}
+	format, err := route.handler.ParseExportFormat(ctx, params)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Invalid export format")
+		return
+	}
+
+	writer := conversationresponses.NewExportWriter(reqCtx.Writer, format, true)
+	fileName := conversationresponses.ExportFileName(conversationresponses.BulkExportBaseName(time.Now()), format)
+	setAttachmentHeaders(reqCtx, writer.ContentType(), fileName)
+
+	if err := route.handler.ExportUserConversations(ctx, user.ID, writer); err != nil {
+		if writer.Written() > 0 {
+			// Headers are sent; the truncated body is all the client gets
+			_ = reqCtx.Error(err)
+			return
+		}
+		reqCtx.Writer.Header().Del("Content-Type")
+		reqCtx.Writer.Header().Del("Content-Disposition")
+		responses.HandleError(reqCtx, err, "Failed to export conversations")
+		return
+	}
+	reqCtx.Status(http.StatusOK)
+	if err := writer.Close(); err != nil {
+		_ = reqCtx.Error(err)
+		return
+	}
+	reqCtx.Writer.Flush()
+}
+
+// importConversations godoc
+// @Summary Import conversations
+// @Description Create conversations from an export document, sent as the request body or as a multipart `file` field.
+// @Description
+// @Description **Accepted formats:**
+// @Description - Our own `json` and `jsonl` exports, of one conversation or of all conversations
+// @Description - ChatGPT's `conversations.json` data export; regenerated and edited replies become extra branches
+// @Description
+// @Description **Behavior:**
+// @Description - Imported conversations and items get new IDs; timestamps, branches and models are kept, ratings are dropped
+// @Description - Imported conversations are not assigned to a project
+// @Description - Every conversation is validated before any is created, and all are created in one transaction; uploads are limited to 64 MB
+// @Tags Conversations API
+// @Security BearerAuth
+// @Accept json
+// @Accept mpfd
+// @Produce json
+// @Param file formData file false "Export file (multipart uploads)"
+// @Success 201 {object} conversationresponses.ConversationImportResponse "Created conversations"
+// @Failure 400 {object} responses.ErrorResponse "Unreadable or invalid export document"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 413 {object} responses.ErrorResponse "Export document too large"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/import [post]
+func (route *ConversationRoute) importConversations(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "c8f2b6e4-0a3d-4e19-8b7c-5f1e9d2a6c30")
+		return
+	}
+
+	reqCtx.Request.Body = http.MaxBytesReader(reqCtx.Writer, reqCtx.Request.Body, maxImportSize)
+
+	var body io.Reader = reqCtx.Request.Body
+	if strings.HasPrefix(reqCtx.ContentType(), "multipart/form-data") {
+		fileHeader, err := reqCtx.FormFile("file")
+		if err != nil {
+			responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "multipart uploads require a file field", "2e9b5d7f-4c1a-4f63-a8d2-0b6e3c9f1a54")
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "failed to read uploaded file", "6a3c8e1d-7b5f-4d20-9e4a-f2c1b8d5a076")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	response, err := route.handler.ImportConversations(ctx, user.ID, body)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to import conversations")
+		return
+	}
+	reqCtx.JSON(http.StatusCreated, response)
+}
+
+// setAttachmentHeaders marks the response as a file download
+func setAttachmentHeaders(reqCtx *gin.Context, contentType string, fileName string) {
+	reqCtx.Header("Content-Type", contentType)
+	reqCtx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+}
@@ -5,10 +5,13 @@ import (
 	"strings"
 
 	"jan-server/services/llm-api/internal/interfaces/httpserver/handlers/authhandler"
+	"jan-server/services/llm-api/internal/interfaces/httpserver/handlers/chathandler"
 	"jan-server/services/llm-api/internal/interfaces/httpserver/handlers/conversationhandler"
 	"jan-server/services/llm-api/internal/interfaces/httpserver/requests"
+	chatrequests "jan-server/services/llm-api/internal/interfaces/httpserver/requests/chat"
 	conversationrequests "jan-server/services/llm-api/internal/interfaces/httpserver/requests/conversation"
 	"jan-server/services/llm-api/internal/interfaces/httpserver/responses"
+	chatresponses "jan-server/services/llm-api/internal/interfaces/httpserver/responses/chat"
 	conversationresponses "jan-server/services/llm-api/internal/interfaces/httpserver/responses/conversation"
 	"jan-server/services/llm-api/internal/utils/platformerrors"
 
@@ -17,15 +20,18 @@ import (
 
 type ConversationRoute struct {
 	handler     *conversationhandler.ConversationHandler
+	chatHandler *chathandler.ChatHandler
 	authHandler *authhandler.AuthHandler
 }
 
 func NewConversationRoute(
 	handler *conversationhandler.ConversationHandler,
+	chatHandler *chathandler.ChatHandler,
 	authHandler *authhandler.AuthHandler,
 ) *ConversationRoute {
 	return &ConversationRoute{
 		handler:     handler,
+		chatHandler: chatHandler,
 		authHandler: authHandler,
 	}
 }
@@ -34,13 +40,26 @@ func (route *ConversationRoute) RegisterRouter(router gin.IRouter) {
 	conversations := router.Group("/conversations")
 	conversations.GET("", route.authHandler.WithAppUserAuthChain(route.listConversations)...)
 	conversations.POST("", route.authHandler.WithAppUserAuthChain(route.createConversation)...)
+	conversations.GET("/search", route.authHandler.WithAppUserAuthChain(route.searchConversations)...)
+	conversations.GET("/export", route.authHandler.WithAppUserAuthChain(route.exportConversations)...)
+	conversations.POST("/import", route.authHandler.WithAppUserAuthChain(route.importConversations)...)
 	conversations.GET("/:conv_public_id", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.getConversation)...)
 	conversations.POST("/:conv_public_id", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.updateConversation)...)
 	conversations.DELETE("/:conv_public_id", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.deleteConversation)...)
+	conversations.GET("/:conv_public_id/export", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.exportConversation)...)
 	conversations.GET("/:conv_public_id/items", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.listItems)...)
 	conversations.POST("/:conv_public_id/items", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.createItems)...)
 	conversations.GET("/:conv_public_id/items/:item_id", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.getItem)...)
 	conversations.DELETE("/:conv_public_id/items/:item_id", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.deleteItem)...)
+	conversations.POST("/:conv_public_id/items/:item_id/rating", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.rateItem)...)
+	conversations.DELETE("/:conv_public_id/items/:item_id/rating", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.removeItemRating)...)
+	conversations.POST("/:conv_public_id/items/:item_id/edit", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.editItem)...)
+	conversations.POST("/:conv_public_id/items/:item_id/regenerate", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.regenerateItem)...)
+	conversations.GET("/:conv_public_id/branches", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.listBranches)...)
+	conversations.POST("/:conv_public_id/branches", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.createBranch)...)
+	conversations.DELETE("/:conv_public_id/branches/:branch_name", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.deleteBranch)...)
+	conversations.POST("/:conv_public_id/branches/:branch_name/activate", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.activateBranch)...)
+	conversations.GET("/:conv_public_id/branches/:branch_name/items", route.authHandler.WithAppUserAuthChain(route.handler.ConversationMiddleware(), route.listBranchItems)...)
 }
 
 // listConversations godoc
@@ -123,6 +142,53 @@ func (route *ConversationRoute) listConversations(reqCtx *gin.Context) {
 	reqCtx.JSON(http.StatusOK, response)
 }
 
+// searchConversations godoc
+// @Summary Search conversations
+// @Description Full-text search over the titles and messages of the authenticated user's conversations.
+// @Description
+// @Description **Features:**
+// @Description - Web search syntax: `"exact phrase"`, `or`, `-excluded`
+// @Description - Searches user and assistant messages in every branch
+// @Description - Hits are ranked by relevance; title hits have no `item_id`
+// @Description - `snippet` wraps matched terms in `<mark></mark>`; the rest of the text is not escaped
+// @Description - Filter by project and by when the message was written (or the conversation last updated)
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Param q query string true "Search text"
+// @Param project_id query string false "Only conversations of this project"
+// @Param from query string false "Written at or after (RFC 3339 timestamp or YYYY-MM-DD)"
+// @Param to query string false "Written before (RFC 3339 timestamp) or on (YYYY-MM-DD)"
+// @Param limit query integer false "Number of hits to return (1-100)" default(20) minimum(1) maximum(100)
+// @Param offset query integer false "Number of hits to skip" default(0) minimum(0)
+// @Success 200 {object} conversationresponses.SearchResultListResponse "Ranked search hits"
+// @Failure 400 {object} responses.ErrorResponse "Missing or invalid query parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/search [get]
+func (route *ConversationRoute) searchConversations(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "4b7d1e93-2a6c-4f85-9d3e-8c1a5b7f0e24")
+		return
+	}
+
+	var params conversationrequests.SearchConversationsQueryParams
+	if err := reqCtx.ShouldBindQuery(&params); err != nil {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "invalid query parameters: q is required", "e2c9a7f1-5d3b-4e86-a0f4-7b2d9c6e1a38")
+		return
+	}
+
+	response, err := route.handler.SearchConversations(ctx, user.ID, params)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to search conversations")
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
 // createConversation godoc
 // @Summary Create a conversation
 // @Description Create a new conversation to store and retrieve conversation state across Response API calls
@@ -345,6 +411,11 @@ func (route *ConversationRoute) deleteConversation(reqCtx *gin.Context) {
 // @Failure 500 {object} responses.ErrorResponse "Internal server error - listing failed"
 // @Router /v1/conversations/{conv_public_id}/items [get]
 func (route *ConversationRoute) listItems(reqCtx *gin.Context) {
+	route.listItemsOfBranch(reqCtx, "")
+}
+
+// listItemsOfBranch writes a page of items of a branch; an empty branch name lists the active branch
+func (route *ConversationRoute) listItemsOfBranch(reqCtx *gin.Context, branchName string) {
 	ctx := reqCtx.Request.Context()
 
 	// Get conversation from context (set by middleware)
@@ -403,7 +474,7 @@ func (route *ConversationRoute) listItems(reqCtx *gin.Context) {
 	pagination.Limit = &fetchLimit
 
 	// Get items from handler
-	items, err := route.handler.ListItems(ctx, user.ID, conv.PublicID, pagination)
+	items, err := route.handler.ListBranchItems(ctx, user.ID, conv.PublicID, branchName, pagination)
 	if err != nil {
 		responses.HandleError(reqCtx, err, "Failed to list items")
 		return
@@ -605,3 +676,398 @@ func (route *ConversationRoute) deleteItem(reqCtx *gin.Context) {
 	}
 	reqCtx.JSON(http.StatusOK, response)
 }
+
+// rateItem godoc
+// @Summary Rate a conversation item
+// @Description Store like/unlike feedback, with an optional comment, on an assistant message.
+// @Description
+// @Description **Features:**
+// @Description - Rating again replaces the previous rating and comment
+// @Description - Comments are limited to 2000 characters
+// @Description - Only assistant messages can be rated
+// @Tags Conversations API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Param item_id path string true "Item ID (format: msg_xxxxx)"
+// @Param request body conversationrequests.RateItemRequest true "Rating (like or unlike) and optional comment"
+// @Success 200 {object} conversationresponses.ItemResponse "Successfully rated item, returns the item"
+// @Failure 400 {object} responses.ErrorResponse "Invalid rating, comment too long, or item cannot be rated"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation or item not found, or access denied"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/{conv_public_id}/items/{item_id}/rating [post]
+func (route *ConversationRoute) rateItem(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	// Get conversation from context (set by middleware)
+	conv, ok := conversationhandler.GetConversationFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeInternal, "conversation not found in context", "0b6f3d52-5a7e-4c1b-9f2d-7e8a1c4b3d90")
+		return
+	}
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "3f9e2a71-c6d4-4b8e-a5f0-1d2c3b4a5e6f")
+		return
+	}
+
+	var req conversationrequests.RateItemRequest
+	if err := reqCtx.ShouldBindJSON(&req); err != nil {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "invalid request body", "8a4c6e1f-2b3d-4f5a-9c7e-0d1f2a3b4c5d")
+		return
+	}
+
+	itemID := reqCtx.Param("item_id")
+	response, err := route.handler.RateItem(ctx, user.ID, conv.PublicID, itemID, req)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to rate item")
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// removeItemRating godoc
+// @Summary Remove the rating of a conversation item
+// @Description Clear the like/unlike feedback and comment stored on an item.
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Param item_id path string true "Item ID (format: msg_xxxxx)"
+// @Success 200 {object} conversationresponses.ItemResponse "Successfully removed rating, returns the item"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation or item not found, or access denied"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/{conv_public_id}/items/{item_id}/rating [delete]
+func (route *ConversationRoute) removeItemRating(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	// Get conversation from context (set by middleware)
+	conv, ok := conversationhandler.GetConversationFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeInternal, "conversation not found in context", "5d7e9f1a-3b4c-4d6e-8f0a-2b3c4d5e6f7a")
+		return
+	}
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "9e1f3a5b-7c8d-4e0f-a2b3-c4d5e6f7a8b9")
+		return
+	}
+
+	itemID := reqCtx.Param("item_id")
+	response, err := route.handler.RemoveItemRating(ctx, user.ID, conv.PublicID, itemID)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to remove item rating")
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// listBranches godoc
+// @Summary List conversation branches
+// @Description List the branches of a conversation with their metadata
+// @Description
+// @Description **Features:**
+// @Description - MAIN is always listed first, other branches by creation time
+// @Description - Each branch reports its parent branch, fork item and current item count
+// @Description - `active_branch` names the branch used for new items and chat history
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Success 200 {object} conversationresponses.BranchListResponse "Successfully retrieved branches"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation not found or access denied"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/{conv_public_id}/branches [get]
+func (route *ConversationRoute) listBranches(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	// Get conversation from context (set by middleware)
+	conv, ok := conversationhandler.GetConversationFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeInternal, "conversation not found in context", "d95b6a10-94e5-4e53-adac-f1f8f4f05bf9")
+		return
+	}
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "9bcc4915-1a90-47b2-9140-3b5069834295")
+		return
+	}
+
+	response, err := route.handler.ListBranches(ctx, user.ID, conv.PublicID)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to list branches")
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// createBranch godoc
+// @Summary Fork a conversation branch
+// @Description Create a new branch by copying the items of a source branch up to and including an item
+// @Description
+// @Description **Features:**
+// @Description - `source_branch` defaults to the active branch
+// @Description - `from_item_id` is the last item copied; without it the whole source branch is copied
+// @Description - `name` defaults to a generated `EDIT_` name; custom names use letters, numbers, `_` and `-` (max 50)
+// @Description - Copied items get new item IDs
+// @Description - Set `activate` to make the new branch the active branch
+// @Tags Conversations API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Param request body conversationrequests.CreateBranchRequest true "Create branch request"
+// @Success 200 {object} conversationresponses.BranchResponse "Successfully created branch"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request - invalid branch name"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation, source branch or item not found"
+// @Failure 409 {object} responses.ErrorResponse "Branch already exists"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error - fork failed"
+// @Router /v1/conversations/{conv_public_id}/branches [post]
+func (route *ConversationRoute) createBranch(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	// Get conversation from context (set by middleware)
+	conv, ok := conversationhandler.GetConversationFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeInternal, "conversation not found in context", "c4d35120-a90b-44b0-abe0-898295613485")
+		return
+	}
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "beddb6af-0422-442c-882b-de05fbeb7ff7")
+		return
+	}
+
+	var req conversationrequests.CreateBranchRequest
+	if err := reqCtx.ShouldBindJSON(&req); err != nil {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "invalid request body", "639231ea-d275-42e6-991b-3fac38e5e51a")
+		return
+	}
+
+	response, err := route.handler.CreateBranch(ctx, user.ID, conv.PublicID, req)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to create branch")
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// activateBranch godoc
+// @Summary Switch the active branch
+// @Description Make a branch the active branch of a conversation. New items and chat completions use the active branch.
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Param branch_name path string true "Branch name"
+// @Success 200 {object} conversationresponses.ConversationResponse "Successfully switched branch, returns conversation"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation or branch not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/{conv_public_id}/branches/{branch_name}/activate [post]
+func (route *ConversationRoute) activateBranch(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	// Get conversation from context (set by middleware)
+	conv, ok := conversationhandler.GetConversationFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeInternal, "conversation not found in context", "ea91f279-97e5-466b-82e3-b0a0bbf19025")
+		return
+	}
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "494c050b-f2f7-4933-8857-5f1a4936cb8a")
+		return
+	}
+
+	response, err := route.handler.ActivateBranch(ctx, user.ID, conv.PublicID, reqCtx.Param("branch_name"))
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to activate branch")
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// deleteBranch godoc
+// @Summary Delete a conversation branch
+// @Description Delete a branch and its items. MAIN cannot be deleted; deleting the active branch makes MAIN active.
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Param branch_name path string true "Branch name"
+// @Success 200 {object} conversationresponses.BranchDeletedResponse "Successfully deleted branch"
+// @Failure 400 {object} responses.ErrorResponse "MAIN branch cannot be deleted"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation or branch not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error - deletion failed"
+// @Router /v1/conversations/{conv_public_id}/branches/{branch_name} [delete]
+func (route *ConversationRoute) deleteBranch(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+
+	// Get conversation from context (set by middleware)
+	conv, ok := conversationhandler.GetConversationFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeInternal, "conversation not found in context", "fd60b965-f7a4-4443-bd0e-87de47d3c662")
+		return
+	}
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "271bad66-98ac-4c9c-bb45-1430a8533eb8")
+		return
+	}
+
+	response, err := route.handler.DeleteBranch(ctx, user.ID, conv.PublicID, reqCtx.Param("branch_name"))
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to delete branch")
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// listBranchItems godoc
+// @Summary List branch items
+// @Description List the items of a conversation branch with the same cursor-based pagination as the items endpoint
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Param branch_name path string true "Branch name"
+// @Param after query string false "Item ID cursor to list items after (pagination)"
+// @Param limit query integer false "Number of items to return (1-100)" default(20) minimum(1) maximum(100)
+// @Param order query string false "Sort order: asc or desc" default(desc) Enums(asc, desc)
+// @Success 200 {object} conversationresponses.ItemListResponse "Successfully retrieved items list"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation or branch not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error - listing failed"
+// @Router /v1/conversations/{conv_public_id}/branches/{branch_name}/items [get]
+func (route *ConversationRoute) listBranchItems(reqCtx *gin.Context) {
+	route.listItemsOfBranch(reqCtx, reqCtx.Param("branch_name"))
+}
+
+// editItem godoc
+// @Summary Edit a message and regenerate the reply
+// @Description Replace a user message of the active branch and generate a new reply, keeping the original turns
+// @Description
+// @Description **Behavior:**
+// @Description - Forks the active branch before the message into a new `EDIT_` branch
+// @Description - Messages after the edited one are not copied to the new branch
+// @Description - Runs a chat completion with the edited message, using the same provider selection as `/v1/chat/completions`
+// @Description - Stores the edited message and reply in the new branch and makes it active
+// @Description - If the completion fails, the new branch is removed and the previous branch stays active
+// @Description
+// @Description Accepts the chat completion options (`model`, `stream`, `temperature`, ...); `messages` and `conversation` are not accepted.
+// @Tags Conversations API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Produce text/event-stream
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Param item_id path string true "User message item ID (format: msg_xxxxx)"
+// @Param request body chatrequests.EditItemRequest true "New message content and completion options"
+// @Success 200 {object} chatresponses.ChatCompletionResponse "Successful non-streaming response (when stream=false)"
+// @Success 200 {string} string "Successful streaming response (when stream=true) - SSE format with data: {json} events"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request or item is not a user message"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation or item not found in the active branch"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/{conv_public_id}/items/{item_id}/edit [post]
+func (route *ConversationRoute) editItem(reqCtx *gin.Context) {
+	// Get conversation from context (set by middleware)
+	conv, ok := conversationhandler.GetConversationFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeInternal, "conversation not found in context", "1909322e-8e24-43a0-a35c-18f2e8fcf52e")
+		return
+	}
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "cebdea10-14e3-4a18-9aa7-2c1385472971")
+		return
+	}
+
+	var request chatrequests.EditItemRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "invalid request body", "cff44289-5fe1-425f-b59c-438c660c8482")
+		return
+	}
+
+	result, err := route.chatHandler.EditItem(reqCtx.Request.Context(), reqCtx, user.ID, conv.PublicID, reqCtx.Param("item_id"), request)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to edit item")
+		return
+	}
+
+	if !request.Stream {
+		reqCtx.JSON(http.StatusOK, chatresponses.NewChatCompletionResponse(result.Response, result.ConversationID, result.ConversationTitle, result.ConversationBranch, result.UsageEstimated))
+	}
+}
+
+// regenerateItem godoc
+// @Summary Regenerate an assistant reply
+// @Description Generate a new assistant reply in place of a reply of the active branch, keeping the original reply
+// @Description
+// @Description **Behavior:**
+// @Description - Forks the active branch before the reply into a new `EDIT_` branch
+// @Description - Runs a chat completion on the turns before the reply, using the same provider selection as `/v1/chat/completions`
+// @Description - Stores the new reply in the new branch and makes it active
+// @Description - If the completion fails, the new branch is removed and the previous branch stays active
+// @Description
+// @Description Accepts the chat completion options (`model`, `stream`, `temperature`, ...); `messages` and `conversation` are not accepted.
+// @Tags Conversations API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Produce text/event-stream
+// @Param conv_public_id path string true "Conversation ID (format: conv_xxxxx)"
+// @Param item_id path string true "Assistant message item ID (format: msg_xxxxx)"
+// @Param request body chatrequests.ChatCompletionRequest true "Completion options"
+// @Success 200 {object} chatresponses.ChatCompletionResponse "Successful non-streaming response (when stream=false)"
+// @Success 200 {string} string "Successful streaming response (when stream=true) - SSE format with data: {json} events"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request or item is not an assistant message"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 404 {object} responses.ErrorResponse "Conversation or item not found in the active branch"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/{conv_public_id}/items/{item_id}/regenerate [post]
+func (route *ConversationRoute) regenerateItem(reqCtx *gin.Context) {
+	// Get conversation from context (set by middleware)
+	conv, ok := conversationhandler.GetConversationFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeInternal, "conversation not found in context", "ede0592f-00f3-4d16-a5d9-de0d5548a3fc")
+		return
+	}
+
+	user, ok := authhandler.GetUserFromContext(reqCtx)
+	if !ok {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeUnauthorized, "authentication required", "a645d554-de49-48ba-8599-61956a379695")
+		return
+	}
+
+	var request chatrequests.ChatCompletionRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		responses.HandleNewError(reqCtx, platformerrors.ErrorTypeValidation, "invalid request body", "ef473e19-4627-4d0a-be16-24c9aff6ab98")
+		return
+	}
+
+	result, err := route.chatHandler.RegenerateItem(reqCtx.Request.Context(), reqCtx, user.ID, conv.PublicID, reqCtx.Param("item_id"), request)
+	if err != nil {
+		responses.HandleError(reqCtx, err, "Failed to regenerate item")
+		return
+	}
+
+	if !request.Stream {
+		reqCtx.JSON(http.StatusOK, chatresponses.NewChatCompletionResponse(result.Response, result.ConversationID, result.ConversationTitle, result.ConversationBranch, result.UsageEstimated))
+	}
+}
@@ -0,0 +1,170 @@
+package chat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Adapter translates OpenAI-shaped chat completion traffic to and from a vendor's native API.
+// Clients without an adapter talk to OpenAI-compatible upstreams as-is.
+type Adapter interface {
+	// CompletionPath returns the upstream path (relative to the base URL) for the request.
+	CompletionPath(request openai.ChatCompletionRequest, stream bool) string
+	// BuildRequest converts the OpenAI request into the vendor request body.
+	BuildRequest(request openai.ChatCompletionRequest, stream bool) (any, error)
+	// ParseResponse converts a non-streaming vendor response body into an OpenAI response.
+	ParseResponse(body []byte, model string) (*openai.ChatCompletionResponse, error)
+	// NewStreamTranslator returns a translator for a single streaming response.
+	NewStreamTranslator(model string) StreamTranslator
+}
+
+// StreamTranslator converts raw upstream SSE lines into OpenAI SSE lines
+// ("data: {chunk}" and the final "data: [DONE]").
+type StreamTranslator interface {
+	// Translate consumes one upstream line and returns the OpenAI lines it produces, if any.
+	Translate(line string) ([]string, error)
+	// Finish is called once the upstream body ends and flushes any pending lines.
+	Finish() ([]string, error)
+}
+
+// ModelListAdapter translates a vendor's native model listing into OpenAI-style models.
+type ModelListAdapter interface {
+	// ModelsPath returns the upstream path for one page of the listing.
+	ModelsPath(pageToken string) string
+	// ParseModels converts one page of the listing and returns the token of the next page, if any.
+	ParseModels(body []byte) ([]Model, string, error)
+}
+
+// streamChunk is the OpenAI chat.completion.chunk payload emitted by stream translators.
+type streamChunk struct {
+	ID      string              `json:"id"`
+	Object  string              `json:"object"`
+	Created int64               `json:"created"`
+	Model   string              `json:"model"`
+	Choices []streamChunkChoice `json:"choices"`
+	Usage   *openai.Usage       `json:"usage,omitempty"`
+}
+
+type streamChunkChoice struct {
+	Index        int                                    `json:"index"`
+	Delta        openai.ChatCompletionStreamChoiceDelta `json:"delta"`
+	FinishReason *openai.FinishReason                   `json:"finish_reason"`
+}
+
+func chunkLine(chunk streamChunk) (string, error) {
+	if chunk.Choices == nil {
+		chunk.Choices = []streamChunkChoice{}
+	}
+	chunk.Object = "chat.completion.chunk"
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return "", err
+	}
+	return dataPrefix + string(data), nil
+}
+
+// parseDataURL splits a base64 data URL ("data:image/png;base64,...") into its media type and payload.
+func parseDataURL(dataURL string) (mediaType string, data string, ok bool) {
+	rest, found := strings.CutPrefix(dataURL, "data:")
+	if !found {
+		return "", "", false
+	}
+	meta, payload, found := strings.Cut(rest, ",")
+	if !found {
+		return "", "", false
+	}
+	mediaType, encoding, _ := strings.Cut(meta, ";")
+	if encoding != "base64" {
+		// Vendors only accept base64 inline data.
+		if decoded, err := url.PathUnescape(payload); err == nil {
+			payload = decoded
+		}
+		payload = base64.StdEncoding.EncodeToString([]byte(payload))
+	}
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	return mediaType, payload, true
+}
+
+// messageText returns the plain text of a message, joining multi-part text content.
+func messageText(message openai.ChatCompletionMessage) string {
+	if len(message.MultiContent) == 0 {
+		return message.Content
+	}
+	parts := make([]string, 0, len(message.MultiContent))
+	for _, part := range message.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeText && part.Text != "" {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// toolArguments decodes an OpenAI tool call argument string into a JSON object.
+func toolArguments(arguments string) map[string]any {
+	input := map[string]any{}
+	if strings.TrimSpace(arguments) == "" {
+		return input
+	}
+	if err := json.Unmarshal([]byte(arguments), &input); err != nil || input == nil {
+		return map[string]any{}
+	}
+	return input
+}
+
+// toolParameters returns a tool's JSON schema, defaulting to an empty object schema.
+func toolParameters(parameters any) any {
+	if parameters == nil {
+		return map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return parameters
+}
+
+// requestTools collects function tools from both the tools and the deprecated functions fields.
+func requestTools(request openai.ChatCompletionRequest) []openai.FunctionDefinition {
+	var definitions []openai.FunctionDefinition
+	for _, tool := range request.Tools {
+		if tool.Type == openai.ToolTypeFunction && tool.Function != nil {
+			definitions = append(definitions, *tool.Function)
+		}
+	}
+	definitions = append(definitions, request.Functions...)
+	return definitions
+}
+
+// toolChoiceMode normalizes the OpenAI tool_choice value into a mode ("auto", "none",
+// "required" or "function") and the forced function name, if any.
+func toolChoiceMode(choice any) (mode string, name string) {
+	switch value := choice.(type) {
+	case nil:
+		return "", ""
+	case string:
+		return value, ""
+	case openai.ToolChoice:
+		return "function", value.Function.Name
+	case *openai.ToolChoice:
+		if value == nil {
+			return "", ""
+		}
+		return "function", value.Function.Name
+	case map[string]any:
+		if function, ok := value["function"].(map[string]any); ok {
+			if name, ok := function["name"].(string); ok && name != "" {
+				return "function", name
+			}
+		}
+	}
+	return "", ""
+}
+
+func maxOutputTokens(request openai.ChatCompletionRequest) int {
+	if request.MaxCompletionTokens > 0 {
+		return request.MaxCompletionTokens
+	}
+	return request.MaxTokens
+}
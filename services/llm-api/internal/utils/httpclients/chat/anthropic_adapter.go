@@ -0,0 +1,699 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	anthropicDefaultMaxTokens = 4096
+	anthropicMaxTemperature   = 1.0
+)
+
+// anthropicThinkingBudgets maps OpenAI reasoning_effort values to extended thinking budgets.
+var anthropicThinkingBudgets = map[string]int{
+	"minimal": 1024,
+	"low":     1024,
+	"medium":  4096,
+	"high":    16384,
+}
+
+// AnthropicAdapter speaks the Anthropic Messages API (POST /messages).
+type AnthropicAdapter struct{}
+
+func NewAnthropicAdapter() *AnthropicAdapter {
+	return &AnthropicAdapter{}
+}
+
+type anthropicRequest struct {
+	Model         string               `json:"model"`
+	MaxTokens     int                  `json:"max_tokens"`
+	System        string               `json:"system,omitempty"`
+	Messages      []anthropicMessage   `json:"messages"`
+	Temperature   *float32             `json:"temperature,omitempty"`
+	TopP          *float32             `json:"top_p,omitempty"`
+	StopSequences []string             `json:"stop_sequences,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	Tools         []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice    *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Thinking      *anthropicThinking   `json:"thinking,omitempty"`
+	Metadata      *anthropicMetadata   `json:"metadata,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicContentBlock struct {
+	Type      string                `json:"type"`
+	Text      string                `json:"text,omitempty"`
+	Source    *anthropicImageSource `json:"source,omitempty"`
+	ID        string                `json:"id,omitempty"`
+	Name      string                `json:"name,omitempty"`
+	Input     json.RawMessage       `json:"input,omitempty"`
+	ToolUseID string                `json:"tool_use_id,omitempty"`
+	Content   string                `json:"content,omitempty"`
+	IsError   bool                  `json:"is_error,omitempty"`
+	Thinking  string                `json:"thinking,omitempty"`
+	Signature string                `json:"signature,omitempty"`
+	Data      string                `json:"data,omitempty"` // encrypted redacted_thinking payload
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+type anthropicMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+type anthropicResponse struct {
+	ID         string                  `json:"id"`
+	Model      string                  `json:"model"`
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+type anthropicStreamEvent struct {
+	Type         string                 `json:"type"`
+	Index        int                    `json:"index"`
+	Message      *anthropicResponse     `json:"message,omitempty"`
+	ContentBlock *anthropicContentBlock `json:"content_block,omitempty"`
+	Delta        *anthropicStreamDelta  `json:"delta,omitempty"`
+	Usage        *anthropicUsage        `json:"usage,omitempty"`
+	Error        *anthropicError        `json:"error,omitempty"`
+}
+
+type anthropicStreamDelta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text"`
+	PartialJSON string `json:"partial_json"`
+	Thinking    string `json:"thinking"`
+	Signature   string `json:"signature"`
+	StopReason  string `json:"stop_reason"`
+}
+
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func (a *AnthropicAdapter) CompletionPath(request openai.ChatCompletionRequest, stream bool) string {
+	return "/messages"
+}
+
+func (a *AnthropicAdapter) BuildRequest(request openai.ChatCompletionRequest, stream bool) (any, error) {
+	budget, thinking := anthropicThinkingBudgets[strings.ToLower(request.ReasoningEffort)]
+	if thinking && len(requestTools(request)) > 0 {
+		// Extended thinking only supports tool_choice auto and none
+		if mode, _ := toolChoiceMode(request.ToolChoice); mode == "required" || mode == "function" {
+			return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "anthropic models cannot force a tool call while reasoning_effort is set; use tool_choice auto or none", nil, "d4a7e2c9-5b1f-4e83-a6d0-9c3b8f1e2a57")
+		}
+	}
+
+	system, messages := a.convertMessages(request.Messages, thinking)
+	if instruction := responseFormatInstruction(request.ResponseFormat); instruction != "" {
+		system = strings.TrimSpace(system + "\n\n" + instruction)
+	}
+	if len(messages) == 0 {
+		return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "anthropic requests need at least one user or assistant message", nil, "6c0f3a6e-8f57-4c3b-9e0f-4d8f2b7a51c2")
+	}
+
+	body := anthropicRequest{
+		Model:         request.Model,
+		MaxTokens:     maxOutputTokens(request),
+		System:        system,
+		Messages:      messages,
+		StopSequences: request.Stop,
+		Stream:        stream,
+	}
+	if body.MaxTokens <= 0 {
+		body.MaxTokens = anthropicDefaultMaxTokens
+	}
+	if request.User != "" {
+		body.Metadata = &anthropicMetadata{UserID: request.User}
+	}
+
+	if thinking && !thinkingBlocksReplayed(messages) {
+		// The signed thinking blocks of the pending tool-use turn are unknown (e.g. the turn was
+		// generated by another instance). Anthropic rejects such requests while thinking is
+		// enabled, so answer this turn without extended thinking.
+		thinking = false
+		body.Messages = withoutThinkingBlocks(messages)
+	}
+	if thinking {
+		body.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: budget}
+		if body.MaxTokens <= budget {
+			body.MaxTokens = budget + anthropicDefaultMaxTokens
+		}
+	} else {
+		// Sampling parameters cannot be combined with extended thinking.
+		if request.Temperature != 0 {
+			temperature := min(request.Temperature, anthropicMaxTemperature)
+			body.Temperature = &temperature
+		}
+		if request.TopP != 0 {
+			topP := request.TopP
+			body.TopP = &topP
+		}
+	}
+
+	for _, definition := range requestTools(request) {
+		body.Tools = append(body.Tools, anthropicTool{
+			Name:        definition.Name,
+			Description: definition.Description,
+			InputSchema: toolParameters(definition.Parameters),
+		})
+	}
+	if len(body.Tools) > 0 {
+		body.ToolChoice = a.convertToolChoice(request)
+	}
+
+	return body, nil
+}
+
+// jsonOutputInstruction asks for bare JSON, as the Messages API has no response_format.
+const jsonOutputInstruction = "Respond with a single JSON value only, without any surrounding text or code fences."
+
+// responseFormatInstruction turns a JSON response_format into a system prompt instruction. The
+// output is not enforced; callers needing valid JSON validate the reply.
+func responseFormatInstruction(format *openai.ChatCompletionResponseFormat) string {
+	if format == nil {
+		return ""
+	}
+	switch format.Type {
+	case openai.ChatCompletionResponseFormatTypeJSONObject:
+		return jsonOutputInstruction
+	case openai.ChatCompletionResponseFormatTypeJSONSchema:
+		if format.JSONSchema == nil || format.JSONSchema.Schema == nil {
+			return jsonOutputInstruction
+		}
+		schema, err := json.Marshal(format.JSONSchema.Schema)
+		if err != nil {
+			return jsonOutputInstruction
+		}
+		return jsonOutputInstruction + " It must conform to this JSON schema:\n" + string(schema)
+	}
+	return ""
+}
+
+func (a *AnthropicAdapter) ParseResponse(body []byte, model string) (*openai.ChatCompletionResponse, error) {
+	var resp anthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, "failed to decode anthropic response", err, "0d9a1f4e-3b7c-4e21-8a5d-6f2c9e7b1a30")
+	}
+
+	message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	var content, reasoning strings.Builder
+	var thinkingBlocks []anthropicContentBlock
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "thinking":
+			reasoning.WriteString(block.Thinking)
+			thinkingBlocks = append(thinkingBlocks, block)
+		case "redacted_thinking":
+			thinkingBlocks = append(thinkingBlocks, block)
+		case "tool_use":
+			message.ToolCalls = append(message.ToolCalls, openai.ToolCall{
+				ID:   block.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      block.Name,
+					Arguments: rawArguments(block.Input),
+				},
+			})
+		}
+	}
+	message.Content = content.String()
+	message.ReasoningContent = reasoning.String()
+	storeThinkingBlocks(thinkingBlocks, message.ToolCalls)
+
+	if resp.Model != "" {
+		model = resp.Model
+	}
+	return &openai.ChatCompletionResponse{
+		ID:      resp.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []openai.ChatCompletionChoice{{
+			Index:        0,
+			Message:      message,
+			FinishReason: anthropicFinishReason(resp.StopReason),
+		}},
+		Usage: resp.Usage.toOpenAI(),
+	}, nil
+}
+
+func (a *AnthropicAdapter) NewStreamTranslator(model string) StreamTranslator {
+	return &anthropicStreamTranslator{
+		model:          model,
+		created:        time.Now().Unix(),
+		toolIndexes:    make(map[int]int),
+		toolHasArgs:    make(map[int]bool),
+		thinkingBlocks: make(map[int]*anthropicContentBlock),
+	}
+}
+
+// storeThinkingBlocks keeps the signed thinking blocks of a turn that ends in tool calls, so
+// they can be sent back unchanged with the tool results as Anthropic requires.
+func storeThinkingBlocks(blocks []anthropicContentBlock, toolCalls []openai.ToolCall) {
+	if len(blocks) == 0 || len(toolCalls) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		ids = append(ids, toolCall.ID)
+	}
+	reasoningStates.Store(blocks, ids...)
+}
+
+// replayedThinkingBlocks returns the thinking blocks stored for the tool calls of an assistant message.
+func replayedThinkingBlocks(toolCalls []openai.ToolCall) []anthropicContentBlock {
+	for _, toolCall := range toolCalls {
+		if value, ok := reasoningStates.Load(toolCall.ID); ok {
+			if blocks, ok := value.([]anthropicContentBlock); ok {
+				return blocks
+			}
+		}
+	}
+	return nil
+}
+
+// withoutThinkingBlocks drops replayed thinking blocks from every turn.
+func withoutThinkingBlocks(messages []anthropicMessage) []anthropicMessage {
+	result := make([]anthropicMessage, 0, len(messages))
+	for _, message := range messages {
+		blocks := make([]anthropicContentBlock, 0, len(message.Content))
+		for _, block := range message.Content {
+			if block.Type != "thinking" && block.Type != "redacted_thinking" {
+				blocks = append(blocks, block)
+			}
+		}
+		result = append(result, anthropicMessage{Role: message.Role, Content: blocks})
+	}
+	return result
+}
+
+// thinkingBlocksReplayed reports whether the last assistant turn, if it used tools, starts with
+// its thinking blocks. Earlier turns are not checked; Anthropic ignores their thinking.
+func thinkingBlocksReplayed(messages []anthropicMessage) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "assistant" {
+			continue
+		}
+		usesTools := false
+		for _, block := range messages[i].Content {
+			usesTools = usesTools || block.Type == "tool_use"
+		}
+		if !usesTools {
+			return true
+		}
+		first := messages[i].Content[0].Type
+		return first == "thinking" || first == "redacted_thinking"
+	}
+	return true
+}
+
+// convertMessages lifts system/developer messages into the system prompt and folds the rest into
+// alternating user/assistant turns, with tool results sent back as user tool_result blocks.
+// With thinking enabled, assistant tool-use turns are preceded by their stored thinking blocks.
+func (a *AnthropicAdapter) convertMessages(messages []openai.ChatCompletionMessage, thinking bool) (string, []anthropicMessage) {
+	var systemParts []string
+	var converted []anthropicMessage
+
+	appendBlocks := func(role string, blocks []anthropicContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if last := len(converted) - 1; last >= 0 && converted[last].Role == role {
+			converted[last].Content = append(converted[last].Content, blocks...)
+			return
+		}
+		converted = append(converted, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, message := range messages {
+		switch message.Role {
+		case openai.ChatMessageRoleSystem, openai.ChatMessageRoleDeveloper:
+			if text := strings.TrimSpace(messageText(message)); text != "" {
+				systemParts = append(systemParts, text)
+			}
+		case openai.ChatMessageRoleUser:
+			appendBlocks("user", a.userBlocks(message))
+		case openai.ChatMessageRoleAssistant:
+			var blocks []anthropicContentBlock
+			if thinking {
+				blocks = append(blocks, replayedThinkingBlocks(message.ToolCalls)...)
+			}
+			if text := messageText(message); strings.TrimSpace(text) != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: text})
+			}
+			for _, toolCall := range message.ToolCalls {
+				blocks = append(blocks, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    toolCall.ID,
+					Name:  toolCall.Function.Name,
+					Input: objectArguments(toolCall.Function.Arguments),
+				})
+			}
+			appendBlocks("assistant", blocks)
+		case openai.ChatMessageRoleTool:
+			appendBlocks("user", []anthropicContentBlock{{
+				Type:      "tool_result",
+				ToolUseID: message.ToolCallID,
+				Content:   messageText(message),
+			}})
+		}
+	}
+
+	return strings.Join(systemParts, "\n\n"), converted
+}
+
+func (a *AnthropicAdapter) userBlocks(message openai.ChatCompletionMessage) []anthropicContentBlock {
+	if len(message.MultiContent) == 0 {
+		if strings.TrimSpace(message.Content) == "" {
+			return nil
+		}
+		return []anthropicContentBlock{{Type: "text", Text: message.Content}}
+	}
+
+	blocks := make([]anthropicContentBlock, 0, len(message.MultiContent))
+	for _, part := range message.MultiContent {
+		switch part.Type {
+		case openai.ChatMessagePartTypeText:
+			if strings.TrimSpace(part.Text) != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: part.Text})
+			}
+		case openai.ChatMessagePartTypeImageURL:
+			if part.ImageURL == nil || part.ImageURL.URL == "" {
+				continue
+			}
+			source := &anthropicImageSource{Type: "url", URL: part.ImageURL.URL}
+			if mediaType, data, ok := parseDataURL(part.ImageURL.URL); ok {
+				source = &anthropicImageSource{Type: "base64", MediaType: mediaType, Data: data}
+			}
+			blocks = append(blocks, anthropicContentBlock{Type: "image", Source: source})
+		}
+	}
+	return blocks
+}
+
+func (a *AnthropicAdapter) convertToolChoice(request openai.ChatCompletionRequest) *anthropicToolChoice {
+	choice := &anthropicToolChoice{Type: "auto"}
+	switch mode, name := toolChoiceMode(request.ToolChoice); mode {
+	case "none":
+		return &anthropicToolChoice{Type: "none"}
+	case "required":
+		choice.Type = "any"
+	case "function":
+		choice.Type = "tool"
+		choice.Name = name
+	}
+	if parallel, ok := request.ParallelToolCalls.(bool); ok && !parallel {
+		choice.DisableParallelToolUse = true
+	}
+	return choice
+}
+
+func (u anthropicUsage) toOpenAI() openai.Usage {
+	promptTokens := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	usage := openai.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      promptTokens + u.OutputTokens,
+	}
+	if u.CacheReadInputTokens > 0 {
+		usage.PromptTokensDetails = &openai.PromptTokensDetails{CachedTokens: u.CacheReadInputTokens}
+	}
+	return usage
+}
+
+func anthropicFinishReason(stopReason string) openai.FinishReason {
+	switch stopReason {
+	case "max_tokens":
+		return openai.FinishReasonLength
+	case "tool_use":
+		return openai.FinishReasonToolCalls
+	case "refusal":
+		return openai.FinishReasonContentFilter
+	default:
+		return openai.FinishReasonStop
+	}
+}
+
+// anthropicErrorStatus maps mid-stream error types to the HTTP status Anthropic uses for them,
+// so that overload and rate-limit errors stay retryable.
+func anthropicErrorStatus(errorType string) int {
+	switch errorType {
+	case "overloaded_error":
+		return 529
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "not_found_error":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func objectArguments(arguments string) json.RawMessage {
+	data, err := json.Marshal(toolArguments(arguments))
+	if err != nil {
+		return json.RawMessage("{}")
+	}
+	return data
+}
+
+func rawArguments(input json.RawMessage) string {
+	if len(input) == 0 || string(input) == "null" {
+		return "{}"
+	}
+	return string(input)
+}
+
+// anthropicStreamTranslator maps Anthropic Messages stream events onto OpenAI chunks:
+// text and thinking deltas become content/reasoning_content, tool_use blocks become
+// indexed tool_calls, and the final usage is emitted as a choice-less chunk before [DONE].
+type anthropicStreamTranslator struct {
+	model        string
+	id           string
+	created      int64
+	usage        anthropicUsage
+	toolIndexes  map[int]int // content block index -> OpenAI tool call index
+	toolHasArgs  map[int]bool
+	nextTool     int
+	finishReason openai.FinishReason
+	done         bool
+
+	// thinking and redacted_thinking blocks by content block index, kept for the next turn
+	thinkingBlocks map[int]*anthropicContentBlock
+	thinkingOrder  []int
+	toolCalls      []openai.ToolCall
+}
+
+func (t *anthropicStreamTranslator) Translate(line string) ([]string, error) {
+	data, found := strings.CutPrefix(line, "data:")
+	if !found || t.done {
+		return nil, nil
+	}
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return nil, nil
+	}
+
+	var event anthropicStreamEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, "failed to decode anthropic stream event", err, "9b4e2c71-5d3a-4f8e-a6b0-1c7d8e9f2a43")
+	}
+
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			t.id = event.Message.ID
+			if event.Message.Model != "" {
+				t.model = event.Message.Model
+			}
+			t.usage = event.Message.Usage
+		}
+		return t.delta(openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}, nil)
+
+	case "content_block_start":
+		if event.ContentBlock == nil {
+			return nil, nil
+		}
+		switch event.ContentBlock.Type {
+		case "thinking", "redacted_thinking":
+			block := *event.ContentBlock
+			t.thinkingBlocks[event.Index] = &block
+			t.thinkingOrder = append(t.thinkingOrder, event.Index)
+			if block.Thinking != "" {
+				return t.delta(openai.ChatCompletionStreamChoiceDelta{ReasoningContent: block.Thinking}, nil)
+			}
+		case "tool_use":
+			index := t.nextTool
+			t.nextTool++
+			t.toolIndexes[event.Index] = index
+			t.toolCalls = append(t.toolCalls, openai.ToolCall{ID: event.ContentBlock.ID})
+			return t.delta(openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{{
+				Index:    &index,
+				ID:       event.ContentBlock.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: event.ContentBlock.Name},
+			}}}, nil)
+		case "text":
+			if event.ContentBlock.Text != "" {
+				return t.delta(openai.ChatCompletionStreamChoiceDelta{Content: event.ContentBlock.Text}, nil)
+			}
+		}
+		return nil, nil
+
+	case "content_block_delta":
+		if event.Delta == nil {
+			return nil, nil
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			return t.delta(openai.ChatCompletionStreamChoiceDelta{Content: event.Delta.Text}, nil)
+		case "thinking_delta":
+			if block, ok := t.thinkingBlocks[event.Index]; ok {
+				block.Thinking += event.Delta.Thinking
+			}
+			return t.delta(openai.ChatCompletionStreamChoiceDelta{ReasoningContent: event.Delta.Thinking}, nil)
+		case "signature_delta":
+			if block, ok := t.thinkingBlocks[event.Index]; ok {
+				block.Signature += event.Delta.Signature
+			}
+			return nil, nil
+		case "input_json_delta":
+			index, ok := t.toolIndexes[event.Index]
+			if !ok || event.Delta.PartialJSON == "" {
+				return nil, nil
+			}
+			t.toolHasArgs[event.Index] = true
+			return t.delta(openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{{
+				Index:    &index,
+				Function: openai.FunctionCall{Arguments: event.Delta.PartialJSON},
+			}}}, nil)
+		}
+		return nil, nil
+
+	case "content_block_stop":
+		index, ok := t.toolIndexes[event.Index]
+		if !ok || t.toolHasArgs[event.Index] {
+			return nil, nil
+		}
+		// Tools without parameters stream no input deltas; close them with an empty object.
+		return t.delta(openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{{
+			Index:    &index,
+			Function: openai.FunctionCall{Arguments: "{}"},
+		}}}, nil)
+
+	case "message_delta":
+		if event.Usage != nil {
+			if event.Usage.InputTokens > 0 {
+				t.usage.InputTokens = event.Usage.InputTokens
+			}
+			t.usage.OutputTokens = event.Usage.OutputTokens
+		}
+		if event.Delta == nil || event.Delta.StopReason == "" {
+			return nil, nil
+		}
+		t.finishReason = anthropicFinishReason(event.Delta.StopReason)
+		return t.delta(openai.ChatCompletionStreamChoiceDelta{}, &t.finishReason)
+
+	case "message_stop":
+		t.done = true
+		blocks := make([]anthropicContentBlock, 0, len(t.thinkingOrder))
+		for _, index := range t.thinkingOrder {
+			blocks = append(blocks, *t.thinkingBlocks[index])
+		}
+		storeThinkingBlocks(blocks, t.toolCalls)
+		usage := t.usage.toOpenAI()
+		usageLine, err := chunkLine(streamChunk{ID: t.id, Created: t.created, Model: t.model, Usage: &usage})
+		if err != nil {
+			return nil, err
+		}
+		return []string{usageLine, dataPrefix + doneMarker}, nil
+
+	case "error":
+		message := "anthropic stream error"
+		errorType := ""
+		if event.Error != nil {
+			errorType = event.Error.Type
+			message = fmt.Sprintf("%s: %s: %s", message, event.Error.Type, event.Error.Message)
+		}
+		return nil, platformerrors.NewErrorWithContext(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, message, nil, "4f1d6b2e-8a9c-4e73-b5d0-2e6f7a8c9d14", map[string]any{
+			UpstreamStatusCodeKey: anthropicErrorStatus(errorType),
+		})
+	}
+
+	// ping and unknown events carry nothing for the client
+	return nil, nil
+}
+
+func (t *anthropicStreamTranslator) Finish() ([]string, error) {
+	if t.done {
+		return nil, nil
+	}
+	return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, "anthropic stream ended before message_stop", io.ErrUnexpectedEOF, "7e3c9a1b-2d4f-4b86-9c5e-0a1b2c3d4e5f")
+}
+
+func (t *anthropicStreamTranslator) delta(delta openai.ChatCompletionStreamChoiceDelta, finishReason *openai.FinishReason) ([]string, error) {
+	line, err := chunkLine(streamChunk{
+		ID:      t.id,
+		Created: t.created,
+		Model:   t.model,
+		Choices: []streamChunkChoice{{Index: 0, Delta: delta, FinishReason: finishReason}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []string{line}, nil
+}
@@ -0,0 +1,259 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestAnthropicAdapterBuildRequest(t *testing.T) {
+	adapter := NewAnthropicAdapter()
+	request := openai.ChatCompletionRequest{
+		Model: "claude-sonnet-4-5",
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "Be brief."},
+			{Role: openai.ChatMessageRoleUser, MultiContent: []openai.ChatMessagePart{
+				{Type: openai.ChatMessagePartTypeText, Text: "What is this?"},
+				{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "data:image/png;base64,aGVsbG8="}},
+			}},
+			{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{{
+				ID: "toolu_1", Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: "lookup", Arguments: `{"q":"cat"}`},
+			}}},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "toolu_1", Content: "a cat"},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "toolu_2", Content: "a hat"},
+		},
+		Tools: []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "lookup"}}},
+		ToolChoice: map[string]any{
+			"type":     "function",
+			"function": map[string]any{"name": "lookup"},
+		},
+	}
+
+	body, err := adapter.BuildRequest(request, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := body.(anthropicRequest)
+
+	if got.System != "Be brief." || got.MaxTokens != anthropicDefaultMaxTokens || !got.Stream {
+		t.Fatalf("unexpected top-level fields: %+v", got)
+	}
+	if len(got.Messages) != 3 {
+		t.Fatalf("expected user/assistant/user turns, got %d", len(got.Messages))
+	}
+	image := got.Messages[0].Content[1]
+	if image.Type != "image" || image.Source.Type != "base64" || image.Source.MediaType != "image/png" || image.Source.Data != "aGVsbG8=" {
+		t.Fatalf("unexpected image block: %+v", image)
+	}
+	if toolUse := got.Messages[1].Content[0]; toolUse.Type != "tool_use" || string(toolUse.Input) != `{"q":"cat"}` {
+		t.Fatalf("unexpected tool_use block: %+v", toolUse)
+	}
+	if results := got.Messages[2].Content; len(results) != 2 || results[1].ToolUseID != "toolu_2" {
+		t.Fatalf("expected tool results merged into one user turn, got %+v", results)
+	}
+	if got.ToolChoice == nil || got.ToolChoice.Type != "tool" || got.ToolChoice.Name != "lookup" {
+		t.Fatalf("unexpected tool choice: %+v", got.ToolChoice)
+	}
+	if schema, ok := got.Tools[0].InputSchema.(map[string]any); !ok || schema["type"] != "object" {
+		t.Fatalf("expected default object schema, got %+v", got.Tools[0].InputSchema)
+	}
+}
+
+func TestAnthropicAdapterBuildRequestResponseFormat(t *testing.T) {
+	adapter := NewAnthropicAdapter()
+	request := openai.ChatCompletionRequest{
+		Model:    "claude-sonnet-4-5",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "List two colors."}},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   "colors",
+				Schema: json.RawMessage(`{"type":"array","items":{"type":"string"}}`),
+			},
+		},
+	}
+
+	body, err := adapter.BuildRequest(request, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := body.(anthropicRequest)
+	if !strings.HasPrefix(got.System, jsonOutputInstruction) || !strings.HasSuffix(got.System, `{"type":"array","items":{"type":"string"}}`) {
+		t.Fatalf("expected schema instruction in system prompt, got %q", got.System)
+	}
+}
+
+func TestAnthropicStreamTranslator(t *testing.T) {
+	translator := NewAnthropicAdapter().NewStreamTranslator("claude")
+	events := []string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-x","usage":{"input_tokens":10,"cache_read_input_tokens":5}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"hmm"}}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"now","input":{}}}`,
+		`data: {"type":"content_block_stop","index":2}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+		`data: {"type":"message_stop"}`,
+	}
+
+	var chunks []streamChunk
+	for _, event := range events {
+		lines, err := translator.Translate(event)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, line := range lines {
+			data := strings.TrimPrefix(line, dataPrefix)
+			if data == doneMarker {
+				continue
+			}
+			var chunk streamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				t.Fatalf("invalid chunk %q: %v", line, err)
+			}
+			chunks = append(chunks, chunk)
+		}
+	}
+	if _, err := translator.Finish(); err != nil {
+		t.Fatalf("expected clean finish, got %v", err)
+	}
+
+	var content, reasoning, arguments string
+	var finish openai.FinishReason
+	for _, chunk := range chunks {
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			reasoning += choice.Delta.ReasoningContent
+			for _, call := range choice.Delta.ToolCalls {
+				arguments += call.Function.Arguments
+			}
+			if choice.FinishReason != nil {
+				finish = *choice.FinishReason
+			}
+		}
+	}
+	if content != "Hi" || reasoning != "hmm" || arguments != "{}" || finish != openai.FinishReasonToolCalls {
+		t.Fatalf("unexpected translation: content=%q reasoning=%q args=%q finish=%q", content, reasoning, arguments, finish)
+	}
+
+	usage := chunks[len(chunks)-1].Usage
+	if usage == nil || usage.PromptTokens != 15 || usage.CompletionTokens != 7 || usage.TotalTokens != 22 {
+		t.Fatalf("unexpected usage chunk: %+v", usage)
+	}
+}
+
+func TestAnthropicStreamTranslatorErrors(t *testing.T) {
+	translator := NewAnthropicAdapter().NewStreamTranslator("claude")
+	_, err := translator.Translate(`data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+	if err == nil || !IsRetryableError(err) {
+		t.Fatalf("expected retryable overload error, got %v", err)
+	}
+
+	if _, err := NewAnthropicAdapter().NewStreamTranslator("claude").Finish(); err == nil || !IsRetryableError(err) {
+		t.Fatalf("expected truncated stream to be retryable, got %v", err)
+	}
+}
+
+func thinkingFollowUp(toolCallID string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:           "claude-sonnet-4-5",
+		ReasoningEffort: "low",
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: "What time is it?"},
+			{Role: openai.ChatMessageRoleAssistant, ReasoningContent: "need the clock", ToolCalls: []openai.ToolCall{{
+				ID: toolCallID, Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: "now", Arguments: `{}`},
+			}}},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: toolCallID, Content: "12:00"},
+		},
+		Tools: []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "now"}}},
+	}
+}
+
+func TestAnthropicAdapterReplaysThinkingBlocks(t *testing.T) {
+	adapter := NewAnthropicAdapter()
+	_, err := adapter.ParseResponse([]byte(`{"id":"msg_1","content":[
+		{"type":"thinking","thinking":"need the clock","signature":"sig_1"},
+		{"type":"redacted_thinking","data":"encrypted"},
+		{"type":"tool_use","id":"toolu_replay","name":"now","input":{}}
+	],"stop_reason":"tool_use"}`), "claude")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, err := adapter.BuildRequest(thinkingFollowUp("toolu_replay"), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := body.(anthropicRequest)
+	if got.Thinking == nil {
+		t.Fatal("expected thinking to stay enabled when the blocks are replayed")
+	}
+	blocks := got.Messages[1].Content
+	if len(blocks) != 3 || blocks[0].Type != "thinking" || blocks[0].Signature != "sig_1" || blocks[1].Type != "redacted_thinking" || blocks[1].Data != "encrypted" || blocks[2].Type != "tool_use" {
+		t.Fatalf("expected signed thinking blocks before tool_use, got %+v", blocks)
+	}
+}
+
+func TestAnthropicAdapterDisablesThinkingWithoutSignatures(t *testing.T) {
+	body, err := NewAnthropicAdapter().BuildRequest(thinkingFollowUp("toolu_unknown"), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := body.(anthropicRequest)
+	if got.Thinking != nil {
+		t.Fatal("expected thinking to be disabled when the thinking blocks are unknown")
+	}
+	if blocks := got.Messages[1].Content; len(blocks) != 1 || blocks[0].Type != "tool_use" {
+		t.Fatalf("expected only the tool_use block, got %+v", blocks)
+	}
+}
+
+func TestAnthropicAdapterRejectsForcedToolChoiceWithThinking(t *testing.T) {
+	request := thinkingFollowUp("toolu_forced")
+	request.ToolChoice = "required"
+	if _, err := NewAnthropicAdapter().BuildRequest(request, false); err == nil {
+		t.Fatal("expected forced tool choice with thinking to be rejected")
+	}
+
+	request.ReasoningEffort = ""
+	if _, err := NewAnthropicAdapter().BuildRequest(request, false); err != nil {
+		t.Fatalf("expected forced tool choice without thinking to be accepted, got %v", err)
+	}
+}
+
+func TestAnthropicStreamTranslatorKeepsSignatures(t *testing.T) {
+	adapter := NewAnthropicAdapter()
+	translator := adapter.NewStreamTranslator("claude")
+	events := []string{
+		`data: {"type":"message_start","message":{"id":"msg_2","usage":{"input_tokens":1}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"need the clock"}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig_stream"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"encrypted"}}`,
+		`data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_stream","name":"now","input":{}}}`,
+		`data: {"type":"content_block_stop","index":2}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":3}}`,
+		`data: {"type":"message_stop"}`,
+	}
+	for _, event := range events {
+		if _, err := translator.Translate(event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	body, err := adapter.BuildRequest(thinkingFollowUp("toolu_stream"), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	blocks := body.(anthropicRequest).Messages[1].Content
+	if len(blocks) != 3 || blocks[0].Thinking != "need the clock" || blocks[0].Signature != "sig_stream" || blocks[1].Data != "encrypted" {
+		t.Fatalf("expected streamed thinking blocks to be replayed, got %+v", blocks)
+	}
+}
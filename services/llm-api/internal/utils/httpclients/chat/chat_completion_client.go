@@ -3,17 +3,15 @@ package chat
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
-	"sync"
 	"time"
 
 	"jan-server/services/llm-api/internal/infrastructure/logger"
 	"jan-server/services/llm-api/internal/utils/platformerrors"
 
-	"github.com/gin-gonic/gin"
 	"github.com/sashabaranov/go-openai"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -25,7 +23,6 @@ import (
 const (
 	requestTimeout       = 120 * time.Second
 	channelBufferSize    = 100
-	errorBufferSize      = 10
 	dataPrefix           = "data: "
 	doneMarker           = "[DONE]"
 	newlineChar          = "\n"
@@ -33,27 +30,10 @@ const (
 	scannerMaxBuffer     = 10 * 1024 * 1024 // 10MB
 )
 
-type StreamOption func(*resty.Request)
-
-// BeforeDoneCallback is called before writing [DONE] marker
-type BeforeDoneCallback func(*gin.Context) error
-
-type TokenUsage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
-}
+// errStreamDone stops line processing once the upstream [DONE] marker is seen.
+var errStreamDone = errors.New("stream done")
 
-type ChoiceDelta struct {
-	Content          string               `json:"content"`
-	ReasoningContent string               `json:"reasoning_content"`
-	FunctionCall     *openai.FunctionCall `json:"function_call,omitempty"`
-	ToolCalls        []openai.ToolCall    `json:"tool_calls,omitempty"`
-}
-
-type StreamChoice struct {
-	Delta ChoiceDelta `json:"delta"`
-}
+type StreamOption func(*resty.Request)
 
 func WithHeader(key, value string) StreamOption {
 	return func(r *resty.Request) {
@@ -72,28 +52,16 @@ func WithAcceptEncodingIdentity() StreamOption {
 	return WithHeader("Accept-Encoding", "identity")
 }
 
+// ChatCompletionClient is the HTTP ChatCompleter. It talks to OpenAI-compatible upstreams
+// directly and to vendors with a native API through an Adapter.
 type ChatCompletionClient struct {
 	client  *resty.Client
 	baseURL string
 	name    string
+	adapter Adapter
 }
 
-type functionCallAccumulator struct {
-	Name      string
-	Arguments string
-	Complete  bool
-}
-
-type toolCallAccumulator struct {
-	ID       string
-	Type     string
-	Index    int
-	Function struct {
-		Name      string
-		Arguments string
-	}
-	Complete bool
-}
+var _ ChatCompleter = (*ChatCompletionClient)(nil)
 
 func NewChatCompletionClient(client *resty.Client, name, baseURL string) *ChatCompletionClient {
 	return &ChatCompletionClient{
@@ -103,6 +71,12 @@ func NewChatCompletionClient(client *resty.Client, name, baseURL string) *ChatCo
 	}
 }
 
+// WithAdapter routes requests through a vendor adapter instead of the OpenAI-compatible API.
+func (c *ChatCompletionClient) WithAdapter(adapter Adapter) *ChatCompletionClient {
+	c.adapter = adapter
+	return c
+}
+
 func (c *ChatCompletionClient) CreateChatCompletion(ctx context.Context, apiKey string, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
 	// Start OpenTelemetry span for tracking
 	ctx, span := otel.Tracer("chat-completion-client").Start(ctx, "CreateChatCompletion",
@@ -127,13 +101,21 @@ func (c *ChatCompletionClient) CreateChatCompletion(ctx context.Context, apiKey
 		span.SetAttributes(attribute.Float64("llm.top_p", float64(request.TopP)))
 	}
 
+	path, body, err := c.requestBody(ctx, request, false)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
 	start := time.Now()
 
 	var respBody openai.ChatCompletionResponse
-	resp, err := c.prepareRequest(ctx, apiKey).
-		SetBody(request).
-		SetResult(&respBody).
-		Post(c.endpoint("/chat/completions"))
+	req := c.prepareRequest(ctx, apiKey).SetBody(body)
+	if c.adapter == nil {
+		req.SetResult(&respBody)
+	}
+	resp, err := req.Post(c.endpoint(path))
 
 	duration := time.Since(start)
 
@@ -154,6 +136,16 @@ func (c *ChatCompletionClient) CreateChatCompletion(ctx context.Context, apiKey
 		return nil, reqErr
 	}
 
+	if c.adapter != nil {
+		parsed, parseErr := c.adapter.ParseResponse(resp.Bytes(), request.Model)
+		if parseErr != nil {
+			span.RecordError(parseErr)
+			span.SetStatus(codes.Error, parseErr.Error())
+			return nil, parseErr
+		}
+		respBody = *parsed
+	}
+
 	// Record token usage and timing in span
 	span.SetAttributes(
 		attribute.Int("llm.usage.prompt_tokens", respBody.Usage.PromptTokens),
@@ -181,15 +173,26 @@ func (c *ChatCompletionClient) CreateChatCompletion(ctx context.Context, apiKey
 	return &respBody, nil
 }
 
-func (c *ChatCompletionClient) CreateChatCompletionStream(ctx context.Context, apiKey string, request openai.ChatCompletionRequest, opts ...StreamOption) (io.ReadCloser, error) {
-	resp, err := c.doStreamingRequest(ctx, apiKey, request, opts...)
+// CreateChatCompletionStream sends the streaming request and returns once the upstream has
+// accepted it, so connection and status errors are returned directly. Chunks are then
+// delivered on the channel until [DONE], the request timeout, or cancellation of ctx.
+func (c *ChatCompletionClient) CreateChatCompletionStream(ctx context.Context, apiKey string, request openai.ChatCompletionRequest, opts ...StreamOption) (<-chan StreamChunk, error) {
+	// force to true to collect tokens
+	request.StreamOptions = &openai.StreamOptions{
+		IncludeUsage: true,
+	}
+
+	streamCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	resp, err := c.doStreamingRequest(streamCtx, apiKey, request, opts...)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 
-	reader, writer := io.Pipe()
-
+	chunks := make(chan StreamChunk, channelBufferSize)
 	go func() {
+		defer cancel()
+		defer close(chunks)
 		defer func() {
 			if closeErr := resp.RawResponse.Body.Close(); closeErr != nil {
 				log := logger.GetLogger()
@@ -197,236 +200,38 @@ func (c *ChatCompletionClient) CreateChatCompletionStream(ctx context.Context, a
 			}
 		}()
 
-		if _, copyErr := io.Copy(writer, resp.RawResponse.Body); copyErr != nil {
-			_ = writer.CloseWithError(copyErr)
+		err := c.readStream(streamCtx, resp.RawResponse.Body, request.Model, func(data string) error {
+			select {
+			case chunks <- StreamChunk{Data: data}:
+				return nil
+			case <-streamCtx.Done():
+				return streamCtx.Err()
+			}
+		})
+		if err == nil || errors.Is(err, errStreamDone) {
 			return
 		}
-		_ = writer.Close()
-	}()
-
-	return reader, nil
-}
-
-func (c *ChatCompletionClient) StreamChatCompletionToContext(reqCtx *gin.Context, apiKey string, request openai.ChatCompletionRequest, opts ...StreamOption) (*openai.ChatCompletionResponse, error) {
-	return c.StreamChatCompletionToContextWithCallback(reqCtx, apiKey, request, nil, opts...)
-}
-
-func (c *ChatCompletionClient) StreamChatCompletionToContextWithCallback(reqCtx *gin.Context, apiKey string, request openai.ChatCompletionRequest, beforeDone BeforeDoneCallback, opts ...StreamOption) (*openai.ChatCompletionResponse, error) {
-	// Start OpenTelemetry span for tracking streaming completion
-	ctx := reqCtx.Request.Context()
-	ctx, span := otel.Tracer("chat-completion-client").Start(ctx, "StreamChatCompletion",
-		trace.WithSpanKind(trace.SpanKindClient),
-		trace.WithAttributes(
-			attribute.String("llm.provider", c.name),
-			attribute.String("llm.model", request.Model),
-			attribute.Int("llm.message_count", len(request.Messages)),
-			attribute.Bool("llm.stream", true),
-		),
-	)
-	defer span.End()
-
-	// Add optional parameters as attributes
-	if request.Temperature != 0 {
-		span.SetAttributes(attribute.Float64("llm.temperature", float64(request.Temperature)))
-	}
-	if request.MaxTokens != 0 {
-		span.SetAttributes(attribute.Int("llm.max_tokens", request.MaxTokens))
-	}
-	if request.TopP != 0 {
-		span.SetAttributes(attribute.Float64("llm.top_p", float64(request.TopP)))
-	}
-
-	start := time.Now()
-
-	// force to true to collect tokens
-	request.StreamOptions = &openai.StreamOptions{
-		IncludeUsage: true,
-	}
-
-	streamCtx, cancel := context.WithTimeout(ctx, requestTimeout)
-	defer cancel()
-
-	c.SetupSSEHeaders(reqCtx)
-
-	dataChan := make(chan string, channelBufferSize)
-	errChan := make(chan error, errorBufferSize)
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	go c.streamResponseToChannel(streamCtx, apiKey, request, dataChan, errChan, &wg, opts)
-
-	var contentBuilder strings.Builder
-	var reasoningBuilder strings.Builder
-	functionCallAccumulator := make(map[int]*functionCallAccumulator)
-	toolCallAccumulator := make(map[int]*toolCallAccumulator)
-
-	// Track streaming metrics
-	var chunksReceived int
-	var totalUsage *TokenUsage
-
-	streamingComplete := false
+		if streamErr := streamCtx.Err(); streamErr != nil {
+			err = streamErr
+		}
 
-	for !streamingComplete {
+		// The consumer is gone once its own context is cancelled; otherwise it is still reading.
 		select {
-		case line, ok := <-dataChan:
-			if !ok {
-				streamingComplete = true
-				break
-			}
-
-			// Check if this is the [DONE] marker BEFORE writing it
-			if data, found := strings.CutPrefix(line, dataPrefix); found {
-				if data == doneMarker {
-					// Call the beforeDone callback BEFORE sending [DONE]
-					if beforeDone != nil {
-						if err := beforeDone(reqCtx); err != nil {
-							log := logger.GetLogger()
-							log.Warn().Err(err).Msg("beforeDone callback failed")
-						}
-					}
-					// Now write the [DONE] marker
-					if err := c.writeSSELine(reqCtx, line); err != nil {
-						cancel()
-						wg.Wait()
-						span.RecordError(err)
-						span.SetStatus(codes.Error, "failed to write SSE done marker")
-						return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "unable to write SSE line")
-					}
-					streamingComplete = true
-					cancel()
-					break
-				}
-			}
-
-			// Write the line for non-[DONE] events
-			if err := c.writeSSELine(reqCtx, line); err != nil {
-				cancel()
-				wg.Wait()
-				span.RecordError(err)
-				span.SetStatus(codes.Error, "failed to write SSE line")
-				return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "unable to write SSE line")
-			}
-
-			// Process the data chunk
-			if data, found := strings.CutPrefix(line, dataPrefix); found {
-				chunksReceived++
-
-				choice, usage := c.processStreamChunkForChannel(data)
-
-				// Capture final usage if available
-				if usage != nil {
-					totalUsage = usage
-				}
-
-				if choice != nil {
-					if choice.Delta.Content != "" {
-						contentBuilder.WriteString(choice.Delta.Content)
-					}
-
-					if choice.Delta.ReasoningContent != "" {
-						reasoningBuilder.WriteString(choice.Delta.ReasoningContent)
-					}
-
-					if choice.Delta.FunctionCall != nil {
-						c.handleStreamingFunctionCall(choice.Delta.FunctionCall, functionCallAccumulator)
-					}
-
-					if len(choice.Delta.ToolCalls) > 0 {
-						c.handleStreamingToolCall(&choice.Delta.ToolCalls[0], toolCallAccumulator)
-					}
-				}
-			}
-
-		case err, ok := <-errChan:
-			if ok && err != nil {
-				cancel()
-				wg.Wait()
-				span.RecordError(err)
-				span.SetStatus(codes.Error, "streaming error")
-				return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "streaming error")
-			}
-
-		case <-streamCtx.Done():
-			wg.Wait()
-			span.RecordError(streamCtx.Err())
-			span.SetStatus(codes.Error, "streaming context cancelled")
-			return nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, streamCtx.Err(), "streaming context cancelled")
-
-		case <-reqCtx.Request.Context().Done():
-			cancel()
-			wg.Wait()
-			span.RecordError(reqCtx.Request.Context().Err())
-			span.SetStatus(codes.Error, "client request cancelled")
-			return nil, platformerrors.AsError(reqCtx.Request.Context(), platformerrors.LayerDomain, reqCtx.Request.Context().Err(), "client request cancelled")
+		case chunks <- StreamChunk{Err: err}:
+		case <-ctx.Done():
 		}
-	}
-
-	cancel()
-	wg.Wait()
-
-	close(dataChan)
-	close(errChan)
-
-	duration := time.Since(start)
-
-	response := c.buildCompleteResponse(
-		contentBuilder.String(),
-		reasoningBuilder.String(),
-		functionCallAccumulator,
-		toolCallAccumulator,
-		request.Model,
-		request,
-	)
-
-	// Record streaming metrics in span
-	span.SetAttributes(
-		attribute.Int("llm.streaming.chunks_received", chunksReceived),
-		attribute.Int64("llm.duration_ms", duration.Milliseconds()),
-	)
-
-	// Add token usage if available from streaming
-	if totalUsage != nil {
-		span.SetAttributes(
-			attribute.Int("llm.usage.prompt_tokens", totalUsage.PromptTokens),
-			attribute.Int("llm.usage.completion_tokens", totalUsage.CompletionTokens),
-			attribute.Int("llm.usage.total_tokens", totalUsage.TotalTokens),
-		)
-	} else {
-		// Use estimated usage from response
-		span.SetAttributes(
-			attribute.Int("llm.usage.prompt_tokens", response.Usage.PromptTokens),
-			attribute.Int("llm.usage.completion_tokens", response.Usage.CompletionTokens),
-			attribute.Int("llm.usage.total_tokens", response.Usage.TotalTokens),
-		)
-	}
-
-	// Add finish reason if available
-	if len(response.Choices) > 0 {
-		span.SetAttributes(attribute.String("llm.finish_reason", string(response.Choices[0].FinishReason)))
-	}
-
-	span.SetStatus(codes.Ok, "streaming completion successful")
-	span.AddEvent("streaming_completed", trace.WithAttributes(
-		attribute.Int("chunks.total", chunksReceived),
-		attribute.Int("content.length", len(contentBuilder.String())),
-	))
+	}()
 
-	return &response, nil
+	return chunks, nil
 }
 
-func (c *ChatCompletionClient) SetupSSEHeaders(reqCtx *gin.Context) {
-	if reqCtx == nil {
-		return
+// ListModels lists the upstream's models, through the adapter when it supports native listings.
+func (c *ChatCompletionClient) ListModels(ctx context.Context) (*ModelsResponse, error) {
+	modelClient := NewChatModelClient(c.client, c.name, c.baseURL)
+	if lister, ok := c.adapter.(ModelListAdapter); ok {
+		modelClient.WithAdapter(lister)
 	}
-
-	reqCtx.Header("Content-Type", "text/event-stream")
-	reqCtx.Header("Cache-Control", "no-cache")
-	reqCtx.Header("Connection", "keep-alive")
-	reqCtx.Header("Access-Control-Allow-Origin", "*")
-	reqCtx.Header("Access-Control-Allow-Headers", "Cache-Control")
-	reqCtx.Header("Transfer-Encoding", "chunked")
-	reqCtx.Writer.WriteHeaderNow()
+	return modelClient.ListModels(ctx)
 }
 
 func (c *ChatCompletionClient) prepareRequest(ctx context.Context, apiKey string) *resty.Request {
@@ -455,24 +260,42 @@ func (c *ChatCompletionClient) endpoint(path string) string {
 }
 
 func (c *ChatCompletionClient) errorFromResponse(ctx context.Context, resp *resty.Response, message string) error {
+	fields := map[string]any{UpstreamStatusCodeKey: statusCode(resp)}
 	if resp == nil || resp.RawResponse == nil || resp.RawResponse.Body == nil {
-		return platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, message, nil, "3476dd55-5fc0-4653-bd10-665895ecc099")
+		return platformerrors.NewErrorWithContext(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, message, nil, "3476dd55-5fc0-4653-bd10-665895ecc099", fields)
 	}
 	defer resp.RawResponse.Body.Close()
 	body, err := io.ReadAll(resp.RawResponse.Body)
 	if err != nil {
-		return platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, message, nil, "8cd2cae7-9ad9-40fe-ac00-8f9b24251064")
+		return platformerrors.NewErrorWithContext(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, message, nil, "8cd2cae7-9ad9-40fe-ac00-8f9b24251064", fields)
 	}
 	trimmed := strings.TrimSpace(string(body))
 	if trimmed == "" {
-		return platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, message, nil, "b8797de4-38cb-4bd9-9ae8-b9a04e70f6ab")
+		return platformerrors.NewErrorWithContext(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, message, nil, "b8797de4-38cb-4bd9-9ae8-b9a04e70f6ab", fields)
 	}
-	return platformerrors.NewError(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, fmt.Sprintf("%s: %s", message, trimmed), nil, "a1f46e0d-4017-4411-ac05-987946c3066d")
+	return platformerrors.NewErrorWithContext(ctx, platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, fmt.Sprintf("%s: %s", message, trimmed), nil, "a1f46e0d-4017-4411-ac05-987946c3066d", fields)
+}
+
+// requestBody returns the upstream path and body for the request, translated by the adapter if one is set.
+func (c *ChatCompletionClient) requestBody(ctx context.Context, request openai.ChatCompletionRequest, stream bool) (string, any, error) {
+	if c.adapter == nil {
+		return "/chat/completions", request, nil
+	}
+	body, err := c.adapter.BuildRequest(request, stream)
+	if err != nil {
+		return "", nil, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "failed to translate chat completion request")
+	}
+	return c.adapter.CompletionPath(request, stream), body, nil
 }
 
 func (c *ChatCompletionClient) doStreamingRequest(ctx context.Context, apiKey string, request openai.ChatCompletionRequest, opts ...StreamOption) (*resty.Response, error) {
+	path, body, err := c.requestBody(ctx, request, true)
+	if err != nil {
+		return nil, err
+	}
+
 	req := c.prepareRequest(ctx, apiKey).
-		SetBody(request).
+		SetBody(body).
 		SetDoNotParseResponse(true)
 
 	for _, opt := range opts {
@@ -486,7 +309,7 @@ func (c *ChatCompletionClient) doStreamingRequest(ctx context.Context, apiKey st
 		req.SetHeader("Accept-Encoding", "identity")
 	}
 
-	resp, err := req.Post(c.endpoint("/chat/completions"))
+	resp, err := req.Post(c.endpoint(path))
 	if err != nil {
 		return nil, err
 	}
@@ -500,256 +323,72 @@ func (c *ChatCompletionClient) doStreamingRequest(ctx context.Context, apiKey st
 	return resp, nil
 }
 
-func (c *ChatCompletionClient) streamResponseToChannel(ctx context.Context, apiKey string, request openai.ChatCompletionRequest, dataChan chan<- string, errChan chan<- error, wg *sync.WaitGroup, opts []StreamOption) {
-	defer wg.Done()
-
-	resp, err := c.doStreamingRequest(ctx, apiKey, request, opts...)
-	if err != nil {
-		c.sendAsyncError(errChan, err)
-		return
-	}
-
-	defer func() {
-		if closeErr := resp.RawResponse.Body.Close(); closeErr != nil {
-			log := logger.GetLogger()
-			log.Error().Err(closeErr).Str("client", c.name).Msg("unable to close response body")
+// readStream hands each OpenAI chunk payload in body to emit, translating vendor events through
+// the adapter when one is set. It returns errStreamDone once the [DONE] marker is reached.
+func (c *ChatCompletionClient) readStream(ctx context.Context, body io.Reader, model string, emit func(data string) error) error {
+	emitLine := func(line string) error {
+		data, found := strings.CutPrefix(line, "data:")
+		if !found {
+			return nil
 		}
-	}()
-
-	scanner := bufio.NewScanner(resp.RawResponse.Body)
-	scanner.Buffer(make([]byte, 0, scannerInitialBuffer), scannerMaxBuffer)
-
-	for scanner.Scan() {
-		select {
-		case <-ctx.Done():
-			c.sendAsyncError(errChan, ctx.Err())
-			return
-		default:
+		data = strings.TrimSpace(data)
+		if data == "" {
+			return nil
 		}
-
-		line := scanner.Text()
-
-		select {
-		case dataChan <- line:
-		case <-ctx.Done():
-			c.sendAsyncError(errChan, ctx.Err())
-			return
+		if data == doneMarker {
+			return errStreamDone
 		}
+		return emit(data)
 	}
 
-	if err := scanner.Err(); err != nil {
-		c.sendAsyncError(errChan, err)
-	}
-}
-
-func (c *ChatCompletionClient) writeSSELine(reqCtx *gin.Context, line string) error {
-	if reqCtx == nil {
-		return platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "nil gin context provided", nil, "8ee6e88f-07e9-49e5-9c7a-6e1dfe151456")
-	}
-	_, err := reqCtx.Writer.Write([]byte(line + newlineChar))
-	if err != nil {
-		return err
-	}
-	reqCtx.Writer.Flush()
-	return nil
-}
-
-func (c *ChatCompletionClient) processStreamChunkForChannel(data string) (*StreamChoice, *TokenUsage) {
-	var streamData struct {
-		Choices []StreamChoice `json:"choices"`
-		Usage   *TokenUsage    `json:"usage"`
+	var translator StreamTranslator
+	if c.adapter != nil {
+		translator = c.adapter.NewStreamTranslator(model)
 	}
-
-	if err := json.Unmarshal([]byte(data), &streamData); err != nil {
-		log := logger.GetLogger()
-		log.Error().Err(err).Str("client", c.name).Str("data", data).Msg("failed to parse stream chunk JSON")
-		return nil, nil
-	}
-
-	result := &StreamChoice{
-		Delta: ChoiceDelta{},
-	}
-
-	for _, choice := range streamData.Choices {
-		if choice.Delta.Content != "" {
-			result.Delta.Content += choice.Delta.Content
-		}
-
-		if choice.Delta.ReasoningContent != "" {
-			result.Delta.ReasoningContent += choice.Delta.ReasoningContent
-		}
-
-		if choice.Delta.FunctionCall != nil {
-			result.Delta.FunctionCall = choice.Delta.FunctionCall
-		}
-
-		if len(choice.Delta.ToolCalls) > 0 {
-			// TODO: Handle multiple tool calls if needed
-			result.Delta.ToolCalls = choice.Delta.ToolCalls
+	emitLines := func(lines []string) error {
+		for _, line := range lines {
+			if err := emitLine(line); err != nil {
+				return err
+			}
 		}
+		return nil
 	}
 
-	return result, streamData.Usage
-}
-
-func (c *ChatCompletionClient) handleStreamingFunctionCall(functionCall *openai.FunctionCall, accumulator map[int]*functionCallAccumulator) {
-	if functionCall == nil {
-		return
-	}
-
-	index := 0
-	if accumulator[index] == nil {
-		accumulator[index] = &functionCallAccumulator{}
-	}
-
-	if functionCall.Name != "" {
-		accumulator[index].Name = functionCall.Name
-	}
-	if functionCall.Arguments != "" {
-		accumulator[index].Arguments += functionCall.Arguments
-	}
-
-	if accumulator[index].Name != "" && accumulator[index].Arguments != "" && strings.HasSuffix(accumulator[index].Arguments, "}") {
-		accumulator[index].Complete = true
-	}
-}
-
-func (c *ChatCompletionClient) handleStreamingToolCall(toolCall *openai.ToolCall, accumulator map[int]*toolCallAccumulator) {
-	if toolCall == nil || toolCall.Index == nil {
-		return
-	}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, scannerInitialBuffer), scannerMaxBuffer)
 
-	index := *toolCall.Index
-	if accumulator[index] == nil {
-		accumulator[index] = &toolCallAccumulator{
-			ID:    toolCall.ID,
-			Type:  string(toolCall.Type),
-			Index: index,
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-	}
-
-	if toolCall.Function.Name != "" {
-		accumulator[index].Function.Name = toolCall.Function.Name
-	}
-	if toolCall.Function.Arguments != "" {
-		accumulator[index].Function.Arguments += toolCall.Function.Arguments
-	}
-
-	if accumulator[index].Function.Name != "" && accumulator[index].Function.Arguments != "" && strings.HasSuffix(accumulator[index].Function.Arguments, "}") {
-		accumulator[index].Complete = true
-	}
-}
-
-func (c *ChatCompletionClient) buildCompleteResponse(content string, reasoning string, functionCallAccumulator map[int]*functionCallAccumulator, toolCallAccumulator map[int]*toolCallAccumulator, model string, request openai.ChatCompletionRequest) openai.ChatCompletionResponse {
-	message := openai.ChatCompletionMessage{
-		Role:    openai.ChatMessageRoleAssistant,
-		Content: content,
-	}
 
-	if reasoning != "" {
-		message.ReasoningContent = reasoning
-	}
-
-	finishReason := openai.FinishReasonStop
-
-	if len(functionCallAccumulator) > 0 {
-		for _, acc := range functionCallAccumulator {
-			if acc != nil && acc.Complete {
-				message.FunctionCall = &openai.FunctionCall{
-					Name:      acc.Name,
-					Arguments: acc.Arguments,
-				}
-				finishReason = openai.FinishReasonFunctionCall
-				break
+		if translator == nil {
+			if err := emitLine(scanner.Text()); err != nil {
+				return err
 			}
+			continue
 		}
-	}
 
-	if len(toolCallAccumulator) > 0 {
-		var toolCalls []openai.ToolCall
-		for _, acc := range toolCallAccumulator {
-			if acc != nil && acc.Complete {
-				toolCalls = append(toolCalls, openai.ToolCall{
-					ID:   acc.ID,
-					Type: openai.ToolType(acc.Type),
-					Function: openai.FunctionCall{
-						Name:      acc.Function.Name,
-						Arguments: acc.Function.Arguments,
-					},
-				})
-			}
+		lines, err := translator.Translate(scanner.Text())
+		if err != nil {
+			return err
 		}
-
-		if len(toolCalls) > 0 {
-			message.ToolCalls = toolCalls
-			finishReason = openai.FinishReasonToolCalls
+		if err := emitLines(lines); err != nil {
+			return err
 		}
 	}
-
-	choices := []openai.ChatCompletionChoice{
-		{
-			Index:        0,
-			Message:      message,
-			FinishReason: finishReason,
-		},
-	}
-
-	promptTokens := c.estimateTokens(request.Messages)
-	completionTokens := c.estimateTokens([]openai.ChatCompletionMessage{message})
-	totalTokens := promptTokens + completionTokens
-
-	return openai.ChatCompletionResponse{
-		ID:      "",
-		Object:  "chat.completion",
-		Created: time.Now().Unix(),
-		Model:   model,
-		Choices: choices,
-		Usage: openai.Usage{
-			PromptTokens:     promptTokens,
-			CompletionTokens: completionTokens,
-			TotalTokens:      totalTokens,
-		},
-	}
-}
-
-func (c *ChatCompletionClient) estimateTokens(messages []openai.ChatCompletionMessage) int {
-	var allText strings.Builder
-
-	for _, msg := range messages {
-		allText.WriteString(msg.Content)
-		allText.WriteString(" ")
-
-		if msg.FunctionCall != nil {
-			allText.WriteString(msg.FunctionCall.Name)
-			allText.WriteString(" ")
-			allText.WriteString(msg.FunctionCall.Arguments)
-			allText.WriteString(" ")
-		}
-
-		for _, toolCall := range msg.ToolCalls {
-			allText.WriteString(toolCall.ID)
-			allText.WriteString(" ")
-			allText.WriteString(toolCall.Function.Name)
-			allText.WriteString(" ")
-			allText.WriteString(toolCall.Function.Arguments)
-			allText.WriteString(" ")
-		}
+	if err := scanner.Err(); err != nil {
+		return err
 	}
 
-	normalized := strings.Join(strings.Fields(allText.String()), " ")
-	words := strings.Fields(normalized)
-	return len(words)
-}
-
-func (c *ChatCompletionClient) sendAsyncError(errChan chan<- error, err error) {
-	if err == nil {
-		return
+	if translator == nil {
+		return nil
 	}
-
-	select {
-	case errChan <- err:
-	default:
+	lines, err := translator.Finish()
+	if err != nil {
+		return err
 	}
+	return emitLines(lines)
 }
 
 func (c *ChatCompletionClient) BaseURL() string {
@@ -12,10 +12,14 @@ import (
 	"resty.dev/v3"
 )
 
+// maxModelPages bounds paginated native model listings.
+const maxModelPages = 20
+
 type ChatModelClient struct {
 	client  *resty.Client
 	baseURL string
 	name    string
+	adapter ModelListAdapter
 }
 
 type ModelsResponse struct {
@@ -88,7 +92,17 @@ func NewChatModelClient(client *resty.Client, name, baseURL string) *ChatModelCl
 	}
 }
 
+// WithAdapter lists models through a vendor adapter instead of the OpenAI-compatible /models endpoint.
+func (c *ChatModelClient) WithAdapter(adapter ModelListAdapter) *ChatModelClient {
+	c.adapter = adapter
+	return c
+}
+
 func (c *ChatModelClient) ListModels(ctx context.Context) (*ModelsResponse, error) {
+	if c.adapter != nil {
+		return c.listAdapterModels(ctx)
+	}
+
 	var respBody ModelsResponse
 	resp, err := c.client.R().
 		SetContext(ctx).
@@ -103,6 +117,33 @@ func (c *ChatModelClient) ListModels(ctx context.Context) (*ModelsResponse, erro
 	return &respBody, nil
 }
 
+func (c *ChatModelClient) listAdapterModels(ctx context.Context) (*ModelsResponse, error) {
+	result := &ModelsResponse{Object: "list", Data: []Model{}}
+	pageToken := ""
+	for page := 0; page < maxModelPages; page++ {
+		resp, err := c.client.R().
+			SetContext(ctx).
+			Get(c.endpoint(c.adapter.ModelsPath(pageToken)))
+		if err != nil {
+			return nil, err
+		}
+		if resp.IsError() {
+			return nil, c.errorFromResponse(ctx, resp, "list models request failed")
+		}
+
+		models, nextPageToken, err := c.adapter.ParseModels(resp.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		result.Data = append(result.Data, models...)
+		if nextPageToken == "" {
+			break
+		}
+		pageToken = nextPageToken
+	}
+	return result, nil
+}
+
 func (c *ChatModelClient) endpoint(path string) string {
 	if path == "" {
 		return c.baseURL
@@ -0,0 +1,26 @@
+package chat
+
+import (
+	"context"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ChatCompleter is an upstream chat completion backend speaking OpenAI chat completion shapes.
+// ChatCompletionClient implements it over HTTP; vendor clients and test doubles can provide
+// their own implementations.
+type ChatCompleter interface {
+	CreateChatCompletion(ctx context.Context, apiKey string, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error)
+	// CreateChatCompletionStream starts a streamed completion. Errors raised before the first chunk
+	// are returned directly; later failures arrive as a final chunk carrying Err. The channel is
+	// closed when the stream ends or ctx is cancelled.
+	CreateChatCompletionStream(ctx context.Context, apiKey string, request openai.ChatCompletionRequest, opts ...StreamOption) (<-chan StreamChunk, error)
+	ListModels(ctx context.Context) (*ModelsResponse, error)
+}
+
+// StreamChunk is one event of a streamed completion: the JSON payload of an OpenAI
+// chat.completion.chunk, or the error that ended the stream.
+type StreamChunk struct {
+	Data string
+	Err  error
+}
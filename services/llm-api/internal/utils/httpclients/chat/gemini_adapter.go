@@ -0,0 +1,790 @@
+package chat
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"jan-server/services/llm-api/internal/utils/idgen"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	geminiModelsPageSize = 1000
+
+	// geminiMaxInlineImageBytes keeps fetched images within Gemini's inline request size limit.
+	geminiMaxInlineImageBytes = 20 << 20
+	geminiImageFetchTimeout   = 30 * time.Second
+
+	// geminiSkipThoughtSignature is the placeholder Gemini documents for function calls whose
+	// thought signature is unknown. Gemini 3 models reject function call history without one.
+	geminiSkipThoughtSignature = "skip_thought_signature_validator"
+)
+
+// geminiThinkingBudgets maps OpenAI reasoning_effort values to Gemini thinking budgets.
+var geminiThinkingBudgets = map[string]int{
+	"minimal": 512,
+	"low":     1024,
+	"medium":  8192,
+	"high":    24576,
+}
+
+// GeminiAdapter speaks the Google Gemini API (models/{model}:generateContent and
+// :streamGenerateContent) and normalizes its model listing.
+type GeminiAdapter struct {
+	// imageClient downloads image URLs, which Gemini only accepts as inline data
+	imageClient *http.Client
+}
+
+func NewGeminiAdapter() *GeminiAdapter {
+	return &GeminiAdapter{imageClient: newPublicHTTPClient(geminiImageFetchTimeout)}
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	ToolConfig        *geminiToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FileData         *geminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+	ThoughtSignature string                  `json:"thoughtSignature,omitempty"`
+}
+
+type geminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string         `json:"id,omitempty"`
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parametersJsonSchema,omitempty"`
+}
+
+type geminiToolConfig struct {
+	FunctionCallingConfig geminiFunctionCallingConfig `json:"functionCallingConfig"`
+}
+
+type geminiFunctionCallingConfig struct {
+	Mode                 string   `json:"mode"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature        *float32              `json:"temperature,omitempty"`
+	TopP               *float32              `json:"topP,omitempty"`
+	MaxOutputTokens    int                   `json:"maxOutputTokens,omitempty"`
+	StopSequences      []string              `json:"stopSequences,omitempty"`
+	CandidateCount     int                   `json:"candidateCount,omitempty"`
+	PresencePenalty    *float32              `json:"presencePenalty,omitempty"`
+	FrequencyPenalty   *float32              `json:"frequencyPenalty,omitempty"`
+	Seed               *int                  `json:"seed,omitempty"`
+	ResponseMimeType   string                `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.Marshaler        `json:"responseJsonSchema,omitempty"`
+	ThinkingConfig     *geminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type geminiThinkingConfig struct {
+	ThinkingBudget  int  `json:"thinkingBudget"`
+	IncludeThoughts bool `json:"includeThoughts"`
+}
+
+type geminiResponse struct {
+	ResponseID     string            `json:"responseId"`
+	ModelVersion   string            `json:"modelVersion"`
+	Candidates     []geminiCandidate `json:"candidates"`
+	UsageMetadata  *geminiUsage      `json:"usageMetadata,omitempty"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback,omitempty"`
+	Error *geminiError `json:"error,omitempty"`
+}
+
+type geminiCandidate struct {
+	Index        int           `json:"index"`
+	Content      geminiContent `json:"content"`
+	FinishReason string        `json:"finishReason"`
+}
+
+type geminiUsage struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+}
+
+type geminiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+type geminiModelsResponse struct {
+	Models        []geminiModel `json:"models"`
+	NextPageToken string        `json:"nextPageToken"`
+}
+
+type geminiModel struct {
+	Name                       string   `json:"name"`
+	DisplayName                string   `json:"displayName"`
+	Description                string   `json:"description"`
+	Version                    string   `json:"version"`
+	InputTokenLimit            int      `json:"inputTokenLimit"`
+	OutputTokenLimit           int      `json:"outputTokenLimit"`
+	SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+	Temperature                *float64 `json:"temperature,omitempty"`
+	TopP                       *float64 `json:"topP,omitempty"`
+	Thinking                   bool     `json:"thinking"`
+}
+
+func (a *GeminiAdapter) CompletionPath(request openai.ChatCompletionRequest, stream bool) string {
+	model := url.PathEscape(strings.TrimPrefix(request.Model, "models/"))
+	if stream {
+		return "/models/" + model + ":streamGenerateContent?alt=sse"
+	}
+	return "/models/" + model + ":generateContent"
+}
+
+func (a *GeminiAdapter) BuildRequest(request openai.ChatCompletionRequest, stream bool) (any, error) {
+	if stream && request.N > 1 {
+		return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "gemini streams return a single choice; n must be 1 when stream is true", nil, "6e2b9d4f-1c7a-4f38-b5e0-8a3d7c2f1b94")
+	}
+
+	system, contents, err := a.convertMessages(request.Messages, strings.HasPrefix(strings.TrimPrefix(request.Model, "models/"), "gemini-3"))
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) == 0 {
+		return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "gemini requests need at least one user or assistant message", nil, "2a7e4c19-6b3d-4f0a-9e81-5c2d7f3b8a64")
+	}
+
+	body := geminiRequest{
+		Contents:          contents,
+		SystemInstruction: system,
+		GenerationConfig:  a.generationConfig(request),
+	}
+
+	var declarations []geminiFunctionDeclaration
+	for _, definition := range requestTools(request) {
+		declarations = append(declarations, geminiFunctionDeclaration{
+			Name:        definition.Name,
+			Description: definition.Description,
+			Parameters:  definition.Parameters,
+		})
+	}
+	if len(declarations) > 0 {
+		body.Tools = []geminiTool{{FunctionDeclarations: declarations}}
+		body.ToolConfig = a.convertToolChoice(request.ToolChoice)
+	}
+
+	return body, nil
+}
+
+func (a *GeminiAdapter) ParseResponse(body []byte, model string) (*openai.ChatCompletionResponse, error) {
+	var resp geminiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, "failed to decode gemini response", err, "8c5b1e3a-0f7d-4a92-b6e4-3d9f1a2c7e58")
+	}
+	if resp.Error != nil {
+		return nil, geminiUpstreamError(resp.Error)
+	}
+
+	blocked := resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != ""
+	choices := make([]openai.ChatCompletionChoice, 0, max(len(resp.Candidates), 1))
+	for _, candidate := range resp.Candidates {
+		message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+		var content, reasoning strings.Builder
+		for _, part := range candidate.Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				toolCall := openai.ToolCall{
+					ID:       geminiToolCallID(part.FunctionCall),
+					Type:     openai.ToolTypeFunction,
+					Function: openai.FunctionCall{Name: part.FunctionCall.Name, Arguments: rawArguments(part.FunctionCall.Args)},
+				}
+				storeThoughtSignature(part, toolCall.ID)
+				message.ToolCalls = append(message.ToolCalls, toolCall)
+			case part.Thought:
+				reasoning.WriteString(part.Text)
+			default:
+				content.WriteString(part.Text)
+			}
+		}
+		message.Content = content.String()
+		message.ReasoningContent = reasoning.String()
+		choices = append(choices, openai.ChatCompletionChoice{
+			Index:        candidate.Index,
+			Message:      message,
+			FinishReason: geminiFinishReason(candidate.FinishReason, len(message.ToolCalls) > 0),
+		})
+	}
+	if len(choices) == 0 {
+		finishReason := openai.FinishReasonStop
+		if blocked {
+			finishReason = openai.FinishReasonContentFilter
+		}
+		choices = append(choices, openai.ChatCompletionChoice{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant},
+			FinishReason: finishReason,
+		})
+	}
+
+	if resp.ModelVersion != "" {
+		model = resp.ModelVersion
+	}
+	return &openai.ChatCompletionResponse{
+		ID:      resp.ResponseID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: choices,
+		Usage:   resp.UsageMetadata.toOpenAI(),
+	}, nil
+}
+
+func (a *GeminiAdapter) NewStreamTranslator(model string) StreamTranslator {
+	return &geminiStreamTranslator{model: model, created: time.Now().Unix()}
+}
+
+func (a *GeminiAdapter) ModelsPath(pageToken string) string {
+	query := url.Values{}
+	query.Set("pageSize", fmt.Sprint(geminiModelsPageSize))
+	if pageToken != "" {
+		query.Set("pageToken", pageToken)
+	}
+	return "/models?" + query.Encode()
+}
+
+// ParseModels converts Gemini model resources into OpenAI-style models, keeping only models that
+// support generateContent. Token limits and capabilities are exposed through Raw using the keys
+// the catalog sync reads for OpenRouter-style listings.
+func (a *GeminiAdapter) ParseModels(body []byte) ([]Model, string, error) {
+	var resp geminiModelsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "", platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, "failed to decode gemini model list", err, "5e9d2b7c-1a4f-4c36-8b0e-7f3a6d1c9e25")
+	}
+
+	models := make([]Model, 0, len(resp.Models))
+	for _, model := range resp.Models {
+		if !containsMethod(model.SupportedGenerationMethods, "generateContent") {
+			continue
+		}
+		id := strings.TrimPrefix(model.Name, "models/")
+		displayName := model.DisplayName
+		if displayName == "" {
+			displayName = id
+		}
+
+		inputModalities, modality := []any{"text"}, "text->text"
+		if strings.HasPrefix(id, "gemini") || strings.HasPrefix(id, "gemma-3") {
+			inputModalities, modality = []any{"text", "image"}, "text+image->text"
+		}
+		supportedParameters := []any{"temperature", "top_p", "max_tokens", "stop", "stream", "tools", "tool_choice", "response_format", "seed"}
+		if model.Thinking {
+			supportedParameters = append(supportedParameters, "reasoning", "include_reasoning")
+		}
+
+		raw := map[string]any{
+			"id":                    id,
+			"object":                "model",
+			"owned_by":              "google",
+			"name":                  displayName,
+			"display_name":          displayName,
+			"description":           model.Description,
+			"version":               model.Version,
+			"context_length":        model.InputTokenLimit,
+			"max_completion_tokens": model.OutputTokenLimit,
+			"supported_parameters":  supportedParameters,
+			"architecture": map[string]any{
+				"modality":          modality,
+				"input_modalities":  inputModalities,
+				"output_modalities": []any{"text"},
+				"tokenizer":         "Gemini",
+			},
+		}
+
+		models = append(models, Model{
+			ID:          id,
+			Object:      "model",
+			OwnedBy:     "google",
+			DisplayName: displayName,
+			Name:        displayName,
+			Raw:         raw,
+		})
+	}
+	return models, resp.NextPageToken, nil
+}
+
+// convertMessages maps system/developer messages to systemInstruction and the conversation to
+// user/model contents. Tool results become functionResponse parts named after the originating call,
+// and function calls carry the thought signature Gemini returned with them. requireSignatures fills
+// unknown signatures with Gemini's placeholder, for models that reject calls without one.
+func (a *GeminiAdapter) convertMessages(messages []openai.ChatCompletionMessage, requireSignatures bool) (*geminiContent, []geminiContent, error) {
+	var systemParts []geminiPart
+	var contents []geminiContent
+	toolNames := make(map[string]string)
+
+	appendParts := func(role string, parts []geminiPart) {
+		if len(parts) == 0 {
+			return
+		}
+		if last := len(contents) - 1; last >= 0 && contents[last].Role == role {
+			contents[last].Parts = append(contents[last].Parts, parts...)
+			return
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: parts})
+	}
+
+	for _, message := range messages {
+		switch message.Role {
+		case openai.ChatMessageRoleSystem, openai.ChatMessageRoleDeveloper:
+			if text := strings.TrimSpace(messageText(message)); text != "" {
+				systemParts = append(systemParts, geminiPart{Text: text})
+			}
+		case openai.ChatMessageRoleUser:
+			parts, err := a.userParts(message)
+			if err != nil {
+				return nil, nil, err
+			}
+			appendParts("user", parts)
+		case openai.ChatMessageRoleAssistant:
+			var parts []geminiPart
+			if text := messageText(message); strings.TrimSpace(text) != "" {
+				parts = append(parts, geminiPart{Text: text})
+			}
+			for idx, toolCall := range message.ToolCalls {
+				toolNames[toolCall.ID] = toolCall.Function.Name
+				part := geminiPart{FunctionCall: &geminiFunctionCall{
+					Name: toolCall.Function.Name,
+					Args: objectArguments(toolCall.Function.Arguments),
+				}}
+				if value, ok := reasoningStates.Load(toolCall.ID); ok {
+					part.ThoughtSignature, _ = value.(string)
+				} else if requireSignatures && idx == 0 {
+					// Only the first call of a step is signed
+					part.ThoughtSignature = geminiSkipThoughtSignature
+				}
+				parts = append(parts, part)
+			}
+			appendParts("model", parts)
+		case openai.ChatMessageRoleTool:
+			name := toolNames[message.ToolCallID]
+			if name == "" {
+				name = message.Name
+			}
+			appendParts("user", []geminiPart{{FunctionResponse: &geminiFunctionResponse{
+				Name:     name,
+				Response: toolResponse(messageText(message)),
+			}}})
+		}
+	}
+
+	var system *geminiContent
+	if len(systemParts) > 0 {
+		system = &geminiContent{Parts: systemParts}
+	}
+	return system, contents, nil
+}
+
+func (a *GeminiAdapter) userParts(message openai.ChatCompletionMessage) ([]geminiPart, error) {
+	if len(message.MultiContent) == 0 {
+		if strings.TrimSpace(message.Content) == "" {
+			return nil, nil
+		}
+		return []geminiPart{{Text: message.Content}}, nil
+	}
+
+	parts := make([]geminiPart, 0, len(message.MultiContent))
+	for _, part := range message.MultiContent {
+		switch part.Type {
+		case openai.ChatMessagePartTypeText:
+			if strings.TrimSpace(part.Text) != "" {
+				parts = append(parts, geminiPart{Text: part.Text})
+			}
+		case openai.ChatMessagePartTypeImageURL:
+			if part.ImageURL == nil || part.ImageURL.URL == "" {
+				continue
+			}
+			// Resolved jan_* media arrives as base64 data URLs and is sent inline.
+			if mediaType, data, ok := parseDataURL(part.ImageURL.URL); ok {
+				parts = append(parts, geminiPart{InlineData: &geminiBlob{MimeType: mediaType, Data: data}})
+				continue
+			}
+			// fileData only accepts files uploaded to Gemini or Cloud Storage
+			if isGeminiFileURI(part.ImageURL.URL) {
+				parts = append(parts, geminiPart{FileData: &geminiFileData{
+					MimeType: imageMimeType(part.ImageURL.URL),
+					FileURI:  part.ImageURL.URL,
+				}})
+				continue
+			}
+			blob, err := a.fetchImage(part.ImageURL.URL)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, geminiPart{InlineData: blob})
+		}
+	}
+	return parts, nil
+}
+
+// isGeminiFileURI reports whether the URL points at the Gemini Files API or Cloud Storage.
+func isGeminiFileURI(fileURI string) bool {
+	return strings.HasPrefix(fileURI, "gs://") ||
+		strings.HasPrefix(fileURI, "https://generativelanguage.googleapis.com/")
+}
+
+// fetchImage downloads an image URL so it can be sent as inline data.
+func (a *GeminiAdapter) fetchImage(imageURL string) (*geminiBlob, error) {
+	invalid := func(message string, err error) error {
+		return platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, message, err, "93c1f6a8-2e5b-4d07-b9a4-7f0e3c8d5a21")
+	}
+
+	parsed, err := url.Parse(imageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, invalid("image_url must be an http(s) or data URL", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), geminiImageFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, invalid("invalid image_url", err)
+	}
+	resp, err := a.imageClient.Do(req)
+	if err != nil {
+		return nil, invalid("failed to download image_url", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, invalid(fmt.Sprintf("failed to download image_url: status %d", resp.StatusCode), nil)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, geminiMaxInlineImageBytes+1))
+	if err != nil {
+		return nil, invalid("failed to download image_url", err)
+	}
+	if len(data) > geminiMaxInlineImageBytes {
+		return nil, invalid("image_url is larger than 20 MB", nil)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		mediaType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mediaType, "image/") {
+		return nil, invalid("image_url does not point to an image", nil)
+	}
+	return &geminiBlob{MimeType: mediaType, Data: base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+func (a *GeminiAdapter) generationConfig(request openai.ChatCompletionRequest) *geminiGenerationConfig {
+	config := &geminiGenerationConfig{
+		MaxOutputTokens: maxOutputTokens(request),
+		StopSequences:   request.Stop,
+		Seed:            request.Seed,
+	}
+	if request.N > 1 {
+		config.CandidateCount = request.N
+	}
+	if request.Temperature != 0 {
+		temperature := request.Temperature
+		config.Temperature = &temperature
+	}
+	if request.TopP != 0 {
+		topP := request.TopP
+		config.TopP = &topP
+	}
+	if request.PresencePenalty != 0 {
+		presencePenalty := request.PresencePenalty
+		config.PresencePenalty = &presencePenalty
+	}
+	if request.FrequencyPenalty != 0 {
+		frequencyPenalty := request.FrequencyPenalty
+		config.FrequencyPenalty = &frequencyPenalty
+	}
+	if budget, ok := geminiThinkingBudgets[strings.ToLower(request.ReasoningEffort)]; ok {
+		config.ThinkingConfig = &geminiThinkingConfig{ThinkingBudget: budget, IncludeThoughts: true}
+	}
+	if format := request.ResponseFormat; format != nil {
+		switch format.Type {
+		case openai.ChatCompletionResponseFormatTypeJSONObject:
+			config.ResponseMimeType = "application/json"
+		case openai.ChatCompletionResponseFormatTypeJSONSchema:
+			config.ResponseMimeType = "application/json"
+			if format.JSONSchema != nil {
+				config.ResponseJSONSchema = format.JSONSchema.Schema
+			}
+		}
+	}
+	return config
+}
+
+func (a *GeminiAdapter) convertToolChoice(choice any) *geminiToolConfig {
+	switch mode, name := toolChoiceMode(choice); mode {
+	case "none":
+		return &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{Mode: "NONE"}}
+	case "required":
+		return &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{Mode: "ANY"}}
+	case "function":
+		return &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{Mode: "ANY", AllowedFunctionNames: []string{name}}}
+	default:
+		return nil
+	}
+}
+
+func (u *geminiUsage) toOpenAI() openai.Usage {
+	if u == nil {
+		return openai.Usage{}
+	}
+	completionTokens := u.CandidatesTokenCount + u.ThoughtsTokenCount
+	usage := openai.Usage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: completionTokens,
+		TotalTokens:      u.TotalTokenCount,
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + completionTokens
+	}
+	if u.CachedContentTokenCount > 0 {
+		usage.PromptTokensDetails = &openai.PromptTokensDetails{CachedTokens: u.CachedContentTokenCount}
+	}
+	if u.ThoughtsTokenCount > 0 {
+		usage.CompletionTokensDetails = &openai.CompletionTokensDetails{ReasoningTokens: u.ThoughtsTokenCount}
+	}
+	return usage
+}
+
+func geminiFinishReason(reason string, hasToolCalls bool) openai.FinishReason {
+	if hasToolCalls {
+		return openai.FinishReasonToolCalls
+	}
+	switch reason {
+	case "MAX_TOKENS":
+		return openai.FinishReasonLength
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return openai.FinishReasonContentFilter
+	default:
+		return openai.FinishReasonStop
+	}
+}
+
+func geminiUpstreamError(upstream *geminiError) error {
+	return platformerrors.NewErrorWithContext(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeExternal,
+		fmt.Sprintf("gemini error %d %s: %s", upstream.Code, upstream.Status, upstream.Message), nil, "b3f8e2d1-7c4a-4e59-a0b6-9d1e5c7f3a82",
+		map[string]any{UpstreamStatusCodeKey: upstream.Code})
+}
+
+// storeThoughtSignature keeps the thought signature of a function call part for the next turn.
+func storeThoughtSignature(part geminiPart, toolCallID string) {
+	if part.ThoughtSignature != "" {
+		reasoningStates.Store(part.ThoughtSignature, toolCallID)
+	}
+}
+
+// geminiToolCallID returns the upstream function call id, generating one when Gemini omits it.
+func geminiToolCallID(call *geminiFunctionCall) string {
+	if call.ID != "" {
+		return call.ID
+	}
+	if id, err := idgen.GenerateSecureID("call", 24); err == nil {
+		return id
+	}
+	return "call_" + call.Name
+}
+
+// toolResponse wraps a tool result as the JSON object Gemini expects in functionResponse.response.
+func toolResponse(content string) map[string]any {
+	var object map[string]any
+	if err := json.Unmarshal([]byte(content), &object); err == nil && object != nil {
+		return object
+	}
+	return map[string]any{"content": content}
+}
+
+func imageMimeType(imageURL string) string {
+	if parsed, err := url.Parse(imageURL); err == nil {
+		if mediaType := mime.TypeByExtension(path.Ext(parsed.Path)); mediaType != "" {
+			return mediaType
+		}
+	}
+	return "image/jpeg"
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, candidate := range methods {
+		if candidate == method {
+			return true
+		}
+	}
+	return false
+}
+
+// geminiStreamTranslator maps streamGenerateContent (alt=sse) chunks onto OpenAI chunks. Gemini has
+// no end-of-stream event, so the finish reason, usage and [DONE] are emitted once the body ends.
+type geminiStreamTranslator struct {
+	model        string
+	id           string
+	created      int64
+	started      bool
+	usage        *geminiUsage
+	nextTool     int
+	finishReason string
+	blocked      bool
+}
+
+func (t *geminiStreamTranslator) Translate(line string) ([]string, error) {
+	data, found := strings.CutPrefix(line, "data:")
+	if !found {
+		return nil, nil
+	}
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return nil, nil
+	}
+
+	var chunk geminiResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, "failed to decode gemini stream chunk", err, "e4a7c2b9-3d1f-4b68-9e05-6c8d2f1a7b39")
+	}
+	if chunk.Error != nil {
+		return nil, geminiUpstreamError(chunk.Error)
+	}
+
+	if chunk.ResponseID != "" {
+		t.id = chunk.ResponseID
+	}
+	if chunk.ModelVersion != "" {
+		t.model = chunk.ModelVersion
+	}
+	if chunk.UsageMetadata != nil {
+		t.usage = chunk.UsageMetadata
+	}
+	if chunk.PromptFeedback != nil && chunk.PromptFeedback.BlockReason != "" {
+		t.blocked = true
+	}
+
+	var lines []string
+	emit := func(delta openai.ChatCompletionStreamChoiceDelta) error {
+		if !t.started {
+			delta.Role = openai.ChatMessageRoleAssistant
+			t.started = true
+		}
+		line, err := t.chunk(delta, nil)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+		return nil
+	}
+
+	if len(chunk.Candidates) == 0 {
+		return lines, nil
+	}
+	candidate := chunk.Candidates[0]
+	if candidate.FinishReason != "" {
+		t.finishReason = candidate.FinishReason
+	}
+	for _, part := range candidate.Content.Parts {
+		var err error
+		switch {
+		case part.FunctionCall != nil:
+			index := t.nextTool
+			t.nextTool++
+			id := geminiToolCallID(part.FunctionCall)
+			storeThoughtSignature(part, id)
+			err = emit(openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{{
+				Index: &index,
+				ID:    id,
+				Type:  openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      part.FunctionCall.Name,
+					Arguments: rawArguments(part.FunctionCall.Args),
+				},
+			}}})
+		case part.Thought && part.Text != "":
+			err = emit(openai.ChatCompletionStreamChoiceDelta{ReasoningContent: part.Text})
+		case part.Text != "":
+			err = emit(openai.ChatCompletionStreamChoiceDelta{Content: part.Text})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+func (t *geminiStreamTranslator) Finish() ([]string, error) {
+	if t.finishReason == "" && !t.blocked {
+		return nil, platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeExternal, "gemini stream ended without a finish reason", io.ErrUnexpectedEOF, "1f6c3e8a-9b2d-4a75-8e14-0d7b5c2a9f63")
+	}
+
+	finishReason := geminiFinishReason(t.finishReason, t.nextTool > 0)
+	if t.blocked {
+		finishReason = openai.FinishReasonContentFilter
+	}
+	finishLine, err := t.chunk(openai.ChatCompletionStreamChoiceDelta{}, &finishReason)
+	if err != nil {
+		return nil, err
+	}
+	usage := t.usage.toOpenAI()
+	usageLine, err := chunkLine(streamChunk{ID: t.id, Created: t.created, Model: t.model, Usage: &usage})
+	if err != nil {
+		return nil, err
+	}
+	return []string{finishLine, usageLine, dataPrefix + doneMarker}, nil
+}
+
+func (t *geminiStreamTranslator) chunk(delta openai.ChatCompletionStreamChoiceDelta, finishReason *openai.FinishReason) (string, error) {
+	return chunkLine(streamChunk{
+		ID:      t.id,
+		Created: t.created,
+		Model:   t.model,
+		Choices: []streamChunkChoice{{Index: 0, Delta: delta, FinishReason: finishReason}},
+	})
+}
@@ -0,0 +1,276 @@
+package chat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestGeminiAdapterBuildRequest(t *testing.T) {
+	adapter := NewGeminiAdapter()
+	request := openai.ChatCompletionRequest{
+		Model: "models/gemini-2.5-flash",
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "Be brief."},
+			{Role: openai.ChatMessageRoleUser, MultiContent: []openai.ChatMessagePart{
+				{Type: openai.ChatMessagePartTypeText, Text: "Describe"},
+				{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "data:image/jpeg;base64,/9j/"}},
+			}},
+			{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{{
+				ID: "call_1", Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: "weather", Arguments: `{"city":"Hanoi"}`},
+			}}},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "call_1", Content: "sunny"},
+		},
+		Tools:      []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "weather"}}},
+		ToolChoice: "required",
+	}
+
+	if got := adapter.CompletionPath(request, true); got != "/models/gemini-2.5-flash:streamGenerateContent?alt=sse" {
+		t.Fatalf("unexpected stream path %q", got)
+	}
+
+	body, err := adapter.BuildRequest(request, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := body.(geminiRequest)
+
+	if got.SystemInstruction == nil || got.SystemInstruction.Parts[0].Text != "Be brief." {
+		t.Fatalf("expected system instruction, got %+v", got.SystemInstruction)
+	}
+	if len(got.Contents) != 3 || got.Contents[1].Role != "model" {
+		t.Fatalf("unexpected contents: %+v", got.Contents)
+	}
+	if inline := got.Contents[0].Parts[1].InlineData; inline == nil || inline.MimeType != "image/jpeg" || inline.Data != "/9j/" {
+		t.Fatalf("expected inline image data, got %+v", got.Contents[0].Parts[1])
+	}
+	response := got.Contents[2].Parts[0].FunctionResponse
+	if response == nil || response.Name != "weather" || response.Response["content"] != "sunny" {
+		t.Fatalf("expected named function response, got %+v", got.Contents[2].Parts[0])
+	}
+	if got.ToolConfig == nil || got.ToolConfig.FunctionCallingConfig.Mode != "ANY" {
+		t.Fatalf("unexpected tool config: %+v", got.ToolConfig)
+	}
+}
+
+func TestGeminiStreamTranslator(t *testing.T) {
+	translator := NewGeminiAdapter().NewStreamTranslator("gemini-2.5-flash")
+	events := []string{
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"plan","thought":true},{"text":"Hel"}]}}],"responseId":"r1"}`,
+		``,
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"lo"},{"functionCall":{"name":"weather","args":{"city":"Hanoi"}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":3,"thoughtsTokenCount":2,"totalTokenCount":9}}`,
+	}
+
+	var lines []string
+	for _, event := range events {
+		translated, err := translator.Translate(event)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lines = append(lines, translated...)
+	}
+	final, err := translator.Finish()
+	if err != nil {
+		t.Fatalf("unexpected finish error: %v", err)
+	}
+	lines = append(lines, final...)
+
+	if lines[len(lines)-1] != dataPrefix+doneMarker {
+		t.Fatalf("expected [DONE] marker last, got %q", lines[len(lines)-1])
+	}
+
+	var content, reasoning, arguments string
+	var finish openai.FinishReason
+	var usage *openai.Usage
+	for _, line := range lines[:len(lines)-1] {
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, dataPrefix)), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", line, err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			reasoning += choice.Delta.ReasoningContent
+			for _, call := range choice.Delta.ToolCalls {
+				arguments += call.Function.Arguments
+			}
+			if choice.FinishReason != nil {
+				finish = *choice.FinishReason
+			}
+		}
+	}
+	if content != "Hello" || reasoning != "plan" || arguments != `{"city":"Hanoi"}` || finish != openai.FinishReasonToolCalls {
+		t.Fatalf("unexpected translation: content=%q reasoning=%q args=%q finish=%q", content, reasoning, arguments, finish)
+	}
+	if usage == nil || usage.PromptTokens != 4 || usage.CompletionTokens != 5 || usage.TotalTokens != 9 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestGeminiAdapterParseModels(t *testing.T) {
+	body := []byte(`{"models":[
+		{"name":"models/gemini-2.5-pro","displayName":"Gemini 2.5 Pro","inputTokenLimit":1048576,"outputTokenLimit":65536,"supportedGenerationMethods":["generateContent","countTokens"],"thinking":true},
+		{"name":"models/text-embedding-004","supportedGenerationMethods":["embedContent"]}
+	],"nextPageToken":"next"}`)
+
+	models, next, err := NewGeminiAdapter().ParseModels(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next != "next" || len(models) != 1 {
+		t.Fatalf("expected one chat model and a next page, got %d models, token %q", len(models), next)
+	}
+	model := models[0]
+	if model.ID != "gemini-2.5-pro" || model.DisplayName != "Gemini 2.5 Pro" || model.Raw["context_length"] != 1048576 {
+		t.Fatalf("unexpected model: %+v", model)
+	}
+}
+
+func TestGeminiAdapterReplaysThoughtSignatures(t *testing.T) {
+	adapter := NewGeminiAdapter()
+	resp, err := adapter.ParseResponse([]byte(`{"candidates":[{"content":{"role":"model","parts":[
+		{"functionCall":{"id":"call_sig","name":"weather","args":{"city":"Hanoi"}},"thoughtSignature":"c2ln"}
+	]},"finishReason":"STOP"}]}`), "gemini-3-pro-preview")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	toolCall := resp.Choices[0].Message.ToolCalls[0]
+
+	conversation := func(calls ...openai.ToolCall) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model: "gemini-3-pro-preview",
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleUser, Content: "Weather?"},
+				{Role: openai.ChatMessageRoleAssistant, ToolCalls: calls},
+			},
+		}
+	}
+
+	body, err := adapter.BuildRequest(conversation(toolCall), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := body.(geminiRequest).Contents[1].Parts[0].ThoughtSignature; got != "c2ln" {
+		t.Fatalf("expected replayed signature, got %q", got)
+	}
+
+	unknown := openai.ToolCall{ID: "call_unknown", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "weather", Arguments: "{}"}}
+	body, err = adapter.BuildRequest(conversation(unknown, unknown), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parts := body.(geminiRequest).Contents[1].Parts
+	if parts[0].ThoughtSignature != geminiSkipThoughtSignature || parts[1].ThoughtSignature != "" {
+		t.Fatalf("expected placeholder on the first call only, got %q and %q", parts[0].ThoughtSignature, parts[1].ThoughtSignature)
+	}
+}
+
+func TestGeminiStreamTranslatorStoresThoughtSignature(t *testing.T) {
+	translator := NewGeminiAdapter().NewStreamTranslator("gemini-2.5-flash")
+	_, err := translator.Translate(`data: {"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"call_stream_sig","name":"weather","args":{}},"thoughtSignature":"c3RyZWFt"}]},"finishReason":"STOP"}]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value, ok := reasoningStates.Load("call_stream_sig"); !ok || value != "c3RyZWFt" {
+		t.Fatalf("expected stored signature, got %v", value)
+	}
+}
+
+func TestGeminiAdapterMapsEveryCandidate(t *testing.T) {
+	adapter := NewGeminiAdapter()
+	resp, err := adapter.ParseResponse([]byte(`{"candidates":[
+		{"index":0,"content":{"role":"model","parts":[{"text":"first"}]},"finishReason":"STOP"},
+		{"index":1,"content":{"role":"model","parts":[{"text":"second"}]},"finishReason":"MAX_TOKENS"}
+	]}`), "gemini-2.5-flash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Choices) != 2 {
+		t.Fatalf("expected two choices, got %d", len(resp.Choices))
+	}
+	second := resp.Choices[1]
+	if second.Index != 1 || second.Message.Content != "second" || second.FinishReason != openai.FinishReasonLength {
+		t.Fatalf("unexpected second choice: %+v", second)
+	}
+
+	request := openai.ChatCompletionRequest{
+		Model:    "gemini-2.5-flash",
+		N:        2,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hi"}},
+	}
+	if _, err := adapter.BuildRequest(request, true); err == nil {
+		t.Fatal("expected streaming with n > 1 to be rejected")
+	}
+}
+
+func TestGeminiAdapterInlinesImageURLs(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(png)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		}
+	}))
+	defer server.Close()
+
+	adapter := NewGeminiAdapter()
+	adapter.imageClient = server.Client()
+	request := func(imageURL string) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model: "gemini-2.5-flash",
+			Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, MultiContent: []openai.ChatMessagePart{
+				{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: imageURL}},
+			}}},
+		}
+	}
+
+	body, err := adapter.BuildRequest(request(server.URL+"/cat.png"), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inline := body.(geminiRequest).Contents[0].Parts[0].InlineData
+	if inline == nil || inline.MimeType != "image/png" || inline.Data != base64.StdEncoding.EncodeToString(png) {
+		t.Fatalf("expected inline image, got %+v", body.(geminiRequest).Contents[0].Parts[0])
+	}
+
+	if _, err := adapter.BuildRequest(request(server.URL+"/page"), false); err == nil {
+		t.Fatal("expected non-image URL to be rejected")
+	}
+
+	body, err = adapter.BuildRequest(request("gs://bucket/cat.png"), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if file := body.(geminiRequest).Contents[0].Parts[0].FileData; file == nil || file.FileURI != "gs://bucket/cat.png" {
+		t.Fatalf("expected file data for storage URI, got %+v", body.(geminiRequest).Contents[0].Parts[0])
+	}
+}
+
+func TestGeminiAdapterRefusesPrivateImageURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("private address must not be fetched")
+	}))
+	defer server.Close()
+
+	request := openai.ChatCompletionRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, MultiContent: []openai.ChatMessagePart{
+			{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: server.URL + "/cat.png"}},
+		}}},
+	}
+	if _, err := NewGeminiAdapter().BuildRequest(request, false); err == nil {
+		t.Fatal("expected loopback image URL to be refused")
+	}
+}
@@ -0,0 +1,87 @@
+package chat
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	reasoningStateTTL        = time.Hour
+	reasoningStateMaxEntries = 10000
+)
+
+// reasoningStates keeps opaque provider reasoning state, such as signed Anthropic thinking
+// blocks, between the turns of a tool-use loop. It is keyed by tool call ID because clients
+// echo tool calls back verbatim, while the OpenAI message format has no field for the state
+// itself. The cache is local to each llm-api instance; adapters must cope with a miss.
+var reasoningStates = newReasoningStateCache(reasoningStateTTL, reasoningStateMaxEntries)
+
+type reasoningStateEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+type reasoningStateCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]reasoningStateEntry
+	order      []string // insertion order, oldest first, for eviction
+	now        func() time.Time
+}
+
+func newReasoningStateCache(ttl time.Duration, maxEntries int) *reasoningStateCache {
+	return &reasoningStateCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]reasoningStateEntry),
+		now:        time.Now,
+	}
+}
+
+// Store saves the state under each of the tool call IDs.
+func (c *reasoningStateCache) Store(value any, toolCallIDs ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	for _, id := range toolCallIDs {
+		if id == "" {
+			continue
+		}
+		if _, exists := c.entries[id]; !exists {
+			c.order = append(c.order, id)
+		}
+		c.entries[id] = reasoningStateEntry{value: value, expiresAt: expiresAt}
+	}
+	c.evictLocked()
+}
+
+// Load returns the state saved for the tool call ID, if it has not expired.
+func (c *reasoningStateCache) Load(toolCallID string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[toolCallID]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *reasoningStateCache) evictLocked() {
+	now := c.now()
+	drop := 0
+	for drop < len(c.order) {
+		id := c.order[drop]
+		entry, ok := c.entries[id]
+		if ok && now.Before(entry.expiresAt) && len(c.order)-drop <= c.maxEntries {
+			break
+		}
+		delete(c.entries, id)
+		drop++
+	}
+	if drop > 0 {
+		c.order = append([]string(nil), c.order[drop:]...)
+	}
+}
@@ -0,0 +1,27 @@
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReasoningStateCacheExpiresAndEvicts(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cache := newReasoningStateCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.Store("a", "call_1", "call_2")
+	if value, ok := cache.Load("call_2"); !ok || value != "a" {
+		t.Fatalf("expected state for call_2, got %v %v", value, ok)
+	}
+
+	cache.Store("b", "call_3")
+	if _, ok := cache.Load("call_1"); ok {
+		t.Fatal("expected the oldest entry to be evicted")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Load("call_3"); ok {
+		t.Fatal("expected the entry to expire")
+	}
+}
@@ -0,0 +1,66 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+)
+
+// UpstreamStatusCodeKey is the PlatformError context key holding the upstream HTTP status code.
+const UpstreamStatusCodeKey = "upstream_status_code"
+
+// UpstreamStatusCode returns the upstream HTTP status code carried by err, or 0 if none.
+func UpstreamStatusCode(err error) int {
+	for current := err; current != nil; current = errors.Unwrap(current) {
+		platformErr, ok := current.(*platformerrors.PlatformError)
+		if !ok || platformErr.Context == nil {
+			continue
+		}
+		if code, ok := platformErr.Context[UpstreamStatusCodeKey].(int); ok && code > 0 {
+			return code
+		}
+	}
+	return 0
+}
+
+// IsRetryableError reports whether a failed upstream call may succeed on another provider:
+// connection failures, upstream timeouts, 5xx responses and 429 rate limits.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if code := UpstreamStatusCode(err); code != 0 {
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsTimeoutError reports whether err is an upstream timeout.
+func IsTimeoutError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,465 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"jan-server/services/llm-api/internal/infrastructure/logger"
+	"jan-server/services/llm-api/internal/utils/platformerrors"
+	"jan-server/services/llm-api/internal/utils/tokenizer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BeforeDoneCallback is called before writing [DONE] marker
+type BeforeDoneCallback func(*gin.Context) error
+
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type ChoiceDelta struct {
+	Content          string               `json:"content"`
+	ReasoningContent string               `json:"reasoning_content"`
+	FunctionCall     *openai.FunctionCall `json:"function_call,omitempty"`
+	ToolCalls        []openai.ToolCall    `json:"tool_calls,omitempty"`
+}
+
+type StreamChoice struct {
+	Delta ChoiceDelta `json:"delta"`
+}
+
+type functionCallAccumulator struct {
+	Name      string
+	Arguments string
+	Complete  bool
+}
+
+type toolCallAccumulator struct {
+	ID       string
+	Type     string
+	Index    int
+	Function struct {
+		Name      string
+		Arguments string
+	}
+	Complete bool
+}
+
+// StreamToContext streams a completion from completer to the client as server-sent events and
+// returns the assembled response. SSE headers are deferred until the first chunk arrives so that
+// upstream errors can still be reported (or retried) by the caller.
+//
+// When the upstream omits usage, prompt and completion tokens are counted with tok (ForModel of
+// the request model when nil) and sent to the client in a final usage chunk; usageEstimated
+// reports whether those counts only approximate the model's vocabulary.
+func StreamToContext(reqCtx *gin.Context, completer ChatCompleter, apiKey string, request openai.ChatCompletionRequest, tok tokenizer.Tokenizer, beforeDone BeforeDoneCallback, opts ...StreamOption) (response *openai.ChatCompletionResponse, usageEstimated bool, err error) {
+	// Start OpenTelemetry span for tracking streaming completion
+	ctx := reqCtx.Request.Context()
+	ctx, span := otel.Tracer("chat-completion-client").Start(ctx, "StreamChatCompletion",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("llm.model", request.Model),
+			attribute.Int("llm.message_count", len(request.Messages)),
+			attribute.Bool("llm.stream", true),
+		),
+	)
+	defer span.End()
+
+	// Add optional parameters as attributes
+	if request.Temperature != 0 {
+		span.SetAttributes(attribute.Float64("llm.temperature", float64(request.Temperature)))
+	}
+	if request.MaxTokens != 0 {
+		span.SetAttributes(attribute.Int("llm.max_tokens", request.MaxTokens))
+	}
+	if request.TopP != 0 {
+		span.SetAttributes(attribute.Float64("llm.top_p", float64(request.TopP)))
+	}
+
+	start := time.Now()
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if tok == nil {
+		tok = tokenizer.ForModel(request.Model)
+	}
+
+	chunks, err := completer.CreateChatCompletionStream(streamCtx, apiKey, request, opts...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "streaming error")
+		return nil, false, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "streaming error")
+	}
+
+	accumulator := newStreamAccumulator()
+	headersSent := false
+
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			if reqCtx.Request.Context().Err() != nil {
+				break
+			}
+			span.RecordError(chunk.Err)
+			span.SetStatus(codes.Error, "streaming error")
+			return nil, false, platformerrors.AsError(ctx, platformerrors.LayerDomain, chunk.Err, "streaming error")
+		}
+
+		if !headersSent {
+			SetupSSEHeaders(reqCtx)
+			headersSent = true
+		}
+
+		if err := writeSSELine(reqCtx, dataPrefix+chunk.Data); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to write SSE line")
+			return nil, false, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "unable to write SSE line")
+		}
+
+		accumulator.add(chunk.Data)
+	}
+
+	if err := reqCtx.Request.Context().Err(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "client request cancelled")
+		return nil, false, platformerrors.AsError(reqCtx.Request.Context(), platformerrors.LayerDomain, err, "client request cancelled")
+	}
+
+	if !headersSent {
+		SetupSSEHeaders(reqCtx)
+	}
+
+	completion, usageEstimated := accumulator.response(request.Model, request, tok)
+
+	// Report counted usage the way upstream usage chunks would have
+	if accumulator.usage == nil {
+		if err := writeUsageChunk(reqCtx, completion, usageEstimated); err != nil {
+			log := logger.GetLogger()
+			log.Warn().Err(err).Msg("failed to write usage chunk")
+		}
+	}
+
+	// Call the beforeDone callback BEFORE sending [DONE]
+	if beforeDone != nil {
+		if err := beforeDone(reqCtx); err != nil {
+			log := logger.GetLogger()
+			log.Warn().Err(err).Msg("beforeDone callback failed")
+		}
+	}
+	if err := writeSSELine(reqCtx, dataPrefix+doneMarker); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to write SSE done marker")
+		return nil, false, platformerrors.AsError(ctx, platformerrors.LayerDomain, err, "unable to write SSE line")
+	}
+
+	duration := time.Since(start)
+
+	// Record streaming metrics in span
+	span.SetAttributes(
+		attribute.Int("llm.streaming.chunks_received", accumulator.chunks),
+		attribute.Int64("llm.duration_ms", duration.Milliseconds()),
+	)
+
+	// Add token usage, reported by upstream or counted with the tokenizer
+	span.SetAttributes(
+		attribute.Int("llm.usage.prompt_tokens", completion.Usage.PromptTokens),
+		attribute.Int("llm.usage.completion_tokens", completion.Usage.CompletionTokens),
+		attribute.Int("llm.usage.total_tokens", completion.Usage.TotalTokens),
+		attribute.Bool("llm.usage.reported", accumulator.usage != nil),
+		attribute.Bool("llm.usage.estimated", usageEstimated),
+		attribute.String("llm.usage.tokenizer", tok.Name()),
+	)
+
+	// Add finish reason if available
+	if len(completion.Choices) > 0 {
+		span.SetAttributes(attribute.String("llm.finish_reason", string(completion.Choices[0].FinishReason)))
+	}
+
+	span.SetStatus(codes.Ok, "streaming completion successful")
+	span.AddEvent("streaming_completed", trace.WithAttributes(
+		attribute.Int("chunks.total", accumulator.chunks),
+		attribute.Int("content.length", accumulator.content.Len()),
+	))
+
+	return &completion, usageEstimated, nil
+}
+
+func SetupSSEHeaders(reqCtx *gin.Context) {
+	if reqCtx == nil {
+		return
+	}
+
+	reqCtx.Header("Content-Type", "text/event-stream")
+	reqCtx.Header("Cache-Control", "no-cache")
+	reqCtx.Header("Connection", "keep-alive")
+	reqCtx.Header("Access-Control-Allow-Origin", "*")
+	reqCtx.Header("Access-Control-Allow-Headers", "Cache-Control")
+	reqCtx.Header("Transfer-Encoding", "chunked")
+	reqCtx.Writer.WriteHeaderNow()
+}
+
+// writeSSELine writes one SSE data line followed by the blank line that ends the event.
+func writeSSELine(reqCtx *gin.Context, line string) error {
+	if reqCtx == nil {
+		return platformerrors.NewError(context.Background(), platformerrors.LayerDomain, platformerrors.ErrorTypeValidation, "nil gin context provided", nil, "8ee6e88f-07e9-49e5-9c7a-6e1dfe151456")
+	}
+	_, err := reqCtx.Writer.Write([]byte(line + newlineChar + newlineChar))
+	if err != nil {
+		return err
+	}
+	reqCtx.Writer.Flush()
+	return nil
+}
+
+// streamAccumulator assembles streamed chunks into a complete chat completion response.
+type streamAccumulator struct {
+	content       strings.Builder
+	reasoning     strings.Builder
+	functionCalls map[int]*functionCallAccumulator
+	toolCalls     map[int]*toolCallAccumulator
+	usage         *TokenUsage
+	chunks        int
+}
+
+func newStreamAccumulator() *streamAccumulator {
+	return &streamAccumulator{
+		functionCalls: make(map[int]*functionCallAccumulator),
+		toolCalls:     make(map[int]*toolCallAccumulator),
+	}
+}
+
+func (a *streamAccumulator) add(data string) {
+	a.chunks++
+
+	choice, usage := processStreamChunk(data)
+
+	// Capture final usage if available
+	if usage != nil {
+		a.usage = usage
+	}
+	if choice == nil {
+		return
+	}
+
+	if choice.Delta.Content != "" {
+		a.content.WriteString(choice.Delta.Content)
+	}
+	if choice.Delta.ReasoningContent != "" {
+		a.reasoning.WriteString(choice.Delta.ReasoningContent)
+	}
+	if choice.Delta.FunctionCall != nil {
+		handleStreamingFunctionCall(choice.Delta.FunctionCall, a.functionCalls)
+	}
+	if len(choice.Delta.ToolCalls) > 0 {
+		handleStreamingToolCall(&choice.Delta.ToolCalls[0], a.toolCalls)
+	}
+}
+
+// response assembles the completion, using the upstream usage when it was streamed and counting
+// tokens with tok otherwise. It reports whether the usage is an estimate.
+func (a *streamAccumulator) response(model string, request openai.ChatCompletionRequest, tok tokenizer.Tokenizer) (openai.ChatCompletionResponse, bool) {
+	response := buildCompleteResponse(a.content.String(), a.reasoning.String(), a.functionCalls, a.toolCalls, model)
+	if a.usage != nil {
+		response.Usage = openai.Usage{
+			PromptTokens:     a.usage.PromptTokens,
+			CompletionTokens: a.usage.CompletionTokens,
+			TotalTokens:      a.usage.TotalTokens,
+		}
+		return response, false
+	}
+
+	usage, estimated := CountUsage(tok, request, response.Choices[0].Message)
+	response.Usage = usage
+	return response, estimated
+}
+
+// writeUsageChunk sends the counted usage as a final chat.completion.chunk with empty choices,
+// flagged with usage_estimated.
+func writeUsageChunk(reqCtx *gin.Context, response openai.ChatCompletionResponse, estimated bool) error {
+	chunk := map[string]any{
+		"id":              response.ID,
+		"object":          "chat.completion.chunk",
+		"created":         response.Created,
+		"model":           response.Model,
+		"choices":         []any{},
+		"usage":           response.Usage,
+		"usage_estimated": estimated,
+	}
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+	return writeSSELine(reqCtx, dataPrefix+string(data))
+}
+
+func processStreamChunk(data string) (*StreamChoice, *TokenUsage) {
+	var streamData struct {
+		Choices []StreamChoice `json:"choices"`
+		Usage   *TokenUsage    `json:"usage"`
+	}
+
+	if err := json.Unmarshal([]byte(data), &streamData); err != nil {
+		log := logger.GetLogger()
+		log.Error().Err(err).Str("data", data).Msg("failed to parse stream chunk JSON")
+		return nil, nil
+	}
+
+	result := &StreamChoice{
+		Delta: ChoiceDelta{},
+	}
+
+	for _, choice := range streamData.Choices {
+		if choice.Delta.Content != "" {
+			result.Delta.Content += choice.Delta.Content
+		}
+
+		if choice.Delta.ReasoningContent != "" {
+			result.Delta.ReasoningContent += choice.Delta.ReasoningContent
+		}
+
+		if choice.Delta.FunctionCall != nil {
+			result.Delta.FunctionCall = choice.Delta.FunctionCall
+		}
+
+		if len(choice.Delta.ToolCalls) > 0 {
+			// TODO: Handle multiple tool calls if needed
+			result.Delta.ToolCalls = choice.Delta.ToolCalls
+		}
+	}
+
+	return result, streamData.Usage
+}
+
+func handleStreamingFunctionCall(functionCall *openai.FunctionCall, accumulator map[int]*functionCallAccumulator) {
+	if functionCall == nil {
+		return
+	}
+
+	index := 0
+	if accumulator[index] == nil {
+		accumulator[index] = &functionCallAccumulator{}
+	}
+
+	if functionCall.Name != "" {
+		accumulator[index].Name = functionCall.Name
+	}
+	if functionCall.Arguments != "" {
+		accumulator[index].Arguments += functionCall.Arguments
+	}
+
+	if accumulator[index].Name != "" && accumulator[index].Arguments != "" && strings.HasSuffix(accumulator[index].Arguments, "}") {
+		accumulator[index].Complete = true
+	}
+}
+
+func handleStreamingToolCall(toolCall *openai.ToolCall, accumulator map[int]*toolCallAccumulator) {
+	if toolCall == nil || toolCall.Index == nil {
+		return
+	}
+
+	index := *toolCall.Index
+	if accumulator[index] == nil {
+		accumulator[index] = &toolCallAccumulator{
+			ID:    toolCall.ID,
+			Type:  string(toolCall.Type),
+			Index: index,
+		}
+	}
+
+	if toolCall.Function.Name != "" {
+		accumulator[index].Function.Name = toolCall.Function.Name
+	}
+	if toolCall.Function.Arguments != "" {
+		accumulator[index].Function.Arguments += toolCall.Function.Arguments
+	}
+
+	if accumulator[index].Function.Name != "" && accumulator[index].Function.Arguments != "" && strings.HasSuffix(accumulator[index].Function.Arguments, "}") {
+		accumulator[index].Complete = true
+	}
+}
+
+func buildCompleteResponse(content string, reasoning string, functionCallAccumulator map[int]*functionCallAccumulator, toolCallAccumulator map[int]*toolCallAccumulator, model string) openai.ChatCompletionResponse {
+	message := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: content,
+	}
+
+	if reasoning != "" {
+		message.ReasoningContent = reasoning
+	}
+
+	finishReason := openai.FinishReasonStop
+
+	if len(functionCallAccumulator) > 0 {
+		for _, acc := range functionCallAccumulator {
+			if acc != nil && acc.Complete {
+				message.FunctionCall = &openai.FunctionCall{
+					Name:      acc.Name,
+					Arguments: acc.Arguments,
+				}
+				finishReason = openai.FinishReasonFunctionCall
+				break
+			}
+		}
+	}
+
+	if len(toolCallAccumulator) > 0 {
+		var toolCalls []openai.ToolCall
+		for _, acc := range toolCallAccumulator {
+			if acc != nil && acc.Complete {
+				toolCalls = append(toolCalls, openai.ToolCall{
+					ID:   acc.ID,
+					Type: openai.ToolType(acc.Type),
+					Function: openai.FunctionCall{
+						Name:      acc.Function.Name,
+						Arguments: acc.Function.Arguments,
+					},
+				})
+			}
+		}
+
+		if len(toolCalls) > 0 {
+			message.ToolCalls = toolCalls
+			finishReason = openai.FinishReasonToolCalls
+		}
+	}
+
+	choices := []openai.ChatCompletionChoice{
+		{
+			Index:        0,
+			Message:      message,
+			FinishReason: finishReason,
+		},
+	}
+
+	return openai.ChatCompletionResponse{
+		ID:      "",
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: choices,
+	}
+}
+
+// CountUsage counts the usage of a completion with tok: the prompt messages and tool definitions
+// of request, and the generated message. It reports whether tok only approximates the model's
+// vocabulary.
+func CountUsage(tok tokenizer.Tokenizer, request openai.ChatCompletionRequest, message openai.ChatCompletionMessage) (openai.Usage, bool) {
+	promptTokens := tokenizer.CountMessages(tok, request.Messages) + tokenizer.CountTools(tok, request.Tools, request.Functions)
+	completionTokens := tokenizer.CountCompletion(tok, message)
+	return openai.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}, !tok.Exact()
+}
@@ -0,0 +1,112 @@
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	sentencepiece "github.com/eliben/go-sentencepiece"
+)
+
+// sentencePieceExt is the file extension of SentencePiece model protos in the model directory
+const sentencePieceExt = ".model"
+
+// Factory builds a tokenizer of one tokenizer family for a model ID. It returns false when it
+// cannot tokenize the model, in which case ForArchitecture falls back to ForModel.
+type Factory func(model string) (Tokenizer, bool)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"gpt": func(model string) (Tokenizer, bool) { return ForModel(model), true },
+	}
+
+	// modelDir holds SentencePiece models named after their tokenizer family, e.g. "llama2.model"
+	modelDir       string
+	sentencePieces = make(map[string]Tokenizer) // family -> loaded tokenizer, nil when unavailable
+)
+
+// Register sets the tokenizer factory for a tokenizer family as named by the model catalog
+// architecture, e.g. "GPT" or "Llama3". Family names are case-insensitive.
+func Register(family string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[normalizeFamily(family)] = factory
+}
+
+// SetModelDir sets the directory holding SentencePiece model files. A file named after a tokenizer
+// family ("mistral.model" for "Mistral") takes precedence over the registered factory.
+func SetModelDir(dir string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	modelDir = strings.TrimSpace(dir)
+	sentencePieces = make(map[string]Tokenizer)
+}
+
+// ForArchitecture returns the tokenizer for a model whose catalog architecture names the
+// tokenizer family. It prefers a SentencePiece model file for the family, then a registered
+// factory, and otherwise falls back to ForModel.
+func ForArchitecture(family, model string) Tokenizer {
+	key := normalizeFamily(family)
+	if key == "" {
+		return ForModel(model)
+	}
+	if tok := sentencePieceFor(key); tok != nil {
+		return tok
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[key]
+	registryMu.RUnlock()
+	if ok {
+		if tok, ok := factory(model); ok {
+			return tok
+		}
+	}
+	return ForModel(model)
+}
+
+func normalizeFamily(family string) string {
+	return strings.ToLower(strings.TrimSpace(family))
+}
+
+// sentencePieceFor loads and caches the SentencePiece model of a family, returning nil when the
+// model directory has none.
+func sentencePieceFor(family string) Tokenizer {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if modelDir == "" || filepath.Base(family) != family {
+		return nil
+	}
+	if tok, ok := sentencePieces[family]; ok {
+		return tok
+	}
+
+	var tok Tokenizer
+	path := filepath.Join(modelDir, family+sentencePieceExt)
+	if _, err := os.Stat(path); err == nil {
+		if processor, err := sentencepiece.NewProcessorFromPath(path); err == nil {
+			tok = &sentencePiece{name: "sentencepiece:" + family, processor: processor}
+		}
+	}
+	sentencePieces[family] = tok
+	return tok
+}
+
+type sentencePiece struct {
+	name      string
+	processor *sentencepiece.Processor
+}
+
+func (t *sentencePiece) Name() string { return t.name }
+
+func (t *sentencePiece) Exact() bool { return true }
+
+func (t *sentencePiece) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(t.processor.Encode(text))
+}
@@ -0,0 +1,201 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	tiktoken "github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	// DefaultEncoding is used for models without a known tiktoken encoding. Counts for
+	// non-OpenAI vocabularies are approximations, typically within a few percent.
+	DefaultEncoding = "o200k_base"
+
+	// Chat formatting overhead from the OpenAI token counting guide
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	tokensPerReply   = 3
+
+	// Fixed image costs: a low-detail image, and a typical high-detail image (4 tiles + base)
+	lowDetailImageTokens  = 85
+	highDetailImageTokens = 765
+)
+
+func init() {
+	// Use the embedded BPE ranks instead of downloading them at first use
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// Tokenizer counts tokens in text.
+type Tokenizer interface {
+	// Name identifies the encoding, e.g. "o200k_base"
+	Name() string
+	Count(text string) int
+	// Exact reports whether counts match the model's own vocabulary rather than approximating it
+	Exact() bool
+}
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = make(map[string]Tokenizer)
+)
+
+// ForModel returns the tokenizer for a model ID. Vendor prefixes such as "openai/" are ignored and
+// unknown models fall back to an approximation with DefaultEncoding.
+func ForModel(model string) Tokenizer {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	encoding, known := encodingName(name)
+	tok := forEncoding(encoding)
+	if !known {
+		return approximate{tok}
+	}
+	return tok
+}
+
+// encodingName resolves the tiktoken encoding name for a model. It returns DefaultEncoding and
+// false for models without a known encoding.
+func encodingName(model string) (string, bool) {
+	if name, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		return name, true
+	}
+	// Prefer the longest matching prefix, e.g. "gpt-4o-" over "gpt-4-"
+	matched, encoding := "", DefaultEncoding
+	for prefix, name := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			matched, encoding = prefix, name
+		}
+	}
+	return encoding, matched != ""
+}
+
+// forEncoding returns a cached tokenizer for the encoding, or a character-based estimate if the
+// encoding cannot be loaded.
+func forEncoding(name string) Tokenizer {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+
+	if tok, ok := encodings[name]; ok {
+		return tok
+	}
+
+	var tok Tokenizer = heuristic{}
+	if encoding, err := tiktoken.GetEncoding(name); err == nil {
+		tok = &bpe{name: name, encoding: encoding}
+	}
+	encodings[name] = tok
+	return tok
+}
+
+type bpe struct {
+	name     string
+	encoding *tiktoken.Tiktoken
+}
+
+func (t *bpe) Name() string { return t.name }
+
+func (t *bpe) Exact() bool { return true }
+
+func (t *bpe) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(t.encoding.EncodeOrdinary(text))
+}
+
+// heuristic approximates roughly four characters per token.
+type heuristic struct{}
+
+func (heuristic) Name() string { return "heuristic" }
+
+func (heuristic) Exact() bool { return false }
+
+func (heuristic) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
+}
+
+// approximate marks counts from a vocabulary other than the model's own as estimates.
+type approximate struct {
+	Tokenizer
+}
+
+func (approximate) Exact() bool { return false }
+
+// CountMessage returns the prompt tokens used by a single chat message, including its formatting
+// overhead.
+func CountMessage(tok Tokenizer, msg openai.ChatCompletionMessage) int {
+	tokens := tokensPerMessage + tok.Count(msg.Role) + tok.Count(msg.Content) + tok.Count(msg.ReasoningContent)
+	if msg.Name != "" {
+		tokens += tokensPerName + tok.Count(msg.Name)
+	}
+	for _, part := range msg.MultiContent {
+		switch part.Type {
+		case openai.ChatMessagePartTypeText:
+			tokens += tok.Count(part.Text)
+		case openai.ChatMessagePartTypeImageURL:
+			if part.ImageURL != nil && part.ImageURL.Detail == openai.ImageURLDetailLow {
+				tokens += lowDetailImageTokens
+			} else {
+				tokens += highDetailImageTokens
+			}
+		}
+	}
+	if msg.FunctionCall != nil {
+		tokens += tok.Count(msg.FunctionCall.Name) + tok.Count(msg.FunctionCall.Arguments)
+	}
+	for _, call := range msg.ToolCalls {
+		tokens += tok.Count(call.ID) + tok.Count(call.Function.Name) + tok.Count(call.Function.Arguments)
+	}
+	tokens += tok.Count(msg.ToolCallID)
+	return tokens
+}
+
+// CountCompletion returns the completion tokens of a generated assistant message: its text,
+// reasoning and function or tool call payloads, without chat formatting overhead.
+func CountCompletion(tok Tokenizer, msg openai.ChatCompletionMessage) int {
+	tokens := tok.Count(msg.Content) + tok.Count(msg.ReasoningContent)
+	if msg.FunctionCall != nil {
+		tokens += tok.Count(msg.FunctionCall.Name) + tok.Count(msg.FunctionCall.Arguments)
+	}
+	for _, call := range msg.ToolCalls {
+		tokens += tok.Count(call.Function.Name) + tok.Count(call.Function.Arguments)
+	}
+	return tokens
+}
+
+// CountMessages returns the prompt tokens used by messages, including the reply priming tokens.
+func CountMessages(tok Tokenizer, messages []openai.ChatCompletionMessage) int {
+	tokens := tokensPerReply
+	for _, msg := range messages {
+		tokens += CountMessage(tok, msg)
+	}
+	return tokens
+}
+
+// CountTools returns the prompt tokens used by tool and legacy function definitions.
+func CountTools(tok Tokenizer, tools []openai.Tool, functions []openai.FunctionDefinition) int {
+	if len(tools) == 0 && len(functions) == 0 {
+		return 0
+	}
+	tokens := 0
+	if len(tools) > 0 {
+		if data, err := json.Marshal(tools); err == nil {
+			tokens += tok.Count(string(data))
+		}
+	}
+	if len(functions) > 0 {
+		if data, err := json.Marshal(functions); err == nil {
+			tokens += tok.Count(string(data))
+		}
+	}
+	return tokens
+}
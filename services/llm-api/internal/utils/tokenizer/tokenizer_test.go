@@ -0,0 +1,56 @@
+package tokenizer
+
+import (
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestForModelSelectsEncoding(t *testing.T) {
+	cases := map[string]string{
+		"gpt-4o-mini":           "o200k_base",
+		"openai/gpt-4":          "cl100k_base",
+		"meta-llama/llama-3-8b": DefaultEncoding,
+	}
+	for model, want := range cases {
+		if got := ForModel(model).Name(); got != want {
+			t.Errorf("ForModel(%q) = %s, want %s", model, got, want)
+		}
+	}
+}
+
+func TestCountMessages(t *testing.T) {
+	tok := ForModel("gpt-4o")
+	if got := tok.Count("hello world"); got != 2 {
+		t.Fatalf("expected 2 tokens for %q, got %d", "hello world", got)
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "hello world"},
+		{Role: openai.ChatMessageRoleUser, MultiContent: []openai.ChatMessagePart{
+			{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "https://example.com/a.png", Detail: openai.ImageURLDetailLow}},
+		}},
+	}
+	// reply priming + (overhead + role + content) + (overhead + role + image)
+	want := tokensPerReply + (tokensPerMessage + 1 + 2) + (tokensPerMessage + 1 + lowDetailImageTokens)
+	if got := CountMessages(tok, messages); got != want {
+		t.Fatalf("expected %d tokens, got %d", want, got)
+	}
+}
+
+func TestForArchitectureFallsBackToApproximation(t *testing.T) {
+	if tok := ForArchitecture("GPT", "gpt-4o"); tok.Name() != "o200k_base" || !tok.Exact() {
+		t.Fatalf("expected exact o200k_base for GPT family, got %s (exact=%v)", tok.Name(), tok.Exact())
+	}
+	if tok := ForArchitecture("Mistral", "mistralai/mistral-7b-instruct"); tok.Exact() {
+		t.Fatalf("expected an approximation without a Mistral model file, got %s", tok.Name())
+	}
+
+	Register("Custom", func(model string) (Tokenizer, bool) { return heuristic{}, model == "custom-1" })
+	if tok := ForArchitecture("custom", "custom-1"); tok.Name() != "heuristic" {
+		t.Fatalf("expected registered factory to be used, got %s", tok.Name())
+	}
+	if tok := ForArchitecture("custom", "custom-2"); tok.Name() != DefaultEncoding || tok.Exact() {
+		t.Fatalf("expected fallback when the factory declines, got %s", tok.Name())
+	}
+}
@@ -0,0 +1,3 @@
+-- Remove instruction_version column from projects table
+ALTER TABLE llm_api.projects
+    DROP COLUMN IF EXISTS instruction_version;
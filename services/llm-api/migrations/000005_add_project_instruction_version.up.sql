@@ -0,0 +1,5 @@
+-- Track project instruction revisions so conversations can detect stale snapshots
+ALTER TABLE llm_api.projects
+    ADD COLUMN IF NOT EXISTS instruction_version INT NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN llm_api.projects.instruction_version IS 'Incremented whenever the project instruction changes';
@@ -0,0 +1,7 @@
+-- Remove model and provider tracking from conversation_items table
+DROP INDEX IF EXISTS llm_api.idx_conversation_items_rated_at;
+DROP INDEX IF EXISTS llm_api.idx_conversation_items_model;
+
+ALTER TABLE llm_api.conversation_items
+    DROP COLUMN IF EXISTS provider_id,
+    DROP COLUMN IF EXISTS model;
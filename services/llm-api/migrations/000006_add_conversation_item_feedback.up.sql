@@ -0,0 +1,13 @@
+-- Record which model and provider generated each item so feedback can be exported per model
+ALTER TABLE llm_api.conversation_items
+    ADD COLUMN IF NOT EXISTS model VARCHAR(128),
+    ADD COLUMN IF NOT EXISTS provider_id VARCHAR(64);
+
+-- Indexes for the feedback export filters
+CREATE INDEX IF NOT EXISTS idx_conversation_items_model
+    ON llm_api.conversation_items(model);
+CREATE INDEX IF NOT EXISTS idx_conversation_items_rated_at
+    ON llm_api.conversation_items(rated_at);
+
+COMMENT ON COLUMN llm_api.conversation_items.model IS 'Public ID of the model that generated the item';
+COMMENT ON COLUMN llm_api.conversation_items.provider_id IS 'Public ID of the provider that served the model';
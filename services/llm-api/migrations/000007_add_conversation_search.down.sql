@@ -0,0 +1,10 @@
+-- Remove full-text search support
+DROP INDEX IF EXISTS llm_api.idx_conversations_search_vector;
+DROP INDEX IF EXISTS llm_api.idx_conversation_items_search_vector;
+
+ALTER TABLE llm_api.conversations
+    DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE llm_api.conversation_items
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS text_content;
@@ -0,0 +1,37 @@
+-- Plain text of each item, written by the service, so items can be searched and highlighted
+ALTER TABLE llm_api.conversation_items
+    ADD COLUMN IF NOT EXISTS text_content TEXT;
+
+-- Backfill existing items from the text-bearing content parts
+UPDATE llm_api.conversation_items AS ci
+SET text_content = parts.text
+FROM (
+    SELECT i.id,
+           string_agg(
+               COALESCE(part->'text'->>'text', part->>'input_text', part->'output_text'->>'text',
+                        part->>'summary_text', part->>'refusal'),
+               E'\n' ORDER BY ord
+           ) AS text
+    FROM llm_api.conversation_items AS i,
+         jsonb_array_elements(CASE WHEN jsonb_typeof(i.content) = 'array' THEN i.content ELSE '[]'::jsonb END)
+             WITH ORDINALITY AS elements(part, ord)
+    GROUP BY i.id
+) AS parts
+WHERE ci.id = parts.id
+  AND ci.text_content IS NULL;
+
+-- Full-text search vectors; the 'simple' configuration does not stem, so it works for any language
+ALTER TABLE llm_api.conversation_items
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+        GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(text_content, ''))) STORED;
+
+ALTER TABLE llm_api.conversations
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+        GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(title, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_conversation_items_search_vector
+    ON llm_api.conversation_items USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_conversations_search_vector
+    ON llm_api.conversations USING GIN (search_vector);
+
+COMMENT ON COLUMN llm_api.conversation_items.text_content IS 'Plain text of the item content, used for search';
@@ -0,0 +1,110 @@
+package toolapproval
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MetaKey is the tools/call _meta field carrying the approval token of a call.
+const MetaKey = "approval_token"
+
+var (
+	// ErrMissing is returned when a call of a tool requiring approval carries no token.
+	ErrMissing = errors.New("tool call requires an approval token")
+	// ErrInvalid is returned for tokens that are malformed, expired or signed for another call.
+	ErrInvalid = errors.New("invalid approval token")
+)
+
+// claims is the signed part of a token: the approved call and when the approval expires.
+type claims struct {
+	Tool      string `json:"tool"`
+	Arguments string `json:"args"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Verifier checks the approval tokens response-api attaches to the tool calls a user approved.
+// Tokens are "<payload>.<signature>", both base64url encoded; the signature is the HMAC-SHA256
+// of the encoded payload under the secret shared with response-api.
+type Verifier struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewVerifier returns a verifier for tokens signed with secret, or nil for an empty secret.
+func NewVerifier(secret string) *Verifier {
+	if secret == "" {
+		return nil
+	}
+	return &Verifier{secret: []byte(secret), now: time.Now}
+}
+
+// Sign returns a token approving one call of tool with args until expiresAt.
+func (v *Verifier) Sign(tool string, args map[string]any, expiresAt time.Time) (string, error) {
+	digest, err := argumentsDigest(args)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims{Tool: tool, Arguments: digest, ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(v.mac(encoded)), nil
+}
+
+// Verify checks that token approves the call of tool with args and has not expired.
+func (v *Verifier) Verify(token, tool string, args map[string]any) error {
+	if token == "" {
+		return ErrMissing
+	}
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, v.mac(encoded)) {
+		return ErrInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalid
+	}
+	var approved claims
+	if err := json.Unmarshal(payload, &approved); err != nil {
+		return ErrInvalid
+	}
+	digest, err := argumentsDigest(args)
+	if err != nil {
+		return err
+	}
+	if approved.Tool != tool || approved.Arguments != digest || v.now().Unix() > approved.ExpiresAt {
+		return ErrInvalid
+	}
+	return nil
+}
+
+func (v *Verifier) mac(encoded string) []byte {
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// argumentsDigest hashes the JSON encoding of the call arguments; map keys encode sorted, so both
+// services arrive at the same digest for the same arguments.
+func argumentsDigest(args map[string]any) (string, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
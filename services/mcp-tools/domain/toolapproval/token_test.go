@@ -0,0 +1,64 @@
+package toolapproval
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// signedByResponseAPI is the token response-api signs with "shared-secret" for python_exec with
+// {"code":"print(1)"}, expiring at 1700000000; both services must agree on it.
+const signedByResponseAPI = "eyJ0b29sIjoicHl0aG9uX2V4ZWMiLCJhcmdzIjoiNTA0MmUyNDkzMTJlZmUyZjczNDkwOTU4ZmUwZmY3N2MyN2JjMDhhNjlhMmMxOTBiNjEyODc2YTcyMTc4NGIyNyIsImV4cCI6MTcwMDAwMDAwMH0.jy51XvYDYWdD6IyjYYlfro9E9zPpwkqYfLjg-BSpw5U"
+
+func verifierAt(secret string, now time.Time) *Verifier {
+	verifier := NewVerifier(secret)
+	verifier.now = func() time.Time { return now }
+	return verifier
+}
+
+func TestVerifyAcceptsTokenOfResponseAPI(t *testing.T) {
+	verifier := verifierAt("shared-secret", time.Unix(1700000000, 0))
+	if err := verifier.Verify(signedByResponseAPI, "python_exec", map[string]any{"code": "print(1)"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyRejectsOtherCalls(t *testing.T) {
+	args := map[string]any{"code": "print(1)"}
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		tool     string
+		args     map[string]any
+		want     error
+	}{
+		{"missing token", verifierAt("shared-secret", time.Unix(1700000000, 0)), "", "python_exec", args, ErrMissing},
+		{"malformed token", verifierAt("shared-secret", time.Unix(1700000000, 0)), "not-a-token", "python_exec", args, ErrInvalid},
+		{"other secret", verifierAt("other-secret", time.Unix(1700000000, 0)), signedByResponseAPI, "python_exec", args, ErrInvalid},
+		{"other tool", verifierAt("shared-secret", time.Unix(1700000000, 0)), signedByResponseAPI, "scrape", args, ErrInvalid},
+		{"other arguments", verifierAt("shared-secret", time.Unix(1700000000, 0)), signedByResponseAPI, "python_exec", map[string]any{"code": "rm -rf /"}, ErrInvalid},
+		{"expired", verifierAt("shared-secret", time.Unix(1700000001, 0)), signedByResponseAPI, "python_exec", args, ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.verifier.Verify(tt.token, tt.tool, tt.args); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSignRoundTrip(t *testing.T) {
+	verifier := NewVerifier("shared-secret")
+	token, err := verifier.Sign("python_exec", nil, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := verifier.Verify(token, "python_exec", map[string]any{}); err != nil {
+		t.Fatalf("expected missing and empty arguments to match, got %v", err)
+	}
+	if NewVerifier("") != nil {
+		t.Fatal("expected no verifier without a secret")
+	}
+}
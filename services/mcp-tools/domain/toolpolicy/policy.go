@@ -0,0 +1,54 @@
+package toolpolicy
+
+import "path"
+
+// Policy limits the tools each user may list and call. It has the layout of response-api's tool
+// policy, so both services can read the same file: a rule of the API key the user sent wins over a
+// rule of the user, which wins over the default rule.
+type Policy struct {
+	Default Rule            `json:"default"`
+	Users   map[string]Rule `json:"users,omitempty"`
+	APIKeys map[string]Rule `json:"api_keys,omitempty"`
+}
+
+// Rule allows and denies tools by name. Entries are tool names or path.Match patterns such as
+// "exa_*". Denied entries win; an empty allow list allows every tool that is not denied.
+type Rule struct {
+	AllowedTools []string `json:"allowed_tools,omitempty"`
+	DeniedTools  []string `json:"denied_tools,omitempty"`
+}
+
+// RuleFor returns the rule applying to the authenticated user and the API key they sent, if any;
+// callers without an authenticated user get the default rule. A nil policy allows every tool.
+func (p *Policy) RuleFor(userID, apiKeyID string) Rule {
+	if p == nil {
+		return Rule{}
+	}
+	if rule, ok := p.APIKeys[apiKeyID]; ok && apiKeyID != "" {
+		return rule
+	}
+	if rule, ok := p.Users[userID]; ok && userID != "" {
+		return rule
+	}
+	return p.Default
+}
+
+// Allows reports whether the rule permits the tool.
+func (r Rule) Allows(name string) bool {
+	if matchesAny(r.DeniedTools, name) {
+		return false
+	}
+	return len(r.AllowedTools) == 0 || matchesAny(r.AllowedTools, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return true
+		}
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package toolpolicy
+
+import "testing"
+
+func TestRuleAllows(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		tool string
+		want bool
+	}{
+		{name: "empty rule allows everything", rule: Rule{}, tool: "python_exec", want: true},
+		{name: "denied by name", rule: Rule{DeniedTools: []string{"python_exec"}}, tool: "python_exec", want: false},
+		{name: "denied by pattern", rule: Rule{DeniedTools: []string{"exa_*"}}, tool: "exa_search", want: false},
+		{name: "allowed by pattern", rule: Rule{AllowedTools: []string{"exa_*"}}, tool: "exa_search", want: true},
+		{name: "not in allow list", rule: Rule{AllowedTools: []string{"google_search"}}, tool: "scrape", want: false},
+		{name: "deny wins over allow", rule: Rule{AllowedTools: []string{"*"}, DeniedTools: []string{"python_exec"}}, tool: "python_exec", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Allows(tt.tool); got != tt.want {
+				t.Fatalf("Allows(%q) = %v, want %v", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleFor(t *testing.T) {
+	policy := &Policy{
+		Default: Rule{DeniedTools: []string{"python_exec"}},
+		Users:   map[string]Rule{"user-1": {}},
+		APIKeys: map[string]Rule{"key-1": {AllowedTools: []string{"google_search"}}},
+	}
+	if policy.RuleFor("user-1", "").Allows("python_exec") != true {
+		t.Fatal("expected the user rule to apply")
+	}
+	if policy.RuleFor("user-2", "").Allows("python_exec") {
+		t.Fatal("expected the default rule for users without a rule")
+	}
+	if policy.RuleFor("", "").Allows("python_exec") {
+		t.Fatal("expected the default rule for unauthenticated callers")
+	}
+	if rule := policy.RuleFor("user-1", "key-1"); rule.Allows("python_exec") || !rule.Allows("google_search") {
+		t.Fatal("expected the API key rule to take precedence over the user rule")
+	}
+	if !policy.RuleFor("user-1", "key-2").Allows("python_exec") {
+		t.Fatal("expected the user rule for API keys without a rule")
+	}
+	var none *Policy
+	if !none.RuleFor("user-1", "key-1").Allows("python_exec") {
+		t.Fatal("expected a nil policy to allow every tool")
+	}
+}
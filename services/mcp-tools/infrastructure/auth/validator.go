@@ -14,6 +14,33 @@ import (
 	"jan-server/services/mcp-tools/infrastructure/config"
 )
 
+type subjectKey struct{}
+
+type apiKeyIDKey struct{}
+
+// ContextWithSubject stores the subject of the validated caller token.
+func ContextWithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext returns the subject of the validated caller token, or "" when auth is
+// disabled or the token has none. Unvalidated tokens never set it.
+func SubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
+// ContextWithAPIKeyID stores the ID of the API key the validated caller sent next to its token.
+func ContextWithAPIKeyID(ctx context.Context, apiKeyID string) context.Context {
+	return context.WithValue(ctx, apiKeyIDKey{}, apiKeyID)
+}
+
+// APIKeyIDFromContext returns the ID of the API key the validated caller sent, or "" without one.
+func APIKeyIDFromContext(ctx context.Context) string {
+	apiKeyID, _ := ctx.Value(apiKeyIDKey{}).(string)
+	return apiKeyID
+}
+
 type Validator struct {
 	cfg  *config.Config
 	log  zerolog.Logger
@@ -68,6 +95,18 @@ func (v *Validator) Middleware() gin.HandlerFunc {
 		}
 
 		c.Set("auth_token", token)
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if sub, ok := claims["sub"].(string); ok {
+				ctx := ContextWithSubject(c.Request.Context(), sub)
+				// The gateway replaces X-API-Key-ID with the key it validated; keys of another user
+				// than the token's are ignored
+				if apiKeyID := strings.TrimSpace(c.GetHeader("X-API-Key-ID")); apiKeyID != "" && sub != "" &&
+					strings.TrimSpace(c.GetHeader("X-User-Subject")) == sub {
+					ctx = ContextWithAPIKeyID(ctx, apiKeyID)
+				}
+				c.Request = c.Request.WithContext(ctx)
+			}
+		}
 		c.Next()
 	}
 }
@@ -21,6 +21,9 @@ type Config struct {
 	VectorStoreURL               string   `env:"VECTOR_STORE_URL" envDefault:"http://vector-store-mcp:3015"`
 	SandboxFusionURL             string   `env:"SANDBOX_FUSION_URL" envDefault:"http://sandbox-fusion:8080"`
 	SandboxFusionRequireApproval bool     `env:"SANDBOX_FUSION_REQUIRE_APPROVAL" envDefault:"false"`
+	ToolApprovalSecret           string   `env:"TOOL_APPROVAL_SECRET"`
+	ToolPolicyFile               string   `env:"TOOL_POLICY_FILE"`
+	DefaultDeniedTools           []string `env:"DEFAULT_DENIED_TOOLS" envSeparator:"," envDefault:"python_exec"`
 	AuthEnabled                  bool     `env:"AUTH_ENABLED" envDefault:"false"`
 	AuthIssuer                   string   `env:"AUTH_ISSUER"`
 	AuthAudience                 string   `env:"AUTH_AUDIENCE"`
@@ -33,6 +36,9 @@ func LoadConfig() (*Config, error) {
 	if err := env.Parse(cfg); err != nil {
 		return nil, err
 	}
+	if cfg.SandboxFusionRequireApproval && strings.TrimSpace(cfg.ToolApprovalSecret) == "" {
+		return nil, fmt.Errorf("TOOL_APPROVAL_SECRET is required when SANDBOX_FUSION_REQUIRE_APPROVAL is true")
+	}
 	if cfg.AuthEnabled {
 		if strings.TrimSpace(cfg.AuthIssuer) == "" {
 			return nil, fmt.Errorf("AUTH_ISSUER is required when AUTH_ENABLED is true")
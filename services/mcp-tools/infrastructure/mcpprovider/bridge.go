@@ -44,6 +44,11 @@ type Bridge struct {
 	sessionID  string // MCP session ID for stateful connections
 }
 
+// RequiresApproval reports whether calls of the provider's tools need user approval
+func (b *Bridge) RequiresApproval() bool {
+	return b.provider.RequireApproval
+}
+
 // NewBridge creates a new MCP provider bridge
 func NewBridge(provider Provider) *Bridge {
 	timeout := provider.TimeoutDuration()
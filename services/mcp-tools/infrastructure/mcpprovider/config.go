@@ -24,14 +24,16 @@ type ProviderTool struct {
 
 // Provider represents an external MCP service provider
 type Provider struct {
-	Name        string         `yaml:"name"`
-	Description string         `yaml:"description"`
-	Enabled     bool           `yaml:"enabled"`
-	Endpoint    string         `yaml:"endpoint"`
-	Type        ProviderType   `yaml:"type"`
-	ProxyMode   bool           `yaml:"proxy_mode"`
-	Timeout     string         `yaml:"timeout"`
-	Tools       []ProviderTool `yaml:"tools,omitempty"`
+	Name        string       `yaml:"name"`
+	Description string       `yaml:"description"`
+	Enabled     bool         `yaml:"enabled"`
+	Endpoint    string       `yaml:"endpoint"`
+	Type        ProviderType `yaml:"type"`
+	ProxyMode   bool         `yaml:"proxy_mode"`
+	// RequireApproval marks every tool of the provider as needing user approval per call
+	RequireApproval bool           `yaml:"require_approval"`
+	Timeout         string         `yaml:"timeout"`
+	Tools           []ProviderTool `yaml:"tools,omitempty"`
 }
 
 // TimeoutDuration returns the timeout as a time.Duration
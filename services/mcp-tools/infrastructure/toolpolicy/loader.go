@@ -0,0 +1,46 @@
+package toolpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"jan-server/services/mcp-tools/domain/toolpolicy"
+)
+
+// policyFile is the JSON layout of TOOL_POLICY_FILE. Keys read only by response-api, such as
+// approval_required_tools, are ignored.
+type policyFile struct {
+	Default *toolpolicy.Rule           `json:"default"`
+	Users   map[string]toolpolicy.Rule `json:"users"`
+	APIKeys map[string]toolpolicy.Rule `json:"api_keys"`
+}
+
+// Load reads the tool policy from path. Rules of api_keys are keyed by the API key ID. Without a
+// file, or when the file has no default rule, users without a rule of their own may use every
+// tool except defaultDenied.
+func Load(path string, defaultDenied []string) (*toolpolicy.Policy, error) {
+	policy := &toolpolicy.Policy{
+		Default: toolpolicy.Rule{DeniedTools: defaultDenied},
+	}
+	if strings.TrimSpace(path) == "" {
+		return policy, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tool policy: %w", err)
+	}
+	var file policyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse tool policy %s: %w", path, err)
+	}
+
+	if file.Default != nil {
+		policy.Default = *file.Default
+	}
+	policy.Users = file.Users
+	policy.APIKeys = file.APIKeys
+	return policy, nil
+}
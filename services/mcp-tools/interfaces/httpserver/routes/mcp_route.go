@@ -9,6 +9,8 @@ import (
 	"github.com/gin-gonic/gin"
 	mcpserver "github.com/mark3labs/mcp-go/server"
 
+	"jan-server/services/mcp-tools/domain/toolapproval"
+	"jan-server/services/mcp-tools/domain/toolpolicy"
 	"jan-server/services/mcp-tools/interfaces/httpserver/responses"
 	"jan-server/services/mcp-tools/utils/platformerrors"
 )
@@ -46,11 +48,19 @@ func NewMCPRoute(
 	serperMCP *SerperMCP,
 	providerMCP *ProviderMCP,
 	sandboxMCP *SandboxFusionMCP,
+	policy *toolpolicy.Policy,
+	approvals *toolapproval.Verifier,
 ) *MCPRoute {
-	server := mcpserver.NewMCPServer("menlo-platform", "1.0.0",
+	var server *mcpserver.MCPServer
+	options := []mcpserver.ServerOption{
 		mcpserver.WithToolCapabilities(true),
 		mcpserver.WithRecovery(),
-	)
+	}
+	options = append(options, toolPolicyOptions(policy)...)
+	options = append(options, toolApprovalOptions(approvals, func(name string) *mcpserver.ServerTool {
+		return server.GetTool(name)
+	})...)
+	server = mcpserver.NewMCPServer("menlo-platform", "1.0.0", options...)
 
 	serperMCP.RegisterTools(server)
 
@@ -89,7 +99,7 @@ func (route *MCPRoute) RegisterRouter(router *gin.RouterGroup) {
 // @Description - `google_search`: Web search via pluggable engines (Serper/SearXNG/duckduckgo) with params: q, gl, hl, location, num, tbs, page, autocorrect, domain_allow_list, location_hint, offline_mode. Returns structured citations.
 // @Description - `scrape`: Web page scraping (params: url, includeMarkdown) returning text, preview, cache_status, and metadata.
 // @Description - `file_search_index` / `file_search_query`: Index arbitrary text and run similarity queries against the lightweight vector store.
-// @Description - `python_exec`: Execute trusted code through SandboxFusion (params: code, language, session_id, approved) to retrieve stdout/stderr/artifacts.
+// @Description - `python_exec`: Execute trusted code through SandboxFusion (params: code, language, session_id) to retrieve stdout/stderr/artifacts; marked `requires_approval` when SANDBOX_FUSION_REQUIRE_APPROVAL is set; calls of such tools need an approval token signed with TOOL_APPROVAL_SECRET in `_meta.approval_token`.
 // @Description
 // @Description **MCP Protocol:**
 // @Description - Request format: JSON-RPC 2.0 with method and params
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"jan-server/services/mcp-tools/infrastructure/mcpprovider"
+	"jan-server/services/mcp-tools/utils/mcp"
 
 	mcpgo "github.com/mark3labs/mcp-go/mcp"
 	mcpserver "github.com/mark3labs/mcp-go/server"
@@ -116,13 +117,19 @@ func (p *ProviderMCP) RegisterTools(server *mcpserver.MCPServer) error {
 			currentBridge := bridge
 			currentToolName := tool.Name
 
+			options := []mcpgo.ToolOption{
+				mcpgo.WithDescription(toolDesc),
+				mcp.WithServerLabel(providerName),
+				// TODO: Parse inputSchema and convert to mcp-go options
+				// For now, we'll accept any arguments and forward them
+			}
+			if bridge.RequiresApproval() {
+				options = append(options, mcp.WithApprovalRequired())
+			}
+
 			// Register the tool with the MCP server
 			server.AddTool(
-				mcpgo.NewTool(toolName,
-					mcpgo.WithDescription(toolDesc),
-					// TODO: Parse inputSchema and convert to mcp-go options
-					// For now, we'll accept any arguments and forward them
-				),
+				mcpgo.NewTool(toolName, options...),
 				func(ctx context.Context, req mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
 					// Extract all arguments from the request
 					arguments := make(map[string]interface{})
@@ -3,7 +3,6 @@ package routes
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"jan-server/services/mcp-tools/infrastructure/sandboxfusion"
 	"jan-server/services/mcp-tools/utils/mcp"
@@ -12,11 +11,13 @@ import (
 	mcpserver "github.com/mark3labs/mcp-go/server"
 )
 
+// sandboxFusionServerLabel groups the code execution tools.
+const sandboxFusionServerLabel = "sandboxfusion"
+
 type SandboxFusionArgs struct {
 	Code      string  `json:"code" jsonschema:"required,description=Python snippet to execute"`
 	Language  *string `json:"language,omitempty" jsonschema:"description=Execution language (default: python)"`
 	SessionID *string `json:"session_id,omitempty" jsonschema:"description=Existing SandboxFusion session to reuse"`
-	Approved  *bool   `json:"approved,omitempty" jsonschema:"description=Set true when approval is required to run code"`
 }
 
 type SandboxFusionMCP struct {
@@ -39,24 +40,18 @@ func (s *SandboxFusionMCP) RegisterTools(server *mcpserver.MCPServer) {
 		return
 	}
 
+	options := append(mcp.ReflectToMCPOptions(
+		"Execute trusted code inside SandboxFusion and return stdout/stderr/artifacts.",
+		SandboxFusionArgs{},
+	), mcp.WithServerLabel(sandboxFusionServerLabel))
+	if s.requireApproval {
+		// Clients such as response-api ask a user to approve each call before running it
+		options = append(options, mcp.WithApprovalRequired())
+	}
+
 	server.AddTool(
-		mcpgo.NewTool("python_exec",
-			mcp.ReflectToMCPOptions(
-				"Execute trusted code inside SandboxFusion and return stdout/stderr/artifacts.",
-				SandboxFusionArgs{},
-			)...,
-		),
+		mcpgo.NewTool("python_exec", options...),
 		func(ctx context.Context, req mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
-			if s.requireApproval {
-				if args := req.GetArguments(); args != nil {
-					if approvedRaw, ok := args["approved"]; !ok || approvedRaw == nil || !req.GetBool("approved", false) {
-						return nil, fmt.Errorf("sandboxfusion execution requires approval; set the `approved` argument to true")
-					}
-				} else {
-					return nil, fmt.Errorf("sandboxfusion execution requires approval; set the `approved` argument to true")
-				}
-			}
-
 			code, err := req.RequireString("code")
 			if err != nil {
 				return nil, err
@@ -78,6 +78,12 @@ type scrapeToolPayload struct {
 	FetchedAt   string         `json:"fetched_at"`
 }
 
+// Server labels of the search tools, see mcp.WithServerLabel.
+const (
+	searchServerLabel     = "search"
+	fileSearchServerLabel = "file_search"
+)
+
 // SerperMCP handles MCP tool registration for search tooling.
 type SerperMCP struct {
 	searchService *domainsearch.SearchService
@@ -97,10 +103,10 @@ func (s *SerperMCP) RegisterTools(server *mcpserver.MCPServer) {
 	// Register google_search tool
 	server.AddTool(
 		mcpgo.NewTool("google_search",
-			mcp.ReflectToMCPOptions(
+			append(mcp.ReflectToMCPOptions(
 				"Perform web searches via the configured engines (Serper, SearXNG, or cached fallback) and fetch structured citations.",
 				SerperSearchArgs{},
-			)...,
+			), mcp.WithServerLabel(searchServerLabel))...,
 		),
 		func(ctx context.Context, req mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
 			q, err := req.RequireString("q")
@@ -165,10 +171,10 @@ func (s *SerperMCP) RegisterTools(server *mcpserver.MCPServer) {
 	// Register scrape tool
 	server.AddTool(
 		mcpgo.NewTool("scrape",
-			mcp.ReflectToMCPOptions(
+			append(mcp.ReflectToMCPOptions(
 				"Scrape a webpage and retrieve the text with optional markdown formatting.",
 				SerperScrapeArgs{},
-			)...,
+			), mcp.WithServerLabel(searchServerLabel))...,
 		),
 		func(ctx context.Context, req mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
 			url, err := req.RequireString("url")
@@ -202,10 +208,10 @@ func (s *SerperMCP) RegisterTools(server *mcpserver.MCPServer) {
 	if s.vectorStore != nil {
 		server.AddTool(
 			mcpgo.NewTool("file_search_index",
-				mcp.ReflectToMCPOptions(
+				append(mcp.ReflectToMCPOptions(
 					"Index arbitrary text into the lightweight vector store used for MCP automations.",
 					FileSearchIndexArgs{},
-				)...,
+				), mcp.WithServerLabel(fileSearchServerLabel))...,
 			),
 			func(ctx context.Context, req mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
 				if s.vectorStore == nil {
@@ -251,10 +257,10 @@ func (s *SerperMCP) RegisterTools(server *mcpserver.MCPServer) {
 
 		server.AddTool(
 			mcpgo.NewTool("file_search_query",
-				mcp.ReflectToMCPOptions(
+				append(mcp.ReflectToMCPOptions(
 					"Run a semantic query against documents indexed via file_search_index.",
 					FileSearchQueryArgs{},
-				)...,
+				), mcp.WithServerLabel(fileSearchServerLabel))...,
 			),
 			func(ctx context.Context, req mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
 				if s.vectorStore == nil {
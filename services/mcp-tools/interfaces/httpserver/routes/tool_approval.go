@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+
+	"jan-server/services/mcp-tools/domain/toolapproval"
+	mcputils "jan-server/services/mcp-tools/utils/mcp"
+)
+
+// toolApprovalOptions refuse calls of tools marked requires_approval unless they carry a token
+// response-api signed after the user approved the call. Without a verifier such tools cannot be
+// called at all. lookup resolves the registered tool of a call.
+func toolApprovalOptions(verifier *toolapproval.Verifier, lookup func(name string) *mcpserver.ServerTool) []mcpserver.ServerOption {
+	return []mcpserver.ServerOption{
+		mcpserver.WithToolHandlerMiddleware(func(next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				registered := lookup(request.Params.Name)
+				if registered == nil || !requiresApproval(registered.Tool) {
+					return next(ctx, request)
+				}
+				if verifier == nil {
+					return mcp.NewToolResultError(fmt.Sprintf("tool %q requires approval, which is not configured", request.Params.Name)), nil
+				}
+				if err := verifier.Verify(approvalToken(request), request.Params.Name, request.GetArguments()); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("tool %q: %v", request.Params.Name, err)), nil
+				}
+				return next(ctx, request)
+			}
+		}),
+	}
+}
+
+func requiresApproval(tool mcp.Tool) bool {
+	if tool.Meta == nil {
+		return false
+	}
+	required, _ := tool.Meta.AdditionalFields[mcputils.ApprovalMetaKey].(bool)
+	return required
+}
+
+func approvalToken(request mcp.CallToolRequest) string {
+	if request.Params.Meta == nil {
+		return ""
+	}
+	token, _ := request.Params.Meta.AdditionalFields[toolapproval.MetaKey].(string)
+	return token
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+
+	"jan-server/services/mcp-tools/domain/toolapproval"
+	mcputils "jan-server/services/mcp-tools/utils/mcp"
+)
+
+func newApprovalServer(verifier *toolapproval.Verifier) *mcpserver.MCPServer {
+	var server *mcpserver.MCPServer
+	options := append([]mcpserver.ServerOption{mcpserver.WithToolCapabilities(true)}, toolApprovalOptions(verifier, func(name string) *mcpserver.ServerTool {
+		return server.GetTool(name)
+	})...)
+	server = mcpserver.NewMCPServer("test", "1.0.0", options...)
+	ran := func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ran"), nil
+	}
+	server.AddTool(mcp.NewTool("google_search"), ran)
+	server.AddTool(mcp.NewTool("python_exec", mcputils.WithApprovalRequired()), ran)
+	return server
+}
+
+func callWithToken(t *testing.T, server *mcpserver.MCPServer, name, token string) *mcp.CallToolResult {
+	t.Helper()
+	params := map[string]any{"name": name, "arguments": map[string]any{"code": "print(1)"}}
+	if token != "" {
+		params["_meta"] = map[string]any{toolapproval.MetaKey: token}
+	}
+	request, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": params})
+	response, ok := server.HandleMessage(context.Background(), request).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatal("expected a tools/call result")
+	}
+	result, ok := response.Result.(mcp.CallToolResult)
+	if !ok {
+		t.Fatalf("unexpected tools/call result %#v", response.Result)
+	}
+	return &result
+}
+
+func TestToolApprovalRequiresSignedToken(t *testing.T) {
+	verifier := toolapproval.NewVerifier("shared-secret")
+	server := newApprovalServer(verifier)
+
+	if result := callWithToken(t, server, "google_search", ""); result.IsError {
+		t.Fatal("expected tools without approval to run without a token")
+	}
+	if result := callWithToken(t, server, "python_exec", ""); !result.IsError {
+		t.Fatal("expected python_exec to be refused without a token")
+	}
+
+	forged, _ := toolapproval.NewVerifier("guessed").Sign("python_exec", map[string]any{"code": "print(1)"}, time.Now().Add(time.Minute))
+	if result := callWithToken(t, server, "python_exec", forged); !result.IsError {
+		t.Fatal("expected python_exec to be refused with a forged token")
+	}
+
+	token, _ := verifier.Sign("python_exec", map[string]any{"code": "print(1)"}, time.Now().Add(time.Minute))
+	if result := callWithToken(t, server, "python_exec", token); result.IsError {
+		t.Fatalf("expected the approved call to run, got %+v", result)
+	}
+}
+
+func TestToolApprovalRefusesWithoutVerifier(t *testing.T) {
+	server := newApprovalServer(nil)
+	token, _ := toolapproval.NewVerifier("shared-secret").Sign("python_exec", map[string]any{"code": "print(1)"}, time.Now().Add(time.Minute))
+	if result := callWithToken(t, server, "python_exec", token); !result.IsError {
+		t.Fatal("expected tools requiring approval to be refused without a configured secret")
+	}
+}
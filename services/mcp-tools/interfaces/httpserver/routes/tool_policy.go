@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+
+	"jan-server/services/mcp-tools/domain/toolpolicy"
+	"jan-server/services/mcp-tools/infrastructure/auth"
+)
+
+// toolPolicyOptions enforce the tool policy for the authenticated caller: tools/list leaves out
+// the tools the caller may not use and tools/call refuses them. Clients such as response-api
+// apply the same policy, but calls reaching mcp-tools directly are checked here.
+func toolPolicyOptions(policy *toolpolicy.Policy) []mcpserver.ServerOption {
+	return []mcpserver.ServerOption{
+		mcpserver.WithToolFilter(func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+			rule := callerRule(ctx, policy)
+			allowed := make([]mcp.Tool, 0, len(tools))
+			for _, tool := range tools {
+				if rule.Allows(tool.Name) {
+					allowed = append(allowed, tool)
+				}
+			}
+			return allowed
+		}),
+		mcpserver.WithToolHandlerMiddleware(func(next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				if !callerRule(ctx, policy).Allows(request.Params.Name) {
+					return mcp.NewToolResultError(fmt.Sprintf("tool %q is not allowed for this caller", request.Params.Name)), nil
+				}
+				return next(ctx, request)
+			}
+		}),
+	}
+}
+
+// callerRule returns the rule of the validated caller in ctx.
+func callerRule(ctx context.Context, policy *toolpolicy.Policy) toolpolicy.Rule {
+	return policy.RuleFor(auth.SubjectFromContext(ctx), auth.APIKeyIDFromContext(ctx))
+}
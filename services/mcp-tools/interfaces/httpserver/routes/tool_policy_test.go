@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+
+	"jan-server/services/mcp-tools/domain/toolpolicy"
+	"jan-server/services/mcp-tools/infrastructure/auth"
+)
+
+func newPolicyServer(policy *toolpolicy.Policy) *mcpserver.MCPServer {
+	options := append([]mcpserver.ServerOption{mcpserver.WithToolCapabilities(true)}, toolPolicyOptions(policy)...)
+	server := mcpserver.NewMCPServer("test", "1.0.0", options...)
+	for _, name := range []string{"google_search", "python_exec"} {
+		server.AddTool(mcp.NewTool(name), func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("ran"), nil
+		})
+	}
+	return server
+}
+
+func listTools(t *testing.T, server *mcpserver.MCPServer, ctx context.Context) []string {
+	t.Helper()
+	message := server.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	response, ok := message.(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a tools/list result, got %#v", message)
+	}
+	result, ok := response.Result.(mcp.ListToolsResult)
+	if !ok {
+		t.Fatalf("unexpected tools/list result %#v", response.Result)
+	}
+	names := make([]string, 0, len(result.Tools))
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func callTool(t *testing.T, server *mcpserver.MCPServer, ctx context.Context, name string) *mcp.CallToolResult {
+	t.Helper()
+	request := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"` + name + `","arguments":{}}}`
+	message := server.HandleMessage(ctx, json.RawMessage(request))
+	response, ok := message.(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a tools/call result, got %#v", message)
+	}
+	result, ok := response.Result.(mcp.CallToolResult)
+	if !ok {
+		t.Fatalf("unexpected tools/call result %#v", response.Result)
+	}
+	return &result
+}
+
+func TestToolPolicyAppliesToAuthenticatedCaller(t *testing.T) {
+	server := newPolicyServer(&toolpolicy.Policy{
+		Default: toolpolicy.Rule{DeniedTools: []string{"python_exec"}},
+		Users:   map[string]toolpolicy.Rule{"user-1": {AllowedTools: []string{"*"}}},
+	})
+	anonymous := context.Background()
+	granted := auth.ContextWithSubject(context.Background(), "user-1")
+
+	if names := listTools(t, server, anonymous); len(names) != 1 || names[0] != "google_search" {
+		t.Fatalf("expected only google_search for the default rule, got %v", names)
+	}
+	if result := callTool(t, server, anonymous, "python_exec"); !result.IsError {
+		t.Fatal("expected python_exec to be refused for the default rule")
+	}
+
+	if names := listTools(t, server, granted); len(names) != 2 {
+		t.Fatalf("expected both tools for the granted user, got %v", names)
+	}
+	if result := callTool(t, server, granted, "python_exec"); result.IsError {
+		t.Fatalf("expected python_exec to run for the granted user, got %+v", result)
+	}
+}
+
+func TestToolPolicyIgnoresUnauthenticatedUserClaims(t *testing.T) {
+	server := newPolicyServer(&toolpolicy.Policy{
+		Default: toolpolicy.Rule{DeniedTools: []string{"python_exec"}},
+		Users:   map[string]toolpolicy.Rule{"user-1": {}},
+	})
+
+	// Only the validator sets the subject; other context values naming a user do not count
+	type claimKey string
+	ctx := context.WithValue(context.Background(), claimKey("sub"), "user-1")
+	if result := callTool(t, server, ctx, "python_exec"); !result.IsError {
+		t.Fatal("expected python_exec to be refused without an authenticated subject")
+	}
+}
+
+func TestToolPolicyAppliesAPIKeyRule(t *testing.T) {
+	server := newPolicyServer(&toolpolicy.Policy{
+		Users:   map[string]toolpolicy.Rule{"user-1": {}},
+		APIKeys: map[string]toolpolicy.Rule{"key-1": {DeniedTools: []string{"python_exec"}}},
+	})
+	withKey := auth.ContextWithAPIKeyID(auth.ContextWithSubject(context.Background(), "user-1"), "key-1")
+
+	if names := listTools(t, server, withKey); len(names) != 1 || names[0] != "google_search" {
+		t.Fatalf("expected only google_search for the API key rule, got %v", names)
+	}
+	if result := callTool(t, server, withKey, "python_exec"); !result.IsError {
+		t.Fatal("expected python_exec to be refused for the API key")
+	}
+	if result := callTool(t, server, auth.ContextWithSubject(context.Background(), "user-1"), "python_exec"); result.IsError {
+		t.Fatalf("expected python_exec to run for the user without the API key, got %+v", result)
+	}
+}
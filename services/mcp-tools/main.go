@@ -9,12 +9,14 @@ import (
 	"github.com/rs/zerolog/log"
 
 	domainsearch "jan-server/services/mcp-tools/domain/search"
+	"jan-server/services/mcp-tools/domain/toolapproval"
 	"jan-server/services/mcp-tools/infrastructure/auth"
 	"jan-server/services/mcp-tools/infrastructure/config"
 	"jan-server/services/mcp-tools/infrastructure/logger"
 	"jan-server/services/mcp-tools/infrastructure/mcpprovider"
 	sandboxfusionclient "jan-server/services/mcp-tools/infrastructure/sandboxfusion"
 	searchclient "jan-server/services/mcp-tools/infrastructure/search"
+	"jan-server/services/mcp-tools/infrastructure/toolpolicy"
 	vectorstoreclient "jan-server/services/mcp-tools/infrastructure/vectorstore"
 	"jan-server/services/mcp-tools/interfaces/httpserver/middlewares"
 	"jan-server/services/mcp-tools/interfaces/httpserver/routes"
@@ -79,7 +81,12 @@ func main() {
 		log.Error().Err(err).Msg("Failed to initialize MCP providers")
 	}
 
-	mcpRoute := routes.NewMCPRoute(serperMCP, providerMCP, sandboxMCP)
+	toolPolicy, err := toolpolicy.Load(cfg.ToolPolicyFile, cfg.DefaultDeniedTools)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load tool policy")
+	}
+
+	mcpRoute := routes.NewMCPRoute(serperMCP, providerMCP, sandboxMCP, toolPolicy, toolapproval.NewVerifier(cfg.ToolApprovalSecret))
 
 	authValidator, err := auth.NewValidator(ctx, cfg, log.Logger)
 	if err != nil {
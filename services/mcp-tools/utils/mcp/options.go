@@ -88,3 +88,34 @@ func extractDescription(tag string) string {
 	}
 	return ""
 }
+
+// Tool _meta fields read by clients such as response-api.
+const (
+	// ServerLabelMetaKey names the server a tool belongs to, used to select tools by server label
+	ServerLabelMetaKey = "server_label"
+	// ApprovalMetaKey marks tools whose calls a user must approve before they run
+	ApprovalMetaKey = "requires_approval"
+)
+
+// WithServerLabel tags a tool with the label of the server providing it.
+func WithServerLabel(label string) mcpgo.ToolOption {
+	return withMeta(ServerLabelMetaKey, label)
+}
+
+// WithApprovalRequired marks a tool whose calls a user must approve. mcp-tools cannot ask a user
+// itself; response-api pauses such calls until the caller approves them.
+func WithApprovalRequired() mcpgo.ToolOption {
+	return withMeta(ApprovalMetaKey, true)
+}
+
+func withMeta(key string, value any) mcpgo.ToolOption {
+	return func(t *mcpgo.Tool) {
+		if t.Meta == nil {
+			t.Meta = &mcpgo.Meta{}
+		}
+		if t.Meta.AdditionalFields == nil {
+			t.Meta.AdditionalFields = make(map[string]any)
+		}
+		t.Meta.AdditionalFields[key] = value
+	}
+}
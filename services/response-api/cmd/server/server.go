@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
@@ -16,12 +17,14 @@ import (
 	"jan-server/services/response-api/internal/domain/tool"
 	"jan-server/services/response-api/internal/infrastructure/auth"
 	"jan-server/services/response-api/internal/infrastructure/database"
+	"jan-server/services/response-api/internal/infrastructure/eventstore"
+	"jan-server/services/response-api/internal/infrastructure/llmconversation"
 	"jan-server/services/response-api/internal/infrastructure/llmprovider"
 	"jan-server/services/response-api/internal/infrastructure/logger"
 	"jan-server/services/response-api/internal/infrastructure/mcp"
 	"jan-server/services/response-api/internal/infrastructure/observability"
-	conversationrepo "jan-server/services/response-api/internal/infrastructure/repository/conversation"
 	respRepo "jan-server/services/response-api/internal/infrastructure/repository/response"
+	"jan-server/services/response-api/internal/infrastructure/toolpolicy"
 	"jan-server/services/response-api/internal/interfaces/httpserver"
 )
 
@@ -35,21 +38,45 @@ import (
 // @in header
 // @name Authorization
 type Application struct {
+	cfg        *config.Config
 	httpServer *httpserver.HttpServer
+	workers    *response.WorkerPool
+	responses  *response.ServiceImpl
 	log        zerolog.Logger
 }
 
-func NewApplication(httpServer *httpserver.HttpServer, log zerolog.Logger) *Application {
+// interruptedGrace is added to the background timeout before a background response still pending
+// or in progress counts as interrupted
+const interruptedGrace = 5 * time.Minute
+
+func NewApplication(cfg *config.Config, httpServer *httpserver.HttpServer, workers *response.WorkerPool, responses *response.ServiceImpl, log zerolog.Logger) *Application {
 	return &Application{
+		cfg:        cfg,
 		httpServer: httpServer,
+		workers:    workers,
+		responses:  responses,
 		log:        log,
 	}
 }
 
 func (a *Application) Start(ctx context.Context) error {
+	// Background runs are bounded by their timeout, so older unfinished ones lost their worker
+	if a.cfg.BackgroundTimeout > 0 {
+		go a.responses.SweepInterrupted(ctx, a.cfg.BackgroundTimeout+interruptedGrace, a.cfg.BackgroundSweep)
+	} else {
+		a.log.Warn().Msg("BACKGROUND_RESPONSE_TIMEOUT is disabled, interrupted background responses are not swept")
+	}
+	if a.cfg.TokenExchangeURL == "" {
+		a.log.Warn().Msg("TOKEN_EXCHANGE_URL is not set, background responses fail once the caller's token expires")
+	}
 	return a.httpServer.Run(ctx)
 }
 
+// Shutdown waits for running background responses until ctx ends.
+func (a *Application) Shutdown(ctx context.Context) error {
+	return a.workers.Shutdown(ctx)
+}
+
 func main() {
 	loadEnvFiles()
 
@@ -96,29 +123,44 @@ func main() {
 	}
 
 	responseRepository := respRepo.NewPostgresRepository(db)
-	conversationRepository := conversationrepo.NewRepository(db)
-	conversationItemRepository := conversationrepo.NewItemRepository(db)
+	conversationClient := llmconversation.NewClient(cfg.LLMAPIURL)
 	llmClient := llmprovider.NewClient(cfg.LLMAPIURL)
-	mcpClient := mcp.NewClient(cfg.MCPToolsURL)
-	orchestrator := tool.NewOrchestrator(llmClient, mcpClient, cfg.MaxToolDepth, cfg.ToolTimeout)
+	mcpClient := mcp.NewClient(cfg.MCPToolsURL, cfg.ToolApprovalSecret)
+	orchestrator := tool.NewOrchestrator(llmClient, mcpClient, cfg.MaxToolDepth, cfg.ToolTimeout, cfg.ToolConcurrency)
+	workers := response.NewWorkerPool(cfg.BackgroundWorkers, cfg.BackgroundQueueSize, cfg.BackgroundTimeout, log)
+	events := eventstore.NewPostgresStore(db, cfg.EventRetention)
+	toolPolicy, err := toolpolicy.Load(cfg.ToolPolicyFile, cfg.DefaultDeniedTools, cfg.ApprovalTools)
+	if err != nil {
+		log.Fatal().Err(err).Msg("load tool policy")
+	}
 
 	responseService := response.NewService(
 		responseRepository,
-		conversationRepository,
-		conversationItemRepository,
+		conversationClient,
+		conversationClient,
+		responseRepository,
 		responseRepository,
 		orchestrator,
 		mcpClient,
+		toolPolicy,
+		workers,
+		auth.NewTokenDelegator(cfg),
 		log,
 	)
 
-	httpServer := httpserver.New(cfg, log, responseService, authValidator)
-	app := NewApplication(httpServer, log)
+	httpServer := httpserver.New(cfg, log, responseService, events, authValidator)
+	app := NewApplication(cfg, httpServer, workers, responseService, log)
 
 	if err := app.Start(ctx); err != nil {
 		log.Fatal().Err(err).Msg("application stopped with error")
 	}
 
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		log.Warn().Err(err).Msg("background responses still running at shutdown")
+	}
+
 	log.Info().Msg("application exited cleanly")
 }
 
@@ -21,11 +21,13 @@ import (
 	"jan-server/services/response-api/internal/domain/tool"
 	"jan-server/services/response-api/internal/infrastructure/auth"
 	"jan-server/services/response-api/internal/infrastructure/database"
+	"jan-server/services/response-api/internal/infrastructure/eventstore"
+	"jan-server/services/response-api/internal/infrastructure/llmconversation"
 	"jan-server/services/response-api/internal/infrastructure/llmprovider"
 	"jan-server/services/response-api/internal/infrastructure/logger"
 	"jan-server/services/response-api/internal/infrastructure/mcp"
-	conversationrepo "jan-server/services/response-api/internal/infrastructure/repository/conversation"
 	responseRepo "jan-server/services/response-api/internal/infrastructure/repository/response"
+	"jan-server/services/response-api/internal/infrastructure/toolpolicy"
 	"jan-server/services/response-api/internal/interfaces/httpserver"
 )
 
@@ -44,24 +46,30 @@ func BuildApplication(ctx context.Context) (*Application, error) {
 		return nil, err
 	}
 	postgresRepository := responseRepo.NewPostgresRepository(db)
-	repository := conversationrepo.NewRepository(db)
-	itemRepository := conversationrepo.NewItemRepository(db)
-	client := newLLMProvider(configConfig)
-	client2 := newMCPClient(configConfig)
-	orchestrator := newOrchestrator(configConfig, client, client2)
-	service := newResponseService(postgresRepository, repository, itemRepository, postgresRepository, orchestrator, client2, zerologLogger)
+	client := newConversationClient(configConfig)
+	llmproviderClient := newLLMProvider(configConfig)
+	mcpClient := newMCPClient(configConfig)
+	orchestrator := newOrchestrator(configConfig, llmproviderClient, mcpClient)
+	policy, err := newToolPolicy(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	workerPool := newWorkerPool(configConfig, zerologLogger)
+	tokenDelegator := auth.NewTokenDelegator(configConfig)
+	serviceImpl := newResponseService(postgresRepository, client, client, postgresRepository, postgresRepository, orchestrator, mcpClient, policy, workerPool, tokenDelegator, zerologLogger)
+	postgresStore := newEventStore(configConfig, db)
 	validator, err := newAuthValidator(ctx, configConfig, zerologLogger)
 	if err != nil {
 		return nil, err
 	}
-	httpServer := httpserver.New(configConfig, zerologLogger, service, validator)
-	application := NewApplication(httpServer, zerologLogger)
+	httpServer := httpserver.New(configConfig, zerologLogger, serviceImpl, postgresStore, validator)
+	application := NewApplication(configConfig, httpServer, workerPool, serviceImpl, zerologLogger)
 	return application, nil
 }
 
 // wire.go:
 
-var responseSet = wire.NewSet(responseRepo.NewPostgresRepository, wire.Bind(new(responseDomain.Repository), new(*responseRepo.PostgresRepository)), wire.Bind(new(responseDomain.ToolExecutionRepository), new(*responseRepo.PostgresRepository)), conversationrepo.NewRepository, wire.Bind(new(conversation.Repository), new(*conversationrepo.Repository)), conversationrepo.NewItemRepository, wire.Bind(new(conversation.ItemRepository), new(*conversationrepo.ItemRepository)), newLLMProvider, wire.Bind(new(llm.Provider), new(*llmprovider.Client)), newMCPClient, wire.Bind(new(tool.MCPClient), new(*mcp.Client)), newOrchestrator, newResponseService)
+var responseSet = wire.NewSet(responseRepo.NewPostgresRepository, wire.Bind(new(responseDomain.Repository), new(*responseRepo.PostgresRepository)), wire.Bind(new(responseDomain.ToolExecutionRepository), new(*responseRepo.PostgresRepository)), wire.Bind(new(responseDomain.LegacyHistory), new(*responseRepo.PostgresRepository)), newConversationClient, wire.Bind(new(conversation.Repository), new(*llmconversation.Client)), wire.Bind(new(conversation.ItemRepository), new(*llmconversation.Client)), newLLMProvider, wire.Bind(new(llm.Provider), new(*llmprovider.Client)), newMCPClient, wire.Bind(new(tool.MCPClient), new(*mcp.Client)), newOrchestrator, newWorkerPool, newEventStore, wire.Bind(new(responseDomain.EventStore), new(*eventstore.PostgresStore)), newToolPolicy, auth.NewTokenDelegator, newResponseService, wire.Bind(new(responseDomain.Service), new(*responseDomain.ServiceImpl)))
 
 func newDatabaseConfig(cfg *config.Config) database.Config {
 	return database.Config{
@@ -88,18 +96,46 @@ func newAuthValidator(ctx context.Context, cfg *config.Config, log zerolog.Logge
 	return auth.NewValidator(ctx, cfg, log)
 }
 
+func newConversationClient(cfg *config.Config) *llmconversation.Client {
+	return llmconversation.NewClient(cfg.LLMAPIURL)
+}
+
 func newLLMProvider(cfg *config.Config) *llmprovider.Client {
 	return llmprovider.NewClient(cfg.LLMAPIURL)
 }
 
 func newMCPClient(cfg *config.Config) *mcp.Client {
-	return mcp.NewClient(cfg.MCPToolsURL)
+	return mcp.NewClient(cfg.MCPToolsURL, cfg.ToolApprovalSecret)
 }
 
 func newOrchestrator(cfg *config.Config, provider llm.Provider, mcpClient tool.MCPClient) *tool.Orchestrator {
-	return tool.NewOrchestrator(provider, mcpClient, cfg.MaxToolDepth, cfg.ToolTimeout)
+	return tool.NewOrchestrator(provider, mcpClient, cfg.MaxToolDepth, cfg.ToolTimeout, cfg.ToolConcurrency)
+}
+
+func newWorkerPool(cfg *config.Config, log zerolog.Logger) *responseDomain.WorkerPool {
+	return responseDomain.NewWorkerPool(cfg.BackgroundWorkers, cfg.BackgroundQueueSize, cfg.BackgroundTimeout, log)
+}
+
+func newEventStore(cfg *config.Config, db *gorm.DB) *eventstore.PostgresStore {
+	return eventstore.NewPostgresStore(db, cfg.EventRetention)
+}
+
+func newToolPolicy(cfg *config.Config) (*tool.Policy, error) {
+	return toolpolicy.Load(cfg.ToolPolicyFile, cfg.DefaultDeniedTools, cfg.ApprovalTools)
 }
 
-func newResponseService(repo responseDomain.Repository, conversations conversation.Repository, conversationItems conversation.ItemRepository, toolRepo responseDomain.ToolExecutionRepository, orchestrator *tool.Orchestrator, mcpClient tool.MCPClient, log zerolog.Logger) responseDomain.Service {
-	return responseDomain.NewService(repo, conversations, conversationItems, toolRepo, orchestrator, mcpClient, log)
+func newResponseService(
+	repo responseDomain.Repository,
+	conversations conversation.Repository,
+	conversationItems conversation.ItemRepository,
+	legacy responseDomain.LegacyHistory,
+	toolRepo responseDomain.ToolExecutionRepository,
+	orchestrator *tool.Orchestrator,
+	mcpClient tool.MCPClient,
+	policy *tool.Policy,
+	workers *responseDomain.WorkerPool,
+	delegator llm.TokenDelegator,
+	log zerolog.Logger,
+) *responseDomain.ServiceImpl {
+	return responseDomain.NewService(repo, conversations, conversationItems, legacy, toolRepo, orchestrator, mcpClient, policy, workers, delegator, log)
 }
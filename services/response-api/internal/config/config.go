@@ -29,6 +29,25 @@ type Config struct {
 	MCPToolsURL     string        `env:"MCP_TOOLS_URL" envDefault:"http://localhost:8091"`
 	MaxToolDepth    int           `env:"MAX_TOOL_EXECUTION_DEPTH" envDefault:"8"`
 	ToolTimeout     time.Duration `env:"TOOL_EXECUTION_TIMEOUT" envDefault:"45s"`
+	ToolConcurrency int           `env:"TOOL_EXECUTION_CONCURRENCY" envDefault:"4"`
+
+	ToolPolicyFile     string   `env:"TOOL_POLICY_FILE"`
+	DefaultDeniedTools []string `env:"DEFAULT_DENIED_TOOLS" envSeparator:"," envDefault:"python_exec"`
+	ApprovalTools      []string `env:"APPROVAL_REQUIRED_TOOLS" envSeparator:","`
+	ToolApprovalSecret string   `env:"TOOL_APPROVAL_SECRET"` // signs approved tool calls for mcp-tools
+
+	BackgroundWorkers   int           `env:"BACKGROUND_WORKERS" envDefault:"4"`
+	BackgroundQueueSize int           `env:"BACKGROUND_QUEUE_SIZE" envDefault:"64"`
+	BackgroundTimeout   time.Duration `env:"BACKGROUND_RESPONSE_TIMEOUT" envDefault:"30m"`
+	BackgroundSweep     time.Duration `env:"BACKGROUND_SWEEP_INTERVAL" envDefault:"5m"` // how often interrupted background responses are failed
+
+	// OAuth token exchange used to keep background responses authenticated to llm-api after the
+	// caller's access token expires. Without a URL they use the caller's token.
+	TokenExchangeURL          string        `env:"TOKEN_EXCHANGE_URL"`
+	TokenExchangeClientID     string        `env:"TOKEN_EXCHANGE_CLIENT_ID"`
+	TokenExchangeClientSecret string        `env:"TOKEN_EXCHANGE_CLIENT_SECRET"`
+	EventRetention            time.Duration `env:"RESPONSE_EVENT_RETENTION" envDefault:"24h"`
+	StreamResumeGrace         time.Duration `env:"STREAM_RESUME_GRACE" envDefault:"30s"`
 }
 
 // Load parses environment variables into Config.
@@ -55,6 +74,30 @@ func Load() (*Config, error) {
 		cfg.ToolTimeout = 45 * time.Second
 	}
 
+	if cfg.ToolConcurrency <= 0 {
+		cfg.ToolConcurrency = 1
+	}
+
+	if cfg.BackgroundWorkers <= 0 {
+		cfg.BackgroundWorkers = 4
+	}
+
+	if cfg.BackgroundSweep <= 0 {
+		cfg.BackgroundSweep = 5 * time.Minute
+	}
+
+	if strings.TrimSpace(cfg.TokenExchangeURL) != "" && strings.TrimSpace(cfg.TokenExchangeClientID) == "" {
+		return nil, fmt.Errorf("TOKEN_EXCHANGE_CLIENT_ID is required when TOKEN_EXCHANGE_URL is set")
+	}
+
+	if cfg.EventRetention <= 0 {
+		cfg.EventRetention = 24 * time.Hour
+	}
+
+	if cfg.StreamResumeGrace < 0 {
+		cfg.StreamResumeGrace = 0
+	}
+
 	return cfg, nil
 }
 
@@ -1,40 +1,125 @@
 package conversation
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when a conversation does not exist or belongs to another user.
+var ErrNotFound = errors.New("conversation not found")
 
-// Conversation represents a logical chat thread for the Responses API.
+// Conversation is a chat thread stored by llm-api. Its ID is shared with /v1/chat/completions and
+// the llm-api Conversations API.
 type Conversation struct {
-	ID        uint                   `json:"-"`
-	PublicID  string                 `json:"id"`
-	UserID    string                 `json:"user_id"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
-	CreatedAt time.Time              `json:"created_at"`
-	UpdatedAt time.Time              `json:"updated_at"`
+	PublicID  string            `json:"id"`
+	Title     *string           `json:"title,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Referrer  *string           `json:"referrer,omitempty"`
+	CreatedAt time.Time         `json:"-"`
 }
 
-// ItemRole indicates who authored the conversation item.
+// ItemType is the kind of a conversation item.
+type ItemType string
+
+// ItemRole indicates who authored a message item.
 type ItemRole string
 
 // ItemStatus tracks whether the item is finalised.
 type ItemStatus string
 
 const (
+	ItemTypeMessage            ItemType = "message"
+	ItemTypeFunctionCall       ItemType = "function_call"
+	ItemTypeFunctionCallOutput ItemType = "function_call_output"
+
 	RoleSystem    ItemRole = "system"
 	RoleUser      ItemRole = "user"
 	RoleAssistant ItemRole = "assistant"
 	RoleTool      ItemRole = "tool"
 
 	ItemStatusCompleted ItemStatus = "completed"
-	ItemStatusPending   ItemStatus = "pending"
 )
 
-// Item contains individual conversation message state.
+// Item is a conversation item in the llm-api shape: a message, a function call or the output of a
+// function call.
 type Item struct {
-	ID             uint                   `json:"-"`
-	ConversationID uint                   `json:"conversation_id"`
-	Role           ItemRole               `json:"role"`
-	Status         ItemStatus             `json:"status"`
-	Content        map[string]interface{} `json:"content"`
-	Sequence       int                    `json:"sequence"`
-	CreatedAt      time.Time              `json:"created_at"`
+	ID      string     `json:"id,omitempty"`
+	Type    ItemType   `json:"type"`
+	Role    ItemRole   `json:"role,omitempty"`
+	Status  ItemStatus `json:"status,omitempty"`
+	Content []Content  `json:"content,omitempty"`
+}
+
+// Content is a part of an item. Fields are set depending on Type.
+type Content struct {
+	Type               string              `json:"type"`
+	Text               *Text               `json:"text,omitempty"`
+	InputText          *string             `json:"input_text,omitempty"`
+	OutputText         *Text               `json:"output_text,omitempty"`
+	SummaryText        *string             `json:"summary_text,omitempty"`
+	SummarizedThrough  *string             `json:"summarized_through,omitempty"`
+	Image              *Image              `json:"image,omitempty"`
+	FunctionCall       *FunctionCall       `json:"function_call,omitempty"`
+	FunctionCallOutput *FunctionCallOutput `json:"function_call_output,omitempty"`
+	ToolCalls          []ToolCall          `json:"tool_calls,omitempty"`
+	ToolCallID         *string             `json:"tool_call_id,omitempty"`
+}
+
+// Text is the text of a text or output_text part.
+type Text struct {
+	Text        string        `json:"text"`
+	Annotations []interface{} `json:"annotations,omitempty"`
+}
+
+// Image references the image of an image part.
+type Image struct {
+	URL    string `json:"url,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// FunctionCall is the call of a function_call item.
+type FunctionCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// FunctionCallOutput is the result of a function_call_output item.
+type FunctionCallOutput struct {
+	CallID string `json:"call_id"`
+	Output string `json:"output"`
+}
+
+// ToolCall is a tool call stored in an assistant message by chat completions.
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// PlainText returns the text of a text, input_text or output_text part, or "" for other parts.
+func (c Content) PlainText() string {
+	switch {
+	case c.Text != nil:
+		return c.Text.Text
+	case c.InputText != nil:
+		return *c.InputText
+	case c.OutputText != nil:
+		return c.OutputText.Text
+	}
+	return ""
+}
+
+// Summary returns the rolling summary held by a system item and the ID of the last item it
+// covers; ok is false for other items.
+func (i Item) Summary() (summary, through string, ok bool) {
+	if i.Type != ItemTypeMessage || i.Role != RoleSystem {
+		return "", "", false
+	}
+	for _, part := range i.Content {
+		if part.SummaryText != nil && part.SummarizedThrough != nil {
+			return *part.SummaryText, *part.SummarizedThrough, true
+		}
+	}
+	return "", "", false
 }
@@ -2,14 +2,16 @@ package conversation
 
 import "context"
 
-// Repository exposes CRUD operations for conversation metadata.
+// Repository creates and reads conversations. Implementations act for the user authenticated in
+// ctx, so conversations of other users are not found.
 type Repository interface {
+	// Create stores a new conversation and sets its PublicID.
 	Create(ctx context.Context, conversation *Conversation) error
 	FindByPublicID(ctx context.Context, publicID string) (*Conversation, error)
 }
 
-// ItemRepository persists individual conversation messages.
+// ItemRepository appends and lists the items of a conversation's active branch.
 type ItemRepository interface {
-	BulkInsert(ctx context.Context, items []Item) error
-	ListByConversationID(ctx context.Context, conversationID uint) ([]Item, error)
+	BulkInsert(ctx context.Context, conversationID string, items []Item) error
+	ListByConversationID(ctx context.Context, conversationID string) ([]Item, error)
 }
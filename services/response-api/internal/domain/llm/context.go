@@ -4,7 +4,27 @@ import "context"
 
 type contextKey string
 
-const authTokenKey contextKey = "llm-auth-token"
+const (
+	authTokenKey   contextKey = "llm-auth-token"
+	tokenSourceKey contextKey = "llm-token-source"
+	apiKeyKey      contextKey = "llm-api-key"
+)
+
+// APIKey identifies the API key a caller sent next to its token, as validated by the gateway.
+type APIKey struct {
+	ID      string
+	Subject string // the user the key belongs to
+}
+
+// TokenSource returns the Authorization header value for a downstream call, renewing the
+// credentials behind it when they are about to expire.
+type TokenSource func(ctx context.Context) (string, error)
+
+// TokenDelegator trades the caller's Authorization header value for a TokenSource that stays valid
+// for long-running background work.
+type TokenDelegator interface {
+	Delegate(ctx context.Context, authHeader string) (TokenSource, error)
+}
 
 // ContextWithAuthToken stores an Authorization header value in context for downstream LLM calls.
 func ContextWithAuthToken(ctx context.Context, authHeader string) context.Context {
@@ -14,13 +34,45 @@ func ContextWithAuthToken(ctx context.Context, authHeader string) context.Contex
 	return context.WithValue(ctx, authTokenKey, authHeader)
 }
 
-// AuthTokenFromContext extracts the Authorization header value if one was provided.
+// ContextWithTokenSource stores a token source in context. It takes precedence over the
+// Authorization header value stored with ContextWithAuthToken.
+func ContextWithTokenSource(ctx context.Context, source TokenSource) context.Context {
+	if ctx == nil || source == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tokenSourceKey, source)
+}
+
+// AuthTokenFromContext extracts the Authorization header value if one was provided. A token source
+// that fails falls back to the stored header value.
 func AuthTokenFromContext(ctx context.Context) string {
 	if ctx == nil {
 		return ""
 	}
+	if source, ok := ctx.Value(tokenSourceKey).(TokenSource); ok {
+		if token, err := source(ctx); err == nil && token != "" {
+			return token
+		}
+	}
 	if token, ok := ctx.Value(authTokenKey).(string); ok {
 		return token
 	}
 	return ""
 }
+
+// ContextWithAPIKey stores the caller's API key in context for downstream MCP calls.
+func ContextWithAPIKey(ctx context.Context, key APIKey) context.Context {
+	if ctx == nil || key.ID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, apiKeyKey, key)
+}
+
+// APIKeyFromContext returns the caller's API key if one was stored.
+func APIKeyFromContext(ctx context.Context) (APIKey, bool) {
+	if ctx == nil {
+		return APIKey{}, false
+	}
+	key, ok := ctx.Value(apiKeyKey).(APIKey)
+	return key, ok
+}
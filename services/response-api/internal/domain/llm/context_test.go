@@ -0,0 +1,24 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAuthTokenFromContextPrefersTokenSource(t *testing.T) {
+	ctx := ContextWithAuthToken(context.Background(), "Bearer caller")
+	if got := AuthTokenFromContext(ctx); got != "Bearer caller" {
+		t.Fatalf("expected the caller token, got %q", got)
+	}
+
+	delegated := ContextWithTokenSource(ctx, func(context.Context) (string, error) { return "Bearer delegated", nil })
+	if got := AuthTokenFromContext(delegated); got != "Bearer delegated" {
+		t.Fatalf("expected the delegated token, got %q", got)
+	}
+
+	failing := ContextWithTokenSource(ctx, func(context.Context) (string, error) { return "", errors.New("refresh failed") })
+	if got := AuthTokenFromContext(failing); got != "Bearer caller" {
+		t.Fatalf("expected a fallback to the caller token, got %q", got)
+	}
+}
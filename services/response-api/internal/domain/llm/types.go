@@ -25,7 +25,31 @@ type ChatCompletionRequest struct {
 	ToolChoice  *ToolChoice      `json:"tool_choice,omitempty"`
 	Temperature *float64         `json:"temperature,omitempty"`
 	MaxTokens   *int             `json:"max_tokens,omitempty"`
-	Stream      bool             `json:"stream"`
+	// ResponseFormat constrains the assistant message to JSON
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Stream         bool            `json:"stream"`
+}
+
+// Response format types.
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat mirrors the OpenAI response_format: plain text, any JSON object, or JSON
+// matching a schema.
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat is the schema a json_schema response format requires.
+type JSONSchemaFormat struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
+	Strict      bool                   `json:"strict,omitempty"`
 }
 
 // ChatMessage represents a single message in the conversation history.
@@ -34,6 +58,8 @@ type ChatMessage struct {
 	Content    interface{} `json:"content"`
 	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
 	ToolCallID *string     `json:"tool_call_id,omitempty"`
+	// ReasoningContent is the reasoning trace of a generated message; it is never sent upstream
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 // ToolCall mirrors the OpenAI tool call format.
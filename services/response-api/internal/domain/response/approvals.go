@@ -0,0 +1,96 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"jan-server/services/response-api/internal/domain/tool"
+)
+
+// approvalRequestID returns the ID of the mcp_approval_request item of a tool call.
+func approvalRequestID(callID string) string {
+	return "mcpr_" + callID
+}
+
+func newApprovalRequests(calls []tool.Call, labels map[string]string) []ApprovalRequest {
+	requests := make([]ApprovalRequest, 0, len(calls))
+	for _, call := range calls {
+		requests = append(requests, ApprovalRequest{
+			ID:          approvalRequestID(call.ID),
+			CallID:      call.ID,
+			ServerLabel: labels[call.Name],
+			Name:        call.Name,
+			Arguments:   callArguments(call),
+		})
+	}
+	return requests
+}
+
+// pendingApprovals returns the approval requests a requires_action response is waiting on.
+func pendingApprovals(resp *Response) []ApprovalRequest {
+	if resp == nil || resp.Status != StatusRequiresAction || resp.RequiredAction == nil {
+		return nil
+	}
+	return resp.RequiredAction.ApprovalRequests
+}
+
+// takeApprovalResponses removes the mcp_approval_response items from input and returns the
+// decisions they carry. Every pending request must be answered, by the ID of its approval request
+// item or by its call ID.
+func takeApprovalResponses(input interface{}, pending []ApprovalRequest) (interface{}, []tool.ApprovalDecision, error) {
+	items, ok := input.([]interface{})
+	if !ok {
+		if len(pending) > 0 {
+			return nil, nil, fmt.Errorf("%w: missing mcp_approval_response for %q", ErrInvalidInput, pending[0].ID)
+		}
+		return input, nil, nil
+	}
+
+	byID := make(map[string]ApprovalRequest, len(pending)*2)
+	for _, request := range pending {
+		byID[request.ID] = request
+		byID[request.CallID] = request
+	}
+
+	remaining := make([]interface{}, 0, len(items))
+	answered := make(map[string]tool.ApprovalDecision, len(pending))
+	for _, raw := range items {
+		payload, ok := raw.(map[string]interface{})
+		if !ok || payload["type"] != "mcp_approval_response" {
+			remaining = append(remaining, raw)
+			continue
+		}
+
+		id, _ := payload["approval_request_id"].(string)
+		request, ok := byID[strings.TrimSpace(id)]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: mcp_approval_response %q does not match a pending approval request", ErrInvalidInput, id)
+		}
+		approve, ok := payload["approve"].(bool)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: mcp_approval_response %q missing approve", ErrInvalidInput, id)
+		}
+		reason, _ := payload["reason"].(string)
+
+		var arguments map[string]interface{}
+		if err := json.Unmarshal([]byte(request.Arguments), &arguments); err != nil {
+			return nil, nil, fmt.Errorf("decode arguments of call %q: %w", request.CallID, err)
+		}
+		answered[request.ID] = tool.ApprovalDecision{
+			Call:     tool.Call{ID: request.CallID, Name: request.Name, Arguments: arguments},
+			Approved: approve,
+			Reason:   strings.TrimSpace(reason),
+		}
+	}
+
+	decisions := make([]tool.ApprovalDecision, 0, len(pending))
+	for _, request := range pending {
+		decision, ok := answered[request.ID]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: missing mcp_approval_response for %q", ErrInvalidInput, request.ID)
+		}
+		decisions = append(decisions, decision)
+	}
+	return remaining, decisions, nil
+}
@@ -0,0 +1,99 @@
+package response
+
+import (
+	"errors"
+	"testing"
+
+	"jan-server/services/response-api/internal/domain/tool"
+)
+
+func waitingResponse(calls ...tool.Call) *Response {
+	return &Response{
+		Status: StatusRequiresAction,
+		RequiredAction: &RequiredAction{
+			ApprovalRequests: newApprovalRequests(calls, map[string]string{"python_exec": "sandboxfusion"}),
+		},
+	}
+}
+
+func TestTakeApprovalResponses(t *testing.T) {
+	pending := pendingApprovals(waitingResponse(
+		tool.Call{ID: "call_1", Name: "python_exec", Arguments: map[string]interface{}{"code": "print(1)"}},
+		tool.Call{ID: "call_2", Name: "python_exec"},
+	))
+	if len(pending) != 2 || pending[0].ID != "mcpr_call_1" || pending[0].ServerLabel != "sandboxfusion" {
+		t.Fatalf("unexpected approval requests %+v", pending)
+	}
+
+	input := []interface{}{
+		map[string]interface{}{"type": "message", "role": "user", "content": "go on"},
+		// Requests can be answered by approval request ID or by call ID
+		map[string]interface{}{"type": "mcp_approval_response", "approval_request_id": "call_2", "approve": false, "reason": " too risky "},
+		map[string]interface{}{"type": "mcp_approval_response", "approval_request_id": "mcpr_call_1", "approve": true},
+	}
+	remaining, decisions, err := takeApprovalResponses(input, pending)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if items := remaining.([]interface{}); len(items) != 1 {
+		t.Fatalf("expected only the message to remain, got %v", items)
+	}
+	if len(decisions) != 2 || decisions[0].Call.ID != "call_1" || decisions[1].Call.ID != "call_2" {
+		t.Fatalf("expected decisions in the order of the requests, got %+v", decisions)
+	}
+	if !decisions[0].Approved || decisions[0].Call.Arguments["code"] != "print(1)" {
+		t.Fatalf("expected call_1 approved with its arguments, got %+v", decisions[0])
+	}
+	if decisions[1].Approved || decisions[1].Reason != "too risky" {
+		t.Fatalf("expected call_2 denied with its reason, got %+v", decisions[1])
+	}
+}
+
+func TestTakeApprovalResponsesRejectsIncompleteAnswers(t *testing.T) {
+	pending := pendingApprovals(waitingResponse(tool.Call{ID: "call_1", Name: "python_exec"}))
+	tests := []struct {
+		name  string
+		input interface{}
+	}{
+		{"text input", "continue"},
+		{"no answer", []interface{}{map[string]interface{}{"type": "message", "role": "user", "content": "hi"}}},
+		{"unknown request", []interface{}{map[string]interface{}{"type": "mcp_approval_response", "approval_request_id": "mcpr_other", "approve": true}}},
+		{"missing approve", []interface{}{map[string]interface{}{"type": "mcp_approval_response", "approval_request_id": "mcpr_call_1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := takeApprovalResponses(tt.input, pending); !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("expected ErrInvalidInput, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPendingApprovalsOnlyWhileRequiringAction(t *testing.T) {
+	resp := waitingResponse(tool.Call{ID: "call_1", Name: "python_exec"})
+	resp.Status = StatusCompleted
+	if pendingApprovals(resp) != nil || pendingApprovals(nil) != nil {
+		t.Fatal("expected no pending approvals outside requires_action")
+	}
+
+	// Without pending approvals the input passes through untouched
+	remaining, decisions, err := takeApprovalResponses("hello", nil)
+	if err != nil || remaining != "hello" || decisions != nil {
+		t.Fatalf("expected the input untouched, got %v %v %v", remaining, decisions, err)
+	}
+}
+
+func TestAddMCPCannotWaiveServerApproval(t *testing.T) {
+	policy := &tool.Policy{ApprovalRequired: []string{"exa_*"}}
+	set := newToolSet()
+	set.addMCP(tool.MCPTool{Name: "python_exec", Meta: map[string]interface{}{"requires_approval": true}}, policy, false)
+	set.addMCP(tool.MCPTool{Name: "exa_search"}, policy, false)
+	set.addMCP(tool.MCPTool{Name: "google_search"}, policy, true)
+	set.addMCP(tool.MCPTool{Name: "scrape"}, policy, false)
+
+	for name, want := range map[string]bool{"python_exec": true, "exa_search": true, "google_search": true, "scrape": false} {
+		if set.approval[name] != want {
+			t.Fatalf("%s: expected approval=%v", name, want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrEventStreamNotFound is returned when no events are kept for a response.
+var ErrEventStreamNotFound = errors.New("event stream not found")
+
+// Event is one streamed lifecycle event of a response, numbered from 1 for replay.
+type Event struct {
+	SequenceNumber int             `json:"sequence_number"`
+	Type           string          `json:"type"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// EventStore keeps the events of a response so clients can (re)attach to its stream.
+type EventStore interface {
+	// Append stores the next events of the response stream, numbered by the caller.
+	Append(ctx context.Context, responseID string, events ...Event) error
+	// Finish marks the stream complete; subscribers are closed after the last event.
+	Finish(ctx context.Context, responseID string) error
+	// Subscribe replays events after afterSequence and then follows live events until the stream
+	// finishes or ctx is done. Subscribers also stop once the response has been in a final state
+	// for a while without new events, in case the stream was never finished.
+	Subscribe(ctx context.Context, responseID string, afterSequence int) (<-chan Event, error)
+	// Attach records a client following the response stream until release is called.
+	Attach(ctx context.Context, responseID string) (release func(), err error)
+	// Attached reports whether a client follows the response stream on any instance.
+	Attached(ctx context.Context, responseID string) (bool, error)
+}
@@ -0,0 +1,292 @@
+package response
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"jan-server/services/response-api/internal/domain/conversation"
+	"jan-server/services/response-api/internal/domain/llm"
+)
+
+// maxItemTextLength is the longest text llm-api accepts in a conversation item; longer text is
+// truncated when stored.
+const maxItemTextLength = 100000
+
+// messagesToItems maps the chat messages of a turn to llm-api conversation items. Tool calls of
+// assistant messages become function_call items and tool messages function_call_output items,
+// the way the chat completions history stores them.
+func messagesToItems(messages []llm.ChatMessage) []conversation.Item {
+	items := make([]conversation.Item, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == string(conversation.RoleTool) {
+			if msg.ToolCallID == nil {
+				continue
+			}
+			items = append(items, completedItem(conversation.ItemTypeFunctionCallOutput, "", conversation.Content{
+				Type: string(conversation.ItemTypeFunctionCallOutput),
+				FunctionCallOutput: &conversation.FunctionCallOutput{
+					CallID: *msg.ToolCallID,
+					Output: truncateItemText(contentText(msg.Content)),
+				},
+			}))
+			continue
+		}
+
+		role := conversation.ItemRole(msg.Role)
+		if role == "" {
+			role = conversation.RoleUser
+		}
+		if content := messageContent(role, msg.Content); len(content) > 0 {
+			items = append(items, completedItem(conversation.ItemTypeMessage, role, content...))
+		}
+		for _, call := range msg.ToolCalls {
+			items = append(items, completedItem(conversation.ItemTypeFunctionCall, "", conversation.Content{
+				Type: string(conversation.ItemTypeFunctionCall),
+				FunctionCall: &conversation.FunctionCall{
+					ID:        call.ID,
+					Name:      call.Function.Name,
+					Arguments: truncateItemText(rawArguments(call.Function.Arguments)),
+				},
+			}))
+		}
+	}
+	return items
+}
+
+func completedItem(itemType conversation.ItemType, role conversation.ItemRole, content ...conversation.Content) conversation.Item {
+	return conversation.Item{
+		Type:    itemType,
+		Role:    role,
+		Status:  conversation.ItemStatusCompleted,
+		Content: content,
+	}
+}
+
+// messageContent maps chat message content to item content parts: output_text for assistant
+// messages, input_text otherwise, and image parts for image inputs. Empty text is dropped.
+func messageContent(role conversation.ItemRole, content interface{}) []conversation.Content {
+	textPart := func(text string) []conversation.Content {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		text = truncateItemText(text)
+		if role == conversation.RoleAssistant {
+			return []conversation.Content{{
+				Type:       "output_text",
+				OutputText: &conversation.Text{Text: text, Annotations: []interface{}{}},
+			}}
+		}
+		return []conversation.Content{{Type: "input_text", InputText: &text}}
+	}
+
+	parts, ok := content.([]interface{})
+	if !ok {
+		if text, isText := content.(string); isText || content == nil {
+			return textPart(text)
+		}
+		return textPart(contentText(content))
+	}
+
+	var result []conversation.Content
+	for _, raw := range parts {
+		if url, detail, ok := imagePart(raw); ok {
+			result = append(result, conversation.Content{
+				Type:  "image",
+				Image: &conversation.Image{URL: url, Detail: detail},
+			})
+			continue
+		}
+		result = append(result, textPart(contentText(raw))...)
+	}
+	return result
+}
+
+// imagePart returns the URL of an input_image (Responses) or image_url (chat completions) part.
+func imagePart(raw interface{}) (url, detail string, ok bool) {
+	part, isMap := raw.(map[string]interface{})
+	if !isMap {
+		return "", "", false
+	}
+	detail, _ = part["detail"].(string)
+	switch part["type"] {
+	case "input_image":
+		url, _ = part["image_url"].(string)
+	case "image_url":
+		switch image := part["image_url"].(type) {
+		case string:
+			url = image
+		case map[string]interface{}:
+			url, _ = image["url"].(string)
+			if value, ok := image["detail"].(string); ok {
+				detail = value
+			}
+		}
+	}
+	return url, detail, url != ""
+}
+
+// rawArguments returns tool call arguments as text; providers send them as a JSON string.
+func rawArguments(arguments json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(arguments, &text); err == nil {
+		return text
+	}
+	return string(arguments)
+}
+
+func truncateItemText(text string) string {
+	if utf8.RuneCountInString(text) <= maxItemTextLength {
+		return text
+	}
+	return string([]rune(text)[:maxItemTextLength])
+}
+
+// itemsToMessages rebuilds the chat history of conversation items. Items that are not completed
+// are skipped, function_call items continue the assistant message that requested them, and the
+// latest rolling summary replaces the items it covers.
+func itemsToMessages(items []conversation.Item) []llm.ChatMessage {
+	summary, recent := splitAtLatestSummary(items)
+
+	messages := make([]llm.ChatMessage, 0, len(recent)+1)
+	if summary != "" {
+		messages = append(messages, llm.ChatMessage{
+			Role:    string(conversation.RoleSystem),
+			Content: "Summary of the earlier conversation:\n" + summary,
+		})
+	}
+	for _, item := range recent {
+		msg, ok := itemToMessage(item)
+		if !ok {
+			continue
+		}
+		if item.Type == conversation.ItemTypeFunctionCall && len(messages) > 0 {
+			last := &messages[len(messages)-1]
+			if last.Role == string(conversation.RoleAssistant) {
+				last.ToolCalls = append(last.ToolCalls, msg.ToolCalls...)
+				continue
+			}
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// splitAtLatestSummary returns the text of the latest summary item and the items it does not
+// cover, without summary items.
+func splitAtLatestSummary(items []conversation.Item) (string, []conversation.Item) {
+	var summary string
+	start := 0
+	for idx := len(items) - 1; idx >= 0; idx-- {
+		text, through, ok := items[idx].Summary()
+		if !ok {
+			continue
+		}
+		summary = text
+		start = idx
+		for j := idx - 1; j >= 0; j-- {
+			if items[j].ID == through {
+				start = j + 1
+				break
+			}
+		}
+		break
+	}
+
+	recent := make([]conversation.Item, 0, len(items)-start)
+	for _, item := range items[start:] {
+		if _, _, ok := item.Summary(); !ok {
+			recent = append(recent, item)
+		}
+	}
+	return summary, recent
+}
+
+// itemToMessage rebuilds a chat message from a stored item, including tool call linkage.
+func itemToMessage(item conversation.Item) (llm.ChatMessage, bool) {
+	if item.Status != "" && item.Status != conversation.ItemStatusCompleted {
+		return llm.ChatMessage{}, false
+	}
+
+	switch item.Type {
+	case conversation.ItemTypeFunctionCall:
+		for _, part := range item.Content {
+			if call := part.FunctionCall; call != nil {
+				return llm.ChatMessage{
+					Role:      string(conversation.RoleAssistant),
+					ToolCalls: []llm.ToolCall{newToolCall(call.ID, "", *call)},
+				}, true
+			}
+		}
+		return llm.ChatMessage{}, false
+	case conversation.ItemTypeFunctionCallOutput:
+		for _, part := range item.Content {
+			if output := part.FunctionCallOutput; output != nil {
+				callID := output.CallID
+				return llm.ChatMessage{
+					Role:       string(conversation.RoleTool),
+					Content:    output.Output,
+					ToolCallID: &callID,
+				}, true
+			}
+		}
+		return llm.ChatMessage{}, false
+	}
+
+	role := item.Role
+	if role == "" {
+		role = conversation.RoleUser
+	}
+	msg := llm.ChatMessage{Role: string(role)}
+
+	var texts []string
+	var parts []interface{}
+	hasImage := false
+	for _, part := range item.Content {
+		if text := part.PlainText(); text != "" {
+			texts = append(texts, text)
+			parts = append(parts, map[string]interface{}{"type": "text", "text": text})
+		}
+		if part.Image != nil && part.Image.URL != "" {
+			hasImage = true
+			imageURL := map[string]interface{}{"url": part.Image.URL}
+			if part.Image.Detail != "" {
+				imageURL["detail"] = part.Image.Detail
+			}
+			parts = append(parts, map[string]interface{}{"type": "image_url", "image_url": imageURL})
+		}
+		// Tool calls and tool results stored by chat completions
+		for _, call := range part.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, newToolCall(call.ID, call.Type, call.Function))
+		}
+		if part.ToolCallID != nil && msg.ToolCallID == nil {
+			callID := *part.ToolCallID
+			msg.ToolCallID = &callID
+		}
+	}
+
+	if hasImage {
+		msg.Content = parts
+	} else {
+		msg.Content = strings.Join(texts, "\n")
+	}
+	if msg.Content == "" && len(msg.ToolCalls) == 0 && msg.ToolCallID == nil {
+		return llm.ChatMessage{}, false
+	}
+	return msg, true
+}
+
+func newToolCall(id, callType string, function conversation.FunctionCall) llm.ToolCall {
+	if callType == "" {
+		callType = "function"
+	}
+	arguments, _ := json.Marshal(function.Arguments)
+	return llm.ToolCall{
+		ID:   id,
+		Type: callType,
+		Function: llm.ToolFunction{
+			Name:      function.Name,
+			Arguments: arguments,
+		},
+	}
+}
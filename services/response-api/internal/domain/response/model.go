@@ -4,19 +4,20 @@ import (
 	"context"
 	"time"
 
+	"jan-server/services/response-api/internal/domain/conversation"
 	"jan-server/services/response-api/internal/domain/llm"
-	"jan-server/services/response-api/internal/domain/tool"
 )
 
 // Status represents the lifecycle of a response.
 type Status string
 
 const (
-	StatusPending    Status = "pending"
-	StatusInProgress Status = "in_progress"
-	StatusCompleted  Status = "completed"
-	StatusFailed     Status = "failed"
-	StatusCancelled  Status = "cancelled"
+	StatusPending        Status = "pending"
+	StatusInProgress     Status = "in_progress"
+	StatusRequiresAction Status = "requires_action" // waiting for client tool outputs or approvals
+	StatusCompleted      Status = "completed"
+	StatusFailed         Status = "failed"
+	StatusCancelled      Status = "cancelled"
 )
 
 // Response is the main aggregate persisted to the database.
@@ -28,14 +29,16 @@ type Response struct {
 	Model                string                 `json:"model"`
 	SystemPrompt         *string                `json:"system_prompt,omitempty"`
 	Input                interface{}            `json:"input"`
-	Output               interface{}            `json:"output,omitempty"`
+	Output               []OutputItem           `json:"output,omitempty"`
 	Status               Status                 `json:"status"`
 	Stream               bool                   `json:"stream"`
+	Background           bool                   `json:"background"`
 	Metadata             map[string]interface{} `json:"metadata,omitempty"`
 	Usage                *llm.Usage             `json:"usage,omitempty"`
 	Error                *ErrorDetails          `json:"error,omitempty"`
-	ConversationID       *uint                  `json:"-"`
+	RequiredAction       *RequiredAction        `json:"required_action,omitempty"`
 	ConversationPublicID *string                `json:"conversation_id,omitempty"`
+	LegacyConversationID *uint                  `json:"-"` // conversation stored by response-api before llm-api held them
 	PreviousResponseID   *string                `json:"previous_response_id,omitempty"`
 	CreatedAt            time.Time              `json:"created"`
 	UpdatedAt            time.Time              `json:"updated_at"`
@@ -50,17 +53,70 @@ type ErrorDetails struct {
 	Message string `json:"message"`
 }
 
+// Required action types.
+const (
+	// RequiredActionSubmitToolOutputs asks the client to run function calls and submit their outputs
+	RequiredActionSubmitToolOutputs = "submit_tool_outputs"
+	// RequiredActionMCPApproval asks the client to approve or deny MCP tool calls
+	RequiredActionMCPApproval = "mcp_approval"
+)
+
+// RequiredAction lists the client-side function calls and the MCP tool calls awaiting approval a
+// requires_action response is waiting on. Type is submit_tool_outputs when there are function
+// calls.
+type RequiredAction struct {
+	Type              string            `json:"type"`
+	SubmitToolOutputs SubmitToolOutputs `json:"submit_tool_outputs"`
+	ApprovalRequests  []ApprovalRequest `json:"approval_requests,omitempty"`
+}
+
+// ApprovalRequest is an MCP tool call that runs only once the user approves it. ID is the ID of
+// its mcp_approval_request output item.
+type ApprovalRequest struct {
+	ID          string `json:"id"`
+	CallID      string `json:"call_id"`
+	ServerLabel string `json:"server_label"`
+	Name        string `json:"name"`
+	Arguments   string `json:"arguments"`
+}
+
+// SubmitToolOutputs holds the function calls awaiting a function_call_output item.
+type SubmitToolOutputs struct {
+	ToolCalls []FunctionCall `json:"tool_calls"`
+}
+
+// FunctionCall is a function call the model requested from a client-side tool.
+type FunctionCall struct {
+	Type      string `json:"type"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// MCPServerSelection offers the model the tools of an MCP server, optionally limited to some of
+// them.
+type MCPServerSelection struct {
+	ServerLabel  string
+	AllowedTools []string
+	// RequireApproval makes every call of the selected tools wait for the user's approval
+	RequireApproval bool
+}
+
 // CreateParams contains inputs collected from the HTTP layer.
 type CreateParams struct {
-	UserID             string
+	UserID             string // the authenticated caller
+	APIKeyID           string // API key the caller sent next to its token, if any
 	Model              string
 	Input              interface{}
 	SystemPrompt       *string
 	Temperature        *float64
 	MaxTokens          *int
 	Stream             bool
+	Background         bool
 	ToolChoice         *llm.ToolChoice
 	Tools              []llm.ToolDefinition
+	MCPServers         []MCPServerSelection
+	ResponseFormat     *llm.ResponseFormat // output format the final message is validated against
 	PreviousResponseID *string
 	ConversationID     *string
 	Metadata           map[string]interface{}
@@ -72,18 +128,19 @@ type Service interface {
 	Create(ctx context.Context, params CreateParams) (*Response, error)
 	GetByPublicID(ctx context.Context, publicID string) (*Response, error)
 	Cancel(ctx context.Context, publicID string) (*Response, error)
-	ListConversationItems(ctx context.Context, publicID string) ([]ConversationItem, error)
-}
-
-// ConversationItem is returned when listing stored conversation history.
-type ConversationItem struct {
-	Role    string      `json:"role"`
-	Content interface{} `json:"content"`
-	Status  string      `json:"status"`
+	ListConversationItems(ctx context.Context, userID, publicID string) ([]conversation.Item, error)
 }
 
-// StreamObserver receives streaming lifecycle events.
+// StreamObserver receives streaming lifecycle events. Output items are identified by their index
+// in Response.Output; item events of parallel tool calls may arrive concurrently.
 type StreamObserver interface {
-	tool.StreamObserver
 	OnResponseCreated(resp *Response)
+	// OnResponseInProgress is called when orchestration of the response starts
+	OnResponseInProgress(resp *Response)
+	OnOutputItemAdded(outputIndex int, item OutputItem)
+	OnOutputTextDelta(outputIndex int, itemID string, delta string)
+	OnReasoningDelta(outputIndex int, itemID string, delta string)
+	OnOutputItemDone(outputIndex int, item OutputItem)
+	// OnResponseFinished is called once with the final state of the response
+	OnResponseFinished(resp *Response)
 }
@@ -0,0 +1,384 @@
+package response
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"jan-server/services/response-api/internal/domain/llm"
+	"jan-server/services/response-api/internal/domain/tool"
+)
+
+// Output item types of the OpenAI Responses API.
+const (
+	OutputItemMessage            = "message"
+	OutputItemFunctionCall       = "function_call"
+	OutputItemFunctionCallOutput = "function_call_output"
+	OutputItemWebSearchCall      = "web_search_call"
+	OutputItemReasoning          = "reasoning"
+	OutputItemMCPApprovalRequest = "mcp_approval_request"
+)
+
+// Output item statuses.
+const (
+	OutputStatusInProgress = "in_progress"
+	OutputStatusCompleted  = "completed"
+	OutputStatusIncomplete = "incomplete"
+	OutputStatusFailed     = "failed"
+)
+
+// webSearchTools are MCP tools reported as web_search_call items instead of function calls.
+var webSearchTools = map[string]bool{
+	"google_search": true,
+}
+
+// OutputItem is one typed entry of a response output. Fields are set depending on Type.
+type OutputItem struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Status string `json:"status,omitempty"`
+
+	// message
+	Role    string          `json:"role,omitempty"`
+	Content []OutputContent `json:"content,omitempty"`
+
+	// function_call, function_call_output and mcp_approval_request
+	ServerLabel string `json:"server_label,omitempty"`
+	CallID      string `json:"call_id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Arguments   string `json:"arguments,omitempty"`
+	Output      string `json:"output,omitempty"`
+
+	// web_search_call
+	Action *WebSearchAction `json:"action,omitempty"`
+
+	// reasoning
+	Summary []OutputContent `json:"summary,omitempty"`
+}
+
+// OutputContent is a text part of a message (output_text) or reasoning item (summary_text).
+type OutputContent struct {
+	Type        string        `json:"type"`
+	Text        string        `json:"text"`
+	Annotations []interface{} `json:"annotations,omitempty"`
+}
+
+// WebSearchAction describes the search run by a web_search_call item.
+type WebSearchAction struct {
+	Type  string `json:"type"`
+	Query string `json:"query,omitempty"`
+}
+
+// OutputText concatenates the output_text parts of the message items.
+func OutputText(items []OutputItem) string {
+	var builder strings.Builder
+	for _, item := range items {
+		if item.Type != OutputItemMessage {
+			continue
+		}
+		for _, part := range item.Content {
+			if part.Type == "output_text" {
+				builder.WriteString(part.Text)
+			}
+		}
+	}
+	return builder.String()
+}
+
+// NewMessageOutput returns the output of a single completed assistant text message.
+func NewMessageOutput(text string) []OutputItem {
+	return []OutputItem{newMessageItem(OutputStatusCompleted, text)}
+}
+
+func newMessageItem(status, text string) OutputItem {
+	return OutputItem{
+		Type:    OutputItemMessage,
+		ID:      newPublicID("msg"),
+		Status:  status,
+		Role:    "assistant",
+		Content: []OutputContent{{Type: "output_text", Text: text, Annotations: []interface{}{}}},
+	}
+}
+
+// outputTracker assembles the output items of a response from orchestration events and reports
+// each item as it is added, streamed and done. It is the tool.StreamObserver of a streaming run.
+type outputTracker struct {
+	mu        sync.Mutex
+	observer  StreamObserver // nil when building the output of a non-streaming run
+	tools     *toolSet       // tools of the run; nil when unknown
+	items     []OutputItem
+	message   int            // index of the message item receiving text, -1 if none
+	reasoning int            // index of the reasoning item receiving text, -1 if none
+	calls     map[string]int // call ID -> index of its function_call or web_search_call item
+	discarded map[int]bool   // indexes of message items left out of the final output
+}
+
+func newOutputTracker(observer StreamObserver, tools *toolSet) *outputTracker {
+	if tools == nil {
+		tools = newToolSet()
+	}
+	return &outputTracker{
+		observer:  observer,
+		tools:     tools,
+		message:   -1,
+		reasoning: -1,
+		calls:     make(map[string]int),
+		discarded: make(map[int]bool),
+	}
+}
+
+// buildOutput returns the output items of the messages generated by a non-streaming run.
+func buildOutput(messages []llm.ChatMessage, executions []tool.Execution, tools *toolSet, status string) []OutputItem {
+	byCall := make(map[string]tool.Execution, len(executions))
+	for _, execution := range executions {
+		byCall[execution.CallID] = execution
+	}
+
+	tracker := newOutputTracker(nil, tools)
+	for _, msg := range messages {
+		switch msg.Role {
+		case "assistant":
+			tracker.appendReasoning(msg.ReasoningContent)
+			tracker.appendText(contentText(msg.Content))
+			for _, call := range msg.ToolCalls {
+				if parsed, err := tool.ParseToolCall(call); err == nil {
+					tracker.OnToolCall(parsed)
+				}
+			}
+		case "tool":
+			if msg.ToolCallID == nil {
+				continue
+			}
+			if execution, ok := byCall[*msg.ToolCallID]; ok {
+				tracker.addToolOutput(execution.CallID, tool.ResultText(execution.Result, execution.ErrorMessage), execution.Status == tool.ExecutionStatusFailed)
+			} else {
+				tracker.addToolOutput(*msg.ToolCallID, contentText(msg.Content), false)
+			}
+		}
+	}
+	return tracker.finish(status)
+}
+
+// OnDelta streams reasoning and text deltas into reasoning and message items.
+func (t *outputTracker) OnDelta(delta llm.ChatCompletionDelta) {
+	for _, choice := range delta.Choices {
+		if choice.Index != 0 {
+			continue
+		}
+		t.appendReasoning(choice.Delta.ReasoningContent)
+		t.appendText(contentText(choice.Delta.Content))
+	}
+}
+
+// OnToolCall adds a function_call item, a web_search_call item for web search tools, or an
+// mcp_approval_request item for calls awaiting the user's approval.
+func (t *outputTracker) OnToolCall(call tool.Call) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeText(OutputStatusCompleted)
+
+	if t.tools.approval[call.Name] {
+		index := t.add(OutputItem{
+			Type:        OutputItemMCPApprovalRequest,
+			ID:          approvalRequestID(call.ID),
+			ServerLabel: t.tools.labels[call.Name],
+			Name:        call.Name,
+			Arguments:   callArguments(call),
+		})
+		t.done(index)
+		return
+	}
+
+	if webSearchTools[call.Name] {
+		query, _ := call.Arguments["q"].(string)
+		t.calls[call.ID] = t.add(OutputItem{
+			Type:   OutputItemWebSearchCall,
+			ID:     newPublicID("ws"),
+			Status: OutputStatusInProgress,
+			Action: &WebSearchAction{Type: "search", Query: query},
+		})
+		return
+	}
+
+	index := t.add(OutputItem{
+		Type:      OutputItemFunctionCall,
+		ID:        newPublicID("fc"),
+		Status:    OutputStatusCompleted,
+		CallID:    call.ID,
+		Name:      call.Name,
+		Arguments: callArguments(call),
+	})
+	t.calls[call.ID] = index
+	t.done(index)
+}
+
+// OnToolResult completes a web_search_call item or adds the function_call_output item of a call.
+// The output of a failed call is the error passed back to the model.
+func (t *outputTracker) OnToolResult(callID string, result *tool.Result, errorMessage string) {
+	failed := result == nil || result.IsError || errorMessage != ""
+	t.addToolOutput(callID, tool.ResultText(result, errorMessage), failed)
+}
+
+func (t *outputTracker) addToolOutput(callID, output string, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeText(OutputStatusCompleted)
+
+	status := OutputStatusCompleted
+	if failed {
+		status = OutputStatusFailed
+	}
+	if index, ok := t.calls[callID]; ok && t.items[index].Type == OutputItemWebSearchCall {
+		t.items[index].Status = status
+		t.done(index)
+		return
+	}
+
+	index := t.add(OutputItem{
+		Type:   OutputItemFunctionCallOutput,
+		ID:     newPublicID("fco"),
+		Status: status,
+		CallID: callID,
+		Output: output,
+	})
+	t.done(index)
+}
+
+func (t *outputTracker) appendReasoning(text string) {
+	if text == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.reasoning < 0 {
+		t.closeMessage(OutputStatusCompleted)
+		t.reasoning = t.add(OutputItem{
+			Type:    OutputItemReasoning,
+			ID:      newPublicID("rs"),
+			Status:  OutputStatusInProgress,
+			Summary: []OutputContent{{Type: "summary_text"}},
+		})
+	}
+	t.items[t.reasoning].Summary[0].Text += text
+	if t.observer != nil {
+		t.observer.OnReasoningDelta(t.reasoning, t.items[t.reasoning].ID, text)
+	}
+}
+
+func (t *outputTracker) appendText(text string) {
+	if text == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.message < 0 {
+		t.closeReasoning(OutputStatusCompleted)
+		t.message = t.add(newMessageItem(OutputStatusInProgress, ""))
+	}
+	t.items[t.message].Content[0].Text += text
+	if t.observer != nil {
+		t.observer.OnOutputTextDelta(t.message, t.items[t.message].ID, text)
+	}
+}
+
+// discardMessage closes the message item receiving text as incomplete and leaves it out of the
+// final output, e.g. when the message was rejected and the model asked for another.
+func (t *outputTracker) discardMessage() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.message >= 0 {
+		t.discarded[t.message] = true
+		t.closeMessage(OutputStatusIncomplete)
+	}
+}
+
+// finish closes the items still open with status and returns the output. In-progress tool calls
+// are closed as incomplete unless status is completed.
+func (t *outputTracker) finish(status string) []OutputItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeText(status)
+
+	output := make([]OutputItem, 0, len(t.items))
+	for index, item := range t.items {
+		if item.Status == OutputStatusInProgress {
+			t.items[index].Status = status
+			t.done(index)
+		}
+		if !t.discarded[index] {
+			output = append(output, t.items[index])
+		}
+	}
+	return output
+}
+
+func (t *outputTracker) closeText(status string) {
+	t.closeReasoning(status)
+	t.closeMessage(status)
+}
+
+func (t *outputTracker) closeMessage(status string) {
+	if t.message >= 0 {
+		t.items[t.message].Status = status
+		t.done(t.message)
+		t.message = -1
+	}
+}
+
+func (t *outputTracker) closeReasoning(status string) {
+	if t.reasoning >= 0 {
+		t.items[t.reasoning].Status = status
+		t.done(t.reasoning)
+		t.reasoning = -1
+	}
+}
+
+func (t *outputTracker) add(item OutputItem) int {
+	t.items = append(t.items, item)
+	index := len(t.items) - 1
+	if t.observer != nil {
+		t.observer.OnOutputItemAdded(index, t.items[index])
+	}
+	return index
+}
+
+func (t *outputTracker) done(index int) {
+	if t.observer != nil {
+		t.observer.OnOutputItemDone(index, t.items[index])
+	}
+}
+
+// callArguments returns the JSON arguments of a tool call.
+func callArguments(call tool.Call) string {
+	if call.Arguments == nil {
+		return "{}"
+	}
+	bytes, err := json.Marshal(call.Arguments)
+	if err != nil {
+		return "{}"
+	}
+	return string(bytes)
+}
+
+// contentText returns the text of chat message content.
+func contentText(content interface{}) string {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var builder strings.Builder
+		for _, part := range v {
+			builder.WriteString(contentText(part))
+		}
+		return builder.String()
+	case map[string]interface{}:
+		if text, ok := v["text"].(string); ok {
+			return text
+		}
+	}
+	return ""
+}
+
+var _ tool.StreamObserver = (*outputTracker)(nil)
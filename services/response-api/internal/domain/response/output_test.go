@@ -0,0 +1,116 @@
+package response
+
+import (
+	"encoding/json"
+	"testing"
+
+	"jan-server/services/response-api/internal/domain/llm"
+	"jan-server/services/response-api/internal/domain/tool"
+)
+
+// shape drops the generated IDs of output items so outputs can be compared.
+func shape(items []OutputItem) []OutputItem {
+	shaped := make([]OutputItem, len(items))
+	for i, item := range items {
+		item.ID = ""
+		shaped[i] = item
+	}
+	return shaped
+}
+
+func TestStreamedOutputMatchesBuiltOutput(t *testing.T) {
+	callID := func(id string) *string { return &id }
+	messages := []llm.ChatMessage{
+		{
+			Role:             "assistant",
+			ReasoningContent: "look it up",
+			ToolCalls: []llm.ToolCall{
+				{ID: "call_1", Type: "function", Function: llm.ToolFunction{Name: "google_search", Arguments: json.RawMessage(`{"q":"weather"}`)}},
+				{ID: "call_2", Type: "function", Function: llm.ToolFunction{Name: "scrape", Arguments: json.RawMessage(`{"url":"https://example.com"}`)}},
+				{ID: "call_3", Type: "function", Function: llm.ToolFunction{Name: "python_exec", Arguments: json.RawMessage(`{}`)}},
+			},
+		},
+		{Role: "tool", ToolCallID: callID("call_1"), Content: map[string]string{"type": "text", "text": "sunny"}},
+		{Role: "tool", ToolCallID: callID("call_2"), Content: map[string]string{"type": "text", "text": "timeout"}},
+		{Role: "tool", ToolCallID: callID("call_3"), Content: map[string]string{"type": "text", "text": "error"}},
+		{Role: "assistant", Content: "It is sunny."},
+	}
+	executions := []tool.Execution{
+		{CallID: "call_1", ToolName: "google_search", Status: tool.ExecutionStatusCompleted, Result: &tool.Result{Content: []tool.MCPContent{{Type: "text", Text: "sunny"}}}},
+		{CallID: "call_2", ToolName: "scrape", Status: tool.ExecutionStatusFailed, ErrorMessage: "context deadline exceeded"},
+		{CallID: "call_3", ToolName: "python_exec", Status: tool.ExecutionStatusFailed, ErrorMessage: "boom", Result: &tool.Result{IsError: true, Error: "boom"}},
+	}
+
+	built := buildOutput(messages, executions, nil, OutputStatusCompleted)
+
+	// Replay the same run the way the orchestrator reports it while streaming
+	streamed := newOutputTracker(&recordingObserver{}, nil)
+	streamed.OnDelta(llm.ChatCompletionDelta{Choices: []llm.ChatCompletionDeltaChoice{{Delta: llm.ChatMessage{ReasoningContent: "look it up"}}}})
+	for _, call := range messages[0].ToolCalls {
+		parsed, err := tool.ParseToolCall(call)
+		if err != nil {
+			t.Fatalf("parse tool call: %v", err)
+		}
+		streamed.OnToolCall(parsed)
+	}
+	for _, execution := range executions {
+		streamed.OnToolResult(execution.CallID, execution.Result, execution.ErrorMessage)
+	}
+	streamed.OnDelta(llm.ChatCompletionDelta{Choices: []llm.ChatCompletionDeltaChoice{{Delta: llm.ChatMessage{Content: "It is sunny."}}}})
+
+	got, want := shape(streamed.finish(OutputStatusCompleted)), shape(built)
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Fatalf("streamed output differs from built output\nstreamed: %s\nbuilt:    %s", gotJSON, wantJSON)
+	}
+
+	types := []string{
+		OutputItemReasoning, OutputItemWebSearchCall, OutputItemFunctionCall, OutputItemFunctionCall,
+		OutputItemFunctionCallOutput, OutputItemFunctionCallOutput, OutputItemMessage,
+	}
+	if len(got) != len(types) {
+		t.Fatalf("expected %d items, got %d: %s", len(types), len(got), gotJSON)
+	}
+	for i, itemType := range types {
+		if got[i].Type != itemType {
+			t.Fatalf("item %d: expected %s, got %s", i, itemType, got[i].Type)
+		}
+	}
+
+	failed := got[4]
+	if failed.CallID != "call_2" || failed.Status != OutputStatusFailed || failed.Output != "context deadline exceeded" {
+		t.Fatalf("expected the failed call to carry its error, got %+v", failed)
+	}
+	if got[5].Status != OutputStatusFailed || got[5].Output != "boom" {
+		t.Fatalf("expected the tool error to be reported, got %+v", got[5])
+	}
+	if OutputText(got) != "It is sunny." {
+		t.Fatalf("unexpected output text %q", OutputText(got))
+	}
+}
+
+func TestOutputTrackerFinishClosesOpenItems(t *testing.T) {
+	tracker := newOutputTracker(nil, nil)
+	tracker.OnToolCall(tool.Call{ID: "call_1", Name: "google_search", Arguments: map[string]interface{}{"q": "go"}})
+	tracker.appendText("partial")
+
+	output := tracker.finish(OutputStatusIncomplete)
+	for _, item := range output {
+		if item.Status != OutputStatusIncomplete {
+			t.Fatalf("expected %s to be closed as incomplete, got %s", item.Type, item.Status)
+		}
+	}
+}
+
+func TestOutputTrackerDiscardsRejectedMessage(t *testing.T) {
+	tracker := newOutputTracker(nil, nil)
+	tracker.appendText("not json")
+	tracker.discardMessage()
+	tracker.appendText(`{"ok":true}`)
+
+	output := tracker.finish(OutputStatusCompleted)
+	if len(output) != 1 || OutputText(output) != `{"ok":true}` {
+		t.Fatalf("expected only the accepted message, got %+v", output)
+	}
+}
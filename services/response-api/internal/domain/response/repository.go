@@ -2,7 +2,9 @@ package response
 
 import (
 	"context"
+	"time"
 
+	"jan-server/services/response-api/internal/domain/llm"
 	"jan-server/services/response-api/internal/domain/tool"
 )
 
@@ -10,8 +12,32 @@ import (
 type Repository interface {
 	Create(ctx context.Context, response *Response) error
 	Update(ctx context.Context, response *Response) error
+	// UpdateUnlessCancelled persists the response unless its stored status is cancelled, and
+	// reports whether it did. Runs use it for their final state, so a cancellation recorded by
+	// another instance is not overwritten.
+	UpdateUnlessCancelled(ctx context.Context, response *Response) (bool, error)
 	FindByPublicID(ctx context.Context, publicID string) (*Response, error)
+	// FindStatus returns the stored status of a response.
+	FindStatus(ctx context.Context, publicID string) (Status, error)
 	MarkCancelled(ctx context.Context, response *Response) error
+	// TransitionStatus moves a response from one status to another only if it still has the from
+	// status, and reports whether it did.
+	TransitionStatus(ctx context.Context, publicID string, from, to Status) (bool, error)
+	// FailInterrupted marks background responses still pending or in progress that were last
+	// updated before the cutoff as failed with details, and returns how many it marked.
+	FailInterrupted(ctx context.Context, before time.Time, details ErrorDetails) (int64, error)
+}
+
+// LegacyHistory reads the conversations response-api stored itself before conversations moved to
+// llm-api, so responses created before the move can still be continued.
+type LegacyHistory interface {
+	// ListLegacyMessages returns the messages of a legacy conversation, oldest first. Installs
+	// without legacy tables have no messages.
+	ListLegacyMessages(ctx context.Context, legacyConversationID uint) ([]llm.ChatMessage, error)
+	// AssignConversation points the responses of a legacy conversation without an llm-api
+	// conversation at conversationID. It returns the llm-api conversation the responses hold
+	// afterwards, which differs from conversationID when another request moved them first.
+	AssignConversation(ctx context.Context, legacyConversationID uint, conversationID string) (string, error)
 }
 
 // ToolExecutionRepository persists tool execution metadata.
@@ -0,0 +1,100 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrResponseCancelled is the cancellation cause of a response stopped through Cancel.
+var ErrResponseCancelled = errors.New("response cancelled")
+
+// runRegistry tracks the responses being orchestrated by this instance, keyed by public ID, so
+// Cancel can abort their in-flight work.
+type runRegistry struct {
+	mu   sync.Mutex
+	runs map[string]*activeRun
+}
+
+func newRunRegistry() *runRegistry {
+	return &runRegistry{runs: make(map[string]*activeRun)}
+}
+
+// track registers a response that is about to be orchestrated.
+func (r *runRegistry) track(publicID string) *activeRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run := &activeRun{}
+	r.runs[publicID] = run
+	return run
+}
+
+// untrack removes a response once its final state is stored.
+func (r *runRegistry) untrack(publicID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.runs, publicID)
+}
+
+// cancel stops a tracked response that has not stored its final state yet, running write before
+// the run can store it. It reports whether such a response was found.
+func (r *runRegistry) cancel(publicID string, write func() error) (bool, error) {
+	r.mu.Lock()
+	run, ok := r.runs[publicID]
+	r.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return run.stop(write)
+}
+
+// activeRun is the cancellation state of one tracked response.
+type activeRun struct {
+	mu        sync.Mutex
+	cancelFn  context.CancelCauseFunc
+	cancelled bool
+	settled   bool // the final state is stored; cancelling has no effect anymore
+}
+
+// begin derives the context the orchestration runs with. It is already cancelled when the
+// response was cancelled before it started, e.g. while queued for a background worker.
+func (a *activeRun) begin(parent context.Context) (context.Context, context.CancelCauseFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cancelFn = cancel
+	if a.cancelled {
+		cancel(ErrResponseCancelled)
+	}
+	return ctx, cancel
+}
+
+func (a *activeRun) stop(write func() error) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.settled {
+		return false, nil
+	}
+	a.cancelled = true
+	if a.cancelFn != nil {
+		a.cancelFn(ErrResponseCancelled)
+	}
+	return true, write()
+}
+
+// guard runs a status write that Cancel cannot interleave with. cancelled is true once the
+// response was cancelled or ctx, the run context, was cancelled by its caller going away.
+func (a *activeRun) guard(ctx context.Context, write func(cancelled bool) error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return write(a.cancelled || errors.Is(ctx.Err(), context.Canceled))
+}
+
+// settle runs the write storing the final state of the response, like guard.
+func (a *activeRun) settle(ctx context.Context, write func(cancelled bool) error) error {
+	return a.guard(ctx, func(cancelled bool) error {
+		a.settled = true
+		return write(cancelled)
+	})
+}
@@ -0,0 +1,79 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRunRegistryCancelStopsTrackedRun(t *testing.T) {
+	registry := newRunRegistry()
+	active := registry.track("resp_1")
+	ctx, cancel := active.begin(context.Background())
+	defer cancel(nil)
+
+	var wrote bool
+	found, err := registry.cancel("resp_1", func() error { wrote = true; return nil })
+	if err != nil || !found || !wrote {
+		t.Fatalf("expected the tracked run to be cancelled, got found=%v wrote=%v err=%v", found, wrote, err)
+	}
+	if !errors.Is(context.Cause(ctx), ErrResponseCancelled) {
+		t.Fatalf("expected ErrResponseCancelled as the cause, got %v", context.Cause(ctx))
+	}
+
+	registry.untrack("resp_1")
+	if found, _ := registry.cancel("resp_1", func() error { return nil }); found {
+		t.Fatal("expected an untracked run not to be found")
+	}
+}
+
+func TestRunRegistryCancelBeforeBegin(t *testing.T) {
+	registry := newRunRegistry()
+	active := registry.track("resp_queued")
+	if _, err := registry.cancel("resp_queued", func() error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A queued background run starts already cancelled
+	ctx, cancel := active.begin(context.Background())
+	defer cancel(nil)
+	if ctx.Err() == nil {
+		t.Fatal("expected the run context to be cancelled")
+	}
+}
+
+func TestRunRegistryCancelAfterSettle(t *testing.T) {
+	registry := newRunRegistry()
+	active := registry.track("resp_done")
+	ctx, cancel := active.begin(context.Background())
+	defer cancel(nil)
+
+	var sawCancel bool
+	_ = active.settle(ctx, func(cancelled bool) error { sawCancel = cancelled; return nil })
+	if sawCancel {
+		t.Fatal("expected the run not to be cancelled when it settled")
+	}
+
+	var wrote bool
+	found, _ := registry.cancel("resp_done", func() error { wrote = true; return nil })
+	if found || wrote {
+		t.Fatal("expected a settled run not to be cancelled")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("expected the settled run context to stay active")
+	}
+}
+
+func TestActiveRunGuardSeesCallerCancellation(t *testing.T) {
+	active := &activeRun{}
+	parent, stop := context.WithCancel(context.Background())
+	ctx, cancel := active.begin(parent)
+	defer cancel(nil)
+	stop()
+
+	var cancelled bool
+	_ = active.guard(ctx, func(c bool) error { cancelled = c; return nil })
+	if !cancelled {
+		t.Fatal("expected a caller that went away to count as cancelled")
+	}
+}
@@ -0,0 +1,436 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// validateSchema reports the first place where value, decoded from JSON, does not match schema.
+// It covers the keywords of structured output schemas: type, enum, const, properties, required,
+// additionalProperties, items, length and range bounds, pattern, anyOf, oneOf, allOf, not and
+// local $ref pointers. Other keywords are ignored.
+func validateSchema(schema map[string]interface{}, value interface{}) error {
+	v := &schemaValidator{root: schema, following: make(map[string]bool)}
+	return v.validate(schema, value, "$")
+}
+
+// checkSchema rejects schemas validateSchema cannot apply: $ref pointers that do not resolve and
+// $ref cycles that come back to a schema without descending into the value, such as
+// {"$ref": "#"}. Recursive schemas whose references pass through properties or items are fine.
+func checkSchema(schema map[string]interface{}) error {
+	v := &schemaValidator{root: schema}
+	acyclic := make(map[uintptr]bool)
+	return walkSchemas(schema, func(node map[string]interface{}) error {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return nil
+		}
+		if err := v.findCycle(node, make(map[uintptr]bool), acyclic); err != nil {
+			return fmt.Errorf("$ref %q: %w", ref, err)
+		}
+		return nil
+	})
+}
+
+type schemaValidator struct {
+	root map[string]interface{}
+	// following holds the $ref pointers being followed per value path, to stop on cycles
+	following map[string]bool
+}
+
+// errSchemaCycle marks $ref pointers that lead back to a schema applied to the same value.
+var errSchemaCycle = errors.New("refers back to itself without matching a value")
+
+func (v *schemaValidator) validate(node interface{}, value interface{}, path string) error {
+	switch schema := node.(type) {
+	case nil:
+		return nil
+	case bool:
+		if !schema {
+			return fmt.Errorf("%s: no value is allowed", path)
+		}
+		return nil
+	case map[string]interface{}:
+		return v.validateObjectSchema(schema, value, path)
+	default:
+		return fmt.Errorf("%s: invalid schema", path)
+	}
+}
+
+func (v *schemaValidator) validateObjectSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		key := path + " " + ref
+		if v.following[key] {
+			return fmt.Errorf("%s: $ref %q %w", path, ref, errSchemaCycle)
+		}
+		v.following[key] = true
+		err = v.validate(target, value, path)
+		delete(v.following, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		return fmt.Errorf("%s: expected %s, got %s", path, describeTypes(types), jsonType(value))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of the allowed values", path)
+		}
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		return fmt.Errorf("%s: value must be %v", path, constant)
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if err := v.validateObject(schema, typed, path); err != nil {
+			return err
+		}
+	case []interface{}:
+		if err := v.validateArray(schema, typed, path); err != nil {
+			return err
+		}
+	case string:
+		if err := validateString(schema, typed, path); err != nil {
+			return err
+		}
+	case float64:
+		if err := validateNumber(schema, typed, path); err != nil {
+			return err
+		}
+	}
+
+	return v.validateCombinators(schema, value, path)
+}
+
+func (v *schemaValidator) validateObject(schema map[string]interface{}, object map[string]interface{}, path string) error {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, present := object[key]; !present {
+				return fmt.Errorf("%s: missing required property %q", path, key)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "." + key
+		if property, ok := properties[key]; ok {
+			if err := v.validate(property, object[key], childPath); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s: property %q is not allowed", path, key)
+			}
+		case map[string]interface{}:
+			if err := v.validate(additional, object[key], childPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	if lower, ok := schemaNumber(schema, "minProperties"); ok && float64(len(object)) < lower {
+		return fmt.Errorf("%s: expected at least %v properties", path, lower)
+	}
+	if upper, ok := schemaNumber(schema, "maxProperties"); ok && float64(len(object)) > upper {
+		return fmt.Errorf("%s: expected at most %v properties", path, upper)
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateArray(schema map[string]interface{}, array []interface{}, path string) error {
+	if lower, ok := schemaNumber(schema, "minItems"); ok && float64(len(array)) < lower {
+		return fmt.Errorf("%s: expected at least %v items", path, lower)
+	}
+	if upper, ok := schemaNumber(schema, "maxItems"); ok && float64(len(array)) > upper {
+		return fmt.Errorf("%s: expected at most %v items", path, upper)
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range array {
+			for j := i + 1; j < len(array); j++ {
+				if reflect.DeepEqual(array[i], array[j]) {
+					return fmt.Errorf("%s: items %d and %d are equal", path, i, j)
+				}
+			}
+		}
+	}
+
+	items, ok := schema["items"]
+	if !ok {
+		return nil
+	}
+	for i, item := range array {
+		if err := v.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateCombinators(schema map[string]interface{}, value interface{}, path string) error {
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if err := v.validate(sub, value, path); err != nil {
+				return err
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		var firstErr error
+		matched := false
+		for _, sub := range anyOf {
+			err := v.validate(sub, value, path)
+			if err == nil {
+				matched = true
+				break
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if !matched && firstErr != nil {
+			return fmt.Errorf("%s: value matches none of anyOf (%v)", path, firstErr)
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if v.validate(sub, value, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: value must match exactly one of oneOf, matched %d", path, matches)
+		}
+	}
+	if not, ok := schema["not"]; ok && v.validate(not, value, path) == nil {
+		return fmt.Errorf("%s: value must not match the not schema", path)
+	}
+	return nil
+}
+
+// resolve returns the schema a local $ref such as #/$defs/item points to.
+func (v *schemaValidator) resolve(ref string) (interface{}, error) {
+	if ref == "#" {
+		return v.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	var node interface{} = v.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = object[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// findCycle reports errSchemaCycle when node reaches itself through the keywords applying
+// schemas to the same value: $ref, allOf, anyOf, oneOf and not. Nodes in acyclic were checked.
+func (v *schemaValidator) findCycle(node interface{}, visiting, acyclic map[uintptr]bool) error {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	id := reflect.ValueOf(schema).Pointer()
+	if acyclic[id] {
+		return nil
+	}
+	if visiting[id] {
+		return errSchemaCycle
+	}
+	visiting[id] = true
+	defer delete(visiting, id)
+
+	var next []interface{}
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			return err
+		}
+		next = append(next, target)
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		if list, ok := schema[keyword].([]interface{}); ok {
+			next = append(next, list...)
+		}
+	}
+	if not, ok := schema["not"]; ok {
+		next = append(next, not)
+	}
+	for _, sub := range next {
+		if err := v.findCycle(sub, visiting, acyclic); err != nil {
+			return err
+		}
+	}
+	acyclic[id] = true
+	return nil
+}
+
+// walkSchemas calls visit for every object in schema, skipping keywords that hold JSON values
+// rather than schemas.
+func walkSchemas(node interface{}, visit func(map[string]interface{}) error) error {
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		if err := visit(typed); err != nil {
+			return err
+		}
+		for key, child := range typed {
+			switch key {
+			case "enum", "const", "default", "examples":
+				continue
+			}
+			if err := walkSchemas(child, visit); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range typed {
+			if err := walkSchemas(child, visit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateString(schema map[string]interface{}, value, path string) error {
+	length := float64(utf8.RuneCountInString(value))
+	if lower, ok := schemaNumber(schema, "minLength"); ok && length < lower {
+		return fmt.Errorf("%s: expected at least %v characters", path, lower)
+	}
+	if upper, ok := schemaNumber(schema, "maxLength"); ok && length > upper {
+		return fmt.Errorf("%s: expected at most %v characters", path, upper)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %q", path, pattern)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%s: value does not match pattern %q", path, pattern)
+		}
+	}
+	return nil
+}
+
+func validateNumber(schema map[string]interface{}, value float64, path string) error {
+	if lower, ok := schemaNumber(schema, "minimum"); ok && value < lower {
+		return fmt.Errorf("%s: expected a value >= %v", path, lower)
+	}
+	if upper, ok := schemaNumber(schema, "maximum"); ok && value > upper {
+		return fmt.Errorf("%s: expected a value <= %v", path, upper)
+	}
+	if lower, ok := schemaNumber(schema, "exclusiveMinimum"); ok && value <= lower {
+		return fmt.Errorf("%s: expected a value > %v", path, lower)
+	}
+	if upper, ok := schemaNumber(schema, "exclusiveMaximum"); ok && value >= upper {
+		return fmt.Errorf("%s: expected a value < %v", path, upper)
+	}
+	if step, ok := schemaNumber(schema, "multipleOf"); ok && step > 0 {
+		if quotient := value / step; math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			return fmt.Errorf("%s: expected a multiple of %v", path, step)
+		}
+	}
+	return nil
+}
+
+func schemaNumber(schema map[string]interface{}, keyword string) (float64, bool) {
+	number, ok := schema[keyword].(float64)
+	return number, ok
+}
+
+// matchesType reports whether value has the type, or one of the types, of a type keyword.
+func matchesType(types interface{}, value interface{}) bool {
+	switch t := types.(type) {
+	case string:
+		return isType(t, value)
+	case []interface{}:
+		for _, candidate := range t {
+			if name, ok := candidate.(string); ok && isType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, value interface{}) bool {
+	switch name {
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonType(value) == name
+	}
+}
+
+func describeTypes(types interface{}) string {
+	if list, ok := types.([]interface{}); ok {
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(types)
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
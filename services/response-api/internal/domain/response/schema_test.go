@@ -0,0 +1,143 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"jan-server/services/response-api/internal/domain/llm"
+)
+
+func decodeJSON(t *testing.T, raw string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	return value
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  string
+		// problem is a part of the expected error, empty when the value matches
+		problem string
+	}{
+		{"required present", `{"type":"object","required":["city"],"properties":{"city":{"type":"string"}}}`, `{"city":"Hanoi"}`, ""},
+		{"required missing", `{"type":"object","required":["city"]}`, `{}`, `missing required property "city"`},
+		{"wrong property type", `{"properties":{"temp":{"type":"number"}}}`, `{"temp":"hot"}`, "$.temp: expected number, got string"},
+		{"integer rejects fraction", `{"type":"integer"}`, `1.5`, "expected integer"},
+		{"enum match", `{"enum":["c","f"]}`, `"c"`, ""},
+		{"enum mismatch", `{"enum":["c","f"]}`, `"k"`, "not one of the allowed values"},
+		{"enum of objects", `{"enum":[{"unit":"c"}]}`, `{"unit":"c"}`, ""},
+		{"additional properties allowed", `{"properties":{"a":{}}}`, `{"a":1,"b":2}`, ""},
+		{"additional properties denied", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"b":2}`, `property "b" is not allowed`},
+		{"additional properties schema", `{"additionalProperties":{"type":"string"}}`, `{"b":2}`, "$.b: expected string"},
+		{"ref to defs", `{"$defs":{"unit":{"enum":["c","f"]}},"properties":{"unit":{"$ref":"#/$defs/unit"}}}`, `{"unit":"f"}`, ""},
+		{"ref mismatch", `{"$defs":{"unit":{"enum":["c","f"]}},"properties":{"unit":{"$ref":"#/$defs/unit"}}}`, `{"unit":"k"}`, "$.unit: value is not one of"},
+		{"escaped ref", `{"$defs":{"a/b":{"type":"string"}},"$ref":"#/$defs/a~1b"}`, `1`, "expected string"},
+		{"unresolvable ref", `{"$ref":"#/$defs/missing"}`, `1`, "unresolvable $ref"},
+		{"remote ref", `{"$ref":"https://example.com/schema.json"}`, `1`, "unsupported $ref"},
+		{
+			"recursive tree",
+			`{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#"}}}}`,
+			`{"children":[{"children":[]},{"children":[{"children":[]}]}]}`,
+			"",
+		},
+		{
+			"recursive tree mismatch",
+			`{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#"}}}}`,
+			`{"children":[{"children":[1]}]}`,
+			"$.children[0].children[0]: expected object",
+		},
+		{"self reference", `{"$ref":"#"}`, `1`, errSchemaCycle.Error()},
+		{"reference cycle", `{"$defs":{"a":{"$ref":"#/$defs/b"},"b":{"allOf":[{"$ref":"#/$defs/a"}]}},"$ref":"#/$defs/a"}`, `1`, errSchemaCycle.Error()},
+		{"anyOf", `{"anyOf":[{"type":"string"},{"type":"null"}]}`, `null`, ""},
+		{"oneOf matching twice", `{"oneOf":[{"type":"number"},{"type":"integer"}]}`, `1`, "matched 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := decodeJSON(t, tt.schema).(map[string]interface{})
+			err := validateSchema(schema, decodeJSON(t, tt.value))
+			if tt.problem == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("expected an error containing %q, got %v", tt.problem, err)
+			}
+		})
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		cycle  bool
+		valid  bool
+	}{
+		{"no refs", `{"type":"object"}`, false, true},
+		{"recursion through items", `{"properties":{"children":{"items":{"$ref":"#"}}}}`, false, true},
+		{"shared definition", `{"$defs":{"n":{"type":"number"}},"properties":{"a":{"$ref":"#/$defs/n"},"b":{"$ref":"#/$defs/n"}}}`, false, true},
+		{"ref-like enum value", `{"enum":[{"$ref":"#"}]}`, false, true},
+		{"self reference", `{"$ref":"#"}`, true, false},
+		{"cycle through anyOf", `{"$defs":{"a":{"anyOf":[{"$ref":"#/$defs/b"}]},"b":{"not":{"$ref":"#/$defs/a"}}},"properties":{"x":{"$ref":"#/$defs/a"}}}`, true, false},
+		{"unresolvable", `{"properties":{"x":{"$ref":"#/$defs/missing"}}}`, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSchema(decodeJSON(t, tt.schema).(map[string]interface{}))
+			if tt.valid != (err == nil) {
+				t.Fatalf("expected valid=%v, got %v", tt.valid, err)
+			}
+			if tt.cycle != errors.Is(err, errSchemaCycle) {
+				t.Fatalf("expected cycle=%v, got %v", tt.cycle, err)
+			}
+		})
+	}
+}
+
+func TestCreateRejectsCyclicSchema(t *testing.T) {
+	service := &ServiceImpl{}
+	format := &llm.ResponseFormat{
+		Type:       llm.ResponseFormatJSONSchema,
+		JSONSchema: &llm.JSONSchemaFormat{Name: "loop", Schema: map[string]interface{}{"$ref": "#"}},
+	}
+	if _, err := service.Create(context.Background(), CreateParams{ResponseFormat: format}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	schema := &llm.ResponseFormat{
+		Type:       llm.ResponseFormatJSONSchema,
+		JSONSchema: &llm.JSONSchemaFormat{Name: "weather", Schema: decodeJSON(t, `{"type":"object","required":["temp"]}`).(map[string]interface{})},
+	}
+	tests := []struct {
+		name   string
+		format *llm.ResponseFormat
+		text   string
+		ok     bool
+	}{
+		{"text format", &llm.ResponseFormat{Type: llm.ResponseFormatText}, "anything", true},
+		{"json object", &llm.ResponseFormat{Type: llm.ResponseFormatJSONObject}, `{"a":1}`, true},
+		{"json object rejects arrays", &llm.ResponseFormat{Type: llm.ResponseFormatJSONObject}, `[1]`, false},
+		{"invalid json", schema, `{"temp":`, false},
+		{"schema match", schema, `{"temp":21}`, true},
+		{"schema mismatch", schema, `{}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkFormat(tt.format, tt.text); (err == nil) != tt.ok {
+				t.Fatalf("expected ok=%v, got %v", tt.ok, err)
+			}
+		})
+	}
+}
@@ -16,53 +16,120 @@ import (
 	"jan-server/services/response-api/internal/domain/tool"
 )
 
+// cancelPollInterval is how often a running response checks whether it was cancelled through
+// another instance.
+var cancelPollInterval = 2 * time.Second
+
+// ErrInvalidInput marks request input the service rejects, such as missing function call outputs.
+var ErrInvalidInput = errors.New("invalid input")
+
+// ErrRequiredActionTaken is returned when another request already continued the requires_action
+// response named by previous_response_id.
+var ErrRequiredActionTaken = errors.New("previous response is no longer waiting for action")
+
+// ErrResponseNotFound is returned when a response does not exist or belongs to another user.
+var ErrResponseNotFound = errors.New("response not found")
+
 // ServiceImpl provides the domain implementation.
 type ServiceImpl struct {
 	responses         Repository
 	conversations     conversation.Repository
 	conversationItems conversation.ItemRepository
+	legacy            LegacyHistory // history stored before conversations moved to llm-api; may be nil
 	toolExecutions    ToolExecutionRepository
 	orchestrator      *tool.Orchestrator
 	mcpClient         tool.MCPClient
+	policy            *tool.Policy
+	workers           *WorkerPool
+	delegator         llm.TokenDelegator // renews caller credentials for background runs; nil keeps the caller's token
+	running           *runRegistry
 	log               zerolog.Logger
 }
 
-// NewService wires dependencies.
+// responseRun carries a created response and its prepared input into orchestration.
+type responseRun struct {
+	params        CreateParams
+	response      *Response
+	conv          *conversation.Conversation
+	prevResp      *Response
+	existingItems []conversation.Item
+	userMessages  []llm.ChatMessage
+	pendingCalls  map[string]bool
+	approvals     []tool.ApprovalDecision
+	tools         *toolSet
+	active        *activeRun
+	output        *outputTracker // output items streamed to the observer; nil without one
+	continues     bool           // prevResp was claimed out of requires_action by this run
+}
+
+// NewService wires dependencies. policy limits the MCP tools each caller may use; delegator may be
+// nil, in which case background responses call llm-api with the caller's token until it expires.
 func NewService(
 	responses Repository,
 	conversations conversation.Repository,
 	conversationItems conversation.ItemRepository,
+	legacy LegacyHistory,
 	toolExecutions ToolExecutionRepository,
 	orchestrator *tool.Orchestrator,
 	mcpClient tool.MCPClient,
+	policy *tool.Policy,
+	workers *WorkerPool,
+	delegator llm.TokenDelegator,
 	log zerolog.Logger,
 ) *ServiceImpl {
 	return &ServiceImpl{
 		responses:         responses,
 		conversations:     conversations,
 		conversationItems: conversationItems,
+		legacy:            legacy,
 		toolExecutions:    toolExecutions,
 		orchestrator:      orchestrator,
 		mcpClient:         mcpClient,
+		policy:            policy,
+		workers:           workers,
+		delegator:         delegator,
+		running:           newRunRegistry(),
 		log:               log.With().Str("component", "response-service").Logger(),
 	}
 }
 
-// Create orchestrates a complete response lifecycle.
+// Create orchestrates a complete response lifecycle. Background responses are stored as pending
+// and orchestrated on the worker pool; Create then returns without waiting for the result.
 func (s *ServiceImpl) Create(ctx context.Context, params CreateParams) (*Response, error) {
+	if err := checkResponseFormat(params.ResponseFormat); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
 	var conv *conversation.Conversation
+	var prevResp *Response
 	var err error
 
 	// If PreviousResponseID is provided, load that response's conversation for context
 	if params.PreviousResponseID != nil && strings.TrimSpace(*params.PreviousResponseID) != "" {
-		prevResp, err := s.responses.FindByPublicID(ctx, *params.PreviousResponseID)
+		prev, err := s.responses.FindByPublicID(ctx, *params.PreviousResponseID)
+		if err == nil && prev.UserID != params.UserID {
+			// Another user's response is reported like a missing one
+			err = ErrResponseNotFound
+		}
+		if errors.Is(err, ErrResponseNotFound) {
+			return nil, fmt.Errorf("%w: previous response %q", ErrResponseNotFound, *params.PreviousResponseID)
+		}
 		if err != nil {
 			s.log.Warn().Err(err).Str("previous_response_id", *params.PreviousResponseID).Msg("failed to load previous response, continuing without context")
-		} else if prevResp.ConversationPublicID != nil {
-			// Use the previous response's conversation to maintain context
-			conv, err = s.conversations.FindByPublicID(ctx, *prevResp.ConversationPublicID)
-			if err != nil {
-				s.log.Warn().Err(err).Str("conversation_id", *prevResp.ConversationPublicID).Msg("failed to load conversation, creating new one")
+		} else {
+			prevResp = prev
+			if prev.ConversationPublicID == nil && prev.LegacyConversationID != nil && s.legacy != nil {
+				// Created before conversations moved to llm-api; move its history over first
+				conv, err = s.moveLegacyConversation(ctx, prev)
+				if err != nil {
+					return nil, fmt.Errorf("move legacy conversation: %w", err)
+				}
+			} else if prev.ConversationPublicID != nil {
+				// Use the previous response's conversation to maintain context
+				conv, err = s.conversations.FindByPublicID(ctx, *prev.ConversationPublicID)
+				if err != nil {
+					s.log.Warn().Err(err).Str("conversation_id", *prev.ConversationPublicID).Msg("failed to load conversation, creating new one")
+				}
 			}
 		}
 	}
@@ -71,25 +138,50 @@ func (s *ServiceImpl) Create(ctx context.Context, params CreateParams) (*Respons
 	if conv == nil {
 		if params.ConversationID != nil && strings.TrimSpace(*params.ConversationID) != "" {
 			conv, err = s.conversations.FindByPublicID(ctx, *params.ConversationID)
+			if errors.Is(err, conversation.ErrNotFound) {
+				return nil, fmt.Errorf("%w: conversation %q not found", ErrInvalidInput, *params.ConversationID)
+			}
 			if err != nil {
 				return nil, fmt.Errorf("fetch conversation: %w", err)
 			}
 		} else {
-			conv = &conversation.Conversation{
-				PublicID: newPublicID("conv"),
-				UserID:   params.UserID,
-			}
+			// llm-api assigns the ID, shared with its Conversations API and chat completions
+			conv = &conversation.Conversation{}
 			if err := s.conversations.Create(ctx, conv); err != nil {
 				return nil, fmt.Errorf("create conversation: %w", err)
 			}
 		}
 	}
 
-	existingItems, err := s.conversationItems.ListByConversationID(ctx, conv.ID)
+	existingItems, err := s.conversationItems.ListByConversationID(ctx, conv.PublicID)
 	if err != nil {
 		return nil, fmt.Errorf("list conversation items: %w", err)
 	}
 
+	// A continuation of a requires_action response must answer every pending function call and
+	// approval request
+	input, approvals, err := takeApprovalResponses(params.Input, pendingApprovals(prevResp))
+	if err != nil {
+		return nil, err
+	}
+	pendingCalls := pendingCallIDs(prevResp)
+	userMessages, err := s.convertInputToMessages(input, pendingCalls)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := checkToolOutputs(pendingCalls, userMessages); err != nil {
+		return nil, err
+	}
+
+	tools, err := s.resolveTools(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	status := StatusInProgress
+	if params.Background {
+		status = StatusPending
+	}
 	responseModel := &Response{
 		PublicID:             newPublicID("resp"),
 		Object:               "response",
@@ -97,43 +189,227 @@ func (s *ServiceImpl) Create(ctx context.Context, params CreateParams) (*Respons
 		Model:                params.Model,
 		SystemPrompt:         params.SystemPrompt,
 		Input:                params.Input,
-		Status:               StatusInProgress,
+		Status:               status,
 		Stream:               params.Stream,
+		Background:           params.Background,
 		Metadata:             params.Metadata,
-		ConversationID:       &conv.ID,
 		ConversationPublicID: &conv.PublicID,
 		PreviousResponseID:   params.PreviousResponseID,
 		CreatedAt:            time.Now(),
 		UpdatedAt:            time.Now(),
 	}
 
+	// Only one request may answer a requires_action response
+	continues := prevResp != nil && prevResp.Status == StatusRequiresAction
+	if continues {
+		if err := s.claimRequiredAction(ctx, prevResp); err != nil {
+			return nil, err
+		}
+	}
+
 	if params.StreamObserver != nil {
 		params.StreamObserver.OnResponseCreated(responseModel)
 	}
 
 	if err := s.responses.Create(ctx, responseModel); err != nil {
+		if continues {
+			s.releaseRequiredAction(ctx, prevResp)
+		}
 		return nil, fmt.Errorf("create response: %w", err)
 	}
 
-	baseMessages, err := s.buildBaseMessages(params.SystemPrompt, existingItems)
+	run := &responseRun{
+		params:        params,
+		response:      responseModel,
+		conv:          conv,
+		prevResp:      prevResp,
+		existingItems: existingItems,
+		userMessages:  userMessages,
+		pendingCalls:  pendingCalls,
+		approvals:     approvals,
+		tools:         tools,
+		active:        s.running.track(responseModel.PublicID),
+		continues:     continues,
+	}
+	if !params.Background {
+		return s.execute(ctx, run)
+	}
+
+	// Hand the run to the worker pool and return the pending response right away
+	snapshot := *responseModel
+	if err := s.workers.Submit(s.backgroundContext(ctx), func(jobCtx context.Context) { s.runBackground(jobCtx, run) }); err != nil {
+		s.running.untrack(responseModel.PublicID)
+		s.failResponse(ctx, responseModel, err)
+		s.releaseContinuation(ctx, run)
+		s.notifyFinished(run)
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// execute runs the orchestration of a created response and reports its final state to the
+// stream observer. The orchestration is aborted when Cancel is called for the response or ctx is
+// cancelled, e.g. by a streaming client disconnecting.
+func (s *ServiceImpl) execute(ctx context.Context, run *responseRun) (*Response, error) {
+	defer s.running.untrack(run.response.PublicID)
+
+	runCtx, cancel := run.active.begin(ctx)
+	defer cancel(nil)
+	go s.watchCancellation(runCtx, run.response.PublicID)
+
+	if observer := run.params.StreamObserver; observer != nil {
+		run.output = newOutputTracker(observer, run.tools)
+		observer.OnResponseInProgress(run.response)
+	}
+
+	resp, err := s.orchestrate(runCtx, run)
+	s.notifyFinished(run)
+	return resp, err
+}
+
+// runBackground executes a queued background response unless it was cancelled while pending.
+func (s *ServiceImpl) runBackground(ctx context.Context, run *responseRun) {
+	current, err := s.responses.FindByPublicID(ctx, run.response.PublicID)
+	if err != nil {
+		s.running.untrack(run.response.PublicID)
+		s.log.Error().Err(err).Str("response_id", run.response.PublicID).Msg("reload background response failed")
+		storeCtx := context.WithoutCancel(ctx)
+		s.failResponse(storeCtx, run.response, fmt.Errorf("reload background response: %w", err))
+		s.releaseContinuation(storeCtx, run)
+		s.notifyFinished(run)
+		return
+	}
+
+	var cancelled bool
+	err = run.active.guard(ctx, func(stopped bool) error {
+		if cancelled = stopped || current.Status == StatusCancelled; cancelled {
+			return nil
+		}
+		run.response.Status = StatusInProgress
+		run.response.UpdatedAt = time.Now()
+		started, err := s.responses.UpdateUnlessCancelled(ctx, run.response)
+		if err == nil && !started {
+			// Cancelled through another instance since the reload
+			cancelled = true
+			current.Status = StatusCancelled
+		}
+		return err
+	})
 	if err != nil {
-		return s.failResponse(ctx, responseModel, fmt.Errorf("build base messages: %w", err))
+		s.log.Error().Err(err).Str("response_id", run.response.PublicID).Msg("start background response failed")
+	}
+	if cancelled {
+		s.running.untrack(run.response.PublicID)
+		s.releaseContinuation(ctx, run)
+		run.response = current
+		s.notifyFinished(run)
+		return
 	}
 
-	userMessages, convoItems, err := s.convertInputToMessages(conv.ID, len(existingItems), params.Input)
+	if _, err := s.execute(ctx, run); err != nil {
+		s.log.Error().Err(err).Str("response_id", run.response.PublicID).Msg("background response failed")
+	}
+}
+
+// backgroundContext gives a background run credentials that outlive the caller's token, which may
+// expire while the run waits in the queue or calls llm-api. Without a delegator, or when
+// delegation fails, the run keeps the caller's token.
+func (s *ServiceImpl) backgroundContext(ctx context.Context) context.Context {
+	authHeader := llm.AuthTokenFromContext(ctx)
+	if s.delegator == nil || authHeader == "" {
+		return ctx
+	}
+	source, err := s.delegator.Delegate(ctx, authHeader)
 	if err != nil {
-		return s.failResponse(ctx, responseModel, err)
+		s.log.Warn().Err(err).Msg("delegate credentials for background response failed, using the caller's token")
+		return ctx
 	}
-	messages := append(baseMessages, userMessages...)
-	initialLength := len(messages)
+	return llm.ContextWithTokenSource(ctx, source)
+}
 
-	toolDefs := params.Tools
-	if len(toolDefs) == 0 {
-		if toolDefs, err = s.fetchAvailableTools(ctx); err != nil {
-			return s.failResponse(ctx, responseModel, err)
+// SweepInterrupted fails background responses that were left pending or in progress by an
+// instance that stopped, since no worker will finish them. A response counts as interrupted once
+// it has not been updated for maxAge, which must exceed the background timeout. The sweep runs
+// now and then every interval until ctx ends.
+func (s *ServiceImpl) SweepInterrupted(ctx context.Context, maxAge, interval time.Duration) {
+	details := ErrorDetails{
+		Code:    "response_interrupted",
+		Message: "the background response was interrupted before it finished",
+	}
+	sweep := func() {
+		failed, err := s.responses.FailInterrupted(ctx, time.Now().Add(-maxAge), details)
+		if err != nil {
+			s.log.Error().Err(err).Msg("sweep interrupted background responses failed")
+			return
+		}
+		if failed > 0 {
+			s.log.Warn().Int64("responses", failed).Msg("failed interrupted background responses")
 		}
 	}
 
+	sweep()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sweep()
+		}
+	}
+}
+
+// watchCancellation stops a run whose response was cancelled through another instance, which can
+// only record the cancellation in the database. It returns when ctx, the run context, ends.
+func (s *ServiceImpl) watchCancellation(ctx context.Context, publicID string) {
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		status, err := s.responses.FindStatus(ctx, publicID)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Warn().Err(err).Str("response_id", publicID).Msg("check response cancellation failed")
+			}
+			continue
+		}
+		if status == StatusCancelled {
+			// The cancellation is already stored; stop the run so it keeps its partial output
+			s.running.cancel(publicID, func() error { return nil })
+			return
+		}
+	}
+}
+
+func (s *ServiceImpl) notifyFinished(run *responseRun) {
+	if run.params.StreamObserver != nil {
+		run.params.StreamObserver.OnResponseFinished(run.response)
+	}
+}
+
+// orchestrate runs the model and tool loop for a created response and stores its result.
+func (s *ServiceImpl) orchestrate(ctx context.Context, run *responseRun) (*Response, error) {
+	params := run.params
+
+	baseMessages, err := s.buildBaseMessages(params.SystemPrompt, run.existingItems)
+	if err != nil {
+		return s.settle(ctx, run, nil, 0, fmt.Errorf("build base messages: %w", err))
+	}
+
+	messages := append(baseMessages, run.userMessages...)
+	initialLength := len(messages)
+
+	toolDefs := run.tools.definitions
+	var observer tool.StreamObserver
+	if run.output != nil {
+		observer = run.output
+	}
 	execParams := func(defs []llm.ToolDefinition, toolChoice *llm.ToolChoice) tool.ExecuteParams {
 		return tool.ExecuteParams{
 			Ctx:             ctx,
@@ -143,41 +419,204 @@ func (s *ServiceImpl) Create(ctx context.Context, params CreateParams) (*Respons
 			MaxTokens:       params.MaxTokens,
 			ToolChoice:      toolChoice,
 			ToolDefinitions: defs,
-			StreamObserver:  params.StreamObserver,
+			ResponseFormat:  params.ResponseFormat,
+			StreamObserver:  observer,
+			ClientTools:     run.tools.client,
+			MCPTools:        run.tools.mcp,
+			ApprovalTools:   run.tools.approval,
+			Approvals:       run.approvals,
 		}
 	}
 
 	orchestratorResult, err := s.orchestrator.Execute(execParams(toolDefs, params.ToolChoice))
-	if err != nil && shouldRetryWithoutTools(err) && len(toolDefs) > 0 {
-		s.log.Warn().Err(err).Str("response_id", responseModel.PublicID).Msg("llm provider rejected tool definitions, retrying without tools")
+	// Approved calls already ran in the failed attempt, so a retry would run them again
+	if err != nil && ctx.Err() == nil && shouldRetryWithoutTools(err) && len(toolDefs) > 0 && len(run.approvals) == 0 {
+		s.log.Warn().Err(err).Str("response_id", run.response.PublicID).Msg("llm provider rejected tool definitions, retrying without tools")
 		orchestratorResult, err = s.orchestrator.Execute(execParams(nil, nil))
 	}
-	if err != nil {
+	if err == nil && params.ResponseFormat != nil {
+		repair := execParams(nil, nil)
+		repair.Approvals = nil
+		orchestratorResult, err = s.enforceFormat(run, orchestratorResult, repair)
+	}
+	return s.settle(ctx, run, orchestratorResult, initialLength, err)
+}
+
+// enforceFormat checks the final message of a finished run against the requested response format.
+// A mismatching message is handed back to the model with the problem, up to formatRepairAttempts
+// times, using the repair params without tools. Rejected messages are left out of the output and
+// the conversation history.
+func (s *ServiceImpl) enforceFormat(run *responseRun, result *tool.ExecuteResult, repair tool.ExecuteParams) (*tool.ExecuteResult, error) {
+	if len(result.PendingCalls) > 0 || len(result.ApprovalRequests) > 0 {
+		return result, nil
+	}
+
+	// Messages before the final one, which is replaced by each repair
+	kept := result.Messages[:len(result.Messages)-1]
+	for attempt := 0; ; attempt++ {
+		problem := checkFormat(run.params.ResponseFormat, contentText(result.FinalMessage.Content))
+		if problem == nil {
+			return result, nil
+		}
+		if attempt == formatRepairAttempts {
+			return result, fmt.Errorf("%w: %v", ErrInvalidStructuredOutput, problem)
+		}
+
+		s.log.Warn().Str("response_id", run.response.PublicID).Str("problem", problem.Error()).Msg("final message does not match the response format, asking for a repair")
+		if run.output != nil {
+			run.output.discardMessage()
+		}
+		repair.Messages = append(append([]llm.ChatMessage(nil), result.Messages...), repairPrompt(problem))
+		repaired, err := s.orchestrator.Execute(repair)
+		if err != nil {
+			return result, err
+		}
+		result = &tool.ExecuteResult{
+			FinalMessage: repaired.FinalMessage,
+			Messages:     append(append([]llm.ChatMessage(nil), kept...), repaired.FinalMessage),
+			Usage:        sumUsage(result.Usage, repaired.Usage),
+			Executions:   result.Executions,
+		}
+	}
+}
+
+// settle stores the final state of a run. A cancelled run keeps the cancelled status with the
+// partial output of result; otherwise failure marks it failed, and result completes it or pauses
+// it for client tool outputs. Writes use a context without ctx's cancellation so they outlive it.
+func (s *ServiceImpl) settle(ctx context.Context, run *responseRun, result *tool.ExecuteResult, initialLength int, failure error) (*Response, error) {
+	storeCtx := context.WithoutCancel(ctx)
+
+	var resp *Response
+	var err error
+	run.active.settle(ctx, func(cancelled bool) error {
+		switch {
+		case cancelled:
+			resp, err = s.storeCancelled(storeCtx, run, result, initialLength)
+			s.releaseContinuation(storeCtx, run)
+		case failure != nil:
+			resp, err = s.failResponse(storeCtx, run.response, failure)
+			s.releaseContinuation(storeCtx, run)
+		default:
+			resp, err = s.storeCompleted(storeCtx, run, result, initialLength)
+		}
+		return err
+	})
+	return resp, err
+}
+
+func (s *ServiceImpl) storeCompleted(ctx context.Context, run *responseRun, result *tool.ExecuteResult, initialLength int) (*Response, error) {
+	responseModel := run.response
+
+	now := time.Now()
+	if len(result.PendingCalls) > 0 || len(result.ApprovalRequests) > 0 {
+		// Pause until the client posts function_call_output and mcp_approval_response items with
+		// previous_response_id
+		responseModel.Status = StatusRequiresAction
+		responseModel.RequiredAction = newRequiredAction(result.PendingCalls, newApprovalRequests(result.ApprovalRequests, run.tools.labels))
+	} else {
+		responseModel.Status = StatusCompleted
+		responseModel.CompletedAt = &now
+	}
+	responseModel.Output = s.runOutput(run, result, initialLength, OutputStatusCompleted)
+	responseModel.Usage = result.Usage
+	responseModel.UpdatedAt = now
+
+	// The turn goes into the conversation first. A response whose turn is missing there fails, so
+	// no later turn continues from a history without it
+	if err := s.storeTurn(ctx, run, result, initialLength); err != nil {
+		responseModel.CompletedAt = nil
+		responseModel.RequiredAction = nil
+		s.releaseContinuation(ctx, run)
 		return s.failResponse(ctx, responseModel, err)
 	}
 
-	responseModel.Status = StatusCompleted
-	responseModel.Output = orchestratorResult.FinalMessage.Content
-	responseModel.Usage = orchestratorResult.Usage
+	stored, err := s.responses.UpdateUnlessCancelled(ctx, responseModel)
+	if err != nil {
+		return nil, err
+	}
+	if !stored {
+		// Cancelled through another instance before the run could store its result
+		return s.keepCancelled(ctx, run)
+	}
+
+	if run.continues {
+		s.completeRequiredAction(ctx, run.prevResp)
+	}
+
+	return responseModel, nil
+}
+
+// storeCancelled keeps the partial output of a cancelled run. result is nil when the run was
+// cancelled before the model produced anything.
+func (s *ServiceImpl) storeCancelled(ctx context.Context, run *responseRun, result *tool.ExecuteResult, initialLength int) (*Response, error) {
+	responseModel := run.response
+
 	now := time.Now()
-	responseModel.CompletedAt = &now
+	responseModel.Status = StatusCancelled
+	responseModel.CancelledAt = &now
+	responseModel.RequiredAction = nil
 	responseModel.UpdatedAt = now
+	responseModel.Output = s.runOutput(run, result, initialLength, OutputStatusIncomplete)
+	if result != nil {
+		responseModel.Usage = result.Usage
+	}
 
 	if err := s.responses.Update(ctx, responseModel); err != nil {
 		return nil, err
 	}
+	if err := s.storeTurn(ctx, run, result, initialLength); err != nil {
+		s.log.Error().Err(err).Str("response_id", responseModel.PublicID).Msg("store turn of cancelled response failed")
+	}
+	return responseModel, nil
+}
 
-	if err := s.toolExecutions.RecordExecutions(ctx, responseModel.ID, orchestratorResult.Executions); err != nil {
-		s.log.Error().Err(err).Str("response_id", responseModel.PublicID).Msg("store tool executions failed")
+// keepCancelled stores the finished output of a run whose response was cancelled in the meantime,
+// keeping the cancelled status. The turn is already in the conversation.
+func (s *ServiceImpl) keepCancelled(ctx context.Context, run *responseRun) (*Response, error) {
+	responseModel := run.response
+
+	now := time.Now()
+	responseModel.Status = StatusCancelled
+	responseModel.CancelledAt = &now
+	responseModel.CompletedAt = nil
+	responseModel.RequiredAction = nil
+	responseModel.UpdatedAt = now
+
+	if err := s.responses.Update(ctx, responseModel); err != nil {
+		return nil, err
 	}
+	s.releaseContinuation(ctx, run)
+	return responseModel, nil
+}
 
-	newMessages := orchestratorResult.Messages[initialLength:]
-	newItems := append(convoItems, s.convertMessagesToItems(conv.ID, len(existingItems)+len(convoItems), newMessages)...)
-	if err := s.conversationItems.BulkInsert(ctx, newItems); err != nil {
-		s.log.Error().Err(err).Str("response_id", responseModel.PublicID).Msg("store conversation items failed")
+// runOutput returns the output items of a run, closing items still open with status. Streaming
+// runs keep the items already sent to the observer; otherwise they are built from result.
+func (s *ServiceImpl) runOutput(run *responseRun, result *tool.ExecuteResult, initialLength int, status string) []OutputItem {
+	if run.output != nil {
+		return run.output.finish(status)
 	}
+	if result == nil {
+		return nil
+	}
+	return buildOutput(result.Messages[initialLength:], result.Executions, run.tools, status)
+}
 
-	return responseModel, nil
+// storeTurn records the tool executions of a run and appends its input and generated messages to
+// the conversation. Only a failure to append to the conversation is returned.
+func (s *ServiceImpl) storeTurn(ctx context.Context, run *responseRun, result *tool.ExecuteResult, initialLength int) error {
+	var newMessages []llm.ChatMessage
+	if result != nil {
+		if err := s.toolExecutions.RecordExecutions(ctx, run.response.ID, result.Executions); err != nil {
+			s.log.Error().Err(err).Str("response_id", run.response.PublicID).Msg("store tool executions failed")
+		}
+		newMessages = result.Messages[initialLength:]
+	}
+
+	newItems := messagesToItems(append(append([]llm.ChatMessage(nil), run.userMessages...), newMessages...))
+	if err := s.conversationItems.BulkInsert(ctx, run.conv.PublicID, newItems); err != nil {
+		return fmt.Errorf("store conversation items: %w", err)
+	}
+	return nil
 }
 
 // GetByPublicID returns the response by id.
@@ -185,16 +624,38 @@ func (s *ServiceImpl) GetByPublicID(ctx context.Context, publicID string) (*Resp
 	return s.responses.FindByPublicID(ctx, publicID)
 }
 
-// Cancel marks the response as cancelled.
+// Cancel marks a queued or in-progress response as cancelled; other responses are returned
+// unchanged. A response running on this instance is aborted; it then stores the output produced
+// so far and keeps the cancelled status.
 func (s *ServiceImpl) Cancel(ctx context.Context, publicID string) (*Response, error) {
+	var resp *Response
+	markCancelled := func() error {
+		var err error
+		resp, err = s.markCancelled(ctx, publicID)
+		return err
+	}
+
+	stopped, err := s.running.cancel(publicID, markCancelled)
+	if !stopped {
+		err = markCancelled()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *ServiceImpl) markCancelled(ctx context.Context, publicID string) (*Response, error) {
 	resp, err := s.responses.FindByPublicID(ctx, publicID)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.Status == StatusCompleted || resp.Status == StatusCancelled {
+	// Only queued and running responses can be cancelled; finished ones keep their status
+	if resp.Status != StatusPending && resp.Status != StatusInProgress {
 		return resp, nil
 	}
+	resp.RequiredAction = nil
 
 	if err := s.responses.MarkCancelled(ctx, resp); err != nil {
 		return nil, err
@@ -202,44 +663,94 @@ func (s *ServiceImpl) Cancel(ctx context.Context, publicID string) (*Response, e
 	return resp, nil
 }
 
-// ListConversationItems returns the textual conversation history for the response.
-func (s *ServiceImpl) ListConversationItems(ctx context.Context, publicID string) ([]ConversationItem, error) {
+// ListConversationItems returns the items of the conversation of userID's response, oldest first.
+func (s *ServiceImpl) ListConversationItems(ctx context.Context, userID, publicID string) ([]conversation.Item, error) {
 	resp, err := s.responses.FindByPublicID(ctx, publicID)
 	if err != nil {
 		return nil, err
 	}
+	if resp.UserID != userID {
+		// Another user's response is reported like a missing one
+		return nil, ErrResponseNotFound
+	}
 
-	if resp.ConversationID == nil {
+	if resp.ConversationPublicID == nil && resp.LegacyConversationID != nil {
+		messages, err := s.legacyMessages(ctx, *resp.LegacyConversationID)
+		if err != nil {
+			return nil, err
+		}
+		return messagesToItems(messages), nil
+	}
+	if resp.ConversationPublicID == nil {
 		return nil, errors.New("response has no conversation")
 	}
 
-	items, err := s.conversationItems.ListByConversationID(ctx, *resp.ConversationID)
+	return s.conversationItems.ListByConversationID(ctx, *resp.ConversationPublicID)
+}
+
+// moveLegacyConversation copies the history of a response created before conversations moved to
+// llm-api into a new llm-api conversation of the caller, and points the responses of the legacy
+// conversation at it. The legacy rows stay untouched.
+func (s *ServiceImpl) moveLegacyConversation(ctx context.Context, prev *Response) (*conversation.Conversation, error) {
+	messages, err := s.legacyMessages(ctx, *prev.LegacyConversationID)
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([]ConversationItem, 0, len(items))
-	for _, item := range items {
-		result = append(result, ConversationItem{
-			Role:    string(item.Role),
-			Content: item.Content,
-			Status:  string(item.Status),
-		})
+	conv := &conversation.Conversation{}
+	if err := s.conversations.Create(ctx, conv); err != nil {
+		return nil, fmt.Errorf("create conversation: %w", err)
+	}
+	if err := s.conversationItems.BulkInsert(ctx, conv.PublicID, messagesToItems(messages)); err != nil {
+		return nil, fmt.Errorf("copy legacy items: %w", err)
+	}
+
+	assigned, err := s.legacy.AssignConversation(ctx, *prev.LegacyConversationID, conv.PublicID)
+	if err != nil {
+		return nil, fmt.Errorf("assign conversation: %w", err)
+	}
+	prev.ConversationPublicID = &assigned
+	if assigned != conv.PublicID {
+		// Another request moved the conversation first; continue in its copy
+		s.log.Warn().Str("conversation_id", conv.PublicID).Str("moved_to", assigned).Msg("legacy conversation moved concurrently, leaving an unused copy")
+		return s.conversations.FindByPublicID(ctx, assigned)
+	}
+	return conv, nil
+}
+
+func (s *ServiceImpl) legacyMessages(ctx context.Context, legacyConversationID uint) ([]llm.ChatMessage, error) {
+	if s.legacy == nil {
+		return nil, nil
 	}
-	return result, nil
+	messages, err := s.legacy.ListLegacyMessages(ctx, legacyConversationID)
+	if err != nil {
+		return nil, fmt.Errorf("list legacy conversation items: %w", err)
+	}
+	return messages, nil
 }
 
 func (s *ServiceImpl) failResponse(ctx context.Context, resp *Response, failure error) (*Response, error) {
 	now := time.Now()
 	resp.Status = StatusFailed
 	resp.FailedAt = &now
+	code := "response_failed"
+	if errors.Is(failure, ErrInvalidStructuredOutput) {
+		code = "invalid_structured_output"
+	}
 	resp.Error = &ErrorDetails{
-		Code:    "response_failed",
+		Code:    code,
 		Message: failure.Error(),
 	}
-	if err := s.responses.Update(ctx, resp); err != nil {
+	stored, err := s.responses.UpdateUnlessCancelled(ctx, resp)
+	if err != nil {
 		s.log.Error().Err(err).Str("response_id", resp.PublicID).Msg("update failed response")
 	}
+	if err == nil && !stored {
+		// A cancellation stored in the meantime wins over the failure
+		resp.Status = StatusCancelled
+		resp.FailedAt = nil
+		resp.Error = nil
+	}
 	return nil, failure
 }
 
@@ -252,112 +763,153 @@ func (s *ServiceImpl) buildBaseMessages(systemPrompt *string, items []conversati
 		})
 	}
 
-	for _, item := range items {
-		messages = append(messages, llm.ChatMessage{
-			Role:    string(item.Role),
-			Content: contentToLLM(item.Content),
-		})
-	}
-	return messages, nil
+	return append(messages, itemsToMessages(items)...), nil
 }
 
-func (s *ServiceImpl) convertInputToMessages(conversationID uint, startingSeq int, input interface{}) ([]llm.ChatMessage, []conversation.Item, error) {
+// convertInputToMessages maps request input to chat messages. function_call items echoing the
+// pending calls of the previous response are skipped, since its history already holds them.
+func (s *ServiceImpl) convertInputToMessages(input interface{}, pendingCalls map[string]bool) ([]llm.ChatMessage, error) {
 	var messages []llm.ChatMessage
-	var convoItems []conversation.Item
 
 	switch v := input.(type) {
 	case string:
-		msg := llm.ChatMessage{Role: "user", Content: strings.TrimSpace(v)}
-		messages = append(messages, msg)
-		convoItems = append(convoItems, newConversationItem(conversationID, startingSeq, msg))
+		messages = append(messages, llm.ChatMessage{Role: "user", Content: strings.TrimSpace(v)})
 	case []interface{}:
 		for _, raw := range v {
+			if isPendingCallEcho(raw, pendingCalls) {
+				continue
+			}
 			msg, err := mapToChatMessage(raw)
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 			messages = append(messages, msg)
-			convoItems = append(convoItems, newConversationItem(conversationID, startingSeq+len(convoItems), msg))
 		}
 	case map[string]interface{}:
 		msg, err := mapToChatMessage(v)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		messages = append(messages, msg)
-		convoItems = append(convoItems, newConversationItem(conversationID, startingSeq, msg))
 	default:
 		bytes, _ := json.Marshal(input)
-		msg := llm.ChatMessage{
+		messages = append(messages, llm.ChatMessage{
 			Role:    "user",
 			Content: string(bytes),
-		}
-		messages = append(messages, msg)
-		convoItems = append(convoItems, newConversationItem(conversationID, startingSeq, msg))
+		})
 	}
 
-	return messages, convoItems, nil
+	return messages, nil
 }
 
-func (s *ServiceImpl) convertMessagesToItems(conversationID uint, startingSeq int, messages []llm.ChatMessage) []conversation.Item {
-	items := make([]conversation.Item, 0, len(messages))
-	for _, msg := range messages {
-		items = append(items, newConversationItem(conversationID, startingSeq+len(items), msg))
+// claimRequiredAction moves a requires_action response to in_progress while a continuation runs,
+// failing when a concurrent request already claimed it.
+func (s *ServiceImpl) claimRequiredAction(ctx context.Context, resp *Response) error {
+	claimed, err := s.responses.TransitionStatus(ctx, resp.PublicID, StatusRequiresAction, StatusInProgress)
+	if err != nil {
+		return fmt.Errorf("claim previous response: %w", err)
+	}
+	if !claimed {
+		return fmt.Errorf("%w: %q", ErrRequiredActionTaken, resp.PublicID)
 	}
-	return items
+	return nil
 }
 
-func (s *ServiceImpl) fetchAvailableTools(ctx context.Context) ([]llm.ToolDefinition, error) {
-	mcpTools, err := s.mcpClient.ListTools(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("list MCP tools: %w", err)
+// releaseRequiredAction returns a claimed response to requires_action so its function call
+// outputs and approval decisions can be submitted again.
+func (s *ServiceImpl) releaseRequiredAction(ctx context.Context, resp *Response) {
+	if _, err := s.responses.TransitionStatus(ctx, resp.PublicID, StatusInProgress, StatusRequiresAction); err != nil {
+		s.log.Error().Err(err).Str("response_id", resp.PublicID).Msg("release required action failed")
 	}
+}
 
-	defs := make([]llm.ToolDefinition, 0, len(mcpTools))
-	for _, tool := range mcpTools {
-		defs = append(defs, tool.ToLLMTool())
+// releaseContinuation releases the previous response of a run that did not complete.
+func (s *ServiceImpl) releaseContinuation(ctx context.Context, run *responseRun) {
+	if run.continues {
+		s.releaseRequiredAction(ctx, run.prevResp)
 	}
-	return defs, nil
 }
 
-func newConversationItem(conversationID uint, sequence int, msg llm.ChatMessage) conversation.Item {
-	content := normalizeContent(msg.Content)
-	role := conversation.ItemRole(msg.Role)
-	if role == "" {
-		role = conversation.RoleUser
+// completeRequiredAction marks a claimed requires_action response as completed once its function
+// call outputs and approval decisions were submitted and the continuation succeeded.
+func (s *ServiceImpl) completeRequiredAction(ctx context.Context, resp *Response) {
+	now := time.Now()
+	resp.Status = StatusCompleted
+	resp.CompletedAt = &now
+	resp.UpdatedAt = now
+	if err := s.responses.Update(ctx, resp); err != nil {
+		s.log.Error().Err(err).Str("response_id", resp.PublicID).Msg("complete required action failed")
+	}
+}
+
+func newRequiredAction(calls []tool.Call, approvals []ApprovalRequest) *RequiredAction {
+	toolCalls := make([]FunctionCall, 0, len(calls))
+	for _, call := range calls {
+		toolCalls = append(toolCalls, FunctionCall{
+			Type:      OutputItemFunctionCall,
+			CallID:    call.ID,
+			Name:      call.Name,
+			Arguments: callArguments(call),
+		})
 	}
-	return conversation.Item{
-		ConversationID: conversationID,
-		Role:           role,
-		Status:         conversation.ItemStatusCompleted,
-		Content:        content,
-		Sequence:       sequence + 1,
-		CreatedAt:      time.Now(),
+	actionType := RequiredActionSubmitToolOutputs
+	if len(toolCalls) == 0 {
+		actionType = RequiredActionMCPApproval
+	}
+	return &RequiredAction{
+		Type:              actionType,
+		SubmitToolOutputs: SubmitToolOutputs{ToolCalls: toolCalls},
+		ApprovalRequests:  approvals,
 	}
 }
 
-func contentToLLM(content map[string]interface{}) interface{} {
-	if content == nil {
+// pendingCallIDs returns the call IDs a requires_action response is waiting on.
+func pendingCallIDs(resp *Response) map[string]bool {
+	if resp == nil || resp.Status != StatusRequiresAction || resp.RequiredAction == nil {
 		return nil
 	}
-	if text, ok := content["text"]; ok {
-		return text
+	ids := make(map[string]bool, len(resp.RequiredAction.SubmitToolOutputs.ToolCalls))
+	for _, call := range resp.RequiredAction.SubmitToolOutputs.ToolCalls {
+		ids[call.CallID] = true
 	}
-	return content
+	return ids
 }
 
-func normalizeContent(content interface{}) map[string]interface{} {
-	switch v := content.(type) {
-	case string:
-		return map[string]interface{}{"type": "text", "text": v}
-	case map[string]interface{}:
-		return v
-	case []interface{}:
-		return map[string]interface{}{"type": "list", "items": v}
-	default:
-		bytes, _ := json.Marshal(v)
-		return map[string]interface{}{"type": "json", "text": string(bytes)}
+// checkToolOutputs ensures every pending call has an output and every output answers either a
+// pending call or a function call given in the same input.
+func checkToolOutputs(pendingCalls map[string]bool, messages []llm.ChatMessage) error {
+	known := make(map[string]bool, len(pendingCalls))
+	for id := range pendingCalls {
+		known[id] = true
+	}
+	submitted := make(map[string]bool)
+	for _, msg := range messages {
+		for _, call := range msg.ToolCalls {
+			known[call.ID] = true
+		}
+		if msg.Role != string(conversation.RoleTool) || msg.ToolCallID == nil {
+			continue
+		}
+		if !known[*msg.ToolCallID] {
+			return fmt.Errorf("%w: function_call_output %q does not match a function call", ErrInvalidInput, *msg.ToolCallID)
+		}
+		submitted[*msg.ToolCallID] = true
+	}
+	for id := range pendingCalls {
+		if !submitted[id] {
+			return fmt.Errorf("%w: missing function_call_output for call %q", ErrInvalidInput, id)
+		}
 	}
+	return nil
+}
+
+func isPendingCallEcho(raw interface{}, pendingCalls map[string]bool) bool {
+	payload, ok := raw.(map[string]interface{})
+	if !ok || payload["type"] != "function_call" {
+		return false
+	}
+	callID, _ := payload["call_id"].(string)
+	return pendingCalls[callID]
 }
 
 func mapToChatMessage(raw interface{}) (llm.ChatMessage, error) {
@@ -366,6 +918,13 @@ func mapToChatMessage(raw interface{}) (llm.ChatMessage, error) {
 		return llm.ChatMessage{}, errors.New("input items must be objects with role/content")
 	}
 
+	switch payload["type"] {
+	case "function_call_output":
+		return functionCallOutputToMessage(payload)
+	case "function_call":
+		return functionCallToMessage(payload)
+	}
+
 	role, _ := payload["role"].(string)
 	if role == "" {
 		role = "user"
@@ -384,6 +943,56 @@ func mapToChatMessage(raw interface{}) (llm.ChatMessage, error) {
 	}, nil
 }
 
+// functionCallOutputToMessage maps a function_call_output input item to a tool message.
+func functionCallOutputToMessage(payload map[string]interface{}) (llm.ChatMessage, error) {
+	callID, _ := payload["call_id"].(string)
+	if callID == "" {
+		return llm.ChatMessage{}, errors.New("function_call_output item missing call_id")
+	}
+
+	output, ok := payload["output"].(string)
+	if !ok {
+		bytes, err := json.Marshal(payload["output"])
+		if err != nil {
+			return llm.ChatMessage{}, fmt.Errorf("marshal function_call_output: %w", err)
+		}
+		output = string(bytes)
+	}
+
+	return llm.ChatMessage{
+		Role:       string(conversation.RoleTool),
+		Content:    output,
+		ToolCallID: &callID,
+	}, nil
+}
+
+// functionCallToMessage maps a function_call input item to an assistant message requesting it.
+func functionCallToMessage(payload map[string]interface{}) (llm.ChatMessage, error) {
+	callID, _ := payload["call_id"].(string)
+	name, _ := payload["name"].(string)
+	if callID == "" || name == "" {
+		return llm.ChatMessage{}, errors.New("function_call item missing call_id or name")
+	}
+
+	arguments, _ := payload["arguments"].(string)
+	encoded, err := json.Marshal(arguments)
+	if err != nil {
+		return llm.ChatMessage{}, fmt.Errorf("marshal function_call arguments: %w", err)
+	}
+
+	return llm.ChatMessage{
+		Role: string(conversation.RoleAssistant),
+		ToolCalls: []llm.ToolCall{{
+			ID:   callID,
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:      name,
+				Arguments: encoded,
+			},
+		}},
+	}, nil
+}
+
 func newPublicID(prefix string) string {
 	return fmt.Sprintf("%s_%s", prefix, uuid.NewString())
 }
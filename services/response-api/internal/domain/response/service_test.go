@@ -0,0 +1,494 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"jan-server/services/response-api/internal/domain/conversation"
+	"jan-server/services/response-api/internal/domain/llm"
+	"jan-server/services/response-api/internal/domain/tool"
+)
+
+// memoryResponses is an in-memory Repository for service tests.
+type memoryResponses struct {
+	mu        sync.Mutex
+	responses map[string]Response
+	nextID    uint
+}
+
+func newMemoryResponses(responses ...Response) *memoryResponses {
+	repo := &memoryResponses{responses: make(map[string]Response)}
+	for _, resp := range responses {
+		repo.nextID++
+		resp.ID = repo.nextID
+		repo.responses[resp.PublicID] = resp
+	}
+	return repo
+}
+
+func (r *memoryResponses) Create(_ context.Context, resp *Response) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	resp.ID = r.nextID
+	r.responses[resp.PublicID] = *resp
+	return nil
+}
+
+func (r *memoryResponses) Update(_ context.Context, resp *Response) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses[resp.PublicID] = *resp
+	return nil
+}
+
+func (r *memoryResponses) UpdateUnlessCancelled(_ context.Context, resp *Response) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.responses[resp.PublicID]; ok && stored.Status == StatusCancelled {
+		return false, nil
+	}
+	r.responses[resp.PublicID] = *resp
+	return true, nil
+}
+
+func (r *memoryResponses) FindStatus(_ context.Context, publicID string) (Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resp, ok := r.responses[publicID]
+	if !ok {
+		return "", ErrResponseNotFound
+	}
+	return resp.Status, nil
+}
+
+func (r *memoryResponses) FindByPublicID(_ context.Context, publicID string) (*Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resp, ok := r.responses[publicID]
+	if !ok {
+		return nil, ErrResponseNotFound
+	}
+	return &resp, nil
+}
+
+func (r *memoryResponses) MarkCancelled(_ context.Context, resp *Response) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resp.Status = StatusCancelled
+	r.responses[resp.PublicID] = *resp
+	return nil
+}
+
+func (r *memoryResponses) TransitionStatus(_ context.Context, publicID string, from, to Status) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resp, ok := r.responses[publicID]
+	if !ok || resp.Status != from {
+		return false, nil
+	}
+	resp.Status = to
+	r.responses[publicID] = resp
+	return true, nil
+}
+
+func (r *memoryResponses) FailInterrupted(_ context.Context, before time.Time, details ErrorDetails) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var failed int64
+	for id, resp := range r.responses {
+		if !resp.Background || (resp.Status != StatusPending && resp.Status != StatusInProgress) || !resp.UpdatedAt.Before(before) {
+			continue
+		}
+		resp.Status = StatusFailed
+		resp.Error = &details
+		r.responses[id] = resp
+		failed++
+	}
+	return failed, nil
+}
+
+func (r *memoryResponses) status(publicID string) Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.responses[publicID].Status
+}
+
+func TestClaimRequiredActionAdmitsOneContinuation(t *testing.T) {
+	repo := newMemoryResponses(Response{PublicID: "resp_prev", Status: StatusRequiresAction})
+	service := &ServiceImpl{responses: repo, log: zerolog.Nop()}
+	prev, _ := repo.FindByPublicID(context.Background(), "resp_prev")
+
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- service.claimRequiredAction(context.Background(), prev)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	claimed := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			claimed++
+		case !errors.Is(err, ErrRequiredActionTaken):
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if claimed != 1 || repo.status("resp_prev") != StatusInProgress {
+		t.Fatalf("expected exactly one claim, got %d with status %s", claimed, repo.status("resp_prev"))
+	}
+}
+
+func TestReleaseContinuationRestoresRequiredAction(t *testing.T) {
+	repo := newMemoryResponses(Response{PublicID: "resp_prev", Status: StatusRequiresAction})
+	service := &ServiceImpl{responses: repo, log: zerolog.Nop()}
+	prev, _ := repo.FindByPublicID(context.Background(), "resp_prev")
+
+	if err := service.claimRequiredAction(context.Background(), prev); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service.releaseContinuation(context.Background(), &responseRun{prevResp: prev, continues: true})
+	if got := repo.status("resp_prev"); got != StatusRequiresAction {
+		t.Fatalf("expected requires_action after release, got %s", got)
+	}
+	if err := service.claimRequiredAction(context.Background(), prev); err != nil {
+		t.Fatalf("expected released response to be claimable again: %v", err)
+	}
+}
+
+// recordingObserver records the lifecycle events of a response.
+type recordingObserver struct {
+	mu       sync.Mutex
+	finished []*Response
+}
+
+func (o *recordingObserver) OnResponseCreated(*Response)           {}
+func (o *recordingObserver) OnResponseInProgress(*Response)        {}
+func (o *recordingObserver) OnOutputItemAdded(int, OutputItem)     {}
+func (o *recordingObserver) OnOutputTextDelta(int, string, string) {}
+func (o *recordingObserver) OnReasoningDelta(int, string, string)  {}
+func (o *recordingObserver) OnOutputItemDone(int, OutputItem)      {}
+func (o *recordingObserver) OnResponseFinished(resp *Response) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	snapshot := *resp
+	o.finished = append(o.finished, &snapshot)
+}
+
+func TestRunBackgroundFailsWhenReloadFails(t *testing.T) {
+	// The response was never stored, so reloading it fails
+	repo := newMemoryResponses()
+	service := &ServiceImpl{responses: repo, running: newRunRegistry(), log: zerolog.Nop()}
+	observer := &recordingObserver{}
+	run := &responseRun{
+		params:   CreateParams{StreamObserver: observer},
+		response: &Response{PublicID: "resp_lost", Status: StatusPending, Background: true},
+		active:   service.running.track("resp_lost"),
+	}
+
+	service.runBackground(context.Background(), run)
+
+	if got := repo.status("resp_lost"); got != StatusFailed {
+		t.Fatalf("expected the response to be failed, got %q", got)
+	}
+	if len(observer.finished) != 1 || observer.finished[0].Status != StatusFailed {
+		t.Fatalf("expected one finished notification with the failed response, got %+v", observer.finished)
+	}
+	if stopped, _ := service.running.cancel("resp_lost", func() error { return nil }); stopped {
+		t.Fatal("expected the response to be untracked")
+	}
+}
+
+func TestSweepInterruptedFailsStaleBackgroundResponses(t *testing.T) {
+	stale := time.Now().Add(-time.Hour)
+	repo := newMemoryResponses(
+		Response{PublicID: "resp_stale", Status: StatusInProgress, Background: true, UpdatedAt: stale},
+		Response{PublicID: "resp_queued", Status: StatusPending, Background: true, UpdatedAt: stale},
+		Response{PublicID: "resp_recent", Status: StatusInProgress, Background: true, UpdatedAt: time.Now()},
+		Response{PublicID: "resp_foreground", Status: StatusInProgress, UpdatedAt: stale},
+	)
+	service := &ServiceImpl{responses: repo, log: zerolog.Nop()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.SweepInterrupted(ctx, 30*time.Minute, time.Minute)
+
+	for id, want := range map[string]Status{
+		"resp_stale":      StatusFailed,
+		"resp_queued":     StatusFailed,
+		"resp_recent":     StatusInProgress,
+		"resp_foreground": StatusInProgress,
+	} {
+		if got := repo.status(id); got != want {
+			t.Fatalf("expected %s to be %s, got %s", id, want, got)
+		}
+	}
+}
+
+func TestWatchCancellationStopsRunCancelledElsewhere(t *testing.T) {
+	defer func(interval time.Duration) { cancelPollInterval = interval }(cancelPollInterval)
+	cancelPollInterval = 5 * time.Millisecond
+
+	repo := newMemoryResponses(Response{PublicID: "resp_remote", Status: StatusInProgress})
+	service := &ServiceImpl{responses: repo, running: newRunRegistry(), log: zerolog.Nop()}
+	active := service.running.track("resp_remote")
+	runCtx, cancel := active.begin(context.Background())
+	defer cancel(nil)
+	go service.watchCancellation(runCtx, "resp_remote")
+
+	// Another instance cancels the response in the database only
+	resp, _ := repo.FindByPublicID(context.Background(), "resp_remote")
+	_ = repo.MarkCancelled(context.Background(), resp)
+
+	select {
+	case <-runCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the run to be cancelled")
+	}
+	if !errors.Is(context.Cause(runCtx), ErrResponseCancelled) {
+		t.Fatalf("expected ErrResponseCancelled as the cause, got %v", context.Cause(runCtx))
+	}
+}
+
+func TestFinalStateKeepsRemoteCancellation(t *testing.T) {
+	repo := newMemoryResponses(Response{PublicID: "resp_remote", Status: StatusCancelled})
+	service := &ServiceImpl{responses: repo, conversationItems: &memoryItems{}, toolExecutions: noExecutions{}, log: zerolog.Nop()}
+	stored, _ := repo.FindByPublicID(context.Background(), "resp_remote")
+	stored.Status = StatusInProgress
+
+	run := &responseRun{response: stored, conv: &conversation.Conversation{PublicID: "conv_1"}, tools: &toolSet{}}
+	result := &tool.ExecuteResult{
+		FinalMessage: llm.ChatMessage{Role: "assistant", Content: "done"},
+		Messages:     []llm.ChatMessage{{Role: "assistant", Content: "done"}},
+	}
+	resp, err := service.storeCompleted(context.Background(), run, result, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != StatusCancelled || repo.status("resp_remote") != StatusCancelled {
+		t.Fatalf("expected the cancellation to be kept, got %s and stored %s", resp.Status, repo.status("resp_remote"))
+	}
+
+	failed := &Response{PublicID: "resp_remote", Status: StatusInProgress}
+	_, _ = service.failResponse(context.Background(), failed, errors.New("upstream failed"))
+	if failed.Status != StatusCancelled || repo.status("resp_remote") != StatusCancelled {
+		t.Fatalf("expected the failure not to overwrite the cancellation, got %s", failed.Status)
+	}
+}
+
+func TestCancelOnlyCancelsQueuedAndRunningResponses(t *testing.T) {
+	repo := newMemoryResponses(
+		Response{PublicID: "resp_pending", Status: StatusPending},
+		Response{PublicID: "resp_running", Status: StatusInProgress},
+		Response{PublicID: "resp_action", Status: StatusRequiresAction, RequiredAction: &RequiredAction{}},
+		Response{PublicID: "resp_failed", Status: StatusFailed},
+		Response{PublicID: "resp_done", Status: StatusCompleted},
+	)
+	service := &ServiceImpl{responses: repo, running: newRunRegistry(), log: zerolog.Nop()}
+
+	expected := map[string]Status{
+		"resp_pending": StatusCancelled,
+		"resp_running": StatusCancelled,
+		"resp_action":  StatusRequiresAction,
+		"resp_failed":  StatusFailed,
+		"resp_done":    StatusCompleted,
+	}
+	for id, status := range expected {
+		resp, err := service.Cancel(context.Background(), id)
+		if err != nil {
+			t.Fatalf("cancel %s: %v", id, err)
+		}
+		if resp.Status != status || repo.status(id) != status {
+			t.Fatalf("expected %s to end %s, got %s and stored %s", id, status, resp.Status, repo.status(id))
+		}
+	}
+	if resp, _ := repo.FindByPublicID(context.Background(), "resp_action"); resp.RequiredAction == nil {
+		t.Fatal("expected the required action of a response waiting for action to be kept")
+	}
+}
+
+func TestCreateRejectsPreviousResponseOfAnotherUser(t *testing.T) {
+	repo := newMemoryResponses(Response{PublicID: "resp_other", UserID: "user_b", Status: StatusCompleted})
+	service := &ServiceImpl{responses: repo, log: zerolog.Nop()}
+
+	for _, previous := range []string{"resp_other", "resp_missing"} {
+		_, err := service.Create(context.Background(), CreateParams{UserID: "user_a", PreviousResponseID: &previous})
+		if !errors.Is(err, ErrResponseNotFound) {
+			t.Fatalf("%s: expected ErrResponseNotFound, got %v", previous, err)
+		}
+	}
+}
+
+// memoryItems is an in-memory conversation.ItemRepository. Inserts fail with failInsert when set.
+type memoryItems struct {
+	mu         sync.Mutex
+	items      map[string][]conversation.Item
+	failInsert error
+}
+
+func (m *memoryItems) BulkInsert(_ context.Context, conversationID string, items []conversation.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failInsert != nil {
+		return m.failInsert
+	}
+	if m.items == nil {
+		m.items = make(map[string][]conversation.Item)
+	}
+	m.items[conversationID] = append(m.items[conversationID], items...)
+	return nil
+}
+
+func (m *memoryItems) ListByConversationID(_ context.Context, conversationID string) ([]conversation.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]conversation.Item(nil), m.items[conversationID]...), nil
+}
+
+type noExecutions struct{}
+
+func (noExecutions) RecordExecutions(context.Context, uint, []tool.Execution) error { return nil }
+
+func TestStoreCompletedFailsWhenHistoryIsNotStored(t *testing.T) {
+	repo := newMemoryResponses(
+		Response{PublicID: "resp_prev", Status: StatusInProgress},
+		Response{PublicID: "resp_1", Status: StatusInProgress},
+	)
+	items := &memoryItems{failInsert: errors.New("llm api unavailable")}
+	service := &ServiceImpl{responses: repo, conversationItems: items, toolExecutions: noExecutions{}, log: zerolog.Nop()}
+	stored, _ := repo.FindByPublicID(context.Background(), "resp_1")
+	prev, _ := repo.FindByPublicID(context.Background(), "resp_prev")
+
+	run := &responseRun{response: stored, conv: &conversation.Conversation{PublicID: "conv_1"}, tools: &toolSet{}, prevResp: prev, continues: true}
+	result := &tool.ExecuteResult{
+		FinalMessage: llm.ChatMessage{Role: "assistant", Content: "done"},
+		Messages:     []llm.ChatMessage{{Role: "assistant", Content: "done"}},
+	}
+	if _, err := service.storeCompleted(context.Background(), run, result, 0); err == nil {
+		t.Fatal("expected the failed history write to fail the response")
+	}
+	if got := repo.status("resp_1"); got != StatusFailed {
+		t.Fatalf("expected the response to be failed, got %s", got)
+	}
+	if got := repo.status("resp_prev"); got != StatusRequiresAction {
+		t.Fatalf("expected the previous response to wait for action again, got %s", got)
+	}
+}
+
+// memoryConversations is an in-memory conversation.Repository handing out sequential IDs.
+type memoryConversations struct {
+	created []string
+}
+
+func (m *memoryConversations) Create(_ context.Context, conv *conversation.Conversation) error {
+	conv.PublicID = fmt.Sprintf("conv_%d", len(m.created)+1)
+	m.created = append(m.created, conv.PublicID)
+	return nil
+}
+
+func (m *memoryConversations) FindByPublicID(_ context.Context, publicID string) (*conversation.Conversation, error) {
+	for _, id := range m.created {
+		if id == publicID {
+			return &conversation.Conversation{PublicID: id}, nil
+		}
+	}
+	return nil, conversation.ErrNotFound
+}
+
+// memoryLegacy is a LegacyHistory holding the messages of legacy conversations. movedTo, when
+// set, is the conversation another request already moved them to.
+type memoryLegacy struct {
+	messages map[uint][]llm.ChatMessage
+	movedTo  string
+}
+
+func (m *memoryLegacy) ListLegacyMessages(_ context.Context, legacyConversationID uint) ([]llm.ChatMessage, error) {
+	return m.messages[legacyConversationID], nil
+}
+
+func (m *memoryLegacy) AssignConversation(_ context.Context, _ uint, conversationID string) (string, error) {
+	if m.movedTo == "" {
+		m.movedTo = conversationID
+	}
+	return m.movedTo, nil
+}
+
+func TestMoveLegacyConversationCopiesHistory(t *testing.T) {
+	legacyID := uint(7)
+	legacy := &memoryLegacy{messages: map[uint][]llm.ChatMessage{legacyID: {
+		{Role: "user", Content: "hello"},
+		{Role: "assistant", Content: "hi there"},
+	}}}
+	conversations := &memoryConversations{}
+	items := &memoryItems{}
+	service := &ServiceImpl{conversations: conversations, conversationItems: items, legacy: legacy, log: zerolog.Nop()}
+
+	prev := &Response{PublicID: "resp_old", LegacyConversationID: &legacyID}
+	conv, err := service.moveLegacyConversation(context.Background(), prev)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conv.PublicID != "conv_1" || prev.ConversationPublicID == nil || *prev.ConversationPublicID != "conv_1" {
+		t.Fatalf("expected the response to point at the new conversation, got %+v", prev.ConversationPublicID)
+	}
+	copied, _ := items.ListByConversationID(context.Background(), "conv_1")
+	if len(copied) != 2 || copied[0].Role != conversation.RoleUser || copied[1].Role != conversation.RoleAssistant {
+		t.Fatalf("expected the legacy messages copied in order, got %+v", copied)
+	}
+
+	// A second move finds the conversation already moved and continues there
+	again := &Response{PublicID: "resp_old_2", LegacyConversationID: &legacyID}
+	conv, err = service.moveLegacyConversation(context.Background(), again)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conv.PublicID != "conv_1" || *again.ConversationPublicID != "conv_1" {
+		t.Fatalf("expected the earlier copy to be used, got %s", conv.PublicID)
+	}
+}
+
+func TestListConversationItemsOfLegacyResponse(t *testing.T) {
+	legacyID := uint(3)
+	repo := newMemoryResponses(Response{PublicID: "resp_old", UserID: "user_a", LegacyConversationID: &legacyID})
+	legacy := &memoryLegacy{messages: map[uint][]llm.ChatMessage{legacyID: {{Role: "user", Content: "hello"}}}}
+	service := &ServiceImpl{responses: repo, conversationItems: &memoryItems{}, legacy: legacy, log: zerolog.Nop()}
+
+	items, err := service.ListConversationItems(context.Background(), "user_a", "resp_old")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 || items[0].Role != conversation.RoleUser {
+		t.Fatalf("expected the legacy message, got %+v", items)
+	}
+}
+
+func TestListConversationItemsHidesResponsesOfOtherUsers(t *testing.T) {
+	legacyID := uint(3)
+	convID := "conv_1"
+	repo := newMemoryResponses(
+		Response{PublicID: "resp_old", UserID: "user_a", LegacyConversationID: &legacyID},
+		Response{PublicID: "resp_new", UserID: "user_a", ConversationPublicID: &convID},
+	)
+	legacy := &memoryLegacy{messages: map[uint][]llm.ChatMessage{legacyID: {{Role: "user", Content: "hello"}}}}
+	service := &ServiceImpl{responses: repo, conversationItems: &memoryItems{}, legacy: legacy, log: zerolog.Nop()}
+
+	for _, id := range []string{"resp_old", "resp_new"} {
+		items, err := service.ListConversationItems(context.Background(), "user_b", id)
+		if !errors.Is(err, ErrResponseNotFound) {
+			t.Fatalf("expected ErrResponseNotFound for another user's %s, got %v and %+v", id, err, items)
+		}
+	}
+}
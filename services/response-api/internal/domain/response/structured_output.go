@@ -0,0 +1,78 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"jan-server/services/response-api/internal/domain/llm"
+)
+
+// ErrInvalidStructuredOutput marks a response whose final message still does not match the
+// requested response format after the repair attempts.
+var ErrInvalidStructuredOutput = errors.New("output does not match the response format")
+
+// formatRepairAttempts is how often the model is asked to correct a final message that does not
+// match the response format.
+const formatRepairAttempts = 1
+
+// checkFormat reports why text does not satisfy format, or nil when it does. Text formats accept
+// any text.
+func checkFormat(format *llm.ResponseFormat, text string) error {
+	if format == nil || format.Type == "" || format.Type == llm.ResponseFormatText {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return fmt.Errorf("the output is not valid JSON: %v", err)
+	}
+	if format.Type == llm.ResponseFormatJSONObject {
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("the output is a JSON %s, not an object", jsonType(value))
+		}
+		return nil
+	}
+	if format.JSONSchema == nil {
+		return nil
+	}
+	if err := validateSchema(format.JSONSchema.Schema, value); err != nil {
+		return fmt.Errorf("the output does not match the JSON schema: %v", err)
+	}
+	return nil
+}
+
+// checkResponseFormat rejects requested formats whose JSON schema cannot be applied.
+func checkResponseFormat(format *llm.ResponseFormat) error {
+	if format == nil || format.Type != llm.ResponseFormatJSONSchema || format.JSONSchema == nil {
+		return nil
+	}
+	if err := checkSchema(format.JSONSchema.Schema); err != nil {
+		return fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return nil
+}
+
+// repairPrompt asks the model to answer again, explaining what was wrong with its last message.
+func repairPrompt(problem error) llm.ChatMessage {
+	return llm.ChatMessage{
+		Role: "user",
+		Content: fmt.Sprintf("Your previous reply was rejected because %s. Reply again with only the corrected JSON, "+
+			"without any explanation or code fences.", problem),
+	}
+}
+
+// sumUsage adds the token usage of two model calls; either may be nil.
+func sumUsage(a, b *llm.Usage) *llm.Usage {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &llm.Usage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+	}
+}
@@ -0,0 +1,129 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"jan-server/services/response-api/internal/domain/llm"
+	"jan-server/services/response-api/internal/domain/tool"
+)
+
+// ErrToolNotAllowed is returned when a request selects an MCP tool the tool policy denies to the
+// caller.
+var ErrToolNotAllowed = errors.New("tool not allowed")
+
+// toolSet holds the tools offered to the model for one response.
+type toolSet struct {
+	definitions []llm.ToolDefinition
+	client      map[string]bool   // function tools executed by the caller
+	mcp         map[string]bool   // MCP tools the model may call
+	approval    map[string]bool   // MCP tools whose calls wait for the user's approval
+	labels      map[string]string // MCP tool -> server label
+}
+
+func newToolSet() *toolSet {
+	return &toolSet{
+		client:   make(map[string]bool),
+		mcp:      make(map[string]bool),
+		approval: make(map[string]bool),
+		labels:   make(map[string]string),
+	}
+}
+
+// addMCP offers an MCP tool. Its calls need approval when mcp-tools or the policy say so, or when
+// requireApproval is set; a request can add approvals but not waive them.
+func (t *toolSet) addMCP(mcpTool tool.MCPTool, policy *tool.Policy, requireApproval bool) {
+	if requireApproval || mcpTool.RequiresApproval() || policy.RequiresApproval(mcpTool.Name) {
+		t.approval[mcpTool.Name] = true
+	}
+	if t.mcp[mcpTool.Name] {
+		return
+	}
+	t.mcp[mcpTool.Name] = true
+	t.labels[mcpTool.Name] = mcpTool.ServerLabel()
+	t.definitions = append(t.definitions, mcpTool.ToLLMTool())
+}
+
+// resolveTools selects the tools of a response. Without tools in the request the model gets every
+// MCP tool the policy allows the caller. Otherwise it gets the requested function tools, where
+// names of MCP tools select those tools, and the tools of the requested MCP servers.
+func (s *ServiceImpl) resolveTools(ctx context.Context, params CreateParams) (*toolSet, error) {
+	set := newToolSet()
+	rule := s.policy.RuleFor(params.UserID, params.APIKeyID)
+	selectsAll := len(params.Tools) == 0 && len(params.MCPServers) == 0
+
+	mcpTools, err := s.mcpClient.ListTools(ctx)
+	if err != nil {
+		if selectsAll || len(params.MCPServers) > 0 {
+			return nil, fmt.Errorf("list MCP tools: %w", err)
+		}
+		s.log.Warn().Err(err).Msg("list MCP tools failed, treating function tools as client tools")
+	}
+	served := make(map[string]tool.MCPTool, len(mcpTools))
+	for _, t := range mcpTools {
+		served[t.Name] = t
+	}
+
+	if selectsAll {
+		for _, t := range mcpTools {
+			if rule.Allows(t.Name) {
+				set.addMCP(t, s.policy, false)
+			}
+		}
+		return set, nil
+	}
+
+	for _, def := range params.Tools {
+		if def.Type != "" && def.Type != "function" {
+			set.definitions = append(set.definitions, def)
+			continue
+		}
+		name := def.Function.Name
+		mcpTool, isMCP := served[name]
+		if !isMCP {
+			if name != "" {
+				set.client[name] = true
+			}
+			set.definitions = append(set.definitions, def)
+			continue
+		}
+		if !rule.Allows(name) {
+			return nil, fmt.Errorf("%w: %s", ErrToolNotAllowed, name)
+		}
+		set.addMCP(mcpTool, s.policy, false)
+	}
+
+	for _, selection := range params.MCPServers {
+		var labelled []tool.MCPTool
+		for _, t := range mcpTools {
+			if t.ServerLabel() == selection.ServerLabel {
+				labelled = append(labelled, t)
+			}
+		}
+		if len(labelled) == 0 {
+			return nil, fmt.Errorf("%w: unknown MCP server %q", ErrInvalidInput, selection.ServerLabel)
+		}
+
+		if len(selection.AllowedTools) == 0 {
+			// A whole server only brings the tools the caller may use
+			for _, t := range labelled {
+				if rule.Allows(t.Name) {
+					set.addMCP(t, s.policy, selection.RequireApproval)
+				}
+			}
+			continue
+		}
+		for _, name := range selection.AllowedTools {
+			mcpTool, ok := served[name]
+			if !ok || mcpTool.ServerLabel() != selection.ServerLabel {
+				return nil, fmt.Errorf("%w: MCP server %q has no tool %q", ErrInvalidInput, selection.ServerLabel, name)
+			}
+			if !rule.Allows(name) {
+				return nil, fmt.Errorf("%w: %s", ErrToolNotAllowed, name)
+			}
+			set.addMCP(mcpTool, s.policy, selection.RequireApproval)
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,105 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrWorkerPoolFull is returned when the background queue cannot take another response.
+var ErrWorkerPoolFull = errors.New("background response queue is full")
+
+// ErrWorkerPoolClosed is returned when a background response is submitted during shutdown.
+var ErrWorkerPoolClosed = errors.New("background response queue is shut down")
+
+// WorkerPool runs background responses on a fixed number of goroutines.
+type WorkerPool struct {
+	jobs    chan func()
+	timeout time.Duration
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+	log     zerolog.Logger
+}
+
+// NewWorkerPool starts workers goroutines consuming a queue of queueSize jobs. Each job runs with
+// timeout as its deadline when positive.
+func NewWorkerPool(workers, queueSize int, timeout time.Duration, log zerolog.Logger) *WorkerPool {
+	pool := &WorkerPool{
+		jobs:    make(chan func(), max(queueSize, 0)),
+		timeout: timeout,
+		log:     log.With().Str("component", "response-worker-pool").Logger(),
+	}
+	for i := 0; i < max(workers, 1); i++ {
+		pool.wg.Add(1)
+		go pool.work()
+	}
+	return pool
+}
+
+// Submit queues job without blocking. The job context keeps the values of ctx (such as the
+// caller's auth token) but not its cancellation, so it outlives the HTTP request.
+func (p *WorkerPool) Submit(ctx context.Context, job func(ctx context.Context)) error {
+	jobCtx := context.WithoutCancel(ctx)
+	run := func() {
+		ctx, cancel := jobCtx, context.CancelFunc(func() {})
+		if p.timeout > 0 {
+			ctx, cancel = context.WithTimeout(jobCtx, p.timeout)
+		}
+		defer cancel()
+		job(ctx)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrWorkerPoolClosed
+	}
+	select {
+	case p.jobs <- run:
+		return nil
+	default:
+		return ErrWorkerPoolFull
+	}
+}
+
+// Shutdown stops accepting jobs and waits for queued jobs to finish or ctx to end.
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		p.runJob(job)
+	}
+}
+
+func (p *WorkerPool) runJob(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.log.Error().Interface("panic", r).Msg("background response panicked")
+		}
+	}()
+	job()
+}
@@ -0,0 +1,85 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestWorkerPoolRejectsWhenFull(t *testing.T) {
+	pool := NewWorkerPool(1, 1, 0, zerolog.Nop())
+	defer pool.Shutdown(context.Background())
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	if err := pool.Submit(context.Background(), func(context.Context) {
+		close(started)
+		<-release
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-started
+	if err := pool.Submit(context.Background(), func(context.Context) {}); err != nil {
+		t.Fatalf("expected the queue to take one job: %v", err)
+	}
+	if err := pool.Submit(context.Background(), func(context.Context) {}); !errors.Is(err, ErrWorkerPoolFull) {
+		t.Fatalf("expected ErrWorkerPoolFull, got %v", err)
+	}
+	close(release)
+}
+
+func TestWorkerPoolRejectsAfterShutdown(t *testing.T) {
+	pool := NewWorkerPool(1, 4, 0, zerolog.Nop())
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if err := pool.Submit(context.Background(), func(context.Context) {}); !errors.Is(err, ErrWorkerPoolClosed) {
+		t.Fatalf("expected ErrWorkerPoolClosed, got %v", err)
+	}
+}
+
+func TestWorkerPoolJobOutlivesCallerWithTimeout(t *testing.T) {
+	pool := NewWorkerPool(1, 1, time.Minute, zerolog.Nop())
+	defer pool.Shutdown(context.Background())
+
+	type key struct{}
+	caller, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "token"))
+	cancel()
+
+	type observed struct {
+		err         error
+		value       any
+		hasDeadline bool
+	}
+	result := make(chan observed, 1)
+	if err := pool.Submit(caller, func(ctx context.Context) {
+		_, hasDeadline := ctx.Deadline()
+		result <- observed{err: ctx.Err(), value: ctx.Value(key{}), hasDeadline: hasDeadline}
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := <-result
+	if got.err != nil || got.value != "token" {
+		t.Fatalf("expected a live job context with the caller's values, got err=%v value=%v", got.err, got.value)
+	}
+	if !got.hasDeadline {
+		t.Fatal("expected the job context to carry the pool timeout")
+	}
+}
+
+func TestWorkerPoolRecoversPanics(t *testing.T) {
+	pool := NewWorkerPool(1, 2, 0, zerolog.Nop())
+	done := make(chan struct{})
+	_ = pool.Submit(context.Background(), func(context.Context) { panic("boom") })
+	_ = pool.Submit(context.Background(), func(context.Context) { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the worker to keep running after a panic")
+	}
+	_ = pool.Shutdown(context.Background())
+}
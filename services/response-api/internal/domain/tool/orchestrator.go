@@ -23,15 +23,18 @@ type Orchestrator struct {
 	mcpClient       MCPClient
 	maxDepth        int
 	toolCallTimeout time.Duration
+	maxParallel     int
 }
 
-// NewOrchestrator constructs a tool orchestrator instance.
-func NewOrchestrator(llmProvider llm.Provider, mcpClient MCPClient, maxDepth int, toolCallTimeout time.Duration) *Orchestrator {
+// NewOrchestrator constructs a tool orchestrator instance. Tool calls requested in the same turn
+// run concurrently, at most maxParallel at a time.
+func NewOrchestrator(llmProvider llm.Provider, mcpClient MCPClient, maxDepth int, toolCallTimeout time.Duration, maxParallel int) *Orchestrator {
 	return &Orchestrator{
 		llmProvider:     llmProvider,
 		mcpClient:       mcpClient,
 		maxDepth:        maxDepth,
 		toolCallTimeout: toolCallTimeout,
+		maxParallel:     max(maxParallel, 1),
 	}
 }
 
@@ -44,7 +47,18 @@ type ExecuteParams struct {
 	MaxTokens       *int
 	ToolChoice      *llm.ToolChoice
 	ToolDefinitions []llm.ToolDefinition
+	ResponseFormat  *llm.ResponseFormat
 	StreamObserver  StreamObserver
+	// ClientTools names function tools executed by the caller instead of MCP
+	ClientTools map[string]bool
+	// MCPTools names the MCP tools the model may call; calls of other tools fail without reaching
+	// mcp-tools
+	MCPTools map[string]bool
+	// ApprovalTools names the MCP tools whose calls pause orchestration until the user approves them
+	ApprovalTools map[string]bool
+	// Approvals answers the calls a paused response awaited approval for. Approved calls run and
+	// denied calls fail before the model is asked again.
+	Approvals []ApprovalDecision
 }
 
 // ExecuteResult captures the final assistant message and tool execution records.
@@ -53,22 +67,42 @@ type ExecuteResult struct {
 	Messages     []llm.ChatMessage
 	Usage        *llm.Usage
 	Executions   []Execution
+	// PendingCalls are client tool calls awaiting outputs; when set, FinalMessage is the assistant
+	// message requesting them and orchestration is paused.
+	PendingCalls []Call
+	// ApprovalRequests are MCP tool calls awaiting the user's approval; they pause orchestration
+	// like PendingCalls.
+	ApprovalRequests []Call
 }
 
-// Execute drains the orchestration loop until the assistant responds without requesting tools.
+// Execute drains the orchestration loop until the assistant responds without requesting tools, or
+// pauses once it requests a client tool. MCP calls of the same turn still run before pausing.
+// When params.Ctx is cancelled, Execute returns the partial result produced so far together with
+// the error.
 func (o *Orchestrator) Execute(params ExecuteParams) (*ExecuteResult, error) {
 	messages := append([]llm.ChatMessage(nil), params.Messages...)
 	var executions []Execution
 
+	if len(params.Approvals) > 0 {
+		executions = o.executeDecisions(params)
+		for _, execution := range executions {
+			messages = append(messages, toolResultToMessage(execution.CallID, execution.Result, execution.ErrorMessage))
+		}
+		if err := params.Ctx.Err(); err != nil {
+			return interruptedResult(params.Ctx, messages, nil, executions), err
+		}
+	}
+
 	for depth := 0; depth < o.maxDepth; depth++ {
 		req := llm.ChatCompletionRequest{
-			Model:       params.Model,
-			Messages:    messages,
-			Tools:       params.ToolDefinitions,
-			ToolChoice:  params.ToolChoice,
-			Temperature: params.Temperature,
-			MaxTokens:   params.MaxTokens,
-			Stream:      false,
+			Model:          params.Model,
+			Messages:       messages,
+			Tools:          params.ToolDefinitions,
+			ToolChoice:     params.ToolChoice,
+			Temperature:    params.Temperature,
+			MaxTokens:      params.MaxTokens,
+			ResponseFormat: params.ResponseFormat,
+			Stream:         false,
 		}
 		req.Stream = params.StreamObserver != nil
 
@@ -78,13 +112,13 @@ func (o *Orchestrator) Execute(params ExecuteParams) (*ExecuteResult, error) {
 		if params.StreamObserver != nil {
 			streamChoice, err := o.streamChatCompletion(params.Ctx, req, params.StreamObserver)
 			if err != nil {
-				return nil, err
+				return interruptedResult(params.Ctx, messages, streamChoice, executions), err
 			}
 			choice = *streamChoice
 		} else {
 			resp, err := o.llmProvider.CreateChatCompletion(params.Ctx, req)
 			if err != nil {
-				return nil, err
+				return interruptedResult(params.Ctx, messages, nil, executions), err
 			}
 			if len(resp.Choices) == 0 {
 				return nil, errors.New("llm returned no choices")
@@ -104,59 +138,164 @@ func (o *Orchestrator) Execute(params ExecuteParams) (*ExecuteResult, error) {
 			}, nil
 		}
 
+		if err := params.Ctx.Err(); err != nil {
+			return interruptedResult(params.Ctx, messages, nil, executions), err
+		}
+
+		var pending []Call
+		var approvals []Call
+		var calls []Call
 		for _, call := range choice.Message.ToolCalls {
 			parsedCall, err := ParseToolCall(call)
 			if err != nil {
 				return nil, fmt.Errorf("parse tool call: %w", err)
 			}
 
-			execution := Execution{
-				CallID:         parsedCall.ID,
-				ToolName:       parsedCall.Name,
-				Arguments:      parsedCall.Arguments,
-				Status:         ExecutionStatusRunning,
-				ExecutionOrder: len(executions) + 1,
-				CreatedAt:      time.Now(),
-				UpdatedAt:      time.Now(),
-			}
-
 			if params.StreamObserver != nil {
 				params.StreamObserver.OnToolCall(parsedCall)
 			}
-
-			callCtx := params.Ctx
-			var cancel context.CancelFunc
-			if o.toolCallTimeout > 0 {
-				callCtx, cancel = context.WithTimeout(callCtx, o.toolCallTimeout)
+			if params.ClientTools[parsedCall.Name] {
+				pending = append(pending, parsedCall)
+				continue
 			}
+			if params.ApprovalTools[parsedCall.Name] && params.MCPTools[parsedCall.Name] {
+				approvals = append(approvals, parsedCall)
+				continue
+			}
+			calls = append(calls, parsedCall)
+		}
+
+		turnExecutions := o.executeCalls(params.Ctx, calls, len(executions), unavailable(params.MCPTools), params.StreamObserver)
+		for _, execution := range turnExecutions {
+			messages = append(messages, toolResultToMessage(execution.CallID, execution.Result, execution.ErrorMessage))
+		}
+		executions = append(executions, turnExecutions...)
 
-			result, err := o.mcpClient.CallTool(callCtx, parsedCall.Name, parsedCall.Arguments)
-			if cancel != nil {
-				cancel()
+		if err := params.Ctx.Err(); err != nil {
+			return interruptedResult(params.Ctx, messages, nil, executions), err
+		}
+
+		if len(pending) > 0 || len(approvals) > 0 {
+			return &ExecuteResult{
+				FinalMessage:     choice.Message,
+				Messages:         messages,
+				Usage:            usage,
+				Executions:       executions,
+				PendingCalls:     pending,
+				ApprovalRequests: approvals,
+			}, nil
+		}
+	}
+
+	return nil, ErrToolDepthExceeded
+}
+
+// executeDecisions runs the approved calls of params.Approvals and fails the denied ones.
+func (o *Orchestrator) executeDecisions(params ExecuteParams) []Execution {
+	calls := make([]Call, 0, len(params.Approvals))
+	decisions := make(map[string]ApprovalDecision, len(params.Approvals))
+	for _, decision := range params.Approvals {
+		calls = append(calls, decision.Call)
+		decisions[decision.Call.ID] = decision
+	}
+
+	refuse := func(call Call) string {
+		decision := decisions[call.ID]
+		if !decision.Approved {
+			if decision.Reason != "" {
+				return "the user denied this tool call: " + decision.Reason
 			}
-			if err != nil {
-				execution.Status = ExecutionStatusFailed
-				execution.ErrorMessage = err.Error()
-			} else {
-				execution.Status = ExecutionStatusCompleted
-				execution.Result = result
-				if result != nil && result.IsError {
+			return "the user denied this tool call"
+		}
+		return unavailable(params.MCPTools)(call)
+	}
+	// Only approved calls reach mcp-tools; denied ones are refused above
+	return o.executeCalls(ContextWithApproval(params.Ctx), calls, 0, refuse, params.StreamObserver)
+}
+
+// unavailable refuses calls of tools outside allowed.
+func unavailable(allowed map[string]bool) func(Call) string {
+	return func(call Call) string {
+		if allowed[call.Name] {
+			return ""
+		}
+		return fmt.Sprintf("tool %q is not available", call.Name)
+	}
+}
+
+// executeCalls runs the MCP tool calls of one turn, up to maxParallel at a time. Executions are
+// returned in call order and numbered after the startOrder executions of earlier turns, however
+// the calls complete. Calls refuse returns a reason for fail with it, without reaching mcp-tools.
+// The observer is told about results in call order too, as soon as all earlier calls finished.
+func (o *Orchestrator) executeCalls(ctx context.Context, calls []Call, startOrder int, refuse func(Call) string, observer StreamObserver) []Execution {
+	executions := make([]Execution, len(calls))
+	finished := make([]chan struct{}, len(calls))
+	for i, call := range calls {
+		executions[i] = Execution{
+			CallID:         call.ID,
+			ToolName:       call.Name,
+			Arguments:      call.Arguments,
+			Status:         ExecutionStatusRunning,
+			ExecutionOrder: startOrder + i + 1,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+		finished[i] = make(chan struct{})
+	}
+
+	// Start the calls in order while results are reported below
+	go func() {
+		slots := make(chan struct{}, o.maxParallel)
+		for i, call := range calls {
+			slots <- struct{}{}
+			reason := refuse(call)
+			go func(execution *Execution, finished chan struct{}) {
+				defer close(finished)
+				defer func() { <-slots }()
+				if reason == "" {
+					o.executeCall(ctx, execution)
+				} else {
 					execution.Status = ExecutionStatusFailed
-					execution.ErrorMessage = result.Error
+					execution.ErrorMessage = reason
+					execution.UpdatedAt = time.Now()
 				}
-			}
-			execution.UpdatedAt = time.Now()
-			executions = append(executions, execution)
-
-			if params.StreamObserver != nil {
-				params.StreamObserver.OnToolResult(parsedCall.ID, execution.Result)
-			}
+			}(&executions[i], finished[i])
+		}
+	}()
 
-			messages = append(messages, toolResultToMessage(parsedCall.ID, execution.Result, execution.ErrorMessage))
+	for i := range calls {
+		<-finished[i]
+		if observer != nil {
+			observer.OnToolResult(executions[i].CallID, executions[i].Result, executions[i].ErrorMessage)
 		}
 	}
+	return executions
+}
 
-	return nil, ErrToolDepthExceeded
+// executeCall runs one MCP tool call with the per-call timeout and records its outcome.
+func (o *Orchestrator) executeCall(ctx context.Context, execution *Execution) {
+	callCtx := ctx
+	var cancel context.CancelFunc
+	if o.toolCallTimeout > 0 {
+		callCtx, cancel = context.WithTimeout(callCtx, o.toolCallTimeout)
+	}
+
+	result, err := o.mcpClient.CallTool(callCtx, execution.ToolName, execution.Arguments)
+	if cancel != nil {
+		cancel()
+	}
+	if err != nil {
+		execution.Status = ExecutionStatusFailed
+		execution.ErrorMessage = err.Error()
+	} else {
+		execution.Status = ExecutionStatusCompleted
+		execution.Result = result
+		if result != nil && result.IsError {
+			execution.Status = ExecutionStatusFailed
+			execution.ErrorMessage = result.Error
+		}
+	}
+	execution.UpdatedAt = time.Now()
 }
 
 func (o *Orchestrator) streamChatCompletion(ctx context.Context, req llm.ChatCompletionRequest, observer StreamObserver) (*llm.ChatCompletionChoice, error) {
@@ -174,7 +313,8 @@ func (o *Orchestrator) streamChatCompletion(ctx context.Context, req llm.ChatCom
 			break
 		}
 		if err != nil {
-			return nil, err
+			// Hand back what was streamed so far; the caller keeps it if ctx was cancelled
+			return accumulator.Result(), err
 		}
 		if observer != nil && delta != nil {
 			observer.OnDelta(*delta)
@@ -189,6 +329,27 @@ func (o *Orchestrator) streamChatCompletion(ctx context.Context, req llm.ChatCom
 	return choice, nil
 }
 
+// interruptedResult returns the partial result of a cancelled orchestration, or nil when ctx is
+// still active. A partially streamed assistant message is kept as the final message without its
+// incomplete tool calls.
+func interruptedResult(ctx context.Context, messages []llm.ChatMessage, partial *llm.ChatCompletionChoice, executions []Execution) *ExecuteResult {
+	if ctx.Err() == nil {
+		return nil
+	}
+
+	result := &ExecuteResult{
+		Messages:   messages,
+		Executions: executions,
+	}
+	if partial != nil && partial.Message.Content != nil {
+		message := partial.Message
+		message.ToolCalls = nil
+		result.FinalMessage = message
+		result.Messages = append(messages, message)
+	}
+	return result
+}
+
 func toolResultToMessage(toolCallID string, result *Result, errorMessage string) llm.ChatMessage {
 	content := buildContentFromResult(result, errorMessage)
 	return llm.ChatMessage{
@@ -199,18 +360,21 @@ func toolResultToMessage(toolCallID string, result *Result, errorMessage string)
 }
 
 func buildContentFromResult(result *Result, errorMessage string) interface{} {
+	return map[string]string{
+		"type": "text",
+		"text": ResultText(result, errorMessage),
+	}
+}
+
+// ResultText returns the text passed back to the model for a tool result, or errorMessage when
+// the call failed.
+func ResultText(result *Result, errorMessage string) string {
 	if result == nil {
-		return map[string]string{
-			"type": "text",
-			"text": errorMessage,
-		}
+		return firstNonEmpty(errorMessage, "tool execution failed")
 	}
 
 	if result.IsError {
-		return map[string]string{
-			"type": "text",
-			"text": firstNonEmpty(errorMessage, "tool execution returned an error"),
-		}
+		return firstNonEmpty(errorMessage, result.Error, "tool execution returned an error")
 	}
 
 	var sb strings.Builder
@@ -227,11 +391,7 @@ func buildContentFromResult(result *Result, errorMessage string) interface{} {
 	if text == "" {
 		text = "[tool execution completed]"
 	}
-
-	return map[string]string{
-		"type": "text",
-		"text": text,
-	}
+	return text
 }
 
 func firstNonEmpty(values ...string) string {
@@ -288,6 +448,7 @@ type choiceAccumulator struct {
 	role         string
 	finishReason string
 	content      strings.Builder
+	reasoning    strings.Builder
 	toolCalls    map[string]*toolCallAccumulator
 	toolOrder    []string
 }
@@ -300,6 +461,7 @@ func (c *choiceAccumulator) apply(choice llm.ChatCompletionDeltaChoice) {
 	if choice.Delta.Content != nil {
 		c.appendContent(choice.Delta.Content)
 	}
+	c.reasoning.WriteString(choice.Delta.ReasoningContent)
 
 	if len(choice.Delta.ToolCalls) > 0 {
 		for idx, call := range choice.Delta.ToolCalls {
@@ -364,6 +526,7 @@ func (c *choiceAccumulator) build(index int) llm.ChatCompletionChoice {
 	if c.content.Len() > 0 {
 		message.Content = c.content.String()
 	}
+	message.ReasoningContent = c.reasoning.String()
 	if len(c.toolOrder) > 0 {
 		message.ToolCalls = make([]llm.ToolCall, 0, len(c.toolOrder))
 		for _, id := range c.toolOrder {
@@ -0,0 +1,272 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"jan-server/services/response-api/internal/domain/llm"
+)
+
+// scriptedProvider answers chat completions with the scripted assistant messages in order.
+type scriptedProvider struct {
+	mu       sync.Mutex
+	replies  []llm.ChatMessage
+	requests []llm.ChatCompletionRequest
+}
+
+func (p *scriptedProvider) CreateChatCompletion(_ context.Context, req llm.ChatCompletionRequest) (*llm.ChatCompletionResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	if len(p.replies) == 0 {
+		return nil, errors.New("no scripted reply left")
+	}
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return &llm.ChatCompletionResponse{Choices: []llm.ChatCompletionChoice{{Message: reply}}}, nil
+}
+
+func (p *scriptedProvider) CreateChatCompletionStream(context.Context, llm.ChatCompletionRequest) (llm.Stream, error) {
+	return nil, errors.New("streaming is not scripted")
+}
+
+// slowMCP answers tool calls after the delay of the called tool and records the peak number of
+// calls in flight.
+type slowMCP struct {
+	delays map[string]time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (m *slowMCP) ListTools(context.Context) ([]MCPTool, error) { return nil, nil }
+
+func (m *slowMCP) CallTool(ctx context.Context, name string, _ map[string]interface{}) (*Result, error) {
+	m.mu.Lock()
+	m.inFlight++
+	m.peak = max(m.peak, m.inFlight)
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.inFlight--
+		m.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(m.delays[name]):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &Result{ToolName: name, Content: []MCPContent{{Type: "text", Text: name + " result"}}}, nil
+}
+
+func toolCallMessage(names ...string) llm.ChatMessage {
+	calls := make([]llm.ToolCall, 0, len(names))
+	for i, name := range names {
+		calls = append(calls, llm.ToolCall{
+			ID:       fmt.Sprintf("call_%d", i+1),
+			Type:     "function",
+			Function: llm.ToolFunction{Name: name, Arguments: json.RawMessage(`{}`)},
+		})
+	}
+	return llm.ChatMessage{Role: "assistant", ToolCalls: calls}
+}
+
+func allowed(names ...string) map[string]bool {
+	tools := make(map[string]bool, len(names))
+	for _, name := range names {
+		tools[name] = true
+	}
+	return tools
+}
+
+func TestExecuteRunsCallsOfATurnConcurrently(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.ChatMessage{
+		toolCallMessage("slow", "medium", "fast"),
+		{Role: "assistant", Content: "done"},
+	}}
+	mcp := &slowMCP{delays: map[string]time.Duration{
+		"slow":   60 * time.Millisecond,
+		"medium": 30 * time.Millisecond,
+		"fast":   0,
+	}}
+	orchestrator := NewOrchestrator(provider, mcp, 4, time.Second, 2)
+
+	result, err := orchestrator.Execute(ExecuteParams{
+		Ctx:      context.Background(),
+		Model:    "test-model",
+		Messages: []llm.ChatMessage{{Role: "user", Content: "go"}},
+		MCPTools: allowed("slow", "medium", "fast"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mcp.peak != 2 {
+		t.Fatalf("expected at most 2 calls in flight at once, got %d", mcp.peak)
+	}
+
+	// Results keep call order although the calls finished in reverse
+	want := []string{"call_1", "call_2", "call_3"}
+	for i, execution := range result.Executions {
+		if execution.CallID != want[i] || execution.ExecutionOrder != i+1 {
+			t.Fatalf("execution %d: got call %s with order %d", i, execution.CallID, execution.ExecutionOrder)
+		}
+		if execution.Status != ExecutionStatusCompleted {
+			t.Fatalf("execution %d: expected completed, got %s", i, execution.Status)
+		}
+	}
+	followUp := provider.requests[1].Messages
+	for i, id := range want {
+		message := followUp[2+i]
+		if message.Role != "tool" || message.ToolCallID == nil || *message.ToolCallID != id {
+			t.Fatalf("follow-up message %d: expected the result of %s, got %+v", 2+i, id, message)
+		}
+	}
+}
+
+func TestExecuteNumbersExecutionsAcrossTurns(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.ChatMessage{
+		toolCallMessage("a", "b"),
+		toolCallMessage("c"),
+		{Role: "assistant", Content: "done"},
+	}}
+	orchestrator := NewOrchestrator(provider, &slowMCP{}, 4, time.Second, 4)
+
+	result, err := orchestrator.Execute(ExecuteParams{
+		Ctx:      context.Background(),
+		Messages: []llm.ChatMessage{{Role: "user", Content: "go"}},
+		MCPTools: allowed("a", "b", "c"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Executions) != 3 {
+		t.Fatalf("expected 3 executions, got %d", len(result.Executions))
+	}
+	for i, execution := range result.Executions {
+		if execution.ExecutionOrder != i+1 {
+			t.Fatalf("execution %d: expected order %d, got %d", i, i+1, execution.ExecutionOrder)
+		}
+	}
+}
+
+func TestExecuteTimesOutCallsIndividually(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.ChatMessage{
+		toolCallMessage("hang", "fast"),
+		{Role: "assistant", Content: "done"},
+	}}
+	mcp := &slowMCP{delays: map[string]time.Duration{"hang": time.Minute}}
+	orchestrator := NewOrchestrator(provider, mcp, 4, 20*time.Millisecond, 2)
+
+	result, err := orchestrator.Execute(ExecuteParams{
+		Ctx:      context.Background(),
+		Messages: []llm.ChatMessage{{Role: "user", Content: "go"}},
+		MCPTools: allowed("hang", "fast"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Executions[0].Status != ExecutionStatusFailed {
+		t.Fatalf("expected the hanging call to fail, got %s", result.Executions[0].Status)
+	}
+	if result.Executions[1].Status != ExecutionStatusCompleted {
+		t.Fatalf("expected the fast call to complete, got %s", result.Executions[1].Status)
+	}
+}
+
+func TestExecuteRefusesToolsOutsideTheAllowedSet(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.ChatMessage{
+		toolCallMessage("secret"),
+		{Role: "assistant", Content: "done"},
+	}}
+	mcp := &slowMCP{}
+	orchestrator := NewOrchestrator(provider, mcp, 4, time.Second, 2)
+
+	result, err := orchestrator.Execute(ExecuteParams{
+		Ctx:      context.Background(),
+		Messages: []llm.ChatMessage{{Role: "user", Content: "go"}},
+		MCPTools: allowed("search"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mcp.peak != 0 {
+		t.Fatal("expected the refused call not to reach mcp-tools")
+	}
+	if execution := result.Executions[0]; execution.Status != ExecutionStatusFailed || execution.ErrorMessage == "" {
+		t.Fatalf("expected a failed execution with a reason, got %+v", execution)
+	}
+}
+
+// resultRecorder records the tool results reported to a stream observer.
+type resultRecorder struct {
+	callIDs []string
+	errors  []string
+}
+
+func (r *resultRecorder) OnDelta(llm.ChatCompletionDelta) {}
+func (r *resultRecorder) OnToolCall(Call)                 {}
+func (r *resultRecorder) OnToolResult(callID string, _ *Result, errorMessage string) {
+	r.callIDs = append(r.callIDs, callID)
+	r.errors = append(r.errors, errorMessage)
+}
+
+func TestExecuteCallsReportsResultsInCallOrder(t *testing.T) {
+	mcp := &slowMCP{delays: map[string]time.Duration{"slow": 40 * time.Millisecond}}
+	orchestrator := NewOrchestrator(&scriptedProvider{}, mcp, 4, time.Second, 3)
+	recorder := &resultRecorder{}
+
+	calls := []Call{{ID: "call_1", Name: "slow"}, {ID: "call_2", Name: "fast"}, {ID: "call_3", Name: "denied"}}
+	orchestrator.executeCalls(context.Background(), calls, 0, unavailable(allowed("slow", "fast")), recorder)
+
+	if fmt.Sprint(recorder.callIDs) != "[call_1 call_2 call_3]" {
+		t.Fatalf("expected results in call order, got %v", recorder.callIDs)
+	}
+	if recorder.errors[0] != "" || recorder.errors[2] == "" {
+		t.Fatalf("expected only the refused call to report an error, got %q", recorder.errors)
+	}
+}
+
+// approvalMCP records whether each tool call was made as approved.
+type approvalMCP struct {
+	approved map[string]bool
+}
+
+func (m *approvalMCP) ListTools(context.Context) ([]MCPTool, error) { return nil, nil }
+
+func (m *approvalMCP) CallTool(ctx context.Context, name string, _ map[string]interface{}) (*Result, error) {
+	m.approved[name] = ApprovedFromContext(ctx)
+	return &Result{ToolName: name}, nil
+}
+
+func TestExecuteMarksOnlyApprovedCallsAsApproved(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.ChatMessage{
+		toolCallMessage("search"),
+		{Role: "assistant", Content: "done"},
+	}}
+	mcp := &approvalMCP{approved: map[string]bool{}}
+	orchestrator := NewOrchestrator(provider, mcp, 4, time.Second, 2)
+
+	_, err := orchestrator.Execute(ExecuteParams{
+		Ctx:       context.Background(),
+		Messages:  []llm.ChatMessage{{Role: "user", Content: "go"}},
+		MCPTools:  allowed("python_exec", "search"),
+		Approvals: []ApprovalDecision{{Call: Call{ID: "call_0", Name: "python_exec"}, Approved: true}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !mcp.approved["python_exec"] {
+		t.Fatal("expected the approved call to be made as approved")
+	}
+	if called, ok := mcp.approved["search"]; !ok || called {
+		t.Fatal("expected the model's own call not to be made as approved")
+	}
+}
@@ -0,0 +1,61 @@
+package tool
+
+import "path"
+
+// Policy limits the MCP tools callers may use. A rule of the API key the caller authenticated with
+// takes precedence over a rule of the authenticated user, which takes precedence over the default
+// rule. Calls of tools matching ApprovalRequired wait for the user's approval, whoever the caller
+// is. mcp-tools enforces the same rules for calls reaching it directly.
+type Policy struct {
+	Default          PolicyRule            `json:"default"`
+	Users            map[string]PolicyRule `json:"users,omitempty"`
+	APIKeys          map[string]PolicyRule `json:"api_keys,omitempty"`
+	ApprovalRequired []string              `json:"approval_required_tools,omitempty"`
+}
+
+// PolicyRule allows and denies tools by name. Entries are tool names or path.Match patterns such
+// as "exa_*". Denied entries win; an empty allow list allows every tool that is not denied.
+type PolicyRule struct {
+	AllowedTools []string `json:"allowed_tools,omitempty"`
+	DeniedTools  []string `json:"denied_tools,omitempty"`
+}
+
+// RuleFor returns the rule applying to the authenticated user and, when the user authenticated
+// with an API key as well, to that key. A nil policy allows every tool.
+func (p *Policy) RuleFor(userID, apiKeyID string) PolicyRule {
+	if p == nil {
+		return PolicyRule{}
+	}
+	if rule, ok := p.APIKeys[apiKeyID]; ok && apiKeyID != "" {
+		return rule
+	}
+	if rule, ok := p.Users[userID]; ok && userID != "" {
+		return rule
+	}
+	return p.Default
+}
+
+// RequiresApproval reports whether calls of the tool need the user's approval.
+func (p *Policy) RequiresApproval(name string) bool {
+	return p != nil && matchesAny(p.ApprovalRequired, name)
+}
+
+// Allows reports whether the rule permits the tool.
+func (r PolicyRule) Allows(name string) bool {
+	if matchesAny(r.DeniedTools, name) {
+		return false
+	}
+	return len(r.AllowedTools) == 0 || matchesAny(r.AllowedTools, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return true
+		}
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package tool
+
+import "testing"
+
+func TestPolicyRuleAllows(t *testing.T) {
+	tests := []struct {
+		name string
+		rule PolicyRule
+		tool string
+		want bool
+	}{
+		{name: "empty rule allows everything", rule: PolicyRule{}, tool: "python_exec", want: true},
+		{name: "denied by name", rule: PolicyRule{DeniedTools: []string{"python_exec"}}, tool: "python_exec", want: false},
+		{name: "denied by pattern", rule: PolicyRule{DeniedTools: []string{"exa_*"}}, tool: "exa_search", want: false},
+		{name: "allowed by pattern", rule: PolicyRule{AllowedTools: []string{"exa_*"}}, tool: "exa_search", want: true},
+		{name: "not in allow list", rule: PolicyRule{AllowedTools: []string{"google_search"}}, tool: "scrape", want: false},
+		{name: "deny wins over allow", rule: PolicyRule{AllowedTools: []string{"*"}, DeniedTools: []string{"python_exec"}}, tool: "python_exec", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Allows(tt.tool); got != tt.want {
+				t.Fatalf("Allows(%q) = %v, want %v", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyRuleFor(t *testing.T) {
+	policy := &Policy{
+		Default:          PolicyRule{DeniedTools: []string{"python_exec"}},
+		Users:            map[string]PolicyRule{"user-1": {}},
+		APIKeys:          map[string]PolicyRule{"key-1": {AllowedTools: []string{"google_search"}}},
+		ApprovalRequired: []string{"python_exec"},
+	}
+	if !policy.RuleFor("user-1", "").Allows("python_exec") {
+		t.Fatal("expected the user rule to apply")
+	}
+	if policy.RuleFor("user-2", "").Allows("python_exec") || policy.RuleFor("", "").Allows("python_exec") {
+		t.Fatal("expected the default rule for other callers")
+	}
+	if rule := policy.RuleFor("user-1", "key-1"); rule.Allows("python_exec") || !rule.Allows("google_search") {
+		t.Fatal("expected the API key rule to take precedence over the user rule")
+	}
+	if !policy.RuleFor("user-1", "key-2").Allows("python_exec") {
+		t.Fatal("expected the user rule for API keys without a rule")
+	}
+	if !policy.RequiresApproval("python_exec") || policy.RequiresApproval("google_search") {
+		t.Fatal("expected only python_exec to need approval")
+	}
+
+	var none *Policy
+	if !none.RuleFor("user-1", "key-1").Allows("python_exec") || none.RequiresApproval("python_exec") {
+		t.Fatal("expected a nil policy to allow every tool without approval")
+	}
+}
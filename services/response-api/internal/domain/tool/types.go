@@ -62,18 +62,59 @@ type MCPClient interface {
 	CallTool(ctx context.Context, name string, args map[string]interface{}) (*Result, error)
 }
 
-// StreamObserver receives live updates during orchestration.
+// StreamObserver receives live updates during orchestration. Tool results are reported in call
+// order, from one goroutine, although the tool calls of a turn run in parallel. errorMessage is
+// set when the call failed.
 type StreamObserver interface {
 	OnDelta(delta llm.ChatCompletionDelta)
 	OnToolCall(call Call)
-	OnToolResult(callID string, result *Result)
+	OnToolResult(callID string, result *Result, errorMessage string)
 }
 
+// DefaultServerLabel is the server label of MCP tools that mcp-tools does not label.
+const DefaultServerLabel = "mcp-tools"
+
 // MCPTool describes the tool metadata returned by mcp-tools.
 type MCPTool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	Meta        map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// ServerLabel returns the label of the server providing the tool, as tagged by mcp-tools.
+func (t MCPTool) ServerLabel() string {
+	if label, ok := t.Meta["server_label"].(string); ok && label != "" {
+		return label
+	}
+	return DefaultServerLabel
+}
+
+// RequiresApproval reports whether mcp-tools marks the tool as needing user approval per call.
+func (t MCPTool) RequiresApproval() bool {
+	required, _ := t.Meta["requires_approval"].(bool)
+	return required
+}
+
+// ApprovalDecision is the user's answer to a tool call that awaited approval.
+type ApprovalDecision struct {
+	Call     Call
+	Approved bool
+	Reason   string
+}
+
+type approvedKey struct{}
+
+// ContextWithApproval marks the tool calls made with ctx as approved by the user. MCP clients
+// pass the approval on, since mcp-tools refuses unapproved calls of tools requiring approval.
+func ContextWithApproval(ctx context.Context) context.Context {
+	return context.WithValue(ctx, approvedKey{}, true)
+}
+
+// ApprovedFromContext reports whether the tool calls made with ctx were approved by the user.
+func ApprovedFromContext(ctx context.Context) bool {
+	approved, _ := ctx.Value(approvedKey{}).(bool)
+	return approved
 }
 
 // ToLLMTool converts MCP metadata into OpenAI-compatible tool definition.
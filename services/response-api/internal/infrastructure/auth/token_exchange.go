@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"jan-server/services/response-api/internal/config"
+	"jan-server/services/response-api/internal/domain/llm"
+)
+
+const (
+	tokenExchangeGrant   = "urn:ietf:params:oauth:grant-type:token-exchange"
+	accessTokenType      = "urn:ietf:params:oauth:token-type:access_token"
+	refreshTokenType     = "urn:ietf:params:oauth:token-type:refresh_token"
+	tokenRefreshLeeway   = 30 * time.Second
+	tokenExchangeTimeout = 15 * time.Second
+)
+
+// TokenExchanger delegates the caller's credentials to background responses with OAuth 2.0 token
+// exchange (RFC 8693): the caller's access token is traded for a refresh token, which renews the
+// access token sent to llm-api for as long as the run needs it.
+type TokenExchanger struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+// NewTokenExchanger creates an exchanger posting to the token endpoint as the configured client.
+func NewTokenExchanger(tokenURL, clientID, clientSecret string) *TokenExchanger {
+	return &TokenExchanger{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   &http.Client{Timeout: tokenExchangeTimeout},
+	}
+}
+
+// NewTokenDelegator returns the token exchanger configured by TOKEN_EXCHANGE_URL, or nil when
+// token exchange is not configured.
+func NewTokenDelegator(cfg *config.Config) llm.TokenDelegator {
+	if strings.TrimSpace(cfg.TokenExchangeURL) == "" {
+		return nil
+	}
+	return NewTokenExchanger(cfg.TokenExchangeURL, cfg.TokenExchangeClientID, cfg.TokenExchangeClientSecret)
+}
+
+// tokenResponse is the token endpoint reply.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Delegate exchanges the bearer token of authHeader for a refresh token and returns a token
+// source built on it.
+func (e *TokenExchanger) Delegate(ctx context.Context, authHeader string) (llm.TokenSource, error) {
+	subjectToken := bearerToken(authHeader)
+	if subjectToken == "" {
+		return nil, errors.New("authorization header has no bearer token")
+	}
+
+	tokens, err := e.request(ctx, url.Values{
+		"grant_type":           {tokenExchangeGrant},
+		"subject_token":        {subjectToken},
+		"subject_token_type":   {accessTokenType},
+		"requested_token_type": {refreshTokenType},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+	if tokens.RefreshToken == "" {
+		return nil, errors.New("token exchange returned no refresh token")
+	}
+
+	delegated := &delegatedToken{exchanger: e}
+	delegated.store(tokens)
+	return delegated.token, nil
+}
+
+func (e *TokenExchanger) request(ctx context.Context, values url.Values) (*tokenResponse, error) {
+	values.Set("client_id", e.clientID)
+	if e.clientSecret != "" {
+		values.Set("client_secret", e.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.tokenURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		payload, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(payload)))
+	}
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, err
+	}
+	if tokens.AccessToken == "" {
+		return nil, errors.New("token endpoint returned no access token")
+	}
+	return &tokens, nil
+}
+
+// delegatedToken holds the credentials of one background run.
+type delegatedToken struct {
+	exchanger *TokenExchanger
+
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time
+}
+
+// token returns the current access token, refreshing it when it expires within the leeway.
+func (d *delegatedToken) token(ctx context.Context) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if time.Until(d.expiresAt) > tokenRefreshLeeway {
+		return "Bearer " + d.accessToken, nil
+	}
+	tokens, err := d.exchanger.request(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {d.refreshToken},
+	})
+	if err != nil {
+		return "", fmt.Errorf("refresh delegated token: %w", err)
+	}
+	d.store(tokens)
+	return "Bearer " + d.accessToken, nil
+}
+
+func (d *delegatedToken) store(tokens *tokenResponse) {
+	d.accessToken = tokens.AccessToken
+	if tokens.RefreshToken != "" {
+		// Refresh tokens may rotate on every use
+		d.refreshToken = tokens.RefreshToken
+	}
+	d.expiresAt = time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second)
+}
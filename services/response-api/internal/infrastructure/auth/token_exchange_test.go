@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestTokenExchangerDelegatesAndRefreshes(t *testing.T) {
+	var mu sync.Mutex
+	var grants []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		mu.Lock()
+		grants = append(grants, r.PostForm.Get("grant_type"))
+		mu.Unlock()
+		if r.PostForm.Get("client_id") != "response-api" || r.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.PostForm.Get("grant_type") {
+		case tokenExchangeGrant:
+			if r.PostForm.Get("subject_token") != "caller" || r.PostForm.Get("requested_token_type") != refreshTokenType {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			// Already inside the refresh leeway, so the first use refreshes it
+			_ = json.NewEncoder(w).Encode(tokenResponse{AccessToken: "exchanged", RefreshToken: "refresh-1", ExpiresIn: 10})
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(tokenResponse{AccessToken: "renewed", RefreshToken: "refresh-2", ExpiresIn: 300})
+		}
+	}))
+	defer server.Close()
+
+	exchanger := NewTokenExchanger(server.URL, "response-api", "secret")
+	source, err := exchanger.Delegate(context.Background(), "Bearer caller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := source(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token != "Bearer renewed" {
+			t.Fatalf("expected the renewed token, got %q", token)
+		}
+	}
+	if len(grants) != 2 || grants[0] != tokenExchangeGrant || grants[1] != "refresh_token" {
+		t.Fatalf("expected one exchange and one refresh, got %v", grants)
+	}
+}
+
+func TestTokenExchangerRequiresBearerToken(t *testing.T) {
+	exchanger := NewTokenExchanger("http://127.0.0.1:0", "response-api", "")
+	if _, err := exchanger.Delegate(context.Background(), "Basic abc"); err == nil {
+		t.Fatal("expected an error without a bearer token")
+	}
+}
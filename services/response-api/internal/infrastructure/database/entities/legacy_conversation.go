@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// LegacyConversationItem is a message of the conversations response-api stored before they moved
+// to llm-api. The table is only read, to move those conversations over; it is not migrated.
+type LegacyConversationItem struct {
+	ID             uint           `gorm:"primaryKey"`
+	ConversationID uint           `gorm:"index"`
+	Role           string         `gorm:"size:32"`
+	Status         string         `gorm:"size:32"`
+	Content        datatypes.JSON `gorm:"type:jsonb"`
+	Sequence       int            `gorm:"index"`
+	CreatedAt      time.Time
+}
+
+// TableName keeps the table name of the legacy conversation items.
+func (LegacyConversationItem) TableName() string {
+	return "conversation_items"
+}
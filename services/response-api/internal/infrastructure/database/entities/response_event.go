@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ResponseEvent persists one streamed event of a response so clients can replay the stream.
+type ResponseEvent struct {
+	ID             uint           `gorm:"primaryKey"`
+	ResponseID     string         `gorm:"size:64;uniqueIndex:idx_response_events_sequence"`
+	SequenceNumber int            `gorm:"uniqueIndex:idx_response_events_sequence"`
+	Type           string         `gorm:"size:64"`
+	Data           datatypes.JSON `gorm:"type:jsonb"`
+	Final          bool           // last event of a finished stream
+	CreatedAt      time.Time      `gorm:"index"`
+}
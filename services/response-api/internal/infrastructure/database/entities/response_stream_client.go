@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+// ResponseStreamClient records a client following the event stream of a response. The lease is
+// renewed while the client is attached, so rows of instances that died expire on their own.
+type ResponseStreamClient struct {
+	ID         uint      `gorm:"primaryKey"`
+	ResponseID string    `gorm:"size:64;index"`
+	ExpiresAt  time.Time `gorm:"index"`
+}
@@ -12,10 +12,10 @@ import (
 // AutoMigrate applies database schema changes for the response domain.
 func AutoMigrate(ctx context.Context, db *gorm.DB, log zerolog.Logger) error {
 	if err := db.WithContext(ctx).AutoMigrate(
-		&entities.Conversation{},
-		&entities.ConversationItem{},
 		&entities.Response{},
 		&entities.ToolExecution{},
+		&entities.ResponseEvent{},
+		&entities.ResponseStreamClient{},
 	); err != nil {
 		return err
 	}
@@ -0,0 +1,270 @@
+package eventstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	domain "jan-server/services/response-api/internal/domain/response"
+	"jan-server/services/response-api/internal/infrastructure/database/entities"
+)
+
+const (
+	// pollInterval bounds how late subscribers see events appended by another instance
+	pollInterval = time.Second
+	// replayBatchSize caps the events loaded per query
+	replayBatchSize = 500
+	// pruneInterval throttles the deletion of expired events
+	pruneInterval = time.Minute
+	// settledAfter is how long a response must have been in a final state before subscribers of
+	// a stream that was never finished stop
+	settledAfter = 10 * time.Second
+	// clientLease is how long an attached client counts without its lease being renewed
+	clientLease = 30 * time.Second
+)
+
+// finalStatuses end a response stream.
+var finalStatuses = []string{
+	string(domain.StatusCompleted),
+	string(domain.StatusFailed),
+	string(domain.StatusCancelled),
+	string(domain.StatusRequiresAction),
+}
+
+// PostgresStore persists response events so streams survive client reconnects and can be replayed
+// from any instance. Subscribers on the instance writing a stream are woken on every event; others
+// poll for new events.
+type PostgresStore struct {
+	db        *gorm.DB
+	retention time.Duration
+
+	mu        sync.Mutex
+	changed   map[string]chan struct{} // response ID -> closed on the next event
+	lastPrune time.Time
+}
+
+// NewPostgresStore constructs an event store keeping events for retention.
+func NewPostgresStore(db *gorm.DB, retention time.Duration) *PostgresStore {
+	return &PostgresStore{
+		db:        db,
+		retention: retention,
+		changed:   make(map[string]chan struct{}),
+	}
+}
+
+// Append stores events of the response stream in one statement.
+func (s *PostgresStore) Append(ctx context.Context, responseID string, events ...domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rows := make([]entities.ResponseEvent, 0, len(events))
+	for _, event := range events {
+		rows = append(rows, entities.ResponseEvent{
+			ResponseID:     responseID,
+			SequenceNumber: event.SequenceNumber,
+			Type:           event.Type,
+			Data:           datatypes.JSON(event.Data),
+		})
+	}
+	if err := s.db.WithContext(ctx).CreateInBatches(&rows, replayBatchSize).Error; err != nil {
+		return err
+	}
+	s.notify(responseID)
+	return nil
+}
+
+// Finish marks the last stored event of the response as final and prunes expired events.
+func (s *PostgresStore) Finish(ctx context.Context, responseID string) error {
+	last := s.db.Model(&entities.ResponseEvent{}).
+		Select("MAX(sequence_number)").
+		Where("response_id = ?", responseID)
+	err := s.db.WithContext(ctx).
+		Model(&entities.ResponseEvent{}).
+		Where("response_id = ? AND sequence_number = (?)", responseID, last).
+		Update("final", true).Error
+	if err != nil {
+		return err
+	}
+	s.notify(responseID)
+	return s.prune(ctx)
+}
+
+// Subscribe replays events after afterSequence and follows the stream until its final event, or
+// until the response settled in a final state without one.
+func (s *PostgresStore) Subscribe(ctx context.Context, responseID string, afterSequence int) (<-chan domain.Event, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).
+		Model(&entities.ResponseEvent{}).
+		Where("response_id = ?", responseID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, domain.ErrEventStreamNotFound
+	}
+
+	events := make(chan domain.Event)
+	go s.follow(ctx, responseID, max(afterSequence, 0), events)
+	return events, nil
+}
+
+func (s *PostgresStore) follow(ctx context.Context, responseID string, next int, events chan<- domain.Event) {
+	defer close(events)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Watch before querying so an event stored in between still wakes us
+		changed := s.changes(responseID)
+
+		var rows []entities.ResponseEvent
+		if err := s.db.WithContext(ctx).
+			Where("response_id = ? AND sequence_number > ?", responseID, next).
+			Order("sequence_number ASC").
+			Limit(replayBatchSize).
+			Find(&rows).Error; err != nil {
+			s.release(responseID, changed)
+			return
+		}
+
+		for _, row := range rows {
+			event := domain.Event{SequenceNumber: row.SequenceNumber, Type: row.Type, Data: []byte(row.Data)}
+			select {
+			case events <- event:
+				next = row.SequenceNumber
+			case <-ctx.Done():
+				s.release(responseID, changed)
+				return
+			}
+			if row.Final {
+				s.release(responseID, changed)
+				return
+			}
+		}
+		if len(rows) == replayBatchSize {
+			continue
+		}
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+			if len(rows) == 0 && s.settled(ctx, responseID) {
+				// The stream was never finished, e.g. the instance running the response died
+				s.release(responseID, changed)
+				return
+			}
+		case <-ctx.Done():
+			s.release(responseID, changed)
+			return
+		}
+	}
+}
+
+// settled reports whether the response has been in a final state for settledAfter.
+func (s *PostgresStore) settled(ctx context.Context, responseID string) bool {
+	var count int64
+	err := s.db.WithContext(ctx).
+		Model(&entities.Response{}).
+		Where("public_id = ? AND status IN ? AND updated_at < ?", responseID, finalStatuses, time.Now().Add(-settledAfter)).
+		Count(&count).Error
+	return err == nil && count > 0
+}
+
+// Attach leases a client row for the response and renews it until release is called.
+func (s *PostgresStore) Attach(ctx context.Context, responseID string) (func(), error) {
+	row := entities.ResponseStreamClient{ResponseID: responseID, ExpiresAt: time.Now().Add(clientLease)}
+	if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(clientLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.db.Model(&entities.ResponseStreamClient{ID: row.ID}).
+					Update("expires_at", time.Now().Add(clientLease))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			s.db.Delete(&entities.ResponseStreamClient{ID: row.ID})
+		})
+	}, nil
+}
+
+// Attached reports whether the response has an unexpired client lease.
+func (s *PostgresStore) Attached(ctx context.Context, responseID string) (bool, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).
+		Model(&entities.ResponseStreamClient{}).
+		Where("response_id = ? AND expires_at > ?", responseID, time.Now()).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// changes returns a channel closed when the next event of the response is stored here.
+func (s *PostgresStore) changes(responseID string) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch, ok := s.changed[responseID]
+	if !ok {
+		ch = make(chan struct{})
+		s.changed[responseID] = ch
+	}
+	return ch
+}
+
+// release drops the watch channel of a subscriber that stopped following the response. Other
+// subscribers still holding it fall back to polling.
+func (s *PostgresStore) release(responseID string, ch <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.changed[responseID]; ok && current == ch {
+		delete(s.changed, responseID)
+	}
+}
+
+func (s *PostgresStore) notify(responseID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ch, ok := s.changed[responseID]; ok {
+		close(ch)
+		delete(s.changed, responseID)
+	}
+}
+
+// prune deletes events older than the retention, at most once per pruneInterval.
+func (s *PostgresStore) prune(ctx context.Context) error {
+	s.mu.Lock()
+	if time.Since(s.lastPrune) < pruneInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastPrune = time.Now()
+	s.mu.Unlock()
+
+	if err := s.db.WithContext(ctx).
+		Where("created_at < ?", time.Now().Add(-s.retention)).
+		Delete(&entities.ResponseEvent{}).Error; err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).
+		Where("expires_at < ?", time.Now()).
+		Delete(&entities.ResponseStreamClient{}).Error
+}
+
+var _ domain.EventStore = (*PostgresStore)(nil)
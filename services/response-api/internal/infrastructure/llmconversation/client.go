@@ -0,0 +1,192 @@
+package llmconversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"jan-server/services/response-api/internal/domain/conversation"
+	"jan-server/services/response-api/internal/domain/llm"
+)
+
+const (
+	// createBatchSize is the most items llm-api accepts in one create items request.
+	createBatchSize = 20
+	// listPageSize is the largest page of items llm-api returns.
+	listPageSize = 100
+)
+
+// Client stores conversations in llm-api through its Conversations API. It implements
+// conversation.Repository and conversation.ItemRepository, acting for the user whose token is
+// in the request context.
+type Client struct {
+	httpClient *resty.Client
+}
+
+// NewClient creates a Resty-backed client.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		httpClient: resty.New().
+			SetBaseURL(baseURL).
+			SetHeader("Content-Type", "application/json").
+			SetTimeout(30 * time.Second),
+	}
+}
+
+type createConversationRequest struct {
+	Title    *string           `json:"title,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Referrer *string           `json:"referrer,omitempty"`
+}
+
+type conversationResponse struct {
+	ID        string            `json:"id"`
+	Title     *string           `json:"title,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt int64             `json:"created_at"`
+}
+
+type createItemsRequest struct {
+	Items []conversation.Item `json:"items"`
+}
+
+type itemListResponse struct {
+	Data    []conversation.Item `json:"data"`
+	LastID  string              `json:"last_id"`
+	HasMore bool                `json:"has_more"`
+}
+
+// Create calls llm-api POST /v1/conversations.
+func (c *Client) Create(ctx context.Context, conv *conversation.Conversation) error {
+	var created conversationResponse
+	resp, err := c.request(ctx).
+		SetBody(createConversationRequest{Title: conv.Title, Metadata: conv.Metadata, Referrer: conv.Referrer}).
+		SetResult(&created).
+		Post("/v1/conversations")
+	if err := checkResponse(resp, err); err != nil {
+		return fmt.Errorf("create conversation: %w", err)
+	}
+	conv.PublicID = created.ID
+	conv.CreatedAt = time.Unix(created.CreatedAt, 0)
+	return nil
+}
+
+// FindByPublicID calls llm-api GET /v1/conversations/{id}.
+func (c *Client) FindByPublicID(ctx context.Context, publicID string) (*conversation.Conversation, error) {
+	var found conversationResponse
+	resp, err := c.request(ctx).
+		SetPathParam("id", publicID).
+		SetResult(&found).
+		Get("/v1/conversations/{id}")
+	if err := checkResponse(resp, err); err != nil {
+		return nil, fmt.Errorf("get conversation: %w", err)
+	}
+	return &conversation.Conversation{
+		PublicID:  found.ID,
+		Title:     found.Title,
+		Metadata:  found.Metadata,
+		CreatedAt: time.Unix(found.CreatedAt, 0),
+	}, nil
+}
+
+// BulkInsert appends items to the active branch of the conversation, in batches llm-api accepts.
+// llm-api stores each batch atomically; when a batch fails the items of the earlier batches are
+// deleted again, so the conversation gets all of the items or none of them.
+func (c *Client) BulkInsert(ctx context.Context, conversationID string, items []conversation.Item) error {
+	var created []string
+	for start := 0; start < len(items); start += createBatchSize {
+		end := start + createBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		var page itemListResponse
+		resp, err := c.request(ctx).
+			SetPathParam("id", conversationID).
+			SetBody(createItemsRequest{Items: items[start:end]}).
+			SetResult(&page).
+			Post("/v1/conversations/{id}/items")
+		if err := checkResponse(resp, err); err != nil {
+			if rollbackErr := c.deleteItems(context.WithoutCancel(ctx), conversationID, created); rollbackErr != nil {
+				err = errors.Join(err, fmt.Errorf("delete items of earlier batches: %w", rollbackErr))
+			}
+			return fmt.Errorf("create conversation items: %w", err)
+		}
+		for _, item := range page.Data {
+			created = append(created, item.ID)
+		}
+	}
+	return nil
+}
+
+// deleteItems deletes the items with the given IDs from the conversation.
+func (c *Client) deleteItems(ctx context.Context, conversationID string, itemIDs []string) error {
+	var errs []error
+	for _, itemID := range itemIDs {
+		resp, err := c.request(ctx).
+			SetPathParam("id", conversationID).
+			SetPathParam("item_id", itemID).
+			Delete("/v1/conversations/{id}/items/{item_id}")
+		if err := checkResponse(resp, err); err != nil {
+			errs = append(errs, fmt.Errorf("item %s: %w", itemID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListByConversationID returns all items of the active branch of the conversation, oldest first.
+func (c *Client) ListByConversationID(ctx context.Context, conversationID string) ([]conversation.Item, error) {
+	var items []conversation.Item
+	after := ""
+	for {
+		var page itemListResponse
+		request := c.request(ctx).
+			SetPathParam("id", conversationID).
+			SetQueryParam("order", "asc").
+			SetQueryParam("limit", fmt.Sprint(listPageSize)).
+			SetResult(&page)
+		if after != "" {
+			request.SetQueryParam("after", after)
+		}
+		resp, err := request.Get("/v1/conversations/{id}/items")
+		if err := checkResponse(resp, err); err != nil {
+			return nil, fmt.Errorf("list conversation items: %w", err)
+		}
+		items = append(items, page.Data...)
+		if !page.HasMore || page.LastID == "" {
+			return items, nil
+		}
+		after = page.LastID
+	}
+}
+
+func (c *Client) request(ctx context.Context) *resty.Request {
+	request := c.httpClient.R().SetContext(ctx)
+	if token := llm.AuthTokenFromContext(ctx); token != "" {
+		request.SetHeader("Authorization", token)
+	}
+	return request
+}
+
+// checkResponse maps transport errors and error statuses to an error; 404 becomes
+// conversation.ErrNotFound.
+func checkResponse(resp *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return conversation.ErrNotFound
+	}
+	if resp.IsError() {
+		return fmt.Errorf("llm api error: %s", resp.String())
+	}
+	return nil
+}
+
+var (
+	_ conversation.Repository     = (*Client)(nil)
+	_ conversation.ItemRepository = (*Client)(nil)
+)
@@ -0,0 +1,87 @@
+package llmconversation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"jan-server/services/response-api/internal/domain/conversation"
+)
+
+func TestBulkInsertDeletesEarlierBatchesWhenABatchFails(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches int
+		deleted []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPost:
+			batches++
+			if batches == 2 {
+				http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+				return
+			}
+			var body createItemsRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+			page := itemListResponse{}
+			w.Header().Set("Content-Type", "application/json")
+			for i := range body.Items {
+				page.Data = append(page.Data, conversation.Item{ID: fmt.Sprintf("msg_%d", i)})
+			}
+			_ = json.NewEncoder(w).Encode(page)
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	items := make([]conversation.Item, createBatchSize+1)
+	err := NewClient(server.URL).BulkInsert(context.Background(), "conv_1", items)
+	if err == nil {
+		t.Fatal("expected the failed batch to fail the insert")
+	}
+	if batches != 2 {
+		t.Fatalf("expected the insert to stop at the failed batch, got %d batches", batches)
+	}
+	want := make([]string, createBatchSize)
+	for i := range want {
+		want[i] = fmt.Sprintf("msg_%d", i)
+	}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Fatalf("expected the first batch deleted, got %v", deleted)
+	}
+}
+
+func TestBulkInsertSendsBatches(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body createItemsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		sizes = append(sizes, len(body.Items))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(itemListResponse{})
+	}))
+	defer server.Close()
+
+	items := make([]conversation.Item, 2*createBatchSize+1)
+	if err := NewClient(server.URL).BulkInsert(context.Background(), "conv_1", items); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(sizes, []int{createBatchSize, createBatchSize, 1}) {
+		t.Fatalf("unexpected batch sizes %v", sizes)
+	}
+}
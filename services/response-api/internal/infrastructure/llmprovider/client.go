@@ -35,6 +35,8 @@ func NewClient(baseURL string) *Client {
 
 // CreateChatCompletion calls llm-api /v1/chat/completions.
 func (c *Client) CreateChatCompletion(ctx context.Context, req llm.ChatCompletionRequest) (*llm.ChatCompletionResponse, error) {
+	req.Messages = withoutReasoning(req.Messages)
+
 	var completion llm.ChatCompletionResponse
 	request := c.httpClient.R().
 		SetContext(ctx).
@@ -59,6 +61,7 @@ func (c *Client) CreateChatCompletion(ctx context.Context, req llm.ChatCompletio
 // CreateChatCompletionStream calls llm-api /v1/chat/completions with streaming enabled.
 func (c *Client) CreateChatCompletionStream(ctx context.Context, req llm.ChatCompletionRequest) (llm.Stream, error) {
 	req.Stream = true
+	req.Messages = withoutReasoning(req.Messages)
 
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -94,6 +97,17 @@ func (c *Client) CreateChatCompletionStream(ctx context.Context, req llm.ChatCom
 	}, nil
 }
 
+// withoutReasoning drops the reasoning of earlier assistant turns, which providers do not accept
+// as input.
+func withoutReasoning(messages []llm.ChatMessage) []llm.ChatMessage {
+	result := make([]llm.ChatMessage, len(messages))
+	for i, msg := range messages {
+		msg.ReasoningContent = ""
+		result[i] = msg
+	}
+	return result
+}
+
 // Ensure interface compliance.
 var _ llm.Provider = (*Client)(nil)
 
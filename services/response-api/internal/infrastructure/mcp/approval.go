@@ -0,0 +1,47 @@
+package mcp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// approvalMetaKey is the tools/call _meta field mcp-tools reads the approval token from.
+const approvalMetaKey = "approval_token"
+
+// approvalTokenTTL bounds how long after signing mcp-tools accepts an approval token.
+const approvalTokenTTL = 5 * time.Minute
+
+// approvalClaims is the signed part of an approval token, in the layout mcp-tools verifies.
+type approvalClaims struct {
+	Tool      string `json:"tool"`
+	Arguments string `json:"args"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// signApproval returns a token approving one call of tool with args until expiresAt:
+// "<payload>.<signature>", both base64url encoded, where the signature is the HMAC-SHA256 of the
+// encoded payload under the secret shared with mcp-tools. The payload binds the token to the tool
+// and the SHA-256 of the JSON encoded arguments.
+func signApproval(secret []byte, tool string, args map[string]interface{}, expiresAt time.Time) (string, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(raw)
+	payload, err := json.Marshal(approvalClaims{Tool: tool, Arguments: hex.EncodeToString(digest[:]), ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"jan-server/services/response-api/internal/domain/tool"
+)
+
+// verifiedByMCPTools is the token mcp-tools accepts for python_exec with {"code":"print(1)"},
+// signed with "shared-secret" and expiring at 1700000000; both services must agree on it.
+const verifiedByMCPTools = "eyJ0b29sIjoicHl0aG9uX2V4ZWMiLCJhcmdzIjoiNTA0MmUyNDkzMTJlZmUyZjczNDkwOTU4ZmUwZmY3N2MyN2JjMDhhNjlhMmMxOTBiNjEyODc2YTcyMTc4NGIyNyIsImV4cCI6MTcwMDAwMDAwMH0.jy51XvYDYWdD6IyjYYlfro9E9zPpwkqYfLjg-BSpw5U"
+
+func TestSignApprovalMatchesMCPTools(t *testing.T) {
+	token, err := signApproval([]byte("shared-secret"), "python_exec", map[string]interface{}{"code": "print(1)"}, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if token != verifiedByMCPTools {
+		t.Fatalf("unexpected token %q", token)
+	}
+}
+
+func TestCallToolSendsTokenOnlyForApprovedCalls(t *testing.T) {
+	var meta []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Params struct {
+				Meta map[string]interface{} `json:"_meta"`
+			} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		meta = append(meta, request.Params.Meta)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"ran"}]}}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "shared-secret")
+	args := map[string]interface{}{"code": "print(1)"}
+
+	if _, err := client.CallTool(context.Background(), "python_exec", args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.CallTool(tool.ContextWithApproval(context.Background()), "python_exec", args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if meta[0] != nil {
+		t.Fatalf("expected no token without approval, got %v", meta[0])
+	}
+	if token, _ := meta[1][approvalMetaKey].(string); token == "" {
+		t.Fatalf("expected an approval token for the approved call, got %v", meta[1])
+	}
+}
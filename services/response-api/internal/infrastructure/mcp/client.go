@@ -4,23 +4,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 
+	"jan-server/services/response-api/internal/domain/llm"
 	"jan-server/services/response-api/internal/domain/tool"
 )
 
 // Client implements tool.MCPClient.
 type Client struct {
-	httpClient *resty.Client
+	httpClient     *resty.Client
+	approvalSecret []byte
 }
 
-// NewClient constructs the MCP client.
-func NewClient(baseURL string) *Client {
+// NewClient constructs the MCP client. Calls the user approved carry an approval token signed
+// with approvalSecret, which mcp-tools requires for tools marked requires_approval.
+func NewClient(baseURL, approvalSecret string) *Client {
 	return &Client{
 		httpClient: resty.New().
 			SetBaseURL(baseURL).
 			SetHeader("Content-Type", "application/json"),
+		approvalSecret: []byte(approvalSecret),
 	}
 }
 
@@ -34,8 +39,7 @@ func (c *Client) ListTools(ctx context.Context) ([]tool.MCPTool, error) {
 	}
 
 	var rpcResp rpcResponse
-	resp, err := c.httpClient.R().
-		SetContext(ctx).
+	resp, err := c.request(ctx).
 		SetBody(payload).
 		SetResult(&rpcResp).
 		Post("/v1/mcp")
@@ -60,19 +64,26 @@ func (c *Client) ListTools(ctx context.Context) ([]tool.MCPTool, error) {
 
 // CallTool triggers a tool execution via JSON-RPC tools/call.
 func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*tool.Result, error) {
+	params := map[string]interface{}{
+		"name":      name,
+		"arguments": args,
+	}
+	if tool.ApprovedFromContext(ctx) && len(c.approvalSecret) > 0 {
+		token, err := signApproval(c.approvalSecret, name, args, time.Now().Add(approvalTokenTTL))
+		if err != nil {
+			return nil, fmt.Errorf("sign tool approval: %w", err)
+		}
+		params["_meta"] = map[string]interface{}{approvalMetaKey: token}
+	}
 	payload := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"method":  "tools/call",
-		"params": map[string]interface{}{
-			"name":      name,
-			"arguments": args,
-		},
-		"id": name,
+		"params":  params,
+		"id":      name,
 	}
 
 	var rpcResp rpcResponse
-	resp, err := c.httpClient.R().
-		SetContext(ctx).
+	resp, err := c.request(ctx).
 		SetBody(payload).
 		SetResult(&rpcResp).
 		Post("/v1/mcp")
@@ -103,6 +114,21 @@ func (c *Client) CallTool(ctx context.Context, name string, args map[string]inte
 	}, nil
 }
 
+// request starts a call on behalf of the caller in ctx; mcp-tools applies its tool policy to the
+// user the forwarded credentials belong to and to the API key the caller sent, the way the gateway
+// passes them on.
+func (c *Client) request(ctx context.Context) *resty.Request {
+	request := c.httpClient.R().SetContext(ctx)
+	if token := llm.AuthTokenFromContext(ctx); token != "" {
+		request.SetHeader("Authorization", token)
+	}
+	if key, ok := llm.APIKeyFromContext(ctx); ok {
+		request.SetHeader("X-API-Key-ID", key.ID)
+		request.SetHeader("X-User-Subject", key.Subject)
+	}
+	return request
+}
+
 type rpcResponse struct {
 	JSONRPC string          `json:"jsonrpc"`
 	Result  json.RawMessage `json:"result"`
@@ -0,0 +1,37 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"jan-server/services/response-api/internal/domain/llm"
+)
+
+func TestRequestForwardsCallerCredentials(t *testing.T) {
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"tools":[]}}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "")
+
+	ctx := llm.ContextWithAuthToken(context.Background(), "Bearer token-1")
+	if _, err := client.ListTools(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx = llm.ContextWithAPIKey(ctx, llm.APIKey{ID: "key-1", Subject: "user-1"})
+	if _, err := client.ListTools(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if headers[0].Get("Authorization") != "Bearer token-1" || headers[0].Get("X-API-Key-ID") != "" {
+		t.Fatalf("expected only the token without an API key, got %v", headers[0])
+	}
+	if headers[1].Get("X-API-Key-ID") != "key-1" || headers[1].Get("X-User-Subject") != "user-1" {
+		t.Fatalf("expected the API key and its user, got %v", headers[1])
+	}
+}
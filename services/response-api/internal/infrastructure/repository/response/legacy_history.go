@@ -0,0 +1,78 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"jan-server/services/response-api/internal/domain/llm"
+	domain "jan-server/services/response-api/internal/domain/response"
+	"jan-server/services/response-api/internal/infrastructure/database/entities"
+)
+
+// ListLegacyMessages reads the items of a conversation stored by response-api before
+// conversations moved to llm-api, oldest first.
+func (r *PostgresRepository) ListLegacyMessages(ctx context.Context, legacyConversationID uint) ([]llm.ChatMessage, error) {
+	db := r.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&entities.LegacyConversationItem{}) {
+		return nil, nil
+	}
+
+	var rows []entities.LegacyConversationItem
+	if err := db.
+		Where("conversation_id = ?", legacyConversationID).
+		Order("sequence ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	messages := make([]llm.ChatMessage, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, llm.ChatMessage{Role: row.Role, Content: legacyContent(row.Content)})
+	}
+	return messages, nil
+}
+
+// AssignConversation sets the llm-api conversation of the responses of a legacy conversation that
+// have none yet, and returns the conversation they hold.
+func (r *PostgresRepository) AssignConversation(ctx context.Context, legacyConversationID uint, conversationID string) (string, error) {
+	db := r.db.WithContext(ctx)
+	if err := db.
+		Model(&entities.Response{}).
+		Where("conversation_id = ? AND conversation_public_id IS NULL", legacyConversationID).
+		Update("conversation_public_id", conversationID).Error; err != nil {
+		return "", err
+	}
+
+	var assigned []string
+	if err := db.
+		Model(&entities.Response{}).
+		Where("conversation_id = ? AND conversation_public_id IS NOT NULL", legacyConversationID).
+		Order("id ASC").
+		Limit(1).
+		Pluck("conversation_public_id", &assigned).Error; err != nil {
+		return "", err
+	}
+	if len(assigned) == 0 {
+		return "", fmt.Errorf("legacy conversation %d has no responses", legacyConversationID)
+	}
+	return assigned[0], nil
+}
+
+// legacyContent returns the chat content of a legacy item: the text of text items, the parts of
+// list items and the stored JSON otherwise.
+func legacyContent(raw []byte) interface{} {
+	var content map[string]interface{}
+	if err := json.Unmarshal(raw, &content); err != nil || content == nil {
+		return string(raw)
+	}
+	if text, ok := content["text"].(string); ok {
+		return text
+	}
+	if parts, ok := content["items"].([]interface{}); ok {
+		return parts
+	}
+	return string(raw)
+}
+
+var _ domain.LegacyHistory = (*PostgresRepository)(nil)
@@ -3,6 +3,7 @@ package response
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -53,14 +54,44 @@ func (r *PostgresRepository) Update(ctx context.Context, resp *domain.Response)
 	return nil
 }
 
+// UpdateUnlessCancelled persists changes in a statement conditional on the stored status.
+func (r *PostgresRepository) UpdateUnlessCancelled(ctx context.Context, resp *domain.Response) (bool, error) {
+	entity, err := mapToEntity(resp)
+	if err != nil {
+		return false, err
+	}
+	entity.ID = resp.ID
+
+	result := r.db.WithContext(ctx).
+		Model(&entities.Response{ID: resp.ID}).
+		Where("status <> ?", string(domain.StatusCancelled)).
+		Updates(entity)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FindStatus reads only the status column of a response.
+func (r *PostgresRepository) FindStatus(ctx context.Context, publicID string) (domain.Status, error) {
+	var status string
+	if err := r.db.WithContext(ctx).
+		Model(&entities.Response{}).
+		Where("public_id = ?", publicID).
+		Select("status").
+		Take(&status).Error; err != nil {
+		return "", notFound(err, publicID)
+	}
+	return domain.Status(status), nil
+}
+
 // FindByPublicID fetches a response and hydrates the domain model.
 func (r *PostgresRepository) FindByPublicID(ctx context.Context, publicID string) (*domain.Response, error) {
 	var entity entities.Response
 	if err := r.db.WithContext(ctx).
-		Preload("Conversation").
 		Where("public_id = ?", publicID).
 		First(&entity).Error; err != nil {
-		return nil, err
+		return nil, notFound(err, publicID)
 	}
 
 	resp := &domain.Response{}
@@ -70,6 +101,14 @@ func (r *PostgresRepository) FindByPublicID(ctx context.Context, publicID string
 	return resp, nil
 }
 
+// notFound translates a missing row into domain.ErrResponseNotFound.
+func notFound(err error, publicID string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %s", domain.ErrResponseNotFound, publicID)
+	}
+	return err
+}
+
 // MarkCancelled sets the status and timestamps for a cancelled response.
 func (r *PostgresRepository) MarkCancelled(ctx context.Context, resp *domain.Response) error {
 	now := time.Now()
@@ -78,6 +117,39 @@ func (r *PostgresRepository) MarkCancelled(ctx context.Context, resp *domain.Res
 	return r.Update(ctx, resp)
 }
 
+// TransitionStatus updates the status in a single conditional statement, so concurrent callers
+// cannot both move the response out of the from status.
+func (r *PostgresRepository) TransitionStatus(ctx context.Context, publicID string, from, to domain.Status) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.Response{}).
+		Where("public_id = ? AND status = ?", publicID, string(from)).
+		Updates(map[string]interface{}{"status": string(to), "updated_at": time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FailInterrupted fails the stale background responses in a single statement.
+func (r *PostgresRepository) FailInterrupted(ctx context.Context, before time.Time, details domain.ErrorDetails) (int64, error) {
+	errJSON, err := marshalJSON(details)
+	if err != nil {
+		return 0, fmt.Errorf("marshal error: %w", err)
+	}
+
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&entities.Response{}).
+		Where("background = ? AND status IN ? AND updated_at < ?", true, []string{string(domain.StatusPending), string(domain.StatusInProgress)}, before).
+		Updates(map[string]interface{}{
+			"status":     string(domain.StatusFailed),
+			"error":      errJSON,
+			"failed_at":  now,
+			"updated_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
+
 // RecordExecutions persists tool execution snapshot rows.
 func (r *PostgresRepository) RecordExecutions(ctx context.Context, responseID uint, executions []tool.Execution) error {
 	if len(executions) == 0 {
@@ -132,25 +204,32 @@ func mapToEntity(resp *domain.Response) (*entities.Response, error) {
 	if err != nil {
 		return nil, fmt.Errorf("marshal error: %w", err)
 	}
+	requiredAction, err := marshalJSON(resp.RequiredAction)
+	if err != nil {
+		return nil, fmt.Errorf("marshal required action: %w", err)
+	}
 
 	return &entities.Response{
-		PublicID:           resp.PublicID,
-		UserID:             resp.UserID,
-		Model:              resp.Model,
-		SystemPrompt:       resp.SystemPrompt,
-		Input:              input,
-		Output:             output,
-		Status:             string(resp.Status),
-		Stream:             resp.Stream,
-		Metadata:           metadata,
-		Usage:              usage,
-		Error:              errJSON,
-		ConversationID:     resp.ConversationID,
-		PreviousResponseID: resp.PreviousResponseID,
-		Object:             resp.Object,
-		CompletedAt:        resp.CompletedAt,
-		CancelledAt:        resp.CancelledAt,
-		FailedAt:           resp.FailedAt,
+		PublicID:             resp.PublicID,
+		UserID:               resp.UserID,
+		Model:                resp.Model,
+		SystemPrompt:         resp.SystemPrompt,
+		Input:                input,
+		Output:               output,
+		Status:               string(resp.Status),
+		Stream:               resp.Stream,
+		Background:           resp.Background,
+		Metadata:             metadata,
+		Usage:                usage,
+		Error:                errJSON,
+		RequiredAction:       requiredAction,
+		ConversationPublicID: resp.ConversationPublicID,
+		LegacyConversationID: resp.LegacyConversationID,
+		PreviousResponseID:   resp.PreviousResponseID,
+		Object:               resp.Object,
+		CompletedAt:          resp.CompletedAt,
+		CancelledAt:          resp.CancelledAt,
+		FailedAt:             resp.FailedAt,
 	}, nil
 }
 
@@ -162,7 +241,9 @@ func mapFromEntity(entity *entities.Response, resp *domain.Response) error {
 	resp.SystemPrompt = entity.SystemPrompt
 	resp.Status = domain.Status(entity.Status)
 	resp.Stream = entity.Stream
-	resp.ConversationID = entity.ConversationID
+	resp.Background = entity.Background
+	resp.ConversationPublicID = entity.ConversationPublicID
+	resp.LegacyConversationID = entity.LegacyConversationID
 	resp.PreviousResponseID = entity.PreviousResponseID
 	resp.CreatedAt = entity.CreatedAt
 	resp.UpdatedAt = entity.UpdatedAt
@@ -175,9 +256,11 @@ func mapFromEntity(entity *entities.Response, resp *domain.Response) error {
 		return fmt.Errorf("unmarshal input: %w", err)
 	}
 	if len(entity.Output) > 0 {
-		if err := json.Unmarshal(entity.Output, &resp.Output); err != nil {
+		output, err := unmarshalOutput(entity.Output)
+		if err != nil {
 			return fmt.Errorf("unmarshal output: %w", err)
 		}
+		resp.Output = output
 	}
 	if len(entity.Metadata) > 0 {
 		if err := json.Unmarshal(entity.Metadata, &resp.Metadata); err != nil {
@@ -197,8 +280,11 @@ func mapFromEntity(entity *entities.Response, resp *domain.Response) error {
 		}
 	}
 
-	if resp.ConversationPublicID == nil && entity.Conversation != nil {
-		resp.ConversationPublicID = &entity.Conversation.PublicID
+	if len(entity.RequiredAction) > 0 {
+		var action domain.RequiredAction
+		if err := json.Unmarshal(entity.RequiredAction, &action); err == nil && action.Type != "" {
+			resp.RequiredAction = &action
+		}
 	}
 
 	return nil
@@ -211,3 +297,34 @@ func marshalJSON(value interface{}) (datatypes.JSON, error) {
 	bytes, err := json.Marshal(value)
 	return datatypes.JSON(bytes), err
 }
+
+// unmarshalOutput decodes stored output items. Rows written before typed output items hold the
+// final message content, which is returned as a single message item.
+func unmarshalOutput(data []byte) ([]domain.OutputItem, error) {
+	var items []domain.OutputItem
+	if err := json.Unmarshal(data, &items); err == nil && hasItemIDs(items) {
+		return items, nil
+	}
+
+	var content interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+	switch v := content.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return domain.NewMessageOutput(v), nil
+	default:
+		return domain.NewMessageOutput(string(data)), nil
+	}
+}
+
+func hasItemIDs(items []domain.OutputItem) bool {
+	for _, item := range items {
+		if item.ID == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package toolpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"jan-server/services/response-api/internal/domain/tool"
+)
+
+// policyFile is the JSON layout of TOOL_POLICY_FILE.
+type policyFile struct {
+	Default          *tool.PolicyRule           `json:"default"`
+	Users            map[string]tool.PolicyRule `json:"users"`
+	APIKeys          map[string]tool.PolicyRule `json:"api_keys"`
+	ApprovalRequired []string                   `json:"approval_required_tools"`
+}
+
+// Load reads the tool policy from path. Rules of api_keys are keyed by the API key ID. Without a
+// file, or when the file has no default rule, callers without a rule of their own may use every
+// tool except defaultDenied. Calls of tools
+// matching approvalRequired or the file's approval_required_tools need the user's approval.
+func Load(path string, defaultDenied, approvalRequired []string) (*tool.Policy, error) {
+	policy := &tool.Policy{
+		Default:          tool.PolicyRule{DeniedTools: defaultDenied},
+		ApprovalRequired: approvalRequired,
+	}
+	if strings.TrimSpace(path) == "" {
+		return policy, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tool policy: %w", err)
+	}
+	var file policyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse tool policy %s: %w", path, err)
+	}
+
+	if file.Default != nil {
+		policy.Default = *file.Default
+	}
+	policy.Users = file.Users
+	policy.APIKeys = file.APIKeys
+	policy.ApprovalRequired = append(policy.ApprovalRequired, file.ApprovalRequired...)
+	return policy, nil
+}
@@ -0,0 +1,67 @@
+package toolpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	return path
+}
+
+func TestLoadWithoutFileDeniesDefaultTools(t *testing.T) {
+	policy, err := Load("", []string{"python_exec"}, []string{"exa_*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.RuleFor("user-1", "").Allows("python_exec") {
+		t.Fatal("expected python_exec to be denied by default")
+	}
+	if !policy.RequiresApproval("exa_search") {
+		t.Fatal("expected exa_search to need approval")
+	}
+}
+
+func TestLoadReadsUserRules(t *testing.T) {
+	path := writePolicy(t, `{
+		"default": {"denied_tools": ["python_exec", "scrape"]},
+		"users": {"user-1": {"allowed_tools": ["python_exec"]}},
+		"approval_required_tools": ["python_exec"]
+	}`)
+	policy, err := Load(path, []string{"python_exec"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !policy.RuleFor("user-1", "").Allows("python_exec") {
+		t.Fatal("expected the user rule to allow python_exec")
+	}
+	if policy.RuleFor("user-2", "").Allows("scrape") {
+		t.Fatal("expected the file's default rule to replace the default denied tools")
+	}
+	if !policy.RequiresApproval("python_exec") {
+		t.Fatal("expected python_exec to need approval")
+	}
+}
+
+func TestLoadReadsAPIKeyRules(t *testing.T) {
+	path := writePolicy(t, `{
+		"users": {"user-1": {"allowed_tools": ["*"]}},
+		"api_keys": {"key-1": {"allowed_tools": ["google_search", "scrape"]}}
+	}`)
+	policy, err := Load(path, []string{"python_exec"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule := policy.RuleFor("user-1", "key-1"); rule.Allows("python_exec") || !rule.Allows("scrape") {
+		t.Fatal("expected the API key rule to replace the user rule")
+	}
+	if !policy.RuleFor("user-1", "").Allows("python_exec") {
+		t.Fatal("expected the user rule without an API key")
+	}
+}
@@ -7,10 +7,19 @@ type ToolFunctionDefinition struct {
 	Parameters  map[string]interface{} `json:"parameters"`
 }
 
-// ToolDefinition describes a tool in the HTTP contract.
+// ToolDefinition describes a tool in the HTTP contract. Function tools may use the nested chat
+// completions shape or the flat Responses shape with name/description/parameters at the top level.
+// MCP tools ("type": "mcp") select the tools of an mcp-tools server by its label; require_approval
+// "always" makes their calls wait for the user's approval.
 type ToolDefinition struct {
-	Type     string                 `json:"type"`
-	Function ToolFunctionDefinition `json:"function"`
+	Type            string                 `json:"type"`
+	Function        ToolFunctionDefinition `json:"function"`
+	Name            string                 `json:"name,omitempty"`
+	Description     string                 `json:"description,omitempty"`
+	Parameters      map[string]interface{} `json:"parameters,omitempty"`
+	ServerLabel     string                 `json:"server_label,omitempty"`
+	AllowedTools    []string               `json:"allowed_tools,omitempty"`
+	RequireApproval string                 `json:"require_approval,omitempty"`
 }
 
 // ToolChoice allows callers to force or disable tools.
@@ -21,6 +30,36 @@ type ToolChoice struct {
 	} `json:"function"`
 }
 
+// TextConfig configures the text output of a response.
+type TextConfig struct {
+	Format *TextFormat `json:"format,omitempty"`
+}
+
+// TextFormat is the output format in the Responses shape: "text", "json_object", or "json_schema"
+// with the schema inline.
+type TextFormat struct {
+	Type        string                 `json:"type"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema,omitempty"`
+	Strict      bool                   `json:"strict,omitempty"`
+}
+
+// ResponseFormat is the output format in the chat completions shape, accepted in place of
+// text.format.
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat holds the schema of a chat completions json_schema response format.
+type JSONSchemaFormat struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
+	Strict      bool                   `json:"strict,omitempty"`
+}
+
 // CreateResponseRequest models POST /v1/responses input.
 type CreateResponseRequest struct {
 	Model              string                 `json:"model" binding:"required"`
@@ -30,9 +69,13 @@ type CreateResponseRequest struct {
 	Temperature        *float64               `json:"temperature,omitempty"`
 	Tools              []ToolDefinition       `json:"tools,omitempty"`
 	ToolChoice         *ToolChoice            `json:"tool_choice,omitempty"`
+	Text               *TextConfig            `json:"text,omitempty"`
+	ResponseFormat     *ResponseFormat        `json:"response_format,omitempty"`
 	Stream             *bool                  `json:"stream,omitempty"`
+	Background         *bool                  `json:"background,omitempty"`
 	PreviousResponseID *string                `json:"previous_response_id,omitempty"`
 	Conversation       *string                `json:"conversation,omitempty"`
 	Metadata           map[string]interface{} `json:"metadata,omitempty"`
-	User               string                 `json:"user,omitempty"`
+	// User is accepted for OpenAI compatibility; responses belong to the authenticated caller
+	User string `json:"user,omitempty"`
 }
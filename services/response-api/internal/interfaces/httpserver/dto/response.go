@@ -10,18 +10,25 @@ type ResponsePayload struct {
 	Model              string                 `json:"model"`
 	Status             string                 `json:"status"`
 	Input              interface{}            `json:"input"`
-	Output             interface{}            `json:"output,omitempty"`
+	Output             []response.OutputItem  `json:"output"`
+	OutputText         string                 `json:"output_text"`
 	Usage              interface{}            `json:"usage,omitempty"`
 	Metadata           map[string]interface{} `json:"metadata,omitempty"`
 	ConversationID     *string                `json:"conversation_id,omitempty"`
 	PreviousResponseID *string                `json:"previous_response_id,omitempty"`
 	SystemPrompt       *string                `json:"system_prompt,omitempty"`
 	Stream             bool                   `json:"stream"`
+	Background         bool                   `json:"background"`
 	Error              interface{}            `json:"error,omitempty"`
+	RequiredAction     interface{}            `json:"required_action,omitempty"`
 }
 
 // FromDomain maps the domain response to DTO.
 func FromDomain(r *response.Response) ResponsePayload {
+	output := r.Output
+	if output == nil {
+		output = []response.OutputItem{}
+	}
 	return ResponsePayload{
 		ID:                 r.PublicID,
 		Object:             r.Object,
@@ -29,13 +36,16 @@ func FromDomain(r *response.Response) ResponsePayload {
 		Model:              r.Model,
 		Status:             string(r.Status),
 		Input:              r.Input,
-		Output:             r.Output,
+		Output:             output,
+		OutputText:         response.OutputText(r.Output),
 		Usage:              r.Usage,
 		Metadata:           r.Metadata,
 		ConversationID:     r.ConversationPublicID,
 		PreviousResponseID: r.PreviousResponseID,
 		SystemPrompt:       r.SystemPrompt,
 		Stream:             r.Stream,
+		Background:         r.Background,
 		Error:              r.Error,
+		RequiredAction:     r.RequiredAction,
 	}
 }
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"time"
+
 	"github.com/rs/zerolog"
 
 	domain "jan-server/services/response-api/internal/domain/response"
@@ -12,8 +14,8 @@ type Provider struct {
 }
 
 // NewProvider constructs the handler provider with domain services.
-func NewProvider(responseService domain.Service, log zerolog.Logger) *Provider {
+func NewProvider(responseService domain.Service, events domain.EventStore, resumeGrace time.Duration, log zerolog.Logger) *Provider {
 	return &Provider{
-		Response: NewResponseHandler(responseService, log),
+		Response: NewResponseHandler(responseService, events, resumeGrace, log),
 	}
 }
@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -13,33 +18,42 @@ import (
 
 	"jan-server/services/response-api/internal/domain/llm"
 	"jan-server/services/response-api/internal/domain/response"
-	"jan-server/services/response-api/internal/domain/tool"
 	"jan-server/services/response-api/internal/interfaces/httpserver/dto"
 )
 
 // ResponseHandler exposes HTTP entrypoints for the Responses API.
 type ResponseHandler struct {
-	service response.Service
-	log     zerolog.Logger
+	service  response.Service
+	events   response.EventStore
+	watchers *streamWatchers
+	log      zerolog.Logger
 }
 
-// NewResponseHandler constructs the handler.
-func NewResponseHandler(service response.Service, log zerolog.Logger) *ResponseHandler {
-	return &ResponseHandler{
+// NewResponseHandler constructs the handler. Streaming responses whose client dropped are
+// cancelled after resumeGrace unless a client reattaches to their events.
+func NewResponseHandler(service response.Service, events response.EventStore, resumeGrace time.Duration, log zerolog.Logger) *ResponseHandler {
+	h := &ResponseHandler{
 		service: service,
+		events:  events,
 		log:     log.With().Str("handler", "response").Logger(),
 	}
+	h.watchers = newStreamWatchers(resumeGrace, h.cancelDetached)
+	return h
 }
 
 // Create handles POST /v1/responses
 // @Summary Create a response
-// @Description Creates a response and orchestrates MCP tool calls when required.
+// @Description Creates a response and orchestrates MCP tool calls when required. With background
+// @Description set the response is returned as pending and orchestrated asynchronously.
 // @Tags Responses
 // @Accept json
 // @Produce json
 // @Param request body dto.CreateResponseRequest true "Create request"
 // @Success 200 {object} dto.ResponsePayload
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /v1/responses [post]
 func (h *ResponseHandler) Create(c *gin.Context) {
 	var req dto.CreateResponseRequest
@@ -48,34 +62,50 @@ func (h *ResponseHandler) Create(c *gin.Context) {
 		return
 	}
 
-	userID := req.User
-	if userID == "" {
-		userID = extractSubject(c)
-		if userID == "" {
-			userID = "guest"
-		}
-	}
+	// Responses belong to the authenticated caller; the request's user field is not trusted
+	userID := callerID(c)
 
 	stream := req.Stream != nil && *req.Stream
+	background := req.Background != nil && *req.Background
+
+	tools, mcpServers, err := mapTools(req.Tools)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format, err := mapResponseFormat(req.Text, req.ResponseFormat)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	params := response.CreateParams{
 		UserID:             userID,
+		APIKeyID:           callerAPIKey(c),
 		Model:              req.Model,
 		Input:              req.Input,
 		SystemPrompt:       req.SystemPrompt,
 		Temperature:        req.Temperature,
 		MaxTokens:          req.MaxTokens,
 		Stream:             stream,
+		Background:         background,
 		ToolChoice:         mapToolChoice(req.ToolChoice),
-		Tools:              mapTools(req.Tools),
+		Tools:              tools,
+		MCPServers:         mcpServers,
+		ResponseFormat:     format,
 		PreviousResponseID: req.PreviousResponseID,
 		ConversationID:     req.Conversation,
 		Metadata:           req.Metadata,
 	}
 
 	authCtx := llm.ContextWithAuthToken(c.Request.Context(), strings.TrimSpace(c.GetHeader("Authorization")))
+	authCtx = llm.ContextWithAPIKey(authCtx, llm.APIKey{ID: params.APIKeyID, Subject: userID})
 	c.Request = c.Request.WithContext(authCtx)
 
+	if background {
+		h.createBackground(c, params)
+		return
+	}
 	if stream {
 		h.streamResponse(c, params)
 		return
@@ -83,7 +113,7 @@ func (h *ResponseHandler) Create(c *gin.Context) {
 
 	resp, err := h.service.Create(c.Request.Context(), params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(createErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -92,32 +122,64 @@ func (h *ResponseHandler) Create(c *gin.Context) {
 
 // Get handles GET /v1/responses/:id
 // @Summary Get a response by ID
+// @Description With stream=true the stored events of the response are streamed as SSE, starting
+// @Description after the starting_after sequence number.
 // @Tags Responses
 // @Produce json
 // @Param response_id path string true "Response ID"
+// @Param stream query bool false "Stream the response events"
+// @Param starting_after query int false "Sequence number to resume the event stream after"
 // @Success 200 {object} dto.ResponsePayload
 // @Failure 404 {object} map[string]string
 // @Router /v1/responses/{response_id} [get]
 func (h *ResponseHandler) Get(c *gin.Context) {
 	id := c.Param("response_id")
 	resp, err := h.service.GetByPublicID(c.Request.Context(), id)
+	if err == nil && resp.UserID != callerID(c) {
+		// Another user's response is reported like a missing one
+		err = response.ErrResponseNotFound
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+
+	if stream, _ := strconv.ParseBool(c.Query("stream")); stream {
+		after := 0
+		if raw := c.Query("starting_after"); raw != "" {
+			if after, err = strconv.Atoi(raw); err != nil || after < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "starting_after must be a non-negative integer"})
+				return
+			}
+		}
+		h.streamEvents(c, resp.PublicID, after)
+		return
+	}
 	c.JSON(http.StatusOK, dto.FromDomain(resp))
 }
 
 // Cancel handles POST /v1/responses/:id/cancel
 // @Summary Cancel a response
+// @Description Cancels a queued or in-progress response; other responses are returned unchanged.
 // @Tags Responses
 // @Produce json
 // @Param response_id path string true "Response ID"
 // @Success 200 {object} dto.ResponsePayload
+// @Failure 404 {object} map[string]string
 // @Router /v1/responses/{response_id}/cancel [post]
 func (h *ResponseHandler) Cancel(c *gin.Context) {
 	id := c.Param("response_id")
-	resp, err := h.service.Cancel(c.Request.Context(), id)
+	// Cancelling aborts a running response, so only its owner may do it
+	resp, err := h.service.GetByPublicID(c.Request.Context(), id)
+	if err == nil && resp.UserID != callerID(c) {
+		err = response.ErrResponseNotFound
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err = h.service.Cancel(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -131,6 +193,7 @@ func (h *ResponseHandler) Cancel(c *gin.Context) {
 // @Produce json
 // @Param response_id path string true "Response ID"
 // @Success 200 {object} dto.ResponsePayload
+// @Failure 404 {object} map[string]string
 // @Router /v1/responses/{response_id} [delete]
 func (h *ResponseHandler) Delete(c *gin.Context) {
 	h.Cancel(c)
@@ -141,11 +204,18 @@ func (h *ResponseHandler) Delete(c *gin.Context) {
 // @Tags Responses
 // @Produce json
 // @Param response_id path string true "Response ID"
-// @Success 200 {array} response.ConversationItem
+// @Success 200 {array} conversation.Item
+// @Failure 404 {object} map[string]string
 // @Router /v1/responses/{response_id}/input_items [get]
 func (h *ResponseHandler) ListInputItems(c *gin.Context) {
 	id := c.Param("response_id")
-	items, err := h.service.ListConversationItems(c.Request.Context(), id)
+	// Items are read from the llm-api conversation on behalf of the caller
+	authCtx := llm.ContextWithAuthToken(c.Request.Context(), strings.TrimSpace(c.GetHeader("Authorization")))
+	items, err := h.service.ListConversationItems(authCtx, callerID(c), id)
+	if errors.Is(err, response.ErrResponseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -155,31 +225,185 @@ func (h *ResponseHandler) ListInputItems(c *gin.Context) {
 }
 
 func (h *ResponseHandler) streamResponse(c *gin.Context, params response.CreateParams) {
-	writer := c.Writer
-	flusher, ok := writer.(http.Flusher)
+	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming not supported"})
 		return
 	}
+	setSSEHeaders(c)
+
+	// Events are written to the client first and stored behind it, so a client that drops can
+	// reattach with GET /v1/responses/:id?stream=true. The response outlives the connection for
+	// the resume grace period and is cancelled if nobody reattaches.
+	observer := &detachingObserver{
+		sseObserver: newSSEObserver(multiSink{
+			&sseWriter{writer: c.Writer, flusher: flusher},
+			newStoreSink(h.events, h.log),
+		}, h.log),
+		watchers: h.watchers,
+	}
+	params.StreamObserver = observer
 
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
+	requestCtx := c.Request.Context()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-requestCtx.Done():
+			observer.detach()
+		case <-done:
+		}
+	}()
 
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
+	_, err := h.service.Create(context.WithoutCancel(requestCtx), params)
+	h.watchers.forget(observer.ResponseID())
+	if err != nil {
+		if !observer.Finished() {
+			observer.SendError(err)
+		}
+		c.Status(createErrorStatus(err))
+	}
+}
 
-	observer := newSSEObserver(writer, flusher, h.log)
-	params.StreamObserver = observer
+// createBackground queues a background response. Its events are kept in the event store, so a
+// streaming caller is attached to them the same way as a later GET with stream=true.
+func (h *ResponseHandler) createBackground(c *gin.Context, params response.CreateParams) {
+	params.StreamObserver = newSSEObserver(newStoreSink(h.events, h.log), h.log)
 
 	resp, err := h.service.Create(c.Request.Context(), params)
 	if err != nil {
-		observer.SendError(err)
-		c.Status(http.StatusInternalServerError)
+		c.JSON(createErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	observer.SendCompleted(resp)
+	if !params.Stream {
+		c.JSON(http.StatusOK, dto.FromDomain(resp))
+		return
+	}
+	h.streamEvents(c, resp.PublicID, 0)
+}
+
+// streamEvents writes the stored events of a response after afterSequence and follows new events
+// until the response finishes or the client goes away.
+func (h *ResponseHandler) streamEvents(c *gin.Context, responseID string, afterSequence int) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming not supported"})
+		return
+	}
+
+	events, err := h.events.Subscribe(c.Request.Context(), responseID, afterSequence)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, response.ErrEventStreamNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A reattached client keeps a foreground stream alive until it goes away again, whichever
+	// instance runs the response
+	if h.watchers.attach(responseID) {
+		defer h.watchers.detach(responseID)
+	}
+	if release, err := h.events.Attach(c.Request.Context(), responseID); err != nil {
+		h.log.Warn().Err(err).Str("response_id", responseID).Msg("attach response stream client")
+	} else {
+		defer release()
+	}
+
+	setSSEHeaders(c)
+	c.Status(http.StatusOK)
+	var last string
+	for event := range events {
+		writeSSE(c.Writer, flusher, event.SequenceNumber, event.Type, event.Data)
+		last = event.Type
+	}
+	if !isFinishedEvent(last) && c.Request.Context().Err() == nil {
+		h.sendFinalState(c, flusher, responseID)
+	}
+}
+
+// sendFinalState ends a stream whose final event was never stored with the stored state of the
+// response, e.g. after its instance died and the response was failed by the sweep.
+func (h *ResponseHandler) sendFinalState(c *gin.Context, flusher http.Flusher, responseID string) {
+	resp, err := h.service.GetByPublicID(c.Request.Context(), responseID)
+	if err != nil {
+		h.log.Error().Err(err).Str("response_id", responseID).Msg("load final response state")
+		return
+	}
+	name := finishedEventName(resp.Status)
+	data, err := json.Marshal(gin.H{"type": name, "response": dto.FromDomain(resp)})
+	if err != nil {
+		return
+	}
+	writeSSE(c.Writer, flusher, 0, name, data)
+}
+
+func setSSEHeaders(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+}
+
+// cancelDetached cancels a streaming response no client is attached to anymore, unless clients
+// reattached through another instance. It reports whether the response was cancelled.
+func (h *ResponseHandler) cancelDetached(responseID string) bool {
+	attached, err := h.events.Attached(context.Background(), responseID)
+	if err != nil {
+		h.log.Error().Err(err).Str("response_id", responseID).Msg("check response stream clients")
+		return false
+	}
+	if attached {
+		return false
+	}
+	if _, err := h.service.Cancel(context.Background(), responseID); err != nil {
+		h.log.Error().Err(err).Str("response_id", responseID).Msg("cancel detached response")
+	}
+	return true
+}
+
+// createErrorStatus maps response creation errors to HTTP status codes.
+func createErrorStatus(err error) int {
+	if errors.Is(err, response.ErrInvalidInput) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, response.ErrToolNotAllowed) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, response.ErrResponseNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, response.ErrRequiredActionTaken) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, response.ErrWorkerPoolFull) || errors.Is(err, response.ErrWorkerPoolClosed) {
+		return http.StatusServiceUnavailable
+	}
+	if errors.Is(err, response.ErrInvalidStructuredOutput) {
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// callerID returns the user responses of the request belong to; unauthenticated callers share the
+// guest user.
+func callerID(c *gin.Context) string {
+	if userID := extractSubject(c); userID != "" {
+		return userID
+	}
+	return "guest"
+}
+
+// callerAPIKey returns the ID of the API key the caller sent next to its token. The gateway
+// validates the key and replaces any X-API-Key-ID sent by the client; a key of another user than
+// the token's is ignored.
+func callerAPIKey(c *gin.Context) string {
+	subject := extractSubject(c)
+	if subject == "" || strings.TrimSpace(c.GetHeader("X-User-Subject")) != subject {
+		return ""
+	}
+	return strings.TrimSpace(c.GetHeader("X-API-Key-ID"))
 }
 
 func extractSubject(c *gin.Context) string {
@@ -199,22 +423,98 @@ func extractSubject(c *gin.Context) string {
 	return ""
 }
 
-func mapTools(tools []dto.ToolDefinition) []llm.ToolDefinition {
+// mapTools splits the requested tools into function tools and MCP server selections.
+func mapTools(tools []dto.ToolDefinition) ([]llm.ToolDefinition, []response.MCPServerSelection, error) {
 	if len(tools) == 0 {
-		return nil
+		return nil, nil, nil
 	}
-	result := make([]llm.ToolDefinition, 0, len(tools))
+	var result []llm.ToolDefinition
+	var servers []response.MCPServerSelection
 	for _, t := range tools {
+		if t.Type == "mcp" {
+			if strings.TrimSpace(t.ServerLabel) == "" {
+				return nil, nil, errors.New("mcp tool requires server_label")
+			}
+			switch t.RequireApproval {
+			case "", "never", "always":
+			default:
+				return nil, nil, fmt.Errorf("unsupported require_approval %q", t.RequireApproval)
+			}
+			servers = append(servers, response.MCPServerSelection{
+				ServerLabel:     t.ServerLabel,
+				AllowedTools:    t.AllowedTools,
+				RequireApproval: t.RequireApproval == "always",
+			})
+			continue
+		}
+		function := llm.ToolFunctionSchema{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+		}
+		if function.Name == "" {
+			function = llm.ToolFunctionSchema{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			}
+		}
 		result = append(result, llm.ToolDefinition{
-			Type: t.Type,
-			Function: llm.ToolFunctionSchema{
-				Name:        t.Function.Name,
-				Description: t.Function.Description,
-				Parameters:  t.Function.Parameters,
-			},
+			Type:     t.Type,
+			Function: function,
 		})
 	}
-	return result
+	return result, servers, nil
+}
+
+// mapResponseFormat returns the output format requested through text.format or, in the chat
+// completions shape, response_format. Plain text needs no format.
+func mapResponseFormat(text *dto.TextConfig, responseFormat *dto.ResponseFormat) (*llm.ResponseFormat, error) {
+	var format *llm.ResponseFormat
+	switch {
+	case text != nil && text.Format != nil:
+		if responseFormat != nil {
+			return nil, errors.New("set either text.format or response_format, not both")
+		}
+		format = &llm.ResponseFormat{Type: text.Format.Type}
+		if text.Format.Type == llm.ResponseFormatJSONSchema {
+			format.JSONSchema = &llm.JSONSchemaFormat{
+				Name:        text.Format.Name,
+				Description: text.Format.Description,
+				Schema:      text.Format.Schema,
+				Strict:      text.Format.Strict,
+			}
+		}
+	case responseFormat != nil:
+		format = &llm.ResponseFormat{Type: responseFormat.Type}
+		if responseFormat.Type == llm.ResponseFormatJSONSchema && responseFormat.JSONSchema != nil {
+			format.JSONSchema = &llm.JSONSchemaFormat{
+				Name:        responseFormat.JSONSchema.Name,
+				Description: responseFormat.JSONSchema.Description,
+				Schema:      responseFormat.JSONSchema.Schema,
+				Strict:      responseFormat.JSONSchema.Strict,
+			}
+		}
+	default:
+		return nil, nil
+	}
+
+	switch format.Type {
+	case llm.ResponseFormatText:
+		return nil, nil
+	case llm.ResponseFormatJSONObject:
+		return format, nil
+	case llm.ResponseFormatJSONSchema:
+		if format.JSONSchema == nil || len(format.JSONSchema.Schema) == 0 {
+			return nil, errors.New("json_schema format requires a schema")
+		}
+		if strings.TrimSpace(format.JSONSchema.Name) == "" {
+			return nil, errors.New("json_schema format requires a name")
+		}
+		return format, nil
+	default:
+		return nil, fmt.Errorf("unsupported format type %q", format.Type)
+	}
 }
 
 func mapToolChoice(choice *dto.ToolChoice) *llm.ToolChoice {
@@ -231,109 +531,323 @@ func mapToolChoice(choice *dto.ToolChoice) *llm.ToolChoice {
 	}
 }
 
+// eventSink delivers the numbered lifecycle events of a response.
+type eventSink interface {
+	Send(responseID string, event response.Event)
+	Finish(responseID string)
+}
+
+// sseObserver turns response lifecycle events into OpenAI Responses stream events.
 type sseObserver struct {
-	writer     http.ResponseWriter
-	flusher    http.Flusher
+	sink       eventSink
 	log        zerolog.Logger
 	mu         sync.Mutex
 	responseID string
+	sequence   int
+	finished   bool
 }
 
-func newSSEObserver(w http.ResponseWriter, flusher http.Flusher, log zerolog.Logger) *sseObserver {
-	return &sseObserver{
-		writer:  w,
-		flusher: flusher,
-		log:     log,
-	}
+func newSSEObserver(sink eventSink, log zerolog.Logger) *sseObserver {
+	return &sseObserver{sink: sink, log: log}
 }
 
 func (o *sseObserver) OnResponseCreated(resp *response.Response) {
+	o.mu.Lock()
 	o.responseID = resp.PublicID
-	o.sendEvent("response.created", dto.FromDomain(resp))
+	o.mu.Unlock()
+	o.sendEvent("response.created", gin.H{"response": dto.FromDomain(resp)})
 }
 
-func (o *sseObserver) OnDelta(delta llm.ChatCompletionDelta) {
-	text := extractDeltaText(delta)
-	if text == "" {
-		return
+func (o *sseObserver) OnResponseInProgress(resp *response.Response) {
+	o.sendEvent("response.in_progress", gin.H{"response": dto.FromDomain(resp)})
+}
+
+// OnOutputItemAdded announces an item. Text parts are announced as content parts and filled by
+// the delta events that follow, as in the OpenAI stream.
+func (o *sseObserver) OnOutputItemAdded(outputIndex int, item response.OutputItem) {
+	parts := textParts(item)
+	switch item.Type {
+	case response.OutputItemMessage:
+		item.Content = []response.OutputContent{}
+	case response.OutputItemReasoning:
+		item.Summary = []response.OutputContent{}
 	}
-	payload := map[string]interface{}{
-		"id":    o.responseID,
-		"delta": text,
+	o.sendEvent("response.output_item.added", gin.H{"output_index": outputIndex, "item": item})
+
+	for index, part := range parts {
+		part.Text = ""
+		o.sendEvent(partEventPrefix(item.Type)+"part.added", gin.H{
+			"item_id":                 item.ID,
+			"output_index":            outputIndex,
+			partIndexField(item.Type): index,
+			"part":                    part,
+		})
 	}
-	o.sendEvent("response.output_text.delta", payload)
 }
 
-func (o *sseObserver) OnToolCall(call tool.Call) {
-	payload := map[string]interface{}{
-		"id":   o.responseID,
-		"call": call,
-	}
-	o.sendEvent("response.tool_call", payload)
+func (o *sseObserver) OnOutputTextDelta(outputIndex int, itemID string, delta string) {
+	o.sendEvent("response.output_text.delta", gin.H{
+		"item_id":       itemID,
+		"output_index":  outputIndex,
+		"content_index": 0,
+		"delta":         delta,
+	})
 }
 
-func (o *sseObserver) OnToolResult(callID string, result *tool.Result) {
-	payload := map[string]interface{}{
-		"id":      o.responseID,
-		"call_id": callID,
-		"result":  result,
+func (o *sseObserver) OnReasoningDelta(outputIndex int, itemID string, delta string) {
+	o.sendEvent("response.reasoning_summary_text.delta", gin.H{
+		"item_id":       itemID,
+		"output_index":  outputIndex,
+		"summary_index": 0,
+		"delta":         delta,
+	})
+}
+
+func (o *sseObserver) OnOutputItemDone(outputIndex int, item response.OutputItem) {
+	for index, part := range textParts(item) {
+		textEvent := "response.output_text.done"
+		if item.Type == response.OutputItemReasoning {
+			textEvent = "response.reasoning_summary_text.done"
+		}
+		o.sendEvent(textEvent, gin.H{
+			"item_id":                 item.ID,
+			"output_index":            outputIndex,
+			partIndexField(item.Type): index,
+			"text":                    part.Text,
+		})
+		o.sendEvent(partEventPrefix(item.Type)+"part.done", gin.H{
+			"item_id":                 item.ID,
+			"output_index":            outputIndex,
+			partIndexField(item.Type): index,
+			"part":                    part,
+		})
 	}
-	o.sendEvent("response.tool_result", payload)
+	o.sendEvent("response.output_item.done", gin.H{"output_index": outputIndex, "item": item})
 }
 
-func (o *sseObserver) SendCompleted(resp *response.Response) {
-	o.sendEvent("response.completed", dto.FromDomain(resp))
+func (o *sseObserver) OnResponseFinished(resp *response.Response) {
+	o.sendEvent(finishedEventName(resp.Status), gin.H{"response": dto.FromDomain(resp)})
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.finished = true
+	o.sink.Finish(o.responseID)
 }
 
-func (o *sseObserver) SendError(err error) {
-	o.sendEvent("response.error", map[string]string{
-		"message": err.Error(),
-	})
+// ResponseID returns the ID of the observed response, or "" before it was created.
+func (o *sseObserver) ResponseID() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.responseID
 }
 
-func (o *sseObserver) sendEvent(name string, payload interface{}) {
+// Finished reports whether the final response event was sent.
+func (o *sseObserver) Finished() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.finished
+}
+
+func (o *sseObserver) SendError(err error) {
+	o.sendEvent("error", gin.H{"message": err.Error()})
+
+	// The error ends the stream, so followers of the stored events stop as well
 	o.mu.Lock()
 	defer o.mu.Unlock()
+	if o.finished {
+		return
+	}
+	o.finished = true
+	if o.responseID != "" {
+		o.sink.Finish(o.responseID)
+	}
+}
 
+// sendEvent numbers the event and hands it to the sink. Payloads carry their event type and
+// sequence number like OpenAI stream events.
+func (o *sseObserver) sendEvent(name string, payload gin.H) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.finished {
+		return
+	}
+	payload["type"] = name
+	payload["sequence_number"] = o.sequence + 1
 	data, err := json.Marshal(payload)
 	if err != nil {
-		o.log.Error().Err(err).Msg("marshal SSE payload")
+		o.log.Error().Err(err).Str("event", name).Msg("marshal SSE payload")
 		return
 	}
+	o.sequence++
+	o.sink.Send(o.responseID, response.Event{SequenceNumber: o.sequence, Type: name, Data: data})
+}
 
-	fmt.Fprintf(o.writer, "event: %s\n", name)
-	fmt.Fprintf(o.writer, "data: %s\n\n", data)
-	o.flusher.Flush()
+// textParts returns the streamed text parts of message and reasoning items.
+func textParts(item response.OutputItem) []response.OutputContent {
+	switch item.Type {
+	case response.OutputItemMessage:
+		return item.Content
+	case response.OutputItemReasoning:
+		return item.Summary
+	}
+	return nil
 }
 
-func extractDeltaText(delta llm.ChatCompletionDelta) string {
-	for _, choice := range delta.Choices {
-		if choice.Delta.Content == nil {
-			continue
-		}
-		if text := normalizeContent(choice.Delta.Content); text != "" {
-			return text
-		}
+func partEventPrefix(itemType string) string {
+	if itemType == response.OutputItemReasoning {
+		return "response.reasoning_summary_"
 	}
-	return ""
+	return "response.content_"
 }
 
-func normalizeContent(content interface{}) string {
-	switch v := content.(type) {
-	case string:
-		return v
-	case []interface{}:
-		builder := strings.Builder{}
-		for _, item := range v {
-			builder.WriteString(normalizeContent(item))
-		}
-		return builder.String()
-	case map[string]interface{}:
-		if text, ok := v["text"].(string); ok {
-			return text
+func partIndexField(itemType string) string {
+	if itemType == response.OutputItemReasoning {
+		return "summary_index"
+	}
+	return "content_index"
+}
+
+// isFinishedEvent reports whether the event ends a response stream.
+func isFinishedEvent(name string) bool {
+	switch name {
+	case "response.completed", "response.requires_action", "response.failed", "response.cancelled", "error":
+		return true
+	}
+	return false
+}
+
+// finishedEventName returns the terminal event sent for a response status.
+func finishedEventName(status response.Status) string {
+	switch status {
+	case response.StatusRequiresAction:
+		return "response.requires_action"
+	case response.StatusFailed:
+		return "response.failed"
+	case response.StatusCancelled:
+		return "response.cancelled"
+	default:
+		return "response.completed"
+	}
+}
+
+// sseWriter writes events straight to the client connection.
+type sseWriter struct {
+	writer  http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (w *sseWriter) Send(responseID string, event response.Event) {
+	writeSSE(w.writer, w.flusher, event.SequenceNumber, event.Type, event.Data)
+}
+
+func (w *sseWriter) Finish(string) {}
+
+// storeSink appends events to the event store so clients can (re)attach to the stream. Events
+// are stored in the background, in batches of what queued up during the previous write, so the
+// store never holds up the client connection. Finish stores the queued events first.
+type storeSink struct {
+	store response.EventStore
+	log   zerolog.Logger
+
+	mu         sync.Mutex
+	responseID string
+	pending    []response.Event
+	wake       chan struct{} // signals the writer; nil until the first event
+	finish     chan struct{} // closed by Finish once the writer runs
+}
+
+func newStoreSink(store response.EventStore, log zerolog.Logger) *storeSink {
+	return &storeSink{store: store, log: log}
+}
+
+func (s *storeSink) Send(responseID string, event response.Event) {
+	if responseID == "" {
+		// Errors raised before the response was created belong to no stream
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wake == nil {
+		s.responseID = responseID
+		s.wake = make(chan struct{}, 1)
+		s.finish = make(chan struct{})
+		go s.write()
+	}
+	s.pending = append(s.pending, event)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *storeSink) Finish(responseID string) {
+	s.mu.Lock()
+	started := s.wake != nil
+	if started {
+		close(s.finish)
+	}
+	s.mu.Unlock()
+	if !started {
+		s.finishStore(responseID)
+	}
+}
+
+// write stores queued events until the stream is finished.
+func (s *storeSink) write() {
+	for {
+		select {
+		case <-s.wake:
+			s.flush()
+		case <-s.finish:
+			s.flush()
+			s.finishStore(s.responseID)
+			return
 		}
 	}
-	return ""
+}
+
+func (s *storeSink) flush() {
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	if err := s.store.Append(context.Background(), s.responseID, batch...); err != nil {
+		s.log.Error().Err(err).Str("response_id", s.responseID).Int("events", len(batch)).Msg("append response events")
+	}
+}
+
+func (s *storeSink) finishStore(responseID string) {
+	if err := s.store.Finish(context.Background(), responseID); err != nil {
+		s.log.Error().Err(err).Str("response_id", responseID).Msg("finish response events")
+	}
+}
+
+// multiSink delivers events to several sinks in order.
+type multiSink []eventSink
+
+func (m multiSink) Send(responseID string, event response.Event) {
+	for _, sink := range m {
+		sink.Send(responseID, event)
+	}
+}
+
+func (m multiSink) Finish(responseID string) {
+	for _, sink := range m {
+		sink.Finish(responseID)
+	}
+}
+
+// writeSSE writes one server-sent event; sequence numbers above zero are sent as the event id.
+func writeSSE(w io.Writer, flusher http.Flusher, sequence int, name string, data []byte) {
+	if sequence > 0 {
+		fmt.Fprintf(w, "id: %d\n", sequence)
+	}
+	fmt.Fprintf(w, "event: %s\n", name)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+	flusher.Flush()
 }
 
 var _ response.StreamObserver = (*sseObserver)(nil)
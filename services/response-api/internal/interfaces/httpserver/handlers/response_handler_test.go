@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+
+	"jan-server/services/response-api/internal/domain/response"
+)
+
+// memoryEvents is an EventStore recording appended batches. Appends block until unblocked is
+// closed, when set.
+type memoryEvents struct {
+	mu        sync.Mutex
+	batches   [][]response.Event
+	finished  chan struct{}
+	unblocked chan struct{}
+	followed  []string // response IDs whose events were subscribed to
+}
+
+func newMemoryEvents() *memoryEvents {
+	return &memoryEvents{finished: make(chan struct{})}
+}
+
+func (m *memoryEvents) Append(_ context.Context, _ string, events ...response.Event) error {
+	if m.unblocked != nil {
+		<-m.unblocked
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches = append(m.batches, events)
+	return nil
+}
+
+func (m *memoryEvents) Finish(context.Context, string) error {
+	close(m.finished)
+	return nil
+}
+
+func (m *memoryEvents) Subscribe(_ context.Context, responseID string, _ int) (<-chan response.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.followed = append(m.followed, responseID)
+	return nil, response.ErrEventStreamNotFound
+}
+
+func (m *memoryEvents) Attach(context.Context, string) (func(), error) { return func() {}, nil }
+
+func (m *memoryEvents) Attached(context.Context, string) (bool, error) { return false, nil }
+
+func (m *memoryEvents) sequences() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sequences []int
+	for _, batch := range m.batches {
+		for _, event := range batch {
+			sequences = append(sequences, event.SequenceNumber)
+		}
+	}
+	return sequences
+}
+
+// flushRecorder is a ResponseWriter that also implements http.Flusher.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (flushRecorder) Flush() {}
+
+func TestStreamWritesClientBeforeStore(t *testing.T) {
+	store := newMemoryEvents()
+	store.unblocked = make(chan struct{})
+	recorder := flushRecorder{httptest.NewRecorder()}
+	observer := newSSEObserver(multiSink{
+		&sseWriter{writer: recorder, flusher: recorder},
+		newStoreSink(store, zerolog.Nop()),
+	}, zerolog.Nop())
+
+	observer.OnResponseCreated(&response.Response{PublicID: "resp_1", Status: response.StatusInProgress})
+	observer.OnOutputTextDelta(0, "msg_1", "hello")
+
+	// The client has both events although the store is still writing
+	body := recorder.Body.String()
+	if !strings.Contains(body, "event: response.created") || !strings.Contains(body, "event: response.output_text.delta") {
+		t.Fatalf("expected both events on the connection, got %q", body)
+	}
+
+	observer.OnResponseFinished(&response.Response{PublicID: "resp_1", Status: response.StatusCompleted})
+	close(store.unblocked)
+
+	select {
+	case <-store.finished:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to be finished in the store")
+	}
+	sequences := store.sequences()
+	for i, sequence := range sequences {
+		if sequence != i+1 {
+			t.Fatalf("expected events stored in order, got %v", sequences)
+		}
+	}
+	if len(sequences) != 3 {
+		t.Fatalf("expected 3 stored events, got %v", sequences)
+	}
+	if len(store.batches) >= 3 {
+		t.Fatalf("expected events queued behind a slow write to be batched, got %d batches", len(store.batches))
+	}
+}
+
+func TestIsFinishedEvent(t *testing.T) {
+	for _, status := range []response.Status{response.StatusCompleted, response.StatusFailed, response.StatusCancelled, response.StatusRequiresAction} {
+		if !isFinishedEvent(finishedEventName(status)) {
+			t.Fatalf("expected the %s event to end the stream", status)
+		}
+	}
+	if isFinishedEvent("response.output_text.delta") || isFinishedEvent("") {
+		t.Fatal("expected other events not to end the stream")
+	}
+}
+
+// storedResponses is a response service serving stored responses by public ID.
+type storedResponses struct {
+	response.Service
+	responses map[string]*response.Response
+	cancelled []string
+}
+
+func (s *storedResponses) GetByPublicID(_ context.Context, publicID string) (*response.Response, error) {
+	resp, ok := s.responses[publicID]
+	if !ok {
+		return nil, response.ErrResponseNotFound
+	}
+	return resp, nil
+}
+
+func (s *storedResponses) Cancel(_ context.Context, publicID string) (*response.Response, error) {
+	s.cancelled = append(s.cancelled, publicID)
+	resp := s.responses[publicID]
+	resp.Status = response.StatusCancelled
+	return resp, nil
+}
+
+// serveAs handles the request with the routes of h as the user named by subject.
+func serveAs(h *ResponseHandler, subject string, req *http.Request) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("auth_token", jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": subject}))
+	})
+	router.GET("/v1/responses/:response_id", h.Get)
+	router.POST("/v1/responses/:response_id/cancel", h.Cancel)
+	router.DELETE("/v1/responses/:response_id", h.Delete)
+	router.GET("/v1/responses/:response_id/input_items", h.ListInputItems)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestGetHidesResponsesOfOtherUsers(t *testing.T) {
+	events := newMemoryEvents()
+	service := &storedResponses{responses: map[string]*response.Response{
+		"resp_1": {PublicID: "resp_1", UserID: "user-1", Status: response.StatusCompleted},
+	}}
+	h := NewResponseHandler(service, events, time.Minute, zerolog.Nop())
+
+	for _, target := range []string{"/v1/responses/resp_1", "/v1/responses/resp_1?stream=true&starting_after=0"} {
+		recorder := serveAs(h, "user-2", httptest.NewRequest(http.MethodGet, target, nil))
+		if recorder.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for another user's response at %s, got %d", target, recorder.Code)
+		}
+	}
+	if len(events.followed) != 0 {
+		t.Fatalf("expected no event stream opened for another user, got %v", events.followed)
+	}
+
+	recorder := serveAs(h, "user-1", httptest.NewRequest(http.MethodGet, "/v1/responses/resp_1", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected the owner to get the response, got %d", recorder.Code)
+	}
+}
+
+func TestCancelRequiresOwner(t *testing.T) {
+	service := &storedResponses{responses: map[string]*response.Response{
+		"resp_1": {PublicID: "resp_1", UserID: "user-1", Status: response.StatusInProgress},
+	}}
+	h := NewResponseHandler(service, newMemoryEvents(), time.Minute, zerolog.Nop())
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/v1/responses/resp_1/cancel", nil),
+		httptest.NewRequest(http.MethodDelete, "/v1/responses/resp_1", nil),
+		httptest.NewRequest(http.MethodPost, "/v1/responses/resp_missing/cancel", nil),
+	} {
+		recorder := serveAs(h, "user-2", req)
+		if recorder.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for %s %s, got %d", req.Method, req.URL.Path, recorder.Code)
+		}
+	}
+	if len(service.cancelled) != 0 {
+		t.Fatalf("expected no response cancelled for another user, got %v", service.cancelled)
+	}
+
+	recorder := serveAs(h, "user-1", httptest.NewRequest(http.MethodPost, "/v1/responses/resp_1/cancel", nil))
+	if recorder.Code != http.StatusOK || len(service.cancelled) != 1 {
+		t.Fatalf("expected the owner to cancel the response, got %d and %v", recorder.Code, service.cancelled)
+	}
+}
+
+func TestCallerAPIKeyRequiresKeyOfTokenUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		subject string
+		headers map[string]string
+		want    string
+	}{
+		{name: "key of the token user", subject: "user-1", headers: map[string]string{"X-API-Key-ID": "key-1", "X-User-Subject": "user-1"}, want: "key-1"},
+		{name: "key of another user", subject: "user-1", headers: map[string]string{"X-API-Key-ID": "key-2", "X-User-Subject": "user-2"}, want: ""},
+		{name: "no validated key", subject: "user-1", headers: map[string]string{"X-API-Key-ID": "key-1"}, want: ""},
+		{name: "no token", headers: map[string]string{"X-API-Key-ID": "key-1", "X-User-Subject": "user-1"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+			for name, value := range tt.headers {
+				c.Request.Header.Set(name, value)
+			}
+			if tt.subject != "" {
+				c.Set("auth_token", jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": tt.subject}))
+			}
+			if got := callerAPIKey(c); got != tt.want {
+				t.Fatalf("callerAPIKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
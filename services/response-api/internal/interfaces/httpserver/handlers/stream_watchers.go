@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"jan-server/services/response-api/internal/domain/response"
+)
+
+// streamWatchers counts the clients attached to foreground streaming responses on this instance.
+// A response left without clients is cancelled once the grace period passes without a client
+// reattaching. cancel reports false when clients still follow the response on other instances,
+// and the grace period starts over.
+type streamWatchers struct {
+	mu      sync.Mutex
+	grace   time.Duration
+	cancel  func(responseID string) bool
+	streams map[string]*watchedStream
+}
+
+type watchedStream struct {
+	clients int
+	timer   *time.Timer // pending cancellation while no client is attached
+}
+
+func newStreamWatchers(grace time.Duration, cancel func(responseID string) bool) *streamWatchers {
+	return &streamWatchers{
+		grace:   grace,
+		cancel:  cancel,
+		streams: make(map[string]*watchedStream),
+	}
+}
+
+// watch starts tracking a response streamed to the client that created it.
+func (w *streamWatchers) watch(responseID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.streams[responseID] = &watchedStream{clients: 1}
+}
+
+// attach registers a client reattaching to a watched response. It reports whether the response
+// is watched; detach must be called for it once the client goes away.
+func (w *streamWatchers) attach(responseID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stream, ok := w.streams[responseID]
+	if !ok {
+		return false
+	}
+	stream.clients++
+	if stream.timer != nil {
+		stream.timer.Stop()
+		stream.timer = nil
+	}
+	return true
+}
+
+// detach unregisters a client and schedules the cancellation when it was the last one.
+func (w *streamWatchers) detach(responseID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stream, ok := w.streams[responseID]
+	if !ok {
+		return
+	}
+	stream.clients--
+	if stream.clients > 0 || stream.timer != nil {
+		return
+	}
+	w.schedule(responseID, stream)
+}
+
+// schedule arms the cancellation of a stream without clients. The caller holds w.mu.
+func (w *streamWatchers) schedule(responseID string, stream *watchedStream) {
+	stream.timer = time.AfterFunc(w.grace, func() {
+		if !w.expired(responseID, stream) {
+			return
+		}
+		if w.cancel(responseID) {
+			w.mu.Lock()
+			if current, ok := w.streams[responseID]; ok && current == stream {
+				delete(w.streams, responseID)
+			}
+			w.mu.Unlock()
+			return
+		}
+
+		// Clients follow the response elsewhere; check again after another grace period
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if current, ok := w.streams[responseID]; ok && current == stream && stream.clients == 0 {
+			w.schedule(responseID, stream)
+		}
+	})
+}
+
+// expired reports whether the stream is still watched without clients when its timer fires.
+func (w *streamWatchers) expired(responseID string, stream *watchedStream) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	current, ok := w.streams[responseID]
+	if !ok || current != stream || stream.clients > 0 {
+		return false
+	}
+	stream.timer = nil
+	return true
+}
+
+// forget stops tracking a response that reached its final state.
+func (w *streamWatchers) forget(responseID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if stream, ok := w.streams[responseID]; ok {
+		if stream.timer != nil {
+			stream.timer.Stop()
+		}
+		delete(w.streams, responseID)
+	}
+}
+
+// detachingObserver is the observer of a foreground stream. It watches the response once created
+// and detaches its client when the connection drops.
+type detachingObserver struct {
+	*sseObserver
+	watchers *streamWatchers
+
+	mu       sync.Mutex
+	watched  string
+	detached bool
+}
+
+func (o *detachingObserver) OnResponseCreated(resp *response.Response) {
+	o.sseObserver.OnResponseCreated(resp)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.watched = resp.PublicID
+	o.watchers.watch(resp.PublicID)
+	if o.detached {
+		o.watchers.detach(resp.PublicID)
+	}
+}
+
+// detach records that the client that created the response went away.
+func (o *detachingObserver) detach() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.detached {
+		return
+	}
+	o.detached = true
+	if o.watched != "" {
+		o.watchers.detach(o.watched)
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// cancelRecorder records the responses a streamWatchers cancels. Responses in remote count as
+// followed by clients on another instance.
+type cancelRecorder struct {
+	mu        sync.Mutex
+	remote    map[string]bool
+	checked   int
+	cancelled []string
+}
+
+func (r *cancelRecorder) cancel(responseID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checked++
+	if r.remote[responseID] {
+		return false
+	}
+	r.cancelled = append(r.cancelled, responseID)
+	return true
+}
+
+func (r *cancelRecorder) snapshot() (int, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.checked, append([]string(nil), r.cancelled...)
+}
+
+func TestStreamWatchersCancelAfterGrace(t *testing.T) {
+	recorder := &cancelRecorder{}
+	watchers := newStreamWatchers(10*time.Millisecond, recorder.cancel)
+
+	watchers.watch("resp_1")
+	watchers.detach("resp_1")
+
+	time.Sleep(50 * time.Millisecond)
+	if _, cancelled := recorder.snapshot(); len(cancelled) != 1 || cancelled[0] != "resp_1" {
+		t.Fatalf("expected resp_1 to be cancelled, got %v", cancelled)
+	}
+	if watchers.attach("resp_1") {
+		t.Fatal("expected a cancelled stream not to be watched anymore")
+	}
+}
+
+func TestStreamWatchersReattachStopsCancel(t *testing.T) {
+	recorder := &cancelRecorder{}
+	watchers := newStreamWatchers(20*time.Millisecond, recorder.cancel)
+
+	watchers.watch("resp_1")
+	watchers.detach("resp_1")
+	if !watchers.attach("resp_1") {
+		t.Fatal("expected the stream to be watched")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if checked, _ := recorder.snapshot(); checked != 0 {
+		t.Fatal("expected no cancellation while a client is attached")
+	}
+
+	watchers.forget("resp_1")
+	if watchers.attach("resp_1") {
+		t.Fatal("expected a forgotten stream not to be watched")
+	}
+}
+
+func TestStreamWatchersKeepStreamsFollowedElsewhere(t *testing.T) {
+	recorder := &cancelRecorder{remote: map[string]bool{"resp_1": true}}
+	watchers := newStreamWatchers(10*time.Millisecond, recorder.cancel)
+
+	watchers.watch("resp_1")
+	watchers.detach("resp_1")
+
+	time.Sleep(55 * time.Millisecond)
+	checked, cancelled := recorder.snapshot()
+	if len(cancelled) != 0 {
+		t.Fatalf("expected no cancellation while clients follow elsewhere, got %v", cancelled)
+	}
+	if checked < 2 {
+		t.Fatalf("expected the grace period to start over, checked %d times", checked)
+	}
+
+	// Once the remote client goes away the stream is cancelled
+	recorder.mu.Lock()
+	recorder.remote = nil
+	recorder.mu.Unlock()
+	time.Sleep(30 * time.Millisecond)
+	if _, cancelled := recorder.snapshot(); len(cancelled) != 1 {
+		t.Fatalf("expected resp_1 to be cancelled, got %v", cancelled)
+	}
+}
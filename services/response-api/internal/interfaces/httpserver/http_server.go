@@ -29,7 +29,7 @@ type HttpServer struct {
 }
 
 // New constructs the HTTP server with default middleware and routes.
-func New(cfg *config.Config, log zerolog.Logger, responseService domain.Service, authValidator *auth.Validator) *HttpServer {
+func New(cfg *config.Config, log zerolog.Logger, responseService domain.Service, events domain.EventStore, authValidator *auth.Validator) *HttpServer {
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -41,7 +41,7 @@ func New(cfg *config.Config, log zerolog.Logger, responseService domain.Service,
 	if authValidator != nil {
 		engine.Use(authValidator.Middleware())
 	}
-	handlerProvider := handlers.NewProvider(responseService, log)
+	handlerProvider := handlers.NewProvider(responseService, events, cfg.StreamResumeGrace, log)
 	routeProvider := routes.NewProvider(handlerProvider)
 	registerCoreRoutes(engine, cfg, routeProvider, authValidator)
 